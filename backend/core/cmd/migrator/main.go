@@ -1,150 +1,348 @@
-package main
-
-import (
-	"flag"
-	"fmt"
-	"log"
-	"log/slog"
-	"os"
-	"service-core/internal/domain"
-	"service-core/pkg/config"
-	"service-core/pkg/logger"
-	"service-core/pkg/utils"
-
-	"gorm.io/driver/postgres"
-	"gorm.io/gorm"
-)
-
-func main() {
-	// Настройка флагов
-	resetFlag := flag.Bool("reset", false, "Очистить базу данных")
-	migrateFlag := flag.Bool("migrate", false, "Применить миграции")
-	seedFlag := flag.Bool("seed", false, "Заполнить тестовыми данными")
-	flag.Parse()
-
-	cfg := config.MustLoadEnv()
-	log := setupLogger()
-
-	// Подключение к БД
-	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s",
-		cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Password, cfg.Database.Name)
-	db, err := gorm.Open(postgres.Open(psqlInfo), &gorm.Config{})
-	if err != nil {
-		log.Error("failed to open database", slog.String("error", err.Error()))
-		return
-	}
-	defer func() {
-		sqlDB, _ := db.DB()
-		sqlDB.Close()
-	}()
-
-	// Логика выполнения команд
-	if *resetFlag {
-		resetDatabase(db)
-		log.Info("database cleared successfully")
-	}
-
-	if *migrateFlag {
-		err = db.AutoMigrate(
-			&domain.Role{},
-			&domain.User{},
-			&domain.Approval{},
-			&domain.Workflow{},
-		)
-
-		db.Exec("CREATE SEQUENCE IF NOT EXISTS workflows_workflow_id_seq")
-
-		if err != nil {
-			log.Error("failed to auto migrate", slog.String("error", err.Error()))
-			return
-		}
-		log.Info("migrations applied successfully")
-	}
-
-	if *seedFlag {
-		seedData(db)
-		log.Info("seed data inserted successfully")
-	}
-}
-
-func resetDatabase(db *gorm.DB) {
-	tables := []string{
-		"approvals",
-		"workflows",
-		"users",
-		"roles",
-	}
-
-	// Отключаем проверку внешних ключей
-	db.Exec("SET CONSTRAINTS ALL DEFERRED")
-
-	// Удаляем таблицы в обратном порядке (сначала дочерние)
-	for _, table := range tables {
-		db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", table))
-	}
-
-	log.Println("All tables dropped successfully")
-}
-
-func seedData(db *gorm.DB) {
-	// TODO: сделать трех пользовтелей: 2 конструктора и 1 админ
-
-	// TODO: создать workflows:
-	// 1: user1, user2, user3
-	// 2: user2, user2, user3
-	// 3: user3, user3, user3
-
-	passHash, _ := utils.HashPassword("12345678")
-
-	// 1. Создаем роли
-	adminRole := domain.Role{RoleName: "admin"}
-	db.Where(adminRole).FirstOrCreate(&adminRole)
-
-	constructorRole := domain.Role{RoleName: "constructor"}
-	db.Where(constructorRole).FirstOrCreate(&constructorRole)
-
-	// 2. Создаем пользователей
-	users := []domain.User{
-		{Login: "user1", PassHash: passHash, RoleID: constructorRole.ID},
-		{Login: "user2", PassHash: passHash, RoleID: constructorRole.ID},
-		{Login: "user3", PassHash: passHash, RoleID: adminRole.ID},
-	}
-	if err := db.Create(&users).Error; err != nil {
-		log.Fatalf("Failed to create users: %v", err)
-	}
-
-	// 3. Создаем workflows
-	workflows := []domain.Workflow{
-		// Workflow 1: user1 (order 1), user2 (order 2), user3 (order 3)
-		{WorkflowID: 1, UserID: users[0].ID, WorkflowOrder: 1, WorkflowName: "Процедура согласования 1"},
-		{WorkflowID: 1, UserID: users[1].ID, WorkflowOrder: 2, WorkflowName: "Процедура согласования 1"},
-		{WorkflowID: 1, UserID: users[2].ID, WorkflowOrder: 3, WorkflowName: "Процедура согласования 1"},
-
-		// Workflow 2: user2 (order 1), user2 (order 2), user3 (order 3)
-		{WorkflowID: 2, UserID: users[1].ID, WorkflowOrder: 1, WorkflowName: "Процедура согласования 2"},
-		{WorkflowID: 2, UserID: users[1].ID, WorkflowOrder: 2, WorkflowName: "Процедура согласования 2"},
-		{WorkflowID: 2, UserID: users[2].ID, WorkflowOrder: 3, WorkflowName: "Процедура согласования 2"},
-
-		// Workflow 3: user3 (order 1, 2, 3)
-		{WorkflowID: 3, UserID: users[2].ID, WorkflowOrder: 1, WorkflowName: "Тестовая процедура согласования"},
-		{WorkflowID: 3, UserID: users[2].ID, WorkflowOrder: 2, WorkflowName: "Тестовая процедура согласования"},
-		{WorkflowID: 3, UserID: users[2].ID, WorkflowOrder: 3, WorkflowName: "Тестовая процедура согласования"},
-	}
-	if err := db.Create(&workflows).Error; err != nil {
-		log.Fatalf("Failed to create workflows: %v", err)
-	}
-
-	db.Exec("SELECT setval('workflows_workflow_id_seq', (SELECT MAX(workflow_id) FROM workflows))")
-
-}
-
-func setupLogger() *slog.Logger {
-	opts := logger.PrettyHandlerOptions{
-		SlogOpts: &slog.HandlerOptions{
-			Level: slog.LevelDebug,
-		},
-	}
-
-	handler := opts.NewPrettyHandler(os.Stdout)
-	return slog.New(handler)
-}
+package main
+
+import (
+	"flag"
+	"fmt"
+	"log"
+	"log/slog"
+	"os"
+	"service-core/internal/domain"
+	"service-core/pkg/config"
+	"service-core/pkg/logger"
+	"service-core/pkg/utils"
+
+	"gorm.io/driver/postgres"
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+func main() {
+	// Настройка флагов
+	resetFlag := flag.Bool("reset", false, "Очистить базу данных")
+	migrateFlag := flag.Bool("migrate", false, "Применить миграции")
+	seedFlag := flag.Bool("seed", false, "Заполнить тестовыми данными")
+	flag.Parse()
+
+	cfg := config.MustLoadEnv()
+	log := setupLogger()
+
+	// Подключение к БД
+	psqlInfo := fmt.Sprintf("host=%s port=%d user=%s password=%s dbname=%s",
+		cfg.Database.Host, cfg.Database.Port, cfg.Database.User, cfg.Database.Password, cfg.Database.Name)
+	db, err := gorm.Open(postgres.Open(psqlInfo), &gorm.Config{})
+	if err != nil {
+		log.Error("failed to open database", slog.String("error", err.Error()))
+		return
+	}
+	defer func() {
+		sqlDB, _ := db.DB()
+		sqlDB.Close()
+	}()
+
+	// Логика выполнения команд
+	if *resetFlag {
+		resetDatabase(db)
+		log.Info("database cleared successfully")
+	}
+
+	if *migrateFlag {
+		err = db.AutoMigrate(
+			&domain.Role{},
+			&domain.RolePermission{},
+			&domain.User{},
+			&domain.Approval{},
+			&domain.ApprovalStage{},
+			&domain.ApprovalSignature{},
+			&domain.ApprovalEvent{},
+			&domain.ApprovalComment{},
+			&domain.DecisionSignature{},
+			&domain.SigningKey{},
+			&domain.TokenKey{},
+			&domain.AuthSession{},
+			&domain.RefreshToken{},
+			&domain.WorkflowHeader{},
+			&domain.WorkflowStageRecord{},
+			&domain.WorkflowSigner{},
+			&domain.Delegation{},
+			&domain.RoutingRule{},
+			&domain.Transmittal{},
+			&domain.TransmittalFile{},
+			&domain.OutboxMessage{},
+		)
+
+		// Процедуры, сохраненные построчно до появления workflow_headers, переносим в новые таблицы
+		if err == nil {
+			err = migrateLegacyWorkflows(db)
+		}
+		// Роль admin, которой раньше проверялся административный доступ, получает все разрешения
+		if err == nil {
+			err = grantAdminPermissions(db)
+		}
+		if err == nil {
+			err = db.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_workflow_headers_name
+			ON workflow_headers (name)
+			WHERE deleted_at IS NULL;
+		`).Error
+		}
+		// Представление workflows - одна строка на подписанта этапа, как в прежней таблице
+		if err == nil {
+			err = db.Exec(`
+			CREATE OR REPLACE VIEW workflows AS
+			SELECT sg.id, h.created_at, h.updated_at, h.deleted_at,
+				h.id AS workflow_id, h.name AS workflow_name, sg.user_id, sg.role_id,
+				st.stage_order AS workflow_order, st.stage_rule, st.stage_quorum, h.revision,
+				h.require_resolved_comments, st.stage_due_hours, st.escalation_user_id, st.escalation_role_id
+			FROM workflow_headers h
+			JOIN workflow_stages st ON st.workflow_id = h.id
+			JOIN workflow_signers sg ON sg.stage_id = st.id;
+		`).Error
+		}
+		if err == nil {
+			err = db.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_signature
+			ON approval_signatures (approval_id, workflow_order, user_id)
+			WHERE deleted_at IS NULL;
+		`).Error
+		}
+		if err == nil {
+			err = db.Exec(`
+			CREATE UNIQUE INDEX IF NOT EXISTS idx_approval_signature_role
+			ON approval_signatures (approval_id, workflow_order, role_id)
+			WHERE role_id <> 0 AND deleted_at IS NULL;
+		`).Error
+		}
+
+		// Заполняем отправителя для согласований, созданных до появления submitter_id
+		if err == nil {
+			err = db.Exec(`
+			UPDATE approvals SET submitter_id = e.actor_id
+			FROM approval_events e
+			WHERE e.approval_id = approvals.id AND e.action = 'submit' AND approvals.submitter_id = 0;
+		`).Error
+		}
+
+		// Копируем этапы процедуры для согласований, созданных до появления approval_stages
+		if err == nil {
+			err = db.Exec(`
+			INSERT INTO approval_stages (created_at, approval_id, workflow_order, user_id, role_id,
+				stage_rule, stage_quorum, stage_due_hours, escalation_user_id, escalation_role_id)
+			SELECT NOW(), a.id, w.workflow_order, w.user_id, w.role_id,
+				w.stage_rule, w.stage_quorum, w.stage_due_hours, w.escalation_user_id, w.escalation_role_id
+			FROM approvals a
+			JOIN workflows w ON w.workflow_id = a.workflow_id AND w.deleted_at IS NULL
+			WHERE NOT EXISTS (SELECT 1 FROM approval_stages s WHERE s.approval_id = a.id);
+		`).Error
+		}
+
+		// Журнал согласования только дополняется: запрещаем изменение и удаление записей
+		if err == nil {
+			err = db.Exec(`
+			CREATE OR REPLACE FUNCTION approval_events_append_only() RETURNS trigger AS $$
+			BEGIN
+				RAISE EXCEPTION 'approval_events is append-only';
+			END;
+			$$ LANGUAGE plpgsql;
+		`).Error
+		}
+		if err == nil {
+			err = db.Exec("DROP TRIGGER IF EXISTS approval_events_append_only ON approval_events").Error
+		}
+		if err == nil {
+			err = db.Exec(`
+			CREATE TRIGGER approval_events_append_only
+			BEFORE UPDATE OR DELETE ON approval_events
+			FOR EACH ROW EXECUTE FUNCTION approval_events_append_only();
+		`).Error
+		}
+
+		if err != nil {
+			log.Error("failed to auto migrate", slog.String("error", err.Error()))
+			return
+		}
+		log.Info("migrations applied successfully")
+	}
+
+	if *seedFlag {
+		seedData(db)
+		log.Info("seed data inserted successfully")
+	}
+}
+
+func resetDatabase(db *gorm.DB) {
+	tables := []string{
+		"outbox_messages",
+		"transmittal_files",
+		"transmittals",
+		"routing_rules",
+		"delegations",
+		"approval_events",
+		"approval_comments",
+		"decision_signatures",
+		"signing_keys",
+		"token_keys",
+		"refresh_tokens",
+		"auth_sessions",
+		"approval_signatures",
+		"approval_stages",
+		"approvals",
+		"workflow_signers",
+		"workflow_stages",
+		"workflow_headers",
+		"workflows",
+		"users",
+		"role_permissions",
+		"roles",
+	}
+
+	// Отключаем проверку внешних ключей
+	db.Exec("SET CONSTRAINTS ALL DEFERRED")
+
+	db.Exec("DROP VIEW IF EXISTS workflows")
+
+	// Удаляем таблицы в обратном порядке (сначала дочерние)
+	for _, table := range tables {
+		db.Exec(fmt.Sprintf("DROP TABLE IF EXISTS %s CASCADE", table))
+	}
+
+	log.Println("All tables dropped successfully")
+}
+
+func seedData(db *gorm.DB) {
+	// TODO: сделать трех пользовтелей: 2 конструктора и 1 админ
+
+	// TODO: создать workflows:
+	// 1: user1, user2, user3
+	// 2: user2, user2, user3
+	// 3: user3, user3, user3
+
+	passHash, _ := utils.HashPassword("12345678")
+
+	// 1. Создаем роли
+	adminRole := domain.Role{RoleName: "admin"}
+	db.Where(adminRole).FirstOrCreate(&adminRole)
+
+	if err := grantAdminPermissions(db); err != nil {
+		log.Fatalf("Failed to grant admin permissions: %v", err)
+	}
+
+	constructorRole := domain.Role{RoleName: "constructor"}
+	db.Where(constructorRole).FirstOrCreate(&constructorRole)
+
+	// 2. Создаем пользователей
+	users := []domain.User{
+		{Login: "user1", PassHash: passHash, RoleID: constructorRole.ID},
+		{Login: "user2", PassHash: passHash, RoleID: constructorRole.ID},
+		{Login: "user3", PassHash: passHash, RoleID: adminRole.ID},
+	}
+	if err := db.Create(&users).Error; err != nil {
+		log.Fatalf("Failed to create users: %v", err)
+	}
+
+	// 3. Создаем workflows: на каждом этапе один пользователь
+	sequential := func(name string, userIDs ...uint) domain.WorkflowHeader {
+		workflow := domain.WorkflowHeader{Name: name, Revision: 1}
+		for i, userID := range userIDs {
+			workflow.Stages = append(workflow.Stages, domain.WorkflowStageRecord{
+				StageOrder: i + 1,
+				StageRule:  domain.StageRuleAll,
+				Signers:    []domain.WorkflowSigner{{UserID: userID}},
+			})
+		}
+		return workflow
+	}
+	workflows := []domain.WorkflowHeader{
+		sequential("Процедура согласования 1", users[0].ID, users[1].ID, users[2].ID),
+		sequential("Процедура согласования 2", users[1].ID, users[1].ID, users[2].ID),
+		sequential("Тестовая процедура согласования", users[2].ID, users[2].ID, users[2].ID),
+	}
+	if err := db.Create(&workflows).Error; err != nil {
+		log.Fatalf("Failed to create workflows: %v", err)
+	}
+}
+
+// grantAdminPermissions выдает роли admin все разрешения, в том числе появившиеся после прошлой
+// миграции. Уже выданные разрешения не дублируются
+func grantAdminPermissions(db *gorm.DB) error {
+	var adminRole domain.Role
+	err := db.Where("role_name = ?", "admin").Limit(1).Find(&adminRole).Error
+	if err != nil || adminRole.ID == 0 {
+		return err
+	}
+
+	rows := make([]domain.RolePermission, len(domain.Permissions))
+	for i, info := range domain.Permissions {
+		rows[i] = domain.RolePermission{RoleID: adminRole.ID, Permission: info.Permission}
+	}
+	return db.Clauses(clause.OnConflict{DoNothing: true}).Create(&rows).Error
+}
+
+// migrateLegacyWorkflows переносит процедуры из прежней таблицы workflows (строка на подписанта)
+// в workflow_headers, workflow_stages и workflow_signers. Переносится последняя ревизия каждой
+// процедуры, этапы нумеруются заново без пропусков, повторяющиеся имена неудаленных процедур
+// получают суффикс с ID. Прежняя таблица удаляется, на её месте создается представление
+func migrateLegacyWorkflows(db *gorm.DB) error {
+	var isTable bool
+	if err := db.Raw(`
+		SELECT EXISTS (SELECT 1 FROM pg_class
+			WHERE relname = 'workflows' AND relkind = 'r' AND relnamespace = 'public'::regnamespace)
+	`).Scan(&isTable).Error; err != nil {
+		return err
+	}
+	if !isTable {
+		return nil
+	}
+
+	return db.Transaction(func(tx *gorm.DB) error {
+		statements := []string{
+			"ALTER TABLE workflows RENAME TO workflows_legacy",
+			`CREATE TEMPORARY TABLE workflows_latest ON COMMIT DROP AS
+			SELECT l.*, DENSE_RANK() OVER (PARTITION BY l.workflow_id ORDER BY l.workflow_order) AS stage_order
+			FROM workflows_legacy l
+			WHERE l.revision = (SELECT MAX(m.revision) FROM workflows_legacy m WHERE m.workflow_id = l.workflow_id)`,
+			`INSERT INTO workflow_headers (id, created_at, updated_at, deleted_at, name, revision, require_resolved_comments)
+			SELECT workflow_id, MIN(created_at), MAX(updated_at),
+				CASE WHEN BOOL_AND(deleted_at IS NOT NULL) THEN MAX(deleted_at) END,
+				COALESCE(NULLIF(MAX(workflow_name), ''), 'Процедура согласования ' || workflow_id),
+				GREATEST(MAX(revision), 1), BOOL_OR(require_resolved_comments)
+			FROM workflows_latest
+			GROUP BY workflow_id`,
+			`UPDATE workflow_headers h SET name = h.name || ' (' || h.id || ')'
+			WHERE h.deleted_at IS NULL AND EXISTS (SELECT 1 FROM workflow_headers o
+				WHERE o.name = h.name AND o.deleted_at IS NULL AND o.id < h.id)`,
+			`INSERT INTO workflow_stages (workflow_id, stage_order, stage_rule, stage_quorum,
+				stage_due_hours, escalation_user_id, escalation_role_id)
+			SELECT DISTINCT ON (workflow_id, stage_order) workflow_id, stage_order,
+				CASE WHEN stage_rule IN ('all', 'any', 'quorum') THEN stage_rule ELSE 'all' END,
+				GREATEST(stage_quorum, 0), GREATEST(stage_due_hours, 0),
+				escalation_user_id, CASE WHEN escalation_user_id = 0 THEN escalation_role_id ELSE 0 END
+			FROM workflows_latest
+			ORDER BY workflow_id, stage_order, id`,
+			`INSERT INTO workflow_signers (stage_id, user_id, role_id)
+			SELECT DISTINCT st.id, CASE WHEN l.role_id <> 0 THEN 0 ELSE l.user_id END, l.role_id
+			FROM workflows_latest l
+			JOIN workflow_stages st ON st.workflow_id = l.workflow_id AND st.stage_order = l.stage_order
+			WHERE l.user_id <> 0 OR l.role_id <> 0`,
+			"SELECT setval('workflow_headers_id_seq', GREATEST((SELECT MAX(id) FROM workflow_headers), 1))",
+			"DROP TABLE workflows_legacy",
+			"DROP SEQUENCE IF EXISTS workflows_workflow_id_seq",
+		}
+		for _, statement := range statements {
+			if err := tx.Exec(statement).Error; err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+}
+
+func setupLogger() *slog.Logger {
+	opts := logger.PrettyHandlerOptions{
+		SlogOpts: &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		},
+	}
+
+	handler := opts.NewPrettyHandler(os.Stdout)
+	return slog.New(handler)
+}
@@ -0,0 +1,71 @@
+package main
+
+import (
+	"context"
+	"flag"
+	"log/slog"
+	"os"
+	"service-core/internal/infrastructure/grpc"
+	"service-core/internal/infrastructure/postgresrepo"
+	"service-core/internal/usecase"
+	"service-core/pkg/config"
+	"service-core/pkg/logger"
+)
+
+// Сверяет статусы файлов в file-service со статусами последних Approvals.
+// Без флага -fix только выводит расхождения
+func main() {
+	fixFlag := flag.Bool("fix", false, "Исправить найденные расхождения")
+
+	cfg := config.MustLoadEnv()
+	log := setupLogger()
+
+	db, err := postgresrepo.New(cfg)
+	if err != nil {
+		log.Error("failed to open database", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	grpcClient, err := grpc.NewFileGRPCClient(cfg)
+	if err != nil {
+		log.Error("failed to create gRPC client", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	outboxUsecase := usecase.NewOutboxUsecase(
+		postgresrepo.NewOutboxRepository(db),
+		postgresrepo.NewApprovalRepository(db),
+		grpc.NewFileService(grpcClient),
+		cfg,
+		log,
+	)
+
+	mismatches, err := outboxUsecase.Reconcile(context.Background(), *fixFlag)
+	if err != nil {
+		log.Error("failed to reconcile file statuses", slog.String("error", err.Error()))
+		os.Exit(1)
+	}
+
+	for _, mismatch := range mismatches {
+		log.Info("file status mismatch",
+			slog.Any("file_id", mismatch.FileID),
+			slog.Any("approval_id", mismatch.ApprovalID),
+			slog.String("approval_status", mismatch.ApprovalStatus),
+			slog.String("file_status", mismatch.FileStatus),
+			slog.String("expected_status", mismatch.ExpectedStatus),
+		)
+	}
+
+	log.Info("reconciliation finished", slog.Int("mismatches", len(mismatches)), slog.Bool("fixed", *fixFlag))
+}
+
+func setupLogger() *slog.Logger {
+	opts := logger.PrettyHandlerOptions{
+		SlogOpts: &slog.HandlerOptions{
+			Level: slog.LevelDebug,
+		},
+	}
+
+	handler := opts.NewPrettyHandler(os.Stdout)
+	return slog.New(handler)
+}
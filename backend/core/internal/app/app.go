@@ -1,64 +1,114 @@
-package app
-
-import (
-	"log"
-	"log/slog"
-	httpapp "service-core/internal/app/http"
-	http "service-core/internal/controller"
-
-	"service-core/internal/infrastructure/grpc"
-	"service-core/internal/infrastructure/postgresrepo"
-	"service-core/internal/usecase"
-	"service-core/pkg/config"
-)
-
-type App struct {
-	HTTPSrv *httpapp.App
-}
-
-func New(cfg *config.Config, logger *slog.Logger) (*App, error) {
-	db, err := postgresrepo.New(cfg)
-	if err != nil {
-		return nil, err
-	}
-
-	grpcClient, err := grpc.NewFileGRPCClient(cfg)
-	if err != nil {
-		log.Fatalf("Failed to create gRPC client: %v", err)
-	}
-
-	userRepo := postgresrepo.NewUserRepository(db)
-	roleRepo := postgresrepo.NewRoleRepository(db)
-	approvalRepo := postgresrepo.NewApprovalRepository(db)
-	workflowRepo := postgresrepo.NewWorkflowRepository(db)
-
-	fileService := grpc.NewFileService(grpcClient)
-
-	authUsecase := usecase.NewAuthUsecase(userRepo, roleRepo, cfg, logger)
-	approvalUsecase := usecase.NewApprovalUsecase(approvalRepo, fileService, logger)
-	workflowUsecase := usecase.NewWorkflowUsecase(workflowRepo, userRepo, fileService, logger)
-	roleUsecase := usecase.NewRoleUsecase(roleRepo, userRepo, logger)
-	userUsecase := usecase.NewUserUsecase(userRepo, roleRepo, workflowRepo, fileService, logger)
-
-	authHandler := http.NewAuthHandler(authUsecase)
-	fileHandler := http.NewFileHandler(approvalUsecase)
-	approvalHandler := http.NewFileApprovalsHandler(approvalUsecase)
-	workflowHandler := http.NewWorkflowHandler(workflowUsecase)
-	roleHandler := http.NewRoleHandler(roleUsecase)
-	userHandler := http.NewUserHandler(userUsecase)
-
-	httpApp := httpapp.New(
-		logger,
-		authHandler,
-		fileHandler,
-		approvalHandler,
-		workflowHandler,
-		roleHandler,
-		userHandler,
-		cfg,
-	)
-
-	return &App{
-		HTTPSrv: httpApp,
-	}, nil
-}
+package app
+
+import (
+	"log"
+	"log/slog"
+	httpapp "service-core/internal/app/http"
+	schedulerapp "service-core/internal/app/scheduler"
+	http "service-core/internal/controller"
+
+	"service-core/internal/infrastructure/grpc"
+	"service-core/internal/infrastructure/notifier"
+	"service-core/internal/infrastructure/postgresrepo"
+	"service-core/internal/infrastructure/signer"
+	"service-core/internal/usecase"
+	"service-core/pkg/config"
+)
+
+type App struct {
+	HTTPSrv     *httpapp.App
+	Scheduler   *schedulerapp.App
+	Dispatcher  *schedulerapp.App
+	Revocations *schedulerapp.App
+	TokenKeys   *schedulerapp.App
+}
+
+func New(cfg *config.Config, logger *slog.Logger) (*App, error) {
+	db, err := postgresrepo.New(cfg)
+	if err != nil {
+		return nil, err
+	}
+
+	grpcClient, err := grpc.NewFileGRPCClient(cfg)
+	if err != nil {
+		log.Fatalf("Failed to create gRPC client: %v", err)
+	}
+
+	userRepo := postgresrepo.NewUserRepository(db)
+	roleRepo := postgresrepo.NewRoleRepository(db)
+	approvalRepo := postgresrepo.NewApprovalRepository(db)
+	workflowRepo := postgresrepo.NewWorkflowRepository(db)
+	delegationRepo := postgresrepo.NewDelegationRepository(db)
+	outboxRepo := postgresrepo.NewOutboxRepository(db)
+	routingRepo := postgresrepo.NewRoutingRuleRepository(db)
+	transmittalRepo := postgresrepo.NewTransmittalRepository(db)
+	signingKeyRepo := postgresrepo.NewSigningKeyRepository(db)
+	commentRepo := postgresrepo.NewCommentRepository(db)
+	sessionRepo := postgresrepo.NewSessionRepository(db)
+	tokenKeyRepo := postgresrepo.NewTokenKeyRepository(db)
+
+	fileService := grpc.NewFileService(grpcClient)
+	logNotifier := notifier.NewLogNotifier(logger)
+	decisionSigner, err := signer.NewEd25519Signer(signingKeyRepo, cfg.Signing.MasterKey)
+	if err != nil {
+		return nil, err
+	}
+	tokenKeys, err := signer.NewEd25519TokenKeys(tokenKeyRepo, cfg.Signing.MasterKey, cfg.SecretKeys.TokenKeyRotation, cfg.SecretKeys.TokenTTL)
+	if err != nil {
+		return nil, err
+	}
+
+	authorizer := usecase.NewAuthorizer(roleRepo)
+	authUsecase := usecase.NewAuthUsecase(userRepo, roleRepo, sessionRepo, fileService, tokenKeys, authorizer, cfg, logger)
+	outboxUsecase := usecase.NewOutboxUsecase(outboxRepo, approvalRepo, fileService, cfg, logger)
+	routingUsecase := usecase.NewRoutingUsecase(routingRepo, workflowRepo, userRepo, fileService, authorizer, logger)
+	approvalUsecase := usecase.NewApprovalUsecase(approvalRepo, userRepo, fileService, routingUsecase, outboxUsecase, decisionSigner, authorizer, logger)
+	workflowUsecase := usecase.NewWorkflowUsecase(workflowRepo, userRepo, roleRepo, routingRepo, delegationRepo, fileService, authorizer, logger)
+	roleUsecase := usecase.NewRoleUsecase(roleRepo, userRepo, workflowRepo, authorizer, logger)
+	userUsecase := usecase.NewUserUsecase(userRepo, roleRepo, workflowRepo, fileService, authorizer, logger)
+	delegationUsecase := usecase.NewDelegationUsecase(delegationRepo, userRepo, authorizer, logger)
+	transmittalUsecase := usecase.NewTransmittalUsecase(transmittalRepo, approvalRepo, workflowRepo, userRepo, fileService, outboxUsecase, authorizer, logger)
+	commentUsecase := usecase.NewCommentUsecase(commentRepo, approvalRepo, userRepo, logNotifier, authorizer, logger)
+	deadlineUsecase := usecase.NewDeadlineUsecase(approvalRepo, logNotifier, cfg, logger)
+
+	authHandler := http.NewAuthHandler(authUsecase)
+	fileHandler := http.NewFileHandler(approvalUsecase)
+	approvalHandler := http.NewFileApprovalsHandler(approvalUsecase)
+	workflowHandler := http.NewWorkflowHandler(workflowUsecase)
+	routingHandler := http.NewRoutingHandler(routingUsecase)
+	roleHandler := http.NewRoleHandler(roleUsecase)
+	userHandler := http.NewUserHandler(userUsecase)
+	delegationHandler := http.NewDelegationHandler(delegationUsecase)
+	transmittalHandler := http.NewTransmittalHandler(transmittalUsecase)
+	commentHandler := http.NewCommentHandler(commentUsecase)
+
+	httpApp := httpapp.New(
+		logger,
+		authHandler,
+		fileHandler,
+		approvalHandler,
+		workflowHandler,
+		routingHandler,
+		roleHandler,
+		userHandler,
+		delegationHandler,
+		transmittalHandler,
+		commentHandler,
+		sessionRepo,
+		tokenKeys,
+		cfg,
+	)
+
+	schedulerApp := schedulerapp.New(logger, "deadline", deadlineUsecase.ProcessDeadlines, cfg.Scheduler.Interval)
+	dispatcherApp := schedulerapp.New(logger, "outbox", outboxUsecase.DispatchPending, cfg.Outbox.Interval)
+	revocationsApp := schedulerapp.New(logger, "revocations", authUsecase.SyncRevocations, cfg.Sessions.RevocationSyncInterval)
+	tokenKeysApp := schedulerapp.New(logger, "token-keys", tokenKeys.RotateKey, cfg.Scheduler.Interval)
+
+	return &App{
+		HTTPSrv:     httpApp,
+		Scheduler:   schedulerApp,
+		Dispatcher:  dispatcherApp,
+		Revocations: revocationsApp,
+		TokenKeys:   tokenKeysApp,
+	}, nil
+}
@@ -1,177 +1,255 @@
-package httpapp
-
-import (
-	"context"
-	"fmt"
-	"log/slog"
-	"net/http"
-	controller "service-core/internal/controller"
-	middleware "service-core/internal/controller/middleware"
-	"service-core/pkg/config"
-	"time"
-
-	"github.com/gin-gonic/gin"
-	swaggerFiles "github.com/swaggo/files"
-	ginSwagger "github.com/swaggo/gin-swagger"
-)
-
-type App struct {
-	log                  *slog.Logger
-	authHandler          *controller.AuthHandler
-	fileHandler          *controller.FileHandler
-	fileApprovalsHandler *controller.FileApprovalsHandler
-	workflowHandler      *controller.WorkflowHandler
-	roleHandler          *controller.RoleHandler
-	userHandler          *controller.UserHandler
-	cfg                  *config.Config
-	server               *http.Server
-}
-
-func New(
-	log *slog.Logger,
-	authHandler *controller.AuthHandler,
-	fileHandler *controller.FileHandler,
-	fileApprovalsHandler *controller.FileApprovalsHandler,
-	workflowHandler *controller.WorkflowHandler,
-	roleHandler *controller.RoleHandler,
-	userHandler *controller.UserHandler,
-	cfg *config.Config,
-) *App {
-	return &App{
-		log:                  log,
-		authHandler:          authHandler,
-		fileHandler:          fileHandler,
-		fileApprovalsHandler: fileApprovalsHandler,
-		workflowHandler:      workflowHandler,
-		roleHandler:          roleHandler,
-		userHandler:          userHandler,
-		cfg:                  cfg,
-	}
-}
-
-func (a *App) MustRun() {
-	if err := a.Run(); err != nil {
-		panic(err)
-	}
-}
-
-func (a *App) Run() error {
-	const op = "httpapp.Run"
-
-	log := a.log.With(
-		slog.String("operation", op),
-		slog.String("port", a.cfg.HTTPServer.Address),
-	)
-
-	router := gin.Default()
-	router.Use(gin.Recovery(), middleware.CORSMiddleware())
-
-	setupRoutes(
-		router,
-		a.authHandler,
-		a.fileHandler,
-		a.fileApprovalsHandler,
-		a.workflowHandler,
-		a.roleHandler,
-		a.userHandler,
-		a.cfg,
-	)
-
-	port := a.cfg.HTTPServer.Address
-	log.Info("starting HTTP server", slog.String("port", port))
-
-	srv := &http.Server{
-		Addr:    port,
-		Handler: router,
-	}
-	a.server = srv
-
-	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	return nil
-}
-
-func setupRoutes(
-	router *gin.Engine,
-	authHandler *controller.AuthHandler,
-	fileHandler *controller.FileHandler,
-	fileApprovalsHandler *controller.FileApprovalsHandler,
-	workflowHandler *controller.WorkflowHandler,
-	roleHandler *controller.RoleHandler,
-	userHandler *controller.UserHandler,
-	cfg *config.Config,
-) {
-	router.GET("/docs", func(c *gin.Context) {
-		c.Redirect(http.StatusMovedPermanently, "/docs/index.html")
-	})
-	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
-
-	authGroup := router.Group("/auth")
-	{
-		authGroup.POST("/login", authHandler.Login)
-		authGroup.GET("/me", middleware.AuthMiddleware(cfg), authHandler.GetCurrentUser)
-	}
-
-	filesGroup := router.Group("/files", middleware.AuthMiddleware(cfg))
-	{
-		filesGroup.PUT("/:file_id/approve", fileHandler.ApproveFile)
-	}
-
-	approvalsGroup := router.Group("/file-approvals", middleware.AuthMiddleware(cfg))
-	{
-		approvalsGroup.GET("", fileApprovalsHandler.GetApprovalsByUser)
-		approvalsGroup.PUT("/:approval_id/sign", fileApprovalsHandler.SignApproval)
-		approvalsGroup.PUT("/:approval_id/annotate", fileApprovalsHandler.AnnotateApproval)
-		approvalsGroup.PUT("/:approval_id/finalize", fileApprovalsHandler.FinalizeApproval)
-	}
-
-	adminGroup := router.Group("/admin", middleware.AuthMiddleware(cfg))
-	{
-		workflowsGroup := adminGroup.Group("/workflows")
-		{
-			workflowsGroup.GET("", workflowHandler.GetWorkflows)
-			workflowsGroup.GET("/:workflow_id", workflowHandler.GetWorkflowByID)
-			workflowsGroup.POST("", workflowHandler.CreateWorkflow)
-			workflowsGroup.DELETE("", workflowHandler.DeleteWorkflow)
-			// TODO: get workflow by id
-			workflowsGroup.PUT("/:workflow_id", workflowHandler.UpdateWorkflow)
-			workflowsGroup.PUT("/:workflow_id/assign", workflowHandler.AssignWorkflow)
-		}
-
-		rolesGroup := adminGroup.Group("/roles")
-		{
-			rolesGroup.GET("", roleHandler.GetRoles)
-			rolesGroup.GET("/:role_id", roleHandler.GetRole)
-			rolesGroup.POST("", roleHandler.RegisterRole)
-			rolesGroup.PUT("/:role_id", roleHandler.UpdateRole)
-			rolesGroup.DELETE("", roleHandler.DeleteRole)
-		}
-
-		usersGroup := adminGroup.Group("/users")
-		{
-			usersGroup.GET("", userHandler.GetUsers)
-			usersGroup.POST("/register", userHandler.RegisterUser)
-			usersGroup.PUT("/:user_id", userHandler.UpdateUser)
-			usersGroup.PUT("/:user_id/assign", userHandler.AssignUser)
-			usersGroup.DELETE("", userHandler.DeleteUser)
-		}
-	}
-
-}
-
-func (a *App) Stop() {
-	a.log.Info("http server is stopping")
-	if a.server == nil {
-		a.log.Info("http server is not running")
-		return
-	}
-
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-	defer cancel()
-	if err := a.server.Shutdown(ctx); err != nil {
-		a.log.Error("failed to gracefully shutdown http server", slog.String("error", err.Error()))
-	} else {
-		a.log.Info("http server stopped gracefully")
-	}
-}
+package httpapp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"net/http"
+	controller "service-core/internal/controller"
+	middleware "service-core/internal/controller/middleware"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/config"
+	"time"
+
+	"github.com/gin-gonic/gin"
+	swaggerFiles "github.com/swaggo/files"
+	ginSwagger "github.com/swaggo/gin-swagger"
+)
+
+type App struct {
+	log                  *slog.Logger
+	authHandler          *controller.AuthHandler
+	fileHandler          *controller.FileHandler
+	fileApprovalsHandler *controller.FileApprovalsHandler
+	workflowHandler      *controller.WorkflowHandler
+	routingHandler       *controller.RoutingHandler
+	roleHandler          *controller.RoleHandler
+	userHandler          *controller.UserHandler
+	delegationHandler    *controller.DelegationHandler
+	transmittalHandler   *controller.TransmittalHandler
+	commentHandler       *controller.CommentHandler
+	sessionRepo          interfaces.SessionRepository
+	tokenKeys            interfaces.TokenKeyManager
+	cfg                  *config.Config
+	server               *http.Server
+}
+
+func New(
+	log *slog.Logger,
+	authHandler *controller.AuthHandler,
+	fileHandler *controller.FileHandler,
+	fileApprovalsHandler *controller.FileApprovalsHandler,
+	workflowHandler *controller.WorkflowHandler,
+	routingHandler *controller.RoutingHandler,
+	roleHandler *controller.RoleHandler,
+	userHandler *controller.UserHandler,
+	delegationHandler *controller.DelegationHandler,
+	transmittalHandler *controller.TransmittalHandler,
+	commentHandler *controller.CommentHandler,
+	sessionRepo interfaces.SessionRepository,
+	tokenKeys interfaces.TokenKeyManager,
+	cfg *config.Config,
+) *App {
+	return &App{
+		log:                  log,
+		authHandler:          authHandler,
+		fileHandler:          fileHandler,
+		fileApprovalsHandler: fileApprovalsHandler,
+		workflowHandler:      workflowHandler,
+		routingHandler:       routingHandler,
+		roleHandler:          roleHandler,
+		userHandler:          userHandler,
+		delegationHandler:    delegationHandler,
+		transmittalHandler:   transmittalHandler,
+		commentHandler:       commentHandler,
+		sessionRepo:          sessionRepo,
+		tokenKeys:            tokenKeys,
+		cfg:                  cfg,
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+func (a *App) Run() error {
+	const op = "httpapp.Run"
+
+	log := a.log.With(
+		slog.String("operation", op),
+		slog.String("port", a.cfg.HTTPServer.Address),
+	)
+
+	router := gin.Default()
+	router.Use(gin.Recovery(), middleware.CORSMiddleware())
+
+	setupRoutes(
+		router,
+		a.authHandler,
+		a.fileHandler,
+		a.fileApprovalsHandler,
+		a.workflowHandler,
+		a.routingHandler,
+		a.roleHandler,
+		a.userHandler,
+		a.delegationHandler,
+		a.transmittalHandler,
+		a.commentHandler,
+		a.sessionRepo,
+		a.tokenKeys,
+	)
+
+	port := a.cfg.HTTPServer.Address
+	log.Info("starting HTTP server", slog.String("port", port))
+
+	srv := &http.Server{
+		Addr:    port,
+		Handler: router,
+	}
+	a.server = srv
+
+	if err := srv.ListenAndServe(); err != nil && err != http.ErrServerClosed {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func setupRoutes(
+	router *gin.Engine,
+	authHandler *controller.AuthHandler,
+	fileHandler *controller.FileHandler,
+	fileApprovalsHandler *controller.FileApprovalsHandler,
+	workflowHandler *controller.WorkflowHandler,
+	routingHandler *controller.RoutingHandler,
+	roleHandler *controller.RoleHandler,
+	userHandler *controller.UserHandler,
+	delegationHandler *controller.DelegationHandler,
+	transmittalHandler *controller.TransmittalHandler,
+	commentHandler *controller.CommentHandler,
+	sessionRepo interfaces.SessionRepository,
+	tokenKeys interfaces.TokenKeyManager,
+) {
+	router.GET("/docs", func(c *gin.Context) {
+		c.Redirect(http.StatusMovedPermanently, "/docs/index.html")
+	})
+	router.GET("/docs/*any", ginSwagger.WrapHandler(swaggerFiles.Handler))
+
+	router.GET("/.well-known/jwks.json", authHandler.JWKS)
+
+	authGroup := router.Group("/auth")
+	{
+		authGroup.POST("/login", authHandler.Login)
+		authGroup.POST("/refresh", authHandler.Refresh)
+		authGroup.POST("/logout", authHandler.Logout)
+		authGroup.GET("/me", middleware.AuthMiddleware(tokenKeys, sessionRepo), authHandler.GetCurrentUser)
+	}
+
+	filesGroup := router.Group("/files", middleware.AuthMiddleware(tokenKeys, sessionRepo))
+	{
+		filesGroup.PUT("/:file_id/approve", fileHandler.ApproveFile)
+		filesGroup.POST("/approve", fileHandler.SubmitDirectory)
+		filesGroup.GET("/:file_id/history", fileHandler.GetFileHistory)
+	}
+
+	approvalsGroup := router.Group("/file-approvals", middleware.AuthMiddleware(tokenKeys, sessionRepo))
+	{
+		approvalsGroup.GET("", fileApprovalsHandler.GetApprovalsByUser)
+		approvalsGroup.GET("/counts", fileApprovalsHandler.CountApprovalsByUser)
+		approvalsGroup.GET("/submitted", fileApprovalsHandler.GetSubmissionsByUser)
+		approvalsGroup.POST("/sign", fileApprovalsHandler.SignApprovals)
+		approvalsGroup.PUT("/:approval_id/sign", fileApprovalsHandler.SignApproval)
+		approvalsGroup.PUT("/:approval_id/annotate", fileApprovalsHandler.AnnotateApproval)
+		approvalsGroup.PUT("/:approval_id/request-changes", fileApprovalsHandler.AnnotateApproval)
+		approvalsGroup.PUT("/:approval_id/reject", fileApprovalsHandler.RejectApproval)
+		approvalsGroup.PUT("/:approval_id/withdraw", fileApprovalsHandler.WithdrawApproval)
+		approvalsGroup.PUT("/:approval_id/finalize", fileApprovalsHandler.FinalizeApproval)
+		approvalsGroup.GET("/:approval_id/history", fileApprovalsHandler.GetApprovalHistory)
+		approvalsGroup.GET("/:approval_id/signatures/verify", fileApprovalsHandler.VerifySignatures)
+		approvalsGroup.GET("/:approval_id/comments", commentHandler.GetComments)
+		approvalsGroup.POST("/:approval_id/comments", commentHandler.AddComment)
+		approvalsGroup.PUT("/:approval_id/comments/:comment_id/resolve", commentHandler.ResolveComment)
+		approvalsGroup.PUT("/:approval_id/comments/:comment_id/reopen", commentHandler.ReopenComment)
+	}
+
+	delegationsGroup := router.Group("/delegations", middleware.AuthMiddleware(tokenKeys, sessionRepo))
+	{
+		delegationsGroup.GET("", delegationHandler.GetDelegations)
+		delegationsGroup.POST("", delegationHandler.CreateDelegation)
+		delegationsGroup.DELETE("/:delegation_id", delegationHandler.DeleteDelegation)
+	}
+
+	transmittalsGroup := router.Group("/transmittals", middleware.AuthMiddleware(tokenKeys, sessionRepo))
+	{
+		transmittalsGroup.GET("", transmittalHandler.GetTransmittals)
+		transmittalsGroup.POST("", transmittalHandler.CreateTransmittal)
+		transmittalsGroup.GET("/:transmittal_id", transmittalHandler.GetTransmittal)
+		transmittalsGroup.DELETE("/:transmittal_id", transmittalHandler.DeleteTransmittal)
+		transmittalsGroup.PUT("/:transmittal_id/submit", transmittalHandler.SubmitTransmittal)
+		transmittalsGroup.GET("/:transmittal_id/manifest", transmittalHandler.ExportManifest)
+	}
+
+	adminGroup := router.Group("/admin", middleware.AuthMiddleware(tokenKeys, sessionRepo))
+	{
+		workflowsGroup := adminGroup.Group("/workflows")
+		{
+			workflowsGroup.GET("", workflowHandler.GetWorkflows)
+			workflowsGroup.GET("/:workflow_id", workflowHandler.GetWorkflowByID)
+			workflowsGroup.POST("", workflowHandler.CreateWorkflow)
+			workflowsGroup.DELETE("", workflowHandler.DeleteWorkflow)
+			// TODO: get workflow by id
+			workflowsGroup.PUT("/:workflow_id", workflowHandler.UpdateWorkflow)
+			workflowsGroup.PUT("/:workflow_id/assign", workflowHandler.AssignWorkflow)
+			workflowsGroup.POST("/:workflow_id/simulate", workflowHandler.SimulateWorkflow)
+			workflowsGroup.PUT("/inherit", workflowHandler.InheritWorkflow)
+			workflowsGroup.GET("/export", workflowHandler.ExportWorkflows)
+			workflowsGroup.POST("/import", workflowHandler.ImportWorkflows)
+
+			workflowsGroup.GET("/routing-rules", routingHandler.GetRoutingRules)
+			workflowsGroup.POST("/routing-rules", routingHandler.CreateRoutingRule)
+			workflowsGroup.PUT("/routing-rules/:rule_id", routingHandler.UpdateRoutingRule)
+			workflowsGroup.DELETE("/routing-rules/:rule_id", routingHandler.DeleteRoutingRule)
+			workflowsGroup.GET("/routing-rules/dry-run/:file_id", routingHandler.DryRunRoute)
+		}
+
+		rolesGroup := adminGroup.Group("/roles")
+		{
+			rolesGroup.GET("", roleHandler.GetRoles)
+			rolesGroup.GET("/permissions", roleHandler.GetPermissions)
+			rolesGroup.GET("/:role_id", roleHandler.GetRole)
+			rolesGroup.POST("", roleHandler.RegisterRole)
+			rolesGroup.PUT("/:role_id", roleHandler.UpdateRole)
+			rolesGroup.PUT("/:role_id/permissions", roleHandler.SetRolePermissions)
+			rolesGroup.DELETE("", roleHandler.DeleteRole)
+		}
+
+		usersGroup := adminGroup.Group("/users")
+		{
+			usersGroup.GET("", userHandler.GetUsers)
+			usersGroup.POST("/register", userHandler.RegisterUser)
+			usersGroup.PUT("/:user_id", userHandler.UpdateUser)
+			usersGroup.PUT("/:user_id/assign", userHandler.AssignUser)
+			usersGroup.DELETE("", userHandler.DeleteUser)
+		}
+
+		adminGroup.GET("/delegations", delegationHandler.GetAllDelegations)
+	}
+
+}
+
+func (a *App) Stop() {
+	a.log.Info("http server is stopping")
+	if a.server == nil {
+		a.log.Info("http server is not running")
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	defer cancel()
+	if err := a.server.Shutdown(ctx); err != nil {
+		a.log.Error("failed to gracefully shutdown http server", slog.String("error", err.Error()))
+	} else {
+		a.log.Info("http server stopped gracefully")
+	}
+}
@@ -0,0 +1,85 @@
+package schedulerapp
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Job - периодическая задача планировщика
+type Job func(ctx context.Context) error
+
+// App периодически запускает фоновую задачу (обработку сроков согласования, доставку outbox,
+// передачу отозванных сессий в file-service, ротацию ключей подписи токенов)
+type App struct {
+	log      *slog.Logger
+	name     string
+	job      Job
+	interval time.Duration
+	ctx      context.Context
+	cancel   context.CancelFunc
+	done     chan struct{}
+}
+
+func New(log *slog.Logger, name string, job Job, interval time.Duration) *App {
+	ctx, cancel := context.WithCancel(context.Background())
+	return &App{
+		log:      log.With(slog.String("scheduler", name)),
+		name:     name,
+		job:      job,
+		interval: interval,
+		ctx:      ctx,
+		cancel:   cancel,
+		done:     make(chan struct{}),
+	}
+}
+
+func (a *App) MustRun() {
+	if err := a.Run(); err != nil {
+		panic(err)
+	}
+}
+
+// Run запускает задачу раз в interval и блокируется до вызова Stop
+func (a *App) Run() error {
+	const op = "schedulerapp.Run"
+
+	defer close(a.done)
+
+	if a.interval <= 0 {
+		return fmt.Errorf("%s: invalid %s scheduler interval %s", op, a.name, a.interval)
+	}
+
+	log := a.log.With(
+		slog.String("operation", op),
+		slog.String("interval", a.interval.String()),
+	)
+	log.Info("starting scheduler")
+
+	ticker := time.NewTicker(a.interval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-a.ctx.Done():
+			return nil
+		case <-ticker.C:
+			if err := a.job(a.ctx); err != nil {
+				log.Error("scheduled job failed", slog.String("error", err.Error()))
+			}
+		}
+	}
+}
+
+func (a *App) Stop() {
+	a.log.Info("scheduler is stopping")
+	a.cancel()
+
+	select {
+	case <-a.done:
+		a.log.Info("scheduler stopped gracefully")
+	case <-time.After(5 * time.Second):
+		a.log.Error("scheduler did not stop in time")
+	}
+}
@@ -0,0 +1,244 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type RoutingHandler struct {
+	usecase interfaces.RoutingUsecase
+}
+
+func NewRoutingHandler(usecase interfaces.RoutingUsecase) *RoutingHandler {
+	return &RoutingHandler{usecase: usecase}
+}
+
+// GetRoutingRules godoc
+// @Summary Получить правила маршрутизации
+// @Description Возвращает правила маршрутизации согласований в порядке их проверки (по возрастанию priority). Требуется разрешение workflow.manage
+// @Tags admin-workflows
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} domain.RoutingRuleResponse "Правила маршрутизации"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении правил"
+// @Router /admin/workflows/routing-rules [get]
+func (h *RoutingHandler) GetRoutingRules(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	rules, err := h.usecase.GetRoutingRules(c.Request.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get routing rules")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, rules)
+}
+
+// CreateRoutingRule godoc
+// @Summary Создать правило маршрутизации
+// @Description Создает правило, которое по атрибутам файла (MIME-тип, шаблон имени, размер, глубина директории, процедура директории) выбирает процедуру согласования и/или пропускаемые этапы. Требуется разрешение workflow.manage
+// @Tags admin-workflows
+// @Security ApiKeyAuth
+// @Param input body domain.RoutingRuleSpec true "Условия и действия правила"
+// @Accept json
+// @Produce json
+// @Success 201 {object} nil "Правило создано"
+// @Failure 400 {object} domain.ErrorResponse "Невалидное тело запроса или правило"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 404 {object} domain.ErrorResponse "Процедура согласования не найдена"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при создании правила"
+// @Router /admin/workflows/routing-rules [post]
+func (h *RoutingHandler) CreateRoutingRule(c *gin.Context) {
+	var req domain.RoutingRuleSpec
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.CreateRoutingRule(c.Request.Context(), req, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrInvalidRoutingRule):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_ROUTING_RULE", "Routing rule conditions or actions are invalid")
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Workflow not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create routing rule")
+		}
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// UpdateRoutingRule godoc
+// @Summary Изменить правило маршрутизации
+// @Description Полностью заменяет условия и действия правила. Уже созданные согласования не меняются. Требуется разрешение workflow.manage
+// @Tags admin-workflows
+// @Security ApiKeyAuth
+// @Param rule_id path string true "ID правила (числовой формат)"
+// @Param input body domain.RoutingRuleSpec true "Условия и действия правила"
+// @Accept json
+// @Produce json
+// @Success 200 {object} nil "Правило изменено"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID, тело запроса или правило"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 404 {object} domain.ErrorResponse "Правило или процедура согласования не найдены"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при изменении правила"
+// @Router /admin/workflows/routing-rules/{rule_id} [put]
+func (h *RoutingHandler) UpdateRoutingRule(c *gin.Context) {
+	ruleIDStr := c.Param("rule_id")
+	ruleID, err := strconv.ParseUint(ruleIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_RULE_ID", "Invalid routing rule ID")
+		return
+	}
+
+	var req domain.RoutingRuleSpec
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.UpdateRoutingRule(c.Request.Context(), uint(ruleID), req, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrInvalidRoutingRule):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_ROUTING_RULE", "Routing rule conditions or actions are invalid")
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Workflow not found")
+		case errors.Is(err, domain.ErrRoutingRuleNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Routing rule not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update routing rule")
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DeleteRoutingRule godoc
+// @Summary Удалить правило маршрутизации
+// @Description Удаляет правило. Уже созданные по нему согласования не меняются. Требуется разрешение workflow.manage
+// @Tags admin-workflows
+// @Security ApiKeyAuth
+// @Param rule_id path string true "ID правила (числовой формат)"
+// @Produce json
+// @Success 204 {object} nil "Правило удалено"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID правила"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 404 {object} domain.ErrorResponse "Правило не найдено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при удалении правила"
+// @Router /admin/workflows/routing-rules/{rule_id} [delete]
+func (h *RoutingHandler) DeleteRoutingRule(c *gin.Context) {
+	ruleIDStr := c.Param("rule_id")
+	ruleID, err := strconv.ParseUint(ruleIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_RULE_ID", "Invalid routing rule ID")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.DeleteRoutingRule(c.Request.Context(), uint(ruleID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrRoutingRuleNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Routing rule not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete routing rule")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// DryRunRoute godoc
+// @Summary Проверить маршрут файла
+// @Description Показывает, какое правило сработает для файла, по какой процедуре и с какими этапами пойдет его согласование. Согласование не создается. Требуется разрешение workflow.manage
+// @Tags admin-workflows
+// @Security ApiKeyAuth
+// @Param file_id path string true "ID файла (числовой формат)"
+// @Produce json
+// @Success 200 {object} domain.ApprovalRoute "Маршрут согласования"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID файла"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 404 {object} domain.ErrorResponse "Файл или процедура согласования не найдены"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при определении маршрута"
+// @Router /admin/workflows/routing-rules/dry-run/{file_id} [get]
+func (h *RoutingHandler) DryRunRoute(c *gin.Context) {
+	fileIDStr := c.Param("file_id")
+	fileID, err := strconv.ParseUint(fileIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FILE_ID", "Invalid file ID")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	route, err := h.usecase.DryRunRoute(c.Request.Context(), uint(fileID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrFileNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "File not found")
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Workflow not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to resolve file route")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, route)
+}
@@ -1,214 +1,254 @@
-package http
-
-import (
-	"errors"
-	"net/http"
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-	"service-core/pkg/utils"
-	"strconv"
-
-	"github.com/gin-gonic/gin"
-)
-
-type UserHandler struct {
-	usecase interfaces.UserUsecase
-}
-
-func NewUserHandler(usecase interfaces.UserUsecase) *UserHandler {
-	return &UserHandler{usecase: usecase}
-}
-
-func (userHandler *UserHandler) GetUsers(c *gin.Context) {
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	users, err := userHandler.usecase.GetUsersGrouped(c.Request.Context(), userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get users")
-		}
-		return
-	}
-
-	c.JSON(http.StatusOK, users)
-}
-
-type userInput struct {
-	Login    string `json:"login"`
-	Password string `json:"password"`
-	RoleID   uint   `json:"role_id"`
-}
-
-// RegisterUser godoc
-// @Summary Регистрация нового пользователя
-// @Description Регистрирует нового пользователя на основе предоставленных данных (логин, пароль, ID роли) и возвращает HTTP статус 201 при успешной регистрации.
-// @Tags auth
-// @Accept json
-// @Produce json
-// @Param input body userInput true "Данные для регистрации пользователя"
-// @Success 201 {object} nil "Пользователь успешно зарегистрирован. Тело ответа пустое."
-// @Failure 400 {object} domain.ErrorResponse "Неверный запрос: отсутствуют обязательные поля или некорректный формат данных."
-// @Failure 409 {object} domain.ErrorResponse "Конфликт: пользователь с таким логином уже существует."
-// @Failure 404 {object} domain.ErrorResponse "Роль с указанным ID не найдена."
-// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера: не удалось зарегистрировать пользователя."
-// @Router /admin/users/register [post]
-func (userHandler *UserHandler) RegisterUser(c *gin.Context) {
-	var req userInput
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	if req.Login == "" || req.Password == "" || req.RoleID == 0 {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "MISSING_FIELDS", "Login, password, and role_id are required")
-		return
-	}
-
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = userHandler.usecase.RegisterUser(c.Request.Context(), req.Login, req.Password, req.RoleID, userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		case errors.Is(err, domain.ErrUserAlreadyExists):
-			utils.SendErrorResponse(c, http.StatusConflict, "USER_ALREADY_EXISTS", "User with this login already exists")
-		case errors.Is(err, domain.ErrRoleNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "ROLE_NOT_FOUND", "Role not found")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to register user")
-		}
-		return
-	}
-
-	c.Status(http.StatusCreated)
-}
-
-func (userHandler *UserHandler) UpdateUser(c *gin.Context) {
-	userIDStr := c.Param("user_id")
-	userID, err := strconv.ParseUint(userIDStr, 10, 64)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
-		return
-	}
-
-	var req userInput
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	actorID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = userHandler.usecase.UpdateUser(c.Request.Context(), req.Login, req.Password, req.RoleID, uint(userID), actorID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		case errors.Is(err, domain.ErrUserNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
-		case errors.Is(err, domain.ErrRoleNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "ROLE_NOT_FOUND", "Role not found")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to register user")
-		}
-		return
-	}
-
-	c.Status(http.StatusNoContent)
-}
-
-type deleteUserInput struct {
-	UserID uint `json:"user_id"`
-}
-
-func (userHandler *UserHandler) DeleteUser(c *gin.Context) {
-	var req deleteUserInput
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	actorID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = userHandler.usecase.DeleteUser(c.Request.Context(), req.UserID, actorID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		case errors.Is(err, domain.ErrUserNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "User not found")
-		case errors.Is(err, domain.ErrCannotDeleteUser):
-			utils.SendErrorResponse(c, http.StatusConflict, "CONFILCT", "Cannot delete user if he is in workflow")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete user")
-		}
-		return
-	}
-
-	c.Status(http.StatusNoContent)
-}
-
-type assignUserInput struct {
-	DirectoryIDs []uint `json:"directory_ids"`
-	FileIDs      []uint `json:"file_ids"`
-}
-
-func (userHandler *UserHandler) AssignUser(c *gin.Context) {
-	userIDStr := c.Param("user_id")
-	userID, err := strconv.ParseUint(userIDStr, 10, 64)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
-		return
-	}
-
-	var req assignUserInput
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	actorID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = userHandler.usecase.AssignUser(c.Request.Context(), uint(userID), req.DirectoryIDs, req.FileIDs, actorID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		case errors.Is(err, domain.ErrUserNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "User not found")
-		// TODO: custom errors
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete user")
-		}
-		return
-	}
-
-	c.Status(http.StatusNoContent)
-}
+package http
+
+import (
+	"errors"
+	"net/http"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type UserHandler struct {
+	usecase interfaces.UserUsecase
+}
+
+func NewUserHandler(usecase interfaces.UserUsecase) *UserHandler {
+	return &UserHandler{usecase: usecase}
+}
+
+func (userHandler *UserHandler) GetUsers(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	users, err := userHandler.usecase.GetUsersGrouped(c.Request.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get users")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, users)
+}
+
+type userInput struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+	RoleID   uint   `json:"role_id"`
+}
+
+// RegisterUser godoc
+// @Summary Регистрация нового пользователя
+// @Description Регистрирует нового пользователя на основе предоставленных данных (логин, пароль, ID роли) и возвращает HTTP статус 201 при успешной регистрации.
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body userInput true "Данные для регистрации пользователя"
+// @Success 201 {object} nil "Пользователь успешно зарегистрирован. Тело ответа пустое."
+// @Failure 400 {object} domain.ErrorResponse "Неверный запрос: отсутствуют обязательные поля или некорректный формат данных."
+// @Failure 409 {object} domain.ErrorResponse "Конфликт: пользователь с таким логином уже существует."
+// @Failure 404 {object} domain.ErrorResponse "Роль с указанным ID не найдена."
+// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера: не удалось зарегистрировать пользователя."
+// @Router /admin/users/register [post]
+func (userHandler *UserHandler) RegisterUser(c *gin.Context) {
+	var req userInput
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.Login == "" || req.Password == "" || req.RoleID == 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "MISSING_FIELDS", "Login, password, and role_id are required")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = userHandler.usecase.RegisterUser(c.Request.Context(), req.Login, req.Password, req.RoleID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrUserAlreadyExists):
+			utils.SendErrorResponse(c, http.StatusConflict, "USER_ALREADY_EXISTS", "User with this login already exists")
+		case errors.Is(err, domain.ErrRoleNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "ROLE_NOT_FOUND", "Role not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to register user")
+		}
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+func (userHandler *UserHandler) UpdateUser(c *gin.Context) {
+	userIDStr := c.Param("user_id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	var req userInput
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	actorID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = userHandler.usecase.UpdateUser(c.Request.Context(), req.Login, req.Password, req.RoleID, uint(userID), actorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrUserNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		case errors.Is(err, domain.ErrRoleNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "ROLE_NOT_FOUND", "Role not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to register user")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type deleteUserInput struct {
+	UserID uint `json:"user_id"`
+}
+
+func (userHandler *UserHandler) DeleteUser(c *gin.Context) {
+	var req deleteUserInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	actorID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = userHandler.usecase.DeleteUser(c.Request.Context(), req.UserID, actorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrUserNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "User not found")
+		case errors.Is(err, domain.ErrCannotDeleteUser):
+			utils.SendErrorResponse(c, http.StatusConflict, "CONFILCT", "Cannot delete user if he is in workflow")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete user")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type assignUserInput struct {
+	Directories []domain.ObjectAccess `json:"directories"`
+	Files       []domain.ObjectAccess `json:"files"`
+
+	// Устаревший формат: перечисленные объекты получают уровень approve
+	DirectoryIDs []uint `json:"directory_ids"`
+	FileIDs      []uint `json:"file_ids"`
+}
+
+// withLegacyIDs добавляет объекты из устаревших полей directory_ids и file_ids с уровнем approve
+func (input assignUserInput) withLegacyIDs() (directories, files []domain.ObjectAccess) {
+	directories = input.Directories
+	for _, id := range input.DirectoryIDs {
+		directories = append(directories, domain.ObjectAccess{ID: id, AccessLevel: domain.AccessLevelApprove})
+	}
+	files = input.Files
+	for _, id := range input.FileIDs {
+		files = append(files, domain.ObjectAccess{ID: id, AccessLevel: domain.AccessLevelApprove})
+	}
+	return directories, files
+}
+
+// AssignUser godoc
+// @Summary Назначение доступа пользователю
+// @Description Заменяет директории и файлы, доступные пользователю, и уровни доступа к ним (read, write, approve, manage).
+// @Description Доступ к директории распространяется на вложенные директории и файлы, уровень deny запрещает унаследованный доступ.
+// @Description Поля directory_ids и file_ids поддерживаются для совместимости и выдают уровень approve. Требуется разрешение directory.assign.
+// @Tags admin
+// @Accept json
+// @Param user_id path int true "ID пользователя"
+// @Param input body assignUserInput true "Директории и файлы с уровнями доступа"
+// @Success 204 {object} nil "Доступ назначен"
+// @Failure 400 {object} domain.ErrorResponse "Неверный запрос или уровень доступа"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения directory.assign"
+// @Failure 404 {object} domain.ErrorResponse "Пользователь, директория или файл не найдены"
+// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /admin/users/{user_id}/assign [put]
+
+func (userHandler *UserHandler) AssignUser(c *gin.Context) {
+	userIDStr := c.Param("user_id")
+	userID, err := strconv.ParseUint(userIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	var req assignUserInput
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	actorID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	directories, files := req.withLegacyIDs()
+
+	err = userHandler.usecase.AssignUser(c.Request.Context(), uint(userID), directories, files, actorID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrInvalidAccessLevel):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_ACCESS_LEVEL", "Access level must be one of deny, read, write, approve, manage")
+		case errors.Is(err, domain.ErrUserNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "User not found")
+		case errors.Is(err, domain.ErrDirectoryNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "DIRECTORY_NOT_FOUND", "Directory not found")
+		case errors.Is(err, domain.ErrFileNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "FILE_NOT_FOUND", "File not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to assign user")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
@@ -1,223 +1,464 @@
-package http
-
-import (
-	"errors"
-	"net/http"
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-	"service-core/pkg/utils"
-	"strconv"
-
-	"github.com/gin-gonic/gin"
-)
-
-type WorkflowHandler struct {
-	usecase interfaces.WorkflowUsecase
-}
-
-func NewWorkflowHandler(usecase interfaces.WorkflowUsecase) *WorkflowHandler {
-	return &WorkflowHandler{usecase: usecase}
-}
-
-// TODO: swagger docs
-func (workflowHandler *WorkflowHandler) GetWorkflows(c *gin.Context) {
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	workflows, err := workflowHandler.usecase.GetWorkflows(c.Request.Context(), userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get workflows")
-		}
-		return
-	}
-
-	c.JSON(http.StatusOK, workflows)
-}
-
-func (workflowHandler *WorkflowHandler) GetWorkflowByID(c *gin.Context) {
-	workflowIDStr := c.Param("workflow_id")
-	workflowID, err := strconv.ParseUint(workflowIDStr, 10, 64)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid workflow ID")
-		return
-	}
-
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	workflow, err := workflowHandler.usecase.GetWorkflowByID(c.Request.Context(), uint(workflowID), userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get workflows")
-		}
-		return
-	}
-
-	c.JSON(http.StatusOK, workflow)
-}
-
-type workflowInput struct {
-	WorkflowName string                 `json:"workflow_name"`
-	Stages       []domain.WorkflowStage `json:"stages"`
-}
-
-// TODO: swagger docs
-func (workflowHandler *WorkflowHandler) CreateWorkflow(c *gin.Context) {
-	var req workflowInput
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = workflowHandler.usecase.CreateWorkflow(c.Request.Context(), req.WorkflowName, req.Stages, userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		case errors.Is(err, domain.ErrUserNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Some users are not found")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get workflows")
-		}
-		return
-	}
-
-	c.Status(http.StatusCreated)
-}
-
-type deleteWorkflowInput struct {
-	WorkflowID uint `json:"workflow_id"`
-}
-
-// TODO: swagger docs
-func (workflowHandler *WorkflowHandler) DeleteWorkflow(c *gin.Context) {
-
-	var req deleteWorkflowInput
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = workflowHandler.usecase.DeleteWorkflow(c.Request.Context(), req.WorkflowID, userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		case errors.Is(err, domain.ErrWorkflowNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Workflow not found")
-		case errors.Is(err, domain.ErrWorkflowInUse):
-			utils.SendErrorResponse(c, http.StatusConflict, "CONFILCT", "Workflow is in use")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete workflow")
-		}
-		return
-	}
-
-	c.Status(http.StatusNoContent)
-}
-
-// TODO: swagger docs
-func (workflowHandler *WorkflowHandler) UpdateWorkflow(c *gin.Context) {
-	workflowIDStr := c.Param("workflow_id")
-	workflowID, err := strconv.ParseUint(workflowIDStr, 10, 64)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid workflow ID")
-		return
-	}
-
-	var req workflowInput
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = workflowHandler.usecase.UpdateWorkflow(c.Request.Context(), uint(workflowID), req.WorkflowName, req.Stages, userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		case errors.Is(err, domain.ErrWorkflowNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Workflow not found")
-		case errors.Is(err, domain.ErrUserNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Some users are not found")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update workflow")
-		}
-		return
-	}
-
-	c.Status(http.StatusNoContent)
-}
-
-type assignWorkflowInput struct {
-	DirectoryIDs []uint `json:"directory_ids"`
-}
-
-// TODO: swagger docs
-func (workflowHandler *WorkflowHandler) AssignWorkflow(c *gin.Context) {
-	workflowIDStr := c.Param("workflow_id")
-	workflowID, err := strconv.ParseUint(workflowIDStr, 10, 64)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid workflow ID")
-		return
-	}
-
-	var req assignWorkflowInput
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = workflowHandler.usecase.AssignWorkflow(c.Request.Context(), uint(workflowID), req.DirectoryIDs, userID)
-	if err != nil {
-		switch {
-		// TODO: custom errors
-		case errors.Is(err, domain.ErrAccessDenied):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update workflow")
-		}
-		return
-	}
-
-	c.Status(http.StatusNoContent)
-}
+package http
+
+import (
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+	"strconv"
+	"strings"
+
+	"github.com/gin-gonic/gin"
+	"gopkg.in/yaml.v3"
+)
+
+type WorkflowHandler struct {
+	usecase interfaces.WorkflowUsecase
+}
+
+func NewWorkflowHandler(usecase interfaces.WorkflowUsecase) *WorkflowHandler {
+	return &WorkflowHandler{usecase: usecase}
+}
+
+// TODO: swagger docs
+func (workflowHandler *WorkflowHandler) GetWorkflows(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	workflows, err := workflowHandler.usecase.GetWorkflows(c.Request.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get workflows")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, workflows)
+}
+
+func (workflowHandler *WorkflowHandler) GetWorkflowByID(c *gin.Context) {
+	workflowIDStr := c.Param("workflow_id")
+	workflowID, err := strconv.ParseUint(workflowIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid workflow ID")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	workflow, err := workflowHandler.usecase.GetWorkflowByID(c.Request.Context(), uint(workflowID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get workflows")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, workflow)
+}
+
+type workflowInput struct {
+	WorkflowName string                 `json:"workflow_name"`
+	Stages       []domain.WorkflowStage `json:"stages"`
+	domain.WorkflowOptions
+}
+
+// TODO: swagger docs
+func (workflowHandler *WorkflowHandler) CreateWorkflow(c *gin.Context) {
+	var req workflowInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = workflowHandler.usecase.CreateWorkflow(c.Request.Context(), req.WorkflowName, req.WorkflowOptions, req.Stages, userID)
+	if err != nil {
+		var validationErr *domain.WorkflowValidationError
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.As(err, &validationErr):
+			sendWorkflowValidationError(c, validationErr)
+		case errors.Is(err, domain.ErrWorkflowNameTaken):
+			utils.SendErrorResponse(c, http.StatusConflict, "WORKFLOW_NAME_TAKEN", "Workflow with this name already exists")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get workflows")
+		}
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// sendWorkflowValidationError отправляет 422 со списком ошибок полей процедуры в error.fields
+func sendWorkflowValidationError(c *gin.Context, validationErr *domain.WorkflowValidationError) {
+	var response domain.ErrorResponse
+	response.Error.Code = "INVALID_WORKFLOW"
+	response.Error.Message = "Workflow is invalid"
+	response.Error.Fields = validationErr.Fields
+	c.JSON(http.StatusUnprocessableEntity, response)
+}
+
+type deleteWorkflowInput struct {
+	WorkflowID uint `json:"workflow_id"`
+}
+
+// TODO: swagger docs
+func (workflowHandler *WorkflowHandler) DeleteWorkflow(c *gin.Context) {
+
+	var req deleteWorkflowInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = workflowHandler.usecase.DeleteWorkflow(c.Request.Context(), req.WorkflowID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Workflow not found")
+		case errors.Is(err, domain.ErrWorkflowInUse):
+			utils.SendErrorResponse(c, http.StatusConflict, "CONFILCT", "Workflow is in use")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete workflow")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// TODO: swagger docs
+func (workflowHandler *WorkflowHandler) UpdateWorkflow(c *gin.Context) {
+	workflowIDStr := c.Param("workflow_id")
+	workflowID, err := strconv.ParseUint(workflowIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid workflow ID")
+		return
+	}
+
+	var req workflowInput
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = workflowHandler.usecase.UpdateWorkflow(c.Request.Context(), uint(workflowID), req.WorkflowName, req.WorkflowOptions, req.Stages, userID)
+	if err != nil {
+		var validationErr *domain.WorkflowValidationError
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Workflow not found")
+		case errors.As(err, &validationErr):
+			sendWorkflowValidationError(c, validationErr)
+		case errors.Is(err, domain.ErrWorkflowNameTaken):
+			utils.SendErrorResponse(c, http.StatusConflict, "WORKFLOW_NAME_TAKEN", "Workflow with this name already exists")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update workflow")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+type assignWorkflowInput struct {
+	DirectoryIDs []uint `json:"directory_ids"`
+}
+
+// TODO: swagger docs
+func (workflowHandler *WorkflowHandler) AssignWorkflow(c *gin.Context) {
+	workflowIDStr := c.Param("workflow_id")
+	workflowID, err := strconv.ParseUint(workflowIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid workflow ID")
+		return
+	}
+
+	var req assignWorkflowInput
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = workflowHandler.usecase.AssignWorkflow(c.Request.Context(), uint(workflowID), req.DirectoryIDs, userID)
+	if err != nil {
+		switch {
+		// TODO: custom errors
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update workflow")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// InheritWorkflow godoc
+// @Summary Вернуть директориям наследование процедуры
+// @Description Снимает с директорий назначенные им процедуры согласования: директории снова используют процедуру ближайшей родительской директории с назначенной процедурой
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Param input body assignWorkflowInput true "ID директорий"
+// @Success 204 "Директории наследуют процедуру родителя"
+// @Failure 400 {object} domain.ErrorResponse "Невалидное тело запроса"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при изменении директорий"
+// @Router /admin/workflows/inherit [put]
+func (workflowHandler *WorkflowHandler) InheritWorkflow(c *gin.Context) {
+	var req assignWorkflowInput
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.DirectoryIDs) == 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = workflowHandler.usecase.InheritWorkflow(c.Request.Context(), req.DirectoryIDs, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to update directories")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SimulateWorkflow godoc
+// @Summary Смоделировать назначение процедуры
+// @Description Показывает, что произойдет при назначении процедуры согласования директориям, ничего не изменяя: какие директории и файлы получат процедуру, по каким маршрутам с учетом правил маршрутизации пойдут файлы, кого попросят подписать на каждом этапе (с замещениями) и какие незавершенные согласования затронуты
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Param workflow_id path int true "ID процедуры согласования"
+// @Param input body assignWorkflowInput true "ID директорий"
+// @Success 200 {object} domain.WorkflowSimulation "Отчет моделирования"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID процедуры или тело запроса"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 404 {object} domain.ErrorResponse "Процедура или директория не найдена"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при моделировании"
+// @Router /admin/workflows/{workflow_id}/simulate [post]
+func (workflowHandler *WorkflowHandler) SimulateWorkflow(c *gin.Context) {
+	workflowID, err := strconv.ParseUint(c.Param("workflow_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_WORKFLOW_ID", "Invalid workflow ID")
+		return
+	}
+
+	var req assignWorkflowInput
+	if err := c.ShouldBindJSON(&req); err != nil || len(req.DirectoryIDs) == 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	simulation, err := workflowHandler.usecase.SimulateWorkflow(c.Request.Context(), uint(workflowID), req.DirectoryIDs, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Workflow not found")
+		case errors.Is(err, domain.ErrDirectoryNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "DIRECTORY_NOT_FOUND", "Directory not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to simulate workflow")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, simulation)
+}
+
+// ExportWorkflows godoc
+// @Summary Выгрузить процедуры согласования
+// @Description Возвращает документ с ролями, процедурами согласования и их назначением директориям. Пользователи указываются логинами, директории - путями от корня. format=yaml (по умолчанию) - YAML-файл, format=json - JSON
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Param format query string false "Формат документа: yaml или json"
+// @Produce application/yaml
+// @Produce json
+// @Success 200 {object} domain.WorkflowBundle "Документ с процедурами согласования"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный формат"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при выгрузке процедур"
+// @Router /admin/workflows/export [get]
+func (workflowHandler *WorkflowHandler) ExportWorkflows(c *gin.Context) {
+	format := c.DefaultQuery("format", "yaml")
+	if format != "yaml" && format != "json" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FORMAT", "Format must be yaml or json")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	bundle, err := workflowHandler.usecase.ExportWorkflows(c.Request.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export workflows")
+		}
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, bundle)
+		return
+	}
+
+	document, err := yaml.Marshal(bundle)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to export workflows")
+		return
+	}
+
+	c.Header("Content-Disposition", `attachment; filename="workflows.yaml"`)
+	c.Data(http.StatusOK, "application/yaml; charset=utf-8", document)
+}
+
+// ImportWorkflows godoc
+// @Summary Загрузить процедуры согласования
+// @Description Принимает документ в формате выгрузки (YAML или JSON по Content-Type) и возвращает список изменений: новые роли, новые процедуры и ревизии существующих (процедуры сопоставляются по имени), назначения директорий. С dry_run=true документ только проверяется. Роли и процедуры, которых нет в документе, не удаляются. Роли и процедуры записываются вместе, затем назначаются директории: если назначение не выполнено, изменение директории содержит код ошибки, а failed - число таких директорий
+// @Tags workflow
+// @Security ApiKeyAuth
+// @Accept application/yaml
+// @Accept json
+// @Produce json
+// @Param dry_run query bool false "Только проверить документ и показать изменения"
+// @Param input body domain.WorkflowBundle true "Документ с процедурами согласования"
+// @Success 200 {object} domain.ImportResult "Изменения; applied=true, если они применены"
+// @Failure 400 {object} domain.ErrorResponse "Документ не разбирается"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 422 {object} domain.ImportResult "Ошибки в полях документа"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при загрузке процедур"
+// @Router /admin/workflows/import [post]
+func (workflowHandler *WorkflowHandler) ImportWorkflows(c *gin.Context) {
+	dryRun, err := strconv.ParseBool(c.DefaultQuery("dry_run", "false"))
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "dry_run must be a boolean")
+		return
+	}
+
+	var bundle domain.WorkflowBundle
+	if err := decodeWorkflowBundle(c.Request, &bundle); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_DOCUMENT", fmt.Sprintf("Invalid document: %v", err))
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	result, err := workflowHandler.usecase.ImportWorkflows(c.Request.Context(), bundle, dryRun, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to import workflows")
+		}
+		return
+	}
+
+	if !result.Valid {
+		c.JSON(http.StatusUnprocessableEntity, result)
+		return
+	}
+
+	for i := range result.Changes {
+		if result.Changes[i].Err != nil {
+			result.Changes[i].Error = "ASSIGN_FAILED"
+		}
+	}
+
+	c.JSON(http.StatusOK, result)
+}
+
+// decodeWorkflowBundle разбирает документ из тела запроса: JSON при Content-Type application/json,
+// иначе YAML. Неизвестные поля считаются ошибкой, чтобы опечатки в документе не терялись
+func decodeWorkflowBundle(r *http.Request, bundle *domain.WorkflowBundle) error {
+	if strings.HasPrefix(r.Header.Get("Content-Type"), "application/json") {
+		decoder := json.NewDecoder(r.Body)
+		decoder.DisallowUnknownFields()
+		return decoder.Decode(bundle)
+	}
+
+	decoder := yaml.NewDecoder(r.Body)
+	decoder.KnownFields(true)
+	return decoder.Decode(bundle)
+}
@@ -1,113 +1,225 @@
-package http
-
-import (
-	"errors"
-	"net/http"
-
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-	"service-core/pkg/utils"
-
-	"github.com/gin-gonic/gin"
-)
-
-type AuthHandler struct {
-	usecase interfaces.AuthUsecase
-}
-
-// конструктор
-func NewAuthHandler(usecase interfaces.AuthUsecase) *AuthHandler {
-	return &AuthHandler{usecase: usecase}
-}
-
-type loginInput struct {
-	Login    string `json:"login"`
-	Password string `json:"password"`
-}
-
-// Login godoc
-// @Summary Аутентификация пользователя
-// @Description Возвращает JWT токен при успешной аутентификации
-// @Tags auth
-// @Accept json
-// @Produce json
-// @Param input body loginInput true "Данные для входа"
-// @Success 200 {object} map[string]string "Токен доступа"
-// @Failure 400 {object} domain.ErrorResponse "Неверный запрос"
-// @Failure 401 {object} domain.ErrorResponse "Неверные учетные данные"
-// @Failure 404 {object} domain.ErrorResponse "Пользователь не найден"
-// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера"
-// @Router /auth/login [post]
-func (h *AuthHandler) Login(c *gin.Context) {
-	var req loginInput
-
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	if req.Login == "" || req.Password == "" {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "MISSING_FIELDS", "Login and password are required")
-		return
-	}
-
-	// вызов Usecase Login
-	token, err := h.usecase.Login(c.Request.Context(), req.Login, req.Password)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrInvalidCredentials):
-			utils.SendErrorResponse(c, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid login or password")
-		case errors.Is(err, domain.ErrUserNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
-		}
-		return
-	}
-
-	// Установка HTTP-only куки
-	c.SetCookie(
-		"auth_token", // Имя куки
-		token,        // Значение куки (токен)
-		3600,         // Время жизни куки в секундах (1 час)
-		"/",          // Путь, для которого куки доступен
-		"",           // Домен (пустая строка = текущий домен)
-		true,         // Secure: true (куки отправляется только по HTTPS)
-		true,         // HttpOnly: true (куки недоступен через JavaScript)
-	)
-
-	c.JSON(http.StatusOK, gin.H{"token": token})
-}
-
-// GetCurrentUser godoc
-// @Summary Получение информации о текущем пользователе
-// @Description Возвращает информацию о пользователе на основе JWT токена, извлеченного из заголовка Authorization.
-// @Tags auth
-// @Security ApiKeyAuth
-// @Produce json
-// @Success 200 {object} domain.GetCurrentUserResponse "Информация о пользователе"
-// @Failure 401 {object} domain.ErrorResponse "Не авторизован: отсутствует или недействителен JWT токен."
-// @Failure 404 {object} domain.ErrorResponse "Пользователь не найден: пользователь с указанным ID в токене не существует."
-// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера: не удалось получить информацию о пользователе."
-// @Router /auth/me [get]
-func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
-		return
-	}
-
-	// вызов Usecase GetCurrentUser
-	userResponse, err := h.usecase.GetCurrentUser(c.Request.Context(), userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrUserNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
-		}
-		return
-	}
-
-	c.JSON(http.StatusOK, userResponse)
+package http
+
+import (
+	"errors"
+	"net/http"
+	"time"
+
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type AuthHandler struct {
+	usecase interfaces.AuthUsecase
+}
+
+// конструктор
+func NewAuthHandler(usecase interfaces.AuthUsecase) *AuthHandler {
+	return &AuthHandler{usecase: usecase}
+}
+
+type loginInput struct {
+	Login    string `json:"login"`
+	Password string `json:"password"`
+}
+
+// Login godoc
+// @Summary Аутентификация пользователя
+// @Description Открывает сессию и возвращает короткоживущий access-токен (JWT) и refresh-токен. Токены также устанавливаются в HTTP-only куки auth_token и refresh_token
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body loginInput true "Данные для входа"
+// @Success 200 {object} domain.AuthTokens "Токены сессии"
+// @Failure 400 {object} domain.ErrorResponse "Неверный запрос"
+// @Failure 401 {object} domain.ErrorResponse "Неверные учетные данные"
+// @Failure 404 {object} domain.ErrorResponse "Пользователь не найден"
+// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/login [post]
+func (h *AuthHandler) Login(c *gin.Context) {
+	var req loginInput
+
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	if req.Login == "" || req.Password == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "MISSING_FIELDS", "Login and password are required")
+		return
+	}
+
+	// вызов Usecase Login
+	tokens, err := h.usecase.Login(c.Request.Context(), req.Login, req.Password)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidCredentials):
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "INVALID_CREDENTIALS", "Invalid login or password")
+		case errors.Is(err, domain.ErrUserNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+
+	setAuthCookies(c, tokens)
+	c.JSON(http.StatusOK, tokens)
+}
+
+type refreshInput struct {
+	RefreshToken string `json:"refresh_token"`
+}
+
+// Refresh godoc
+// @Summary Обновление токенов
+// @Description Обменивает refresh-токен (из куки refresh_token или тела запроса) на новую пару токенов той же сессии. Каждый refresh-токен действует один раз: повторное использование в течение нескольких секунд возвращает тот же новый refresh-токен, позже - отзывает сессию
+// @Tags auth
+// @Accept json
+// @Produce json
+// @Param input body refreshInput false "Refresh-токен, если он не передан в куки"
+// @Success 200 {object} domain.AuthTokens "Новые токены сессии"
+// @Failure 401 {object} domain.ErrorResponse "Refresh-токен отсутствует, недействителен или уже использован"
+// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/refresh [post]
+func (h *AuthHandler) Refresh(c *gin.Context) {
+	refreshToken := refreshTokenFromRequest(c)
+	if refreshToken == "" {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "MISSING_REFRESH_TOKEN", "Refresh token is missing")
+		return
+	}
+
+	tokens, err := h.usecase.Refresh(c.Request.Context(), refreshToken)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRefreshTokenReused):
+			clearAuthCookies(c)
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "REFRESH_TOKEN_REUSED", "Refresh token has already been used, session revoked")
+		case errors.Is(err, domain.ErrInvalidRefreshToken):
+			clearAuthCookies(c)
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "INVALID_REFRESH_TOKEN", "Invalid or expired refresh token")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+
+	setAuthCookies(c, tokens)
+	c.JSON(http.StatusOK, tokens)
+}
+
+// Logout godoc
+// @Summary Выход из системы
+// @Description Отзывает сессию refresh-токена (из куки refresh_token или тела запроса): refresh-токен и все access-токены сессии перестают приниматься. Куки auth_token и refresh_token удаляются. Повторный выход не считается ошибкой
+// @Tags auth
+// @Accept json
+// @Param input body refreshInput false "Refresh-токен, если он не передан в куки"
+// @Success 204 "Сессия завершена"
+// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /auth/logout [post]
+func (h *AuthHandler) Logout(c *gin.Context) {
+	refreshToken := refreshTokenFromRequest(c)
+	if refreshToken != "" {
+		err := h.usecase.Logout(c.Request.Context(), refreshToken)
+		if err != nil && !errors.Is(err, domain.ErrInvalidRefreshToken) {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+			return
+		}
+	}
+
+	clearAuthCookies(c)
+	c.Status(http.StatusNoContent)
+}
+
+// JWKS godoc
+// @Summary Открытые ключи подписи токенов
+// @Description Возвращает JWKS с открытыми ключами Ed25519, которыми проверяются access-токены (по kid из заголовка токена). Кроме активного ключа содержит выведенные из ротации ключи, токены которых еще действуют
+// @Tags auth
+// @Produce json
+// @Success 200 {object} domain.JWKS "Набор ключей"
+// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера"
+// @Router /.well-known/jwks.json [get]
+func (h *AuthHandler) JWKS(c *gin.Context) {
+	jwks, err := h.usecase.GetJWKS(c.Request.Context())
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		return
+	}
+
+	c.Header("Cache-Control", "public, max-age=300")
+	c.JSON(http.StatusOK, jwks)
+}
+
+// refreshTokenFromRequest берет refresh-токен из куки, а если её нет - из тела запроса
+func refreshTokenFromRequest(c *gin.Context) string {
+	if refreshToken, err := c.Cookie("refresh_token"); err == nil && refreshToken != "" {
+		return refreshToken
+	}
+
+	var req refreshInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		return ""
+	}
+	return req.RefreshToken
+}
+
+// setAuthCookies устанавливает HTTP-only куки с токенами. Куки refresh-токена отправляется
+// только на /auth: обновление и выход
+func setAuthCookies(c *gin.Context, tokens domain.AuthTokens) {
+	c.SetCookie(
+		"auth_token",                   // Имя куки
+		tokens.AccessToken,             // Значение куки (токен)
+		cookieMaxAge(tokens.ExpiresAt), // Время жизни куки в секундах - до истечения токена
+		"/",                            // Путь, для которого куки доступен
+		"",                             // Домен (пустая строка = текущий домен)
+		true,                           // Secure: true (куки отправляется только по HTTPS)
+		true,                           // HttpOnly: true (куки недоступен через JavaScript)
+	)
+	c.SetCookie("refresh_token", tokens.RefreshToken, cookieMaxAge(tokens.RefreshExpiresAt), "/auth", "", true, true)
+}
+
+// clearAuthCookies удаляет куки с токенами
+func clearAuthCookies(c *gin.Context) {
+	c.SetCookie("auth_token", "", -1, "/", "", true, true)
+	c.SetCookie("refresh_token", "", -1, "/auth", "", true, true)
+}
+
+func cookieMaxAge(expiresAt time.Time) int {
+	return max(int(time.Until(expiresAt).Seconds()), 1)
+}
+
+// GetCurrentUser godoc
+// @Summary Получение информации о текущем пользователе
+// @Description Возвращает информацию о пользователе на основе JWT токена, извлеченного из заголовка Authorization.
+// @Tags auth
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {object} domain.GetCurrentUserResponse "Информация о пользователе"
+// @Failure 401 {object} domain.ErrorResponse "Не авторизован: отсутствует или недействителен JWT токен."
+// @Failure 404 {object} domain.ErrorResponse "Пользователь не найден: пользователь с указанным ID в токене не существует."
+// @Failure 500 {object} domain.ErrorResponse "Внутренняя ошибка сервера: не удалось получить информацию о пользователе."
+// @Router /auth/me [get]
+func (h *AuthHandler) GetCurrentUser(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+		return
+	}
+
+	// вызов Usecase GetCurrentUser
+	userResponse, err := h.usecase.GetCurrentUser(c.Request.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrUserNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "User not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Internal server error")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, userResponse)
 }
\ No newline at end of file
@@ -0,0 +1,206 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type CommentHandler struct {
+	usecase interfaces.CommentUsecase
+}
+
+func NewCommentHandler(usecase interfaces.CommentUsecase) *CommentHandler {
+	return &CommentHandler{usecase: usecase}
+}
+
+type createCommentResponse struct {
+	CommentID uint `json:"comment_id" example:"12"`
+}
+
+// GetComments godoc
+// @Summary Получить обсуждения согласования
+// @Description Возвращает обсуждения согласования с вложенными ответами. Параметр file_version оставляет только обсуждения указанной версии файла. Доступно участникам согласования и пользователям с разрешением approval.view.any.
+// @Tags comment
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Param file_version query int false "Версия файла"
+// @Produce json
+// @Success 200 {array} domain.CommentResponse "Обсуждения согласования"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID согласования или версия файла"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не участвует в согласовании"
+// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении обсуждений"
+// @Router /file-approvals/{approval_id}/comments [get]
+func (h *CommentHandler) GetComments(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	approvalID, err := strconv.ParseUint(c.Param("approval_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	fileVersion, err := strconv.Atoi(c.DefaultQuery("file_version", "0"))
+	if err != nil || fileVersion < 0 {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FILE_VERSION", "Invalid file version")
+		return
+	}
+
+	comments, err := h.usecase.GetComments(c.Request.Context(), uint(approvalID), fileVersion, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User is not a participant of this approval")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get comments")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, comments)
+}
+
+// AddComment godoc
+// @Summary Добавить комментарий
+// @Description Открывает обсуждение текущей версии файла согласования или отвечает в обсуждении (parent_id). В согласовании комплекта file_id указывает обсуждаемый файл комплекта. Упомянутые пользователи (mentions) получают уведомление. Доступно участникам согласования и пользователям с разрешением approval.view.any.
+// @Tags comment
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Param input body domain.CommentInput true "Текст, обсуждение, файл и упоминания"
+// @Accept json
+// @Produce json
+// @Success 201 {object} createCommentResponse "Комментарий добавлен"
+// @Failure 400 {object} domain.ErrorResponse "Невалидное тело запроса, пустой текст или файл не входит в согласование"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не участвует в согласовании"
+// @Failure 404 {object} domain.ErrorResponse "Согласование, комментарий или упомянутый пользователь не найдены"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при добавлении комментария"
+// @Router /file-approvals/{approval_id}/comments [post]
+func (h *CommentHandler) AddComment(c *gin.Context) {
+	var req domain.CommentInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	approvalID, err := strconv.ParseUint(c.Param("approval_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	commentID, err := h.usecase.AddComment(c.Request.Context(), uint(approvalID), req, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User is not a participant of this approval")
+		case errors.Is(err, domain.ErrCommentNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "COMMENT_NOT_FOUND", "Parent comment not found")
+		case errors.Is(err, domain.ErrUserNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "USER_NOT_FOUND", "Some mentioned users are not found")
+		case errors.Is(err, domain.ErrInvalidComment):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_COMMENT", "Comment must have a text and refer to a file of the approval")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to add comment")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, createCommentResponse{CommentID: commentID})
+}
+
+// ResolveComment godoc
+// @Summary Разрешить обсуждение
+// @Description Отмечает обсуждение разрешенным. Разрешить обсуждение может его автор, отправитель файла на согласование и пользователь с разрешением approval.finalize.any.
+// @Tags comment
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Param comment_id path string true "ID комментария, открывающего обсуждение (числовой формат)"
+// @Success 204 {object} nil "Обсуждение разрешено"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID или комментарий является ответом"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет прав на изменение обсуждения"
+// @Failure 404 {object} domain.ErrorResponse "Согласование или комментарий не найдены"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при изменении обсуждения"
+// @Router /file-approvals/{approval_id}/comments/{comment_id}/resolve [put]
+func (h *CommentHandler) ResolveComment(c *gin.Context) {
+	h.setResolved(c, true)
+}
+
+// ReopenComment godoc
+// @Summary Открыть обсуждение снова
+// @Description Снимает отметку о разрешении обсуждения. Доступно тем же пользователям, что и разрешение.
+// @Tags comment
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Param comment_id path string true "ID комментария, открывающего обсуждение (числовой формат)"
+// @Success 204 {object} nil "Обсуждение открыто"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID или комментарий является ответом"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет прав на изменение обсуждения"
+// @Failure 404 {object} domain.ErrorResponse "Согласование или комментарий не найдены"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при изменении обсуждения"
+// @Router /file-approvals/{approval_id}/comments/{comment_id}/reopen [put]
+func (h *CommentHandler) ReopenComment(c *gin.Context) {
+	h.setResolved(c, false)
+}
+
+func (h *CommentHandler) setResolved(c *gin.Context, resolved bool) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	approvalID, err := strconv.ParseUint(c.Param("approval_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	commentID, err := strconv.ParseUint(c.Param("comment_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_COMMENT_ID", "Invalid comment ID")
+		return
+	}
+
+	err = h.usecase.ResolveComment(c.Request.Context(), uint(approvalID), uint(commentID), resolved, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrCommentNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "COMMENT_NOT_FOUND", "Comment not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User cannot change this comment thread")
+		case errors.Is(err, domain.ErrInvalidComment):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_COMMENT", "Only a comment that opens a thread can be resolved")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to change comment thread")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
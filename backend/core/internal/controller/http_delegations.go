@@ -0,0 +1,179 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+	"strconv"
+	"time"
+
+	"github.com/gin-gonic/gin"
+)
+
+type DelegationHandler struct {
+	usecase interfaces.DelegationUsecase
+}
+
+func NewDelegationHandler(usecase interfaces.DelegationUsecase) *DelegationHandler {
+	return &DelegationHandler{usecase: usecase}
+}
+
+type createDelegationInput struct {
+	DelegatorID uint      `json:"delegator_id"`
+	DelegateID  uint      `json:"delegate_id" binding:"required"`
+	StartsAt    time.Time `json:"starts_at" binding:"required"`
+	EndsAt      time.Time `json:"ends_at" binding:"required"`
+}
+
+// CreateDelegation godoc
+// @Summary Назначить заместителя
+// @Description Назначает заместителя, который в указанный период может подписывать согласования вместо замещаемого. Если delegator_id не указан, заместитель назначается текущему пользователю. Назначать заместителя другому пользователю может только пользователь с разрешением delegation.manage.
+// @Tags delegation
+// @Security ApiKeyAuth
+// @Param input body createDelegationInput true "Замещаемый, заместитель и период"
+// @Accept json
+// @Produce json
+// @Success 201 {object} nil "Замещение создано"
+// @Failure 400 {object} domain.ErrorResponse "Невалидное тело запроса или период замещения"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет прав назначать заместителя другому пользователю"
+// @Failure 404 {object} domain.ErrorResponse "Пользователь не найден"
+// @Failure 409 {object} domain.ErrorResponse "Период пересекается с другим замещением этого пользователя"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при создании замещения"
+// @Router /delegations [post]
+func (h *DelegationHandler) CreateDelegation(c *gin.Context) {
+	var req createDelegationInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.CreateDelegation(c.Request.Context(), req.DelegatorID, req.DelegateID, req.StartsAt, req.EndsAt, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrInvalidDelegation):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_DELEGATION", "Delegate or delegation period is invalid")
+		case errors.Is(err, domain.ErrUserNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "User not found")
+		case errors.Is(err, domain.ErrDelegationOverlap):
+			utils.SendErrorResponse(c, http.StatusConflict, "DELEGATION_OVERLAP", "Delegation period overlaps another delegation of this user")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create delegation")
+		}
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// GetDelegations godoc
+// @Summary Получить замещения пользователя
+// @Description Возвращает замещения, в которых текущий пользователь замещаемый или заместитель
+// @Tags delegation
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} domain.DelegationResponse "Список замещений"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении замещений"
+// @Router /delegations [get]
+func (h *DelegationHandler) GetDelegations(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	delegations, err := h.usecase.GetDelegations(c.Request.Context(), userID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get delegations")
+		return
+	}
+
+	c.JSON(http.StatusOK, delegations)
+}
+
+// GetAllDelegations godoc
+// @Summary Получить все замещения
+// @Description Возвращает все замещения. Требуется разрешение delegation.manage
+// @Tags delegation
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} domain.DelegationResponse "Список замещений"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет разрешения"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении замещений"
+// @Router /admin/delegations [get]
+func (h *DelegationHandler) GetAllDelegations(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	delegations, err := h.usecase.GetAllDelegations(c.Request.Context(), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get delegations")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, delegations)
+}
+
+// DeleteDelegation godoc
+// @Summary Отменить замещение
+// @Description Отменяет замещение. Доступно замещаемому, тому, кто назначил замещение, и пользователям с разрешением delegation.manage
+// @Tags delegation
+// @Security ApiKeyAuth
+// @Param delegation_id path string true "ID замещения (числовой формат)"
+// @Produce json
+// @Success 204 {object} nil "Замещение отменено"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID замещения"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет прав отменить замещение"
+// @Failure 404 {object} domain.ErrorResponse "Замещение не найдено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при отмене замещения"
+// @Router /delegations/{delegation_id} [delete]
+func (h *DelegationHandler) DeleteDelegation(c *gin.Context) {
+	delegationIDStr := c.Param("delegation_id")
+	delegationID, err := strconv.ParseUint(delegationIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_DELEGATION_ID", "Invalid delegation ID")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.DeleteDelegation(c.Request.Context(), uint(delegationID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no access")
+		case errors.Is(err, domain.ErrDelegationNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Delegation not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete delegation")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
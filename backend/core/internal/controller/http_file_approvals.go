@@ -1,192 +1,640 @@
-package http
-
-import (
-	"errors"
-	"net/http"
-	"strconv"
-
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-	"service-core/pkg/utils"
-
-	"github.com/gin-gonic/gin"
-)
-
-type FileApprovalsHandler struct {
-	usecase interfaces.ApprovalUsecase
-}
-
-func NewFileApprovalsHandler(usecase interfaces.ApprovalUsecase) *FileApprovalsHandler {
-	return &FileApprovalsHandler{usecase: usecase}
-}
-
-// GetApprovalsByUser godoc
-// @Summary Получить список согласований пользователя
-// @Description Возвращает все согласования, в которых участвует текущий пользователь
-// @Tags approval
-// @Security ApiKeyAuth
-// @Accept json
-// @Produce json
-// @Success 200 {array} domain.Approval "Список согласований"
-// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
-// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении данных"
-// @Router /file-approvals [get]
-func (h *FileApprovalsHandler) GetApprovalsByUser(c *gin.Context) {
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	approvals, err := h.usecase.GetApprovalsByUserID(c.Request.Context(), userID)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch approvals")
-		return
-	}
-
-	c.JSON(http.StatusOK, approvals)
-}
-
-// SignApproval godoc
-// @Summary Подписать согласование
-// @Description Подтверждает согласование текущим пользователем. Пользователь должен иметь права на подписание.
-// @Tags approval
-// @Security ApiKeyAuth
-// @Param approval_id path string true "ID согласования (числовой формат, например: 456)"
-// @Accept json
-// @Produce json
-// @Success 204 {object} nil "Согласование успешно подписано"
-// @Failure 400 {object} domain.ErrorResponse "Невалидный ID согласования"
-// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
-// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав или требуется завершение согласования"
-// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
-// @Failure 500 {object} domain.ErrorResponse "Ошибка при обработке подписи"
-// @Router /file-approvals/{approval_id}/sign [put]
-func (h *FileApprovalsHandler) SignApproval(c *gin.Context) {
-	approvalIDStr := c.Param("approval_id")
-	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
-		return
-	}
-
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = h.usecase.SignApproval(c.Request.Context(), uint(approvalID), userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrApprovalNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
-		case errors.Is(err, domain.ErrNoPermission):
-			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User has no permission to sign approval or need to finalize it")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign approval")
-		}
-
-		return
-	}
-	c.Status(http.StatusNoContent)
-}
-
-type annotateApprovalInput struct {
-	Message string `json:"message"`
-}
-
-// AnnotateApproval godoc
-// @Summary Добавить комментарий к согласованию
-// @Description Добавляет примечание к согласованию. Пользователь должен участвовать в этом согласовании.
-// @Tags approval
-// @Security ApiKeyAuth
-// @Param approval_id path string true "ID согласования (числовой формат)"
-// @Param message body annotateApprovalInput true "Текст примечания"
-// @Accept json
-// @Produce json
-// @Success 204 {object} nil "Примечание добавлено"
-// @Failure 400 {object} domain.ErrorResponse "Невалидный ID или тело запроса"
-// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
-// @Failure 403 {object} domain.ErrorResponse "Пользователь не участвует в согласовании"
-// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
-// @Failure 500 {object} domain.ErrorResponse "Ошибка при добавлении примечания"
-// @Router /file-approvals/{approval_id}/annotate [put]
-func (h *FileApprovalsHandler) AnnotateApproval(c *gin.Context) {
-	approvalIDStr := c.Param("approval_id")
-	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
-		return
-	}
-
-	var req annotateApprovalInput
-	if err := c.ShouldBindJSON(&req); err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
-		return
-	}
-
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = h.usecase.AnnotateApproval(c.Request.Context(), uint(approvalID), userID, req.Message)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrApprovalNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
-		case errors.Is(err, domain.ErrNoPermission):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no permission to annotate this approval")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to annotate approval")
-		}
-		return
-	}
-	c.Status(http.StatusNoContent)
-}
-
-// FinalizeApproval godoc
-// @Summary Завершить согласование
-// @Description Завершает процесс согласования. Доступно только последнему участнику в цепочке.
-// @Tags approval
-// @Security ApiKeyAuth
-// @Param approval_id path string true "ID согласования (числовой формат)"
-// @Accept json
-// @Produce json
-// @Success 204 {object} nil "Согласование завершено"
-// @Failure 400 {object} domain.ErrorResponse "Невалидный ID согласования"
-// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
-// @Failure 403 {object} domain.ErrorResponse "Только последний участник может завершить согласование"
-// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
-// @Failure 500 {object} domain.ErrorResponse "Ошибка при завершении согласования"
-// @Router /file-approvals/{approval_id}/finalize [put]
-func (h *FileApprovalsHandler) FinalizeApproval(c *gin.Context) {
-	approvalIDStr := c.Param("approval_id")
-	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
-		return
-	}
-
-	userID, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	err = h.usecase.FinalizeApproval(c.Request.Context(), uint(approvalID), userID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrApprovalNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
-		case errors.Is(err, domain.ErrNoPermission):
-			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "Only the last user in the workflow can finalize this approval")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to finalize approval")
-		}
-		return
-	}
-	c.Status(http.StatusNoContent)
-}
+package http
+
+import (
+	"errors"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FileApprovalsHandler struct {
+	usecase interfaces.ApprovalUsecase
+}
+
+func NewFileApprovalsHandler(usecase interfaces.ApprovalUsecase) *FileApprovalsHandler {
+	return &FileApprovalsHandler{usecase: usecase}
+}
+
+// GetApprovalsByUser godoc
+// @Summary Получить список согласований пользователя
+// @Description Возвращает согласования раздела status списка согласований текущего пользователя со сроком текущего этапа и признаками просрочки и эскалации.
+// @Description Если задан limit и есть следующая страница, её курсор возвращается в заголовке X-Next-Cursor
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param status query string false "Раздел: pending (по умолчанию), signed, finalized, annotated"
+// @Param from query string false "Созданы не раньше даты (RFC 3339 или YYYY-MM-DD)"
+// @Param to query string false "Созданы не позже даты (RFC 3339 или YYYY-MM-DD включительно)"
+// @Param workflow_id query int false "ID процедуры согласования"
+// @Param directory_id query int false "ID директории (с вложенными директориями)"
+// @Param search query string false "Подстрока имени файла, номера или названия комплекта"
+// @Param sort query string false "Сортировка: -created_at (по умолчанию), created_at, due_at"
+// @Param limit query int false "Размер страницы (до 500), без limit возвращаются все согласования"
+// @Param cursor query string false "Курсор следующей страницы из заголовка X-Next-Cursor"
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.ApprovalResponse "Список согласований"
+// @Header 200 {string} X-Next-Cursor "Курсор следующей страницы"
+// @Failure 400 {object} domain.ErrorResponse "Невалидные фильтры или курсор, фильтр подходит под слишком много файлов"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 404 {object} domain.ErrorResponse "Директория не найдена"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении данных"
+// @Router /file-approvals [get]
+func (h *FileApprovalsHandler) GetApprovalsByUser(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	filter, err := approvalFilterFromQuery(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FILTER", err.Error())
+		return
+	}
+
+	approvals, nextCursor, err := h.usecase.GetApprovalsByUserID(c.Request.Context(), userID, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidFilter):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FILTER", "Invalid approvals filter")
+		case errors.Is(err, domain.ErrInvalidCursor):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_CURSOR", "Invalid page cursor")
+		case errors.Is(err, domain.ErrFilterTooBroad):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "FILTER_TOO_BROAD", "Directory or search filter matches too many files")
+		case errors.Is(err, domain.ErrDirectoryNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Directory not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch approvals")
+		}
+		return
+	}
+
+	if nextCursor != "" {
+		c.Header("X-Next-Cursor", nextCursor)
+	}
+	if approvals == nil {
+		approvals = []domain.ApprovalResponse{}
+	}
+
+	c.JSON(http.StatusOK, approvals)
+}
+
+// CountApprovalsByUser godoc
+// @Summary Получить количество согласований пользователя по разделам
+// @Description Возвращает количество согласований в каждом разделе списка согласований текущего пользователя с учетом фильтров
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param from query string false "Созданы не раньше даты (RFC 3339 или YYYY-MM-DD)"
+// @Param to query string false "Созданы не позже даты (RFC 3339 или YYYY-MM-DD включительно)"
+// @Param workflow_id query int false "ID процедуры согласования"
+// @Param directory_id query int false "ID директории (с вложенными директориями)"
+// @Param search query string false "Подстрока имени файла, номера или названия комплекта"
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.ApprovalCounts "Количество согласований по разделам"
+// @Failure 400 {object} domain.ErrorResponse "Невалидные фильтры, фильтр подходит под слишком много файлов"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 404 {object} domain.ErrorResponse "Директория не найдена"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении данных"
+// @Router /file-approvals/counts [get]
+func (h *FileApprovalsHandler) CountApprovalsByUser(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	filter, err := approvalFilterFromQuery(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FILTER", err.Error())
+		return
+	}
+
+	counts, err := h.usecase.CountApprovalsByUserID(c.Request.Context(), userID, filter)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidFilter):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FILTER", "Invalid approvals filter")
+		case errors.Is(err, domain.ErrFilterTooBroad):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "FILTER_TOO_BROAD", "Directory or search filter matches too many files")
+		case errors.Is(err, domain.ErrDirectoryNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Directory not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to count approvals")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, counts)
+}
+
+// GetSubmissionsByUser godoc
+// @Summary Получить согласования, отправленные пользователем
+// @Description Возвращает согласования, отправленные текущим пользователем, начиная с новых: текущий этап, неподписанные места этапа,
+// @Description время прохождения каждого этапа и замечания согласующих
+// @Tags approval
+// @Security ApiKeyAuth
+// @Accept json
+// @Produce json
+// @Success 200 {array} domain.SubmissionResponse "Список отправленных согласований"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении данных"
+// @Router /file-approvals/submitted [get]
+func (h *FileApprovalsHandler) GetSubmissionsByUser(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	submissions, err := h.usecase.GetSubmissionsByUserID(c.Request.Context(), userID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to fetch submissions")
+		return
+	}
+
+	c.JSON(http.StatusOK, submissions)
+}
+
+// approvalFilterFromQuery разбирает фильтры списка согласований из параметров запроса.
+// Дата без времени в to включает весь день
+func approvalFilterFromQuery(c *gin.Context) (domain.ApprovalFilter, error) {
+	filter := domain.ApprovalFilter{
+		Status: c.Query("status"),
+		Search: c.Query("search"),
+		Sort:   c.Query("sort"),
+		Cursor: c.Query("cursor"),
+	}
+
+	var err error
+	if value := c.Query("from"); value != "" {
+		if filter.From, _, err = parseFilterDate(value); err != nil {
+			return filter, errors.New("invalid from date")
+		}
+	}
+	if value := c.Query("to"); value != "" {
+		var dateOnly bool
+		if filter.To, dateOnly, err = parseFilterDate(value); err != nil {
+			return filter, errors.New("invalid to date")
+		}
+		if dateOnly {
+			filter.To = filter.To.AddDate(0, 0, 1)
+		}
+	}
+
+	ids := map[string]*uint{
+		"workflow_id":  &filter.WorkflowID,
+		"directory_id": &filter.DirectoryID,
+	}
+	for name, target := range ids {
+		if value := c.Query(name); value != "" {
+			id, err := strconv.ParseUint(value, 10, 64)
+			if err != nil {
+				return filter, fmt.Errorf("invalid %s", name)
+			}
+			*target = uint(id)
+		}
+	}
+
+	if value := c.Query("limit"); value != "" {
+		if filter.Limit, err = strconv.Atoi(value); err != nil {
+			return filter, errors.New("invalid limit")
+		}
+	}
+
+	return filter, nil
+}
+
+// parseFilterDate разбирает дату в формате RFC 3339 или YYYY-MM-DD (dateOnly = true)
+func parseFilterDate(value string) (date time.Time, dateOnly bool, err error) {
+	if date, err = time.Parse(time.RFC3339, value); err == nil {
+		return date, false, nil
+	}
+	date, err = time.Parse(time.DateOnly, value)
+	return date, true, err
+}
+
+// SignApproval godoc
+// @Summary Подписать согласование
+// @Description Подтверждает согласование текущим пользователем. Пользователь должен иметь права на подписание.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат, например: 456)"
+// @Accept json
+// @Produce json
+// @Success 204 {object} nil "Согласование успешно подписано"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID согласования"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Недостаточно прав или требуется завершение согласования"
+// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
+// @Failure 409 {object} domain.ErrorResponse "Пользователь или другой участник его роли уже подписал текущий этап"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при обработке подписи"
+// @Router /file-approvals/{approval_id}/sign [put]
+func (h *FileApprovalsHandler) SignApproval(c *gin.Context) {
+	approvalIDStr := c.Param("approval_id")
+	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.SignApproval(c.Request.Context(), uint(approvalID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrNoPermission):
+			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User has no permission to sign approval or need to finalize it")
+		case errors.Is(err, domain.ErrAlreadySigned):
+			utils.SendErrorResponse(c, http.StatusConflict, "ALREADY_SIGNED", "User has already signed current approval stage")
+		case errors.Is(err, domain.ErrRoleSlotSigned):
+			utils.SendErrorResponse(c, http.StatusConflict, "ALREADY_SIGNED", "Another member of the role has already signed current approval stage")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign approval")
+		}
+
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+type annotateApprovalInput struct {
+	Message string `json:"message"`
+}
+
+// AnnotateApproval godoc
+// @Summary Запросить изменения
+// @Description Добавляет примечание к согласованию и возвращает файл в черновик. После обновления файла согласование продолжится с текущего этапа. Пользователь должен участвовать в этом согласовании.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Param message body annotateApprovalInput true "Текст примечания"
+// @Accept json
+// @Produce json
+// @Success 204 {object} nil "Примечание добавлено"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID или тело запроса"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не участвует в согласовании"
+// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при добавлении примечания"
+// @Router /file-approvals/{approval_id}/annotate [put]
+// @Router /file-approvals/{approval_id}/request-changes [put]
+func (h *FileApprovalsHandler) AnnotateApproval(c *gin.Context) {
+	approvalIDStr := c.Param("approval_id")
+	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	var req annotateApprovalInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.AnnotateApproval(c.Request.Context(), uint(approvalID), userID, req.Message)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrNoPermission):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no permission to annotate this approval")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to annotate approval")
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// RejectApproval godoc
+// @Summary Отклонить согласование
+// @Description Окончательно отклоняет согласование. Файл получает статус "rejected" и не может быть отправлен на согласование повторно. Пользователь должен участвовать в текущем этапе согласования.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Param message body annotateApprovalInput true "Причина отказа"
+// @Accept json
+// @Produce json
+// @Success 204 {object} nil "Согласование отклонено"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID или тело запроса"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не участвует в текущем этапе согласования"
+// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при отклонении согласования"
+// @Router /file-approvals/{approval_id}/reject [put]
+func (h *FileApprovalsHandler) RejectApproval(c *gin.Context) {
+	approvalIDStr := c.Param("approval_id")
+	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	var req annotateApprovalInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.RejectApproval(c.Request.Context(), uint(approvalID), userID, req.Message)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrNoPermission):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no permission to reject this approval")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to reject approval")
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// WithdrawApproval godoc
+// @Summary Отозвать согласование
+// @Description Отзывает незавершённое согласование. Файл возвращается в статус "draft" и пропадает из очередей подписантов. Доступно пользователю, отправившему файл на согласование, и пользователям с разрешением approval.finalize.any.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Produce json
+// @Success 204 {object} nil "Согласование отозвано"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID согласования"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не отправлял файл на согласование"
+// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено или уже завершено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при отзыве согласования"
+// @Router /file-approvals/{approval_id}/withdraw [put]
+func (h *FileApprovalsHandler) WithdrawApproval(c *gin.Context) {
+	approvalIDStr := c.Param("approval_id")
+	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.WithdrawApproval(c.Request.Context(), uint(approvalID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "Only the submitter or an admin can withdraw this approval")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to withdraw approval")
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// FinalizeApproval godoc
+// @Summary Завершить согласование
+// @Description Подписывает последний этап согласования. Согласование завершается, когда выполнено правило последнего этапа.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Accept json
+// @Produce json
+// @Success 204 {object} nil "Согласование завершено"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID согласования"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Только участник последнего этапа может завершить согласование"
+// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
+// @Failure 409 {object} domain.ErrorResponse "Пользователь или другой участник его роли уже подписал последний этап или есть неразрешенные обсуждения"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при завершении согласования"
+// @Router /file-approvals/{approval_id}/finalize [put]
+func (h *FileApprovalsHandler) FinalizeApproval(c *gin.Context) {
+	approvalIDStr := c.Param("approval_id")
+	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	err = h.usecase.FinalizeApproval(c.Request.Context(), uint(approvalID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrNoPermission):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "Only the last stage users in the workflow can finalize this approval")
+		case errors.Is(err, domain.ErrAlreadySigned):
+			utils.SendErrorResponse(c, http.StatusConflict, "ALREADY_SIGNED", "User has already signed last approval stage")
+		case errors.Is(err, domain.ErrRoleSlotSigned):
+			utils.SendErrorResponse(c, http.StatusConflict, "ALREADY_SIGNED", "Another member of the role has already signed last approval stage")
+		case errors.Is(err, domain.ErrUnresolvedComments):
+			utils.SendErrorResponse(c, http.StatusConflict, "UNRESOLVED_COMMENTS", "All comment threads must be resolved before finalization")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to finalize approval")
+		}
+		return
+	}
+	c.Status(http.StatusNoContent)
+}
+
+// GetApprovalHistory godoc
+// @Summary Получить журнал согласования
+// @Description Возвращает все действия по согласованию (отправка, подписи, замечания, завершение, отзыв) в хронологическом порядке. Доступно участникам согласования и пользователям с разрешением approval.view.any.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Produce json
+// @Success 200 {array} domain.ApprovalEventResponse "Журнал согласования"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID согласования"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не участвует в согласовании"
+// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении журнала"
+// @Router /file-approvals/{approval_id}/history [get]
+func (h *FileApprovalsHandler) GetApprovalHistory(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	approvalIDStr := c.Param("approval_id")
+	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	events, err := h.usecase.GetApprovalHistory(c.Request.Context(), uint(approvalID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User is not a participant of this approval")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get approval history")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+// VerifySignatures godoc
+// @Summary Проверить подписи согласования
+// @Description Проверяет цепочку подписей решений согласования: связь подписей, подписи открытыми ключами подписантов и совпадение подписанных хэшей с содержимым версий файлов в хранилище. Доступно участникам согласования и пользователям с разрешением approval.view.any.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param approval_id path string true "ID согласования (числовой формат)"
+// @Produce json
+// @Success 200 {object} domain.SignatureVerification "Результат проверки по каждой подписи"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID согласования"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не участвует в согласовании"
+// @Failure 404 {object} domain.ErrorResponse "Согласование не найдено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при проверке подписей"
+// @Router /file-approvals/{approval_id}/signatures/verify [get]
+func (h *FileApprovalsHandler) VerifySignatures(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	approvalIDStr := c.Param("approval_id")
+	approvalID, err := strconv.ParseUint(approvalIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_APPROVAL_ID", "Invalid approval ID")
+		return
+	}
+
+	verification, err := h.usecase.VerifySignatures(c.Request.Context(), uint(approvalID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Approval not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User is not a participant of this approval")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to verify approval signatures")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, verification)
+}
+
+type signApprovalsInput struct {
+	ApprovalIDs []uint `json:"approval_ids" binding:"required"`
+}
+
+// SignApprovals godoc
+// @Summary Подписать несколько согласований
+// @Description Подписывает список согласований. Если пользователь подписывает последний этап, согласование завершается. Результат возвращается по каждому согласованию: ошибка по одному согласованию не прерывает обработку остальных.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param input body signApprovalsInput true "ID согласований"
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.BulkResult "Результаты по каждому согласованию"
+// @Failure 400 {object} domain.ErrorResponse "Невалидное тело запроса или слишком много согласований"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при подписании согласований"
+// @Router /file-approvals/sign [post]
+func (h *FileApprovalsHandler) SignApprovals(c *gin.Context) {
+	var req signApprovalsInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	result, err := h.usecase.SignApprovals(c.Request.Context(), req.ApprovalIDs, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrBulkLimitExceeded):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "TOO_MANY_ITEMS", "Too many approvals in request")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to sign approvals")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, withErrorCodes(result))
+}
+
+// withErrorCodes заполняет коды ошибок необработанных элементов пакетной операции
+// теми же кодами, что возвращают одиночные методы
+func withErrorCodes(result domain.BulkResult) domain.BulkResult {
+	for i := range result.Items {
+		err := result.Items[i].Err
+		if err == nil {
+			continue
+		}
+
+		switch {
+		case errors.Is(err, domain.ErrFileNotFound):
+			result.Items[i].Error = "FILE_NOT_FOUND"
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			result.Items[i].Error = "NOT_FOUND"
+		case errors.Is(err, domain.ErrNoPermission), errors.Is(err, domain.ErrAccessDenied):
+			result.Items[i].Error = "ACCESS_DENIED"
+		case errors.Is(err, domain.ErrAlreadySigned), errors.Is(err, domain.ErrRoleSlotSigned):
+			result.Items[i].Error = "ALREADY_SIGNED"
+		case errors.Is(err, domain.ErrUnresolvedComments):
+			result.Items[i].Error = "UNRESOLVED_COMMENTS"
+		case errors.Is(err, domain.ErrInvalidFileStatus):
+			result.Items[i].Error = "INVALID_STATUS"
+		case errors.Is(err, domain.ErrFileNotUpdated):
+			result.Items[i].Error = "FILE_NOT_UPDATED"
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			result.Items[i].Error = "WORKFLOW_NOT_FOUND"
+		case errors.Is(err, domain.ErrEmptyRoute):
+			result.Items[i].Error = "EMPTY_ROUTE"
+		default:
+			result.Items[i].Error = "INTERNAL_ERROR"
+		}
+	}
+	return result
+}
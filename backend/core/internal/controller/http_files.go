@@ -1,63 +1,163 @@
-package http
-
-import (
-	"errors"
-	"net/http"
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-	"service-core/pkg/utils"
-	"strconv"
-
-	"github.com/gin-gonic/gin"
-)
-
-type FileHandler struct {
-	usecase interfaces.ApprovalUsecase
-}
-
-func NewFileHandler(usecase interfaces.ApprovalUsecase) *FileHandler {
-	return &FileHandler{usecase: usecase}
-}
-
-// ApproveFile godoc
-// @Summary Отправить файл на согласование
-// @Description Переводит файл в статус "на согласовании". Файл должен находиться в состоянии черновика.
-// @Tags approval
-// @Security ApiKeyAuth
-// @Param file_id path string true "ID файла (числовой формат, например: 123)"
-// @Accept json
-// @Produce json
-// @Success 201 {object} nil "Файл успешно отправлен на согласование"
-// @Failure 400 {object} domain.ErrorResponse "Невалидный ID файла или файл не в статусе 'черновик'"
-// @Failure 404 {object} domain.ErrorResponse "Файл с указанным ID не найден"
-// @Failure 500 {object} domain.ErrorResponse "Ошибка при изменении статуса файла"
-// @Router /files/{file_id}/approve [put]
-func (h *FileHandler) ApproveFile(c *gin.Context) {
-	_, err := utils.ExtractUserID(c)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
-		return
-	}
-
-	fileIDStr := c.Param("file_id")
-	fileID, err := strconv.ParseUint(fileIDStr, 10, 64)
-	if err != nil {
-		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FILE_ID", "Invalid file ID")
-		return
-	}
-
-	err = h.usecase.ApproveFile(c.Request.Context(), uint(fileID))
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrFileNotFound):
-			utils.SendErrorResponse(c, http.StatusNotFound, "FILE_NOT_FOUND", "File not found")
-		case errors.Is(err, domain.ErrInvalidFileStatus):
-			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_STATUS", "File is not in a draft state")
-		default:
-			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to approve file")
-		}
-		return
-	}
-
-	c.Status(http.StatusCreated)
-}
+package http
+
+import (
+	"errors"
+	"net/http"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type FileHandler struct {
+	usecase interfaces.ApprovalUsecase
+}
+
+func NewFileHandler(usecase interfaces.ApprovalUsecase) *FileHandler {
+	return &FileHandler{usecase: usecase}
+}
+
+// ApproveFile godoc
+// @Summary Отправить файл на согласование
+// @Description Переводит файл в статус "на согласовании". Файл должен находиться в состоянии черновика. Если по файлу были запрошены изменения, согласование продолжается с этапа, на котором они были запрошены; версия файла при этом должна быть обновлена.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param file_id path string true "ID файла (числовой формат, например: 123)"
+// @Accept json
+// @Produce json
+// @Success 201 {object} nil "Файл успешно отправлен на согласование"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID файла, файл не в статусе 'черновик' или не обновлен после запроса изменений, у директории нет процедуры согласования"
+// @Failure 403 {object} domain.ErrorResponse "У пользователя нет уровня доступа approve к файлу"
+// @Failure 404 {object} domain.ErrorResponse "Файл с указанным ID не найден"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при изменении статуса файла"
+// @Router /files/{file_id}/approve [put]
+func (h *FileHandler) ApproveFile(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	fileIDStr := c.Param("file_id")
+	fileID, err := strconv.ParseUint(fileIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FILE_ID", "Invalid file ID")
+		return
+	}
+
+	err = h.usecase.ApproveFile(c.Request.Context(), uint(fileID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no approve access to file")
+		case errors.Is(err, domain.ErrFileNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "FILE_NOT_FOUND", "File not found")
+		case errors.Is(err, domain.ErrInvalidFileStatus):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_STATUS", "File is not in a draft state")
+		case errors.Is(err, domain.ErrFileNotUpdated):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "FILE_NOT_UPDATED", "File must be updated before resubmission")
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "WORKFLOW_NOT_FOUND", "Directory workflow not found")
+		case errors.Is(err, domain.ErrEmptyRoute):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "EMPTY_ROUTE", "Routing rule skips all workflow stages")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to approve file")
+		}
+		return
+	}
+
+	c.Status(http.StatusCreated)
+}
+
+// GetFileHistory godoc
+// @Summary Получить журнал согласований файла
+// @Description Возвращает действия по всем согласованиям файла в хронологическом порядке. Доступно участникам согласований файла и пользователям с разрешением approval.view.any.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param file_id path string true "ID файла (числовой формат, например: 123)"
+// @Produce json
+// @Success 200 {array} domain.ApprovalEventResponse "Журнал согласований файла"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID файла"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Пользователь не участвует в согласованиях файла"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении журнала"
+// @Router /files/{file_id}/history [get]
+func (h *FileHandler) GetFileHistory(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	fileIDStr := c.Param("file_id")
+	fileID, err := strconv.ParseUint(fileIDStr, 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FILE_ID", "Invalid file ID")
+		return
+	}
+
+	events, err := h.usecase.GetFileHistory(c.Request.Context(), uint(fileID), userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User is not a participant of file approvals")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get file history")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, events)
+}
+
+type submitDirectoryInput struct {
+	DirectoryID uint `json:"directory_id" binding:"required"`
+}
+
+// SubmitDirectory godoc
+// @Summary Отправить на согласование все черновики директории
+// @Description Отправляет на согласование все файлы в статусе черновика в директории и всех вложенных директориях. Результат возвращается по каждому файлу: ошибка по одному файлу не прерывает обработку остальных.
+// @Tags approval
+// @Security ApiKeyAuth
+// @Param input body submitDirectoryInput true "ID директории"
+// @Accept json
+// @Produce json
+// @Success 200 {object} domain.BulkResult "Результаты по каждому файлу"
+// @Failure 400 {object} domain.ErrorResponse "Невалидное тело запроса или слишком много файлов"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет уровня доступа approve к одному из черновиков директории"
+// @Failure 404 {object} domain.ErrorResponse "Директория не найдена"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при отправке файлов на согласование"
+// @Router /files/approve [post]
+func (h *FileHandler) SubmitDirectory(c *gin.Context) {
+	var req submitDirectoryInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	result, err := h.usecase.SubmitDirectory(c.Request.Context(), req.DirectoryID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDirectoryNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "DIRECTORY_NOT_FOUND", "Directory not found")
+		case errors.Is(err, domain.ErrBulkLimitExceeded):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "TOO_MANY_ITEMS", "Too many files in directory, submit subdirectories separately")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no approve access to some directory files")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to submit directory files")
+		}
+		return
+	}
+
+	c.JSON(http.StatusOK, withErrorCodes(result))
+}
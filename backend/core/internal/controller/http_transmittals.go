@@ -0,0 +1,319 @@
+package http
+
+import (
+	"encoding/csv"
+	"errors"
+	"fmt"
+	"net/http"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+	"strconv"
+
+	"github.com/gin-gonic/gin"
+)
+
+type TransmittalHandler struct {
+	usecase interfaces.TransmittalUsecase
+}
+
+func NewTransmittalHandler(usecase interfaces.TransmittalUsecase) *TransmittalHandler {
+	return &TransmittalHandler{usecase: usecase}
+}
+
+type createTransmittalInput struct {
+	Title      string `json:"title" binding:"required" example:"Комплект КЖ, раздел 3"`
+	WorkflowID uint   `json:"workflow_id" example:"3"`
+	FileIDs    []uint `json:"file_ids" binding:"required" example:"789,790"`
+}
+
+type createTransmittalResponse struct {
+	TransmittalID uint `json:"transmittal_id" example:"12"`
+}
+
+// CreateTransmittal godoc
+// @Summary Создать комплект
+// @Description Создает комплект файлов, которые согласуются как единое целое. Если workflow_id не указан, комплект согласуется по процедуре директорий файлов, которая должна быть у всех файлов одной. Правила маршрутизации к комплектам не применяются. Файлы не должны быть на отдельном согласовании или в другом незавершенном комплекте.
+// @Tags transmittal
+// @Security ApiKeyAuth
+// @Param input body createTransmittalInput true "Название, процедура и файлы комплекта"
+// @Accept json
+// @Produce json
+// @Success 201 {object} createTransmittalResponse "Комплект создан"
+// @Failure 400 {object} domain.ErrorResponse "Невалидное тело запроса, пустой комплект или разные процедуры файлов"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет уровня доступа approve к одному из файлов"
+// @Failure 404 {object} domain.ErrorResponse "Файл или процедура не найдены"
+// @Failure 409 {object} domain.ErrorResponse "Файл на согласовании или в незавершенном комплекте"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при создании комплекта"
+// @Router /transmittals [post]
+func (h *TransmittalHandler) CreateTransmittal(c *gin.Context) {
+	var req createTransmittalInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return
+	}
+
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	transmittalID, err := h.usecase.CreateTransmittal(c.Request.Context(), req.Title, req.WorkflowID, req.FileIDs, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidTransmittal):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_TRANSMITTAL", "Transmittal must have a title, files and a single workflow")
+		case errors.Is(err, domain.ErrBulkLimitExceeded):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "LIMIT_EXCEEDED", "Too many files in transmittal")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "User has no approve access to some transmittal files")
+		case errors.Is(err, domain.ErrFileNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "FILE_NOT_FOUND", "File not found")
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "WORKFLOW_NOT_FOUND", "Workflow not found")
+		case errors.Is(err, domain.ErrFileInUse):
+			utils.SendErrorResponse(c, http.StatusConflict, "FILE_IN_USE", "File is already on approval or in an open transmittal")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to create transmittal")
+		}
+		return
+	}
+
+	c.JSON(http.StatusCreated, createTransmittalResponse{TransmittalID: transmittalID})
+}
+
+// GetTransmittals godoc
+// @Summary Получить комплекты
+// @Description Возвращает комплекты, созданные текущим пользователем. Администратор получает все комплекты
+// @Tags transmittal
+// @Security ApiKeyAuth
+// @Produce json
+// @Success 200 {array} domain.TransmittalResponse "Список комплектов"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении комплектов"
+// @Router /transmittals [get]
+func (h *TransmittalHandler) GetTransmittals(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return
+	}
+
+	transmittals, err := h.usecase.GetTransmittals(c.Request.Context(), userID)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to get transmittals")
+		return
+	}
+
+	c.JSON(http.StatusOK, transmittals)
+}
+
+// GetTransmittal godoc
+// @Summary Получить комплект
+// @Description Возвращает комплект с версиями и текущими статусами его файлов. Доступно автору, участникам согласования комплекта и пользователям с разрешением approval.view.any
+// @Tags transmittal
+// @Security ApiKeyAuth
+// @Param transmittal_id path string true "ID комплекта (числовой формат)"
+// @Produce json
+// @Success 200 {object} domain.TransmittalDetails "Комплект"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID комплекта"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет доступа к комплекту"
+// @Failure 404 {object} domain.ErrorResponse "Комплект не найден"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при получении комплекта"
+// @Router /transmittals/{transmittal_id} [get]
+func (h *TransmittalHandler) GetTransmittal(c *gin.Context) {
+	transmittalID, userID, ok := transmittalRequest(c)
+	if !ok {
+		return
+	}
+
+	transmittal, err := h.usecase.GetTransmittal(c.Request.Context(), transmittalID, userID)
+	if err != nil {
+		sendTransmittalReadError(c, err, "Failed to get transmittal")
+		return
+	}
+
+	c.JSON(http.StatusOK, transmittal)
+}
+
+// SubmitTransmittal godoc
+// @Summary Отправить комплект на согласование
+// @Description Отправляет комплект на согласование одним Approval: подписание меняет статусы всех файлов комплекта. Все файлы должны быть черновиками. Если по комплекту были запрошены изменения, согласование продолжается с того же этапа, и хотя бы один файл должен быть обновлен. Доступно автору и пользователям с разрешением approval.finalize.any
+// @Tags transmittal
+// @Security ApiKeyAuth
+// @Param transmittal_id path string true "ID комплекта (числовой формат)"
+// @Produce json
+// @Success 200 {object} nil "Комплект отправлен на согласование"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID комплекта, файл не черновик или не обновлен"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет прав отправить комплект"
+// @Failure 404 {object} domain.ErrorResponse "Комплект, файл или процедура не найдены"
+// @Failure 409 {object} domain.ErrorResponse "Комплект уже на согласовании или согласование завершено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при отправке комплекта"
+// @Router /transmittals/{transmittal_id}/submit [put]
+func (h *TransmittalHandler) SubmitTransmittal(c *gin.Context) {
+	transmittalID, userID, ok := transmittalRequest(c)
+	if !ok {
+		return
+	}
+
+	err := h.usecase.SubmitTransmittal(c.Request.Context(), transmittalID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrTransmittalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Transmittal not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "Only the author or an admin with approve access to all files can submit this transmittal")
+		case errors.Is(err, domain.ErrInvalidTransmittalStatus):
+			utils.SendErrorResponse(c, http.StatusConflict, "INVALID_TRANSMITTAL_STATUS", "Transmittal is already on approval or completed")
+		case errors.Is(err, domain.ErrFileNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "FILE_NOT_FOUND", "Transmittal file not found")
+		case errors.Is(err, domain.ErrInvalidFileStatus):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_STATUS", "All transmittal files must be drafts")
+		case errors.Is(err, domain.ErrFileNotUpdated):
+			utils.SendErrorResponse(c, http.StatusBadRequest, "FILE_NOT_UPDATED", "At least one file must be updated before resubmission")
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "WORKFLOW_NOT_FOUND", "Workflow not found")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to submit transmittal")
+		}
+		return
+	}
+
+	c.Status(http.StatusOK)
+}
+
+// DeleteTransmittal godoc
+// @Summary Удалить комплект
+// @Description Удаляет комплект, который не отправлялся на согласование или согласование которого отозвано. Доступно автору и пользователям с разрешением approval.finalize.any
+// @Tags transmittal
+// @Security ApiKeyAuth
+// @Param transmittal_id path string true "ID комплекта (числовой формат)"
+// @Produce json
+// @Success 204 {object} nil "Комплект удален"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID комплекта"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет прав удалить комплект"
+// @Failure 404 {object} domain.ErrorResponse "Комплект не найден"
+// @Failure 409 {object} domain.ErrorResponse "Комплект на согласовании или согласование завершено"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при удалении комплекта"
+// @Router /transmittals/{transmittal_id} [delete]
+func (h *TransmittalHandler) DeleteTransmittal(c *gin.Context) {
+	transmittalID, userID, ok := transmittalRequest(c)
+	if !ok {
+		return
+	}
+
+	err := h.usecase.DeleteTransmittal(c.Request.Context(), transmittalID, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrTransmittalNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Transmittal not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "FORBIDDEN", "Only the author or an admin can delete this transmittal")
+		case errors.Is(err, domain.ErrInvalidTransmittalStatus):
+			utils.SendErrorResponse(c, http.StatusConflict, "INVALID_TRANSMITTAL_STATUS", "Only draft or withdrawn transmittals can be deleted")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to delete transmittal")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// ExportManifest godoc
+// @Summary Выгрузить манифест комплекта
+// @Description Возвращает манифест комплекта: состав с версиями и статусами файлов и подписи последнего согласования. format=json (по умолчанию) - JSON для формирования PDF, format=csv - CSV-файл с составом комплекта без подписей
+// @Tags transmittal
+// @Security ApiKeyAuth
+// @Param transmittal_id path string true "ID комплекта (числовой формат)"
+// @Param format query string false "Формат манифеста: json или csv"
+// @Produce json
+// @Produce text/csv
+// @Success 200 {object} domain.TransmittalManifest "Манифест комплекта"
+// @Failure 400 {object} domain.ErrorResponse "Невалидный ID комплекта или формат"
+// @Failure 401 {object} domain.ErrorResponse "Отсутствует/недействителен API-ключ"
+// @Failure 403 {object} domain.ErrorResponse "Нет доступа к комплекту"
+// @Failure 404 {object} domain.ErrorResponse "Комплект не найден"
+// @Failure 500 {object} domain.ErrorResponse "Ошибка при выгрузке манифеста"
+// @Router /transmittals/{transmittal_id}/manifest [get]
+func (h *TransmittalHandler) ExportManifest(c *gin.Context) {
+	format := c.DefaultQuery("format", "json")
+	if format != "json" && format != "csv" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_FORMAT", "Format must be json or csv")
+		return
+	}
+
+	transmittalID, userID, ok := transmittalRequest(c)
+	if !ok {
+		return
+	}
+
+	manifest, err := h.usecase.ExportManifest(c.Request.Context(), transmittalID, userID)
+	if err != nil {
+		sendTransmittalReadError(c, err, "Failed to export manifest")
+		return
+	}
+
+	if format == "json" {
+		c.JSON(http.StatusOK, manifest)
+		return
+	}
+
+	c.Header("Content-Type", "text/csv; charset=utf-8")
+	c.Header("Content-Disposition", fmt.Sprintf(`attachment; filename="%s.csv"`, manifest.Number))
+	c.Status(http.StatusOK)
+	writeManifestCSV(c.Writer, manifest)
+}
+
+// writeManifestCSV записывает состав комплекта в CSV: одна строка - один файл.
+// Подписи выгружаются только в JSON-манифесте
+func writeManifestCSV(w http.ResponseWriter, manifest domain.TransmittalManifest) {
+	writer := csv.NewWriter(w)
+	_ = writer.Write([]string{"transmittal", "title", "status", "file_id", "file_name", "file_version", "file_status"})
+	for _, file := range manifest.Files {
+		_ = writer.Write([]string{
+			manifest.Number,
+			manifest.Title,
+			manifest.Status,
+			strconv.FormatUint(uint64(file.FileID), 10),
+			file.FileName,
+			strconv.Itoa(file.FileVersion),
+			file.Status,
+		})
+	}
+	writer.Flush()
+}
+
+// transmittalRequest разбирает ID комплекта из пути и ID текущего пользователя.
+// При ошибке отправляет ответ и возвращает ok = false
+func transmittalRequest(c *gin.Context) (transmittalID, userID uint, ok bool) {
+	id, err := strconv.ParseUint(c.Param("transmittal_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_TRANSMITTAL_ID", "Invalid transmittal ID")
+		return 0, 0, false
+	}
+
+	userID, err = utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", "User not authenticated")
+		return 0, 0, false
+	}
+
+	return uint(id), userID, true
+}
+
+func sendTransmittalReadError(c *gin.Context, err error, message string) {
+	switch {
+	case errors.Is(err, domain.ErrTransmittalNotFound):
+		utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Transmittal not found")
+	case errors.Is(err, domain.ErrAccessDenied):
+		utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User has no access to this transmittal")
+	default:
+		utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", message)
+	}
+}
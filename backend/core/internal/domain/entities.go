@@ -1,86 +1,895 @@
-package domain
-
-// TODO: refactor
-
-// GetCurrentUserResponse godoc
-// @Description Информация о текущем пользователе
-type GetCurrentUserResponse struct {
-	ID    uint   `json:"id" example:"1"`
-	Login string `json:"login" example:"john_doe"`
-	Role  string `json:"role" example:"user"`
-}
-
-// ErrorResponse godoc
-// @Description Стандартизированный ответ при ошибке API
-type ErrorResponse struct {
-	Error struct {
-		Code    string `json:"code" example:"NOT_FOUND"`
-		Message string `json:"message" example:"Resource not found"`
-	} `json:"error"`
-}
-
-// ApprovalResponse godoc
-// @Description Информация о процессе одобрения файла
-type ApprovalResponse struct {
-	ID                uint   `json:"approval_id" example:"101"`
-	FileID            uint   `json:"file_id" example:"789"`
-	FileName          string `json:"file_name" example:"report.pdf"`
-	Status            string `json:"status" example:"on approval"`
-	WorkflowOrder     int    `json:"workflow_order" example:"2"`
-	WorkflowUserCount int    `json:"workflow_user_count"`
-}
-
-type WorkflowResponse struct {
-	WorkflowID     uint   `json:"workflow_id"`
-	WorkflowName   string `json:"workflow_name"`
-	WorkflowLength int    `json:"workflow_length"`
-}
-
-type ExtendedWorkflowResponse struct {
-	WorkflowName string          `json:"workflow_name"`
-	Stages       []WorkflowStage `json:"stages"`
-}
-
-type WorkflowStage struct {
-	UserID uint `json:"user_id"`
-	Order  int  `json:"order"`
-}
-
-type RoleResponse struct {
-	RoleID   uint   `json:"role_id"`
-	RoleName string `json:"role_name"`
-}
-
-type RoleData struct {
-	RoleName string     `json:"role_name"`
-	Users    []UserData `json:"users"`
-}
-
-type UserData struct {
-	UserID uint   `json:"user_id"`
-	Login  string `json:"login"`
-}
-
-// Directory модель
-type Directory struct {
-	ID           uint
-	ParentPathID *uint
-	Name         string
-	Status       string
-	Version      int
-	ParentPath   *Directory
-	WorkflowID   uint
-
-	Files []File
-}
-
-// File модель
-type File struct {
-	ID          uint
-	DirectoryID uint
-	Name        string
-	Status      string
-	Version     int
-
-	Directory *Directory
-}
+package domain
+
+import (
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+	"path"
+	"strconv"
+	"strings"
+	"time"
+)
+
+// TODO: refactor
+
+// GetCurrentUserResponse godoc
+// @Description Информация о текущем пользователе
+type GetCurrentUserResponse struct {
+	ID          uint     `json:"id" example:"1"`
+	Login       string   `json:"login" example:"john_doe"`
+	Role        string   `json:"role" example:"user"`
+	Permissions []string `json:"permissions" example:"workflow.manage,directory.assign"` // разрешения роли пользователя
+}
+
+// AuthTokens godoc
+// @Description Токены сессии: короткоживущий access-токен и refresh-токен для получения нового access-токена
+type AuthTokens struct {
+	AccessToken      string    `json:"token" example:"eyJhbGciOiJFZERTQSIsImtpZCI6Ii4uLiIsInR5cCI6IkpXVCJ9..."`
+	ExpiresAt        time.Time `json:"expires_at" example:"2025-01-01T12:15:00Z"`
+	RefreshToken     string    `json:"refresh_token" example:"q2x7J0dE9k3mVZsY1nC8bA5fP4tR6wL0uH2gK9jN3oQ"`
+	RefreshExpiresAt time.Time `json:"refresh_expires_at" example:"2025-01-31T12:00:00Z"`
+}
+
+// JWK godoc
+// @Description Открытый ключ Ed25519 (RFC 8037), которым проверяются access-токены с заголовком kid
+type JWK struct {
+	Kty string `json:"kty" example:"OKP"`
+	Crv string `json:"crv" example:"Ed25519"`
+	X   string `json:"x" example:"11qYAYKxCrfVS_7TyWQHOg7hcvPapiMlrwIaaPcHURo"`
+	Kid string `json:"kid" example:"Yw3m0Qk8e2Zp1sVt7LbN4A"`
+	Alg string `json:"alg" example:"EdDSA"`
+	Use string `json:"use" example:"sig"`
+}
+
+// JWKS godoc
+// @Description Набор открытых ключей подписи access-токенов: активный ключ и выведенные из ротации ключи, токены которых еще действуют
+type JWKS struct {
+	Keys []JWK `json:"keys"`
+}
+
+// ErrorResponse godoc
+// @Description Стандартизированный ответ при ошибке API
+type ErrorResponse struct {
+	Error struct {
+		Code    string       `json:"code" example:"NOT_FOUND"`
+		Message string       `json:"message" example:"Resource not found"`
+		Fields  []FieldError `json:"fields,omitempty"` // ошибки отдельных полей запроса
+	} `json:"error"`
+}
+
+// ApprovalResponse godoc
+// @Description Информация о процессе одобрения файла
+type ApprovalResponse struct {
+	ID                 uint   `json:"approval_id" example:"101"`
+	FileID             uint   `json:"file_id" example:"789"`
+	FileName           string `json:"file_name" example:"report.pdf"`
+	TransmittalID      uint   `json:"transmittal_id,omitempty" example:"12"` // комплект, согласуемый целиком
+	TransmittalNumber  string `json:"transmittal_number,omitempty" example:"TR-000012"`
+	Status             string `json:"status" example:"on approval"`
+	WorkflowOrder      int    `json:"workflow_order" example:"2"`
+	WorkflowUserCount  int    `json:"workflow_user_count"`
+	StageSignedCount   int    `json:"stage_signed_count" example:"1"`
+	StageRequiredCount int    `json:"stage_required_count" example:"2"`
+
+	DueAt     *time.Time `json:"due_at,omitempty" example:"2025-01-02T12:00:00Z"`
+	Overdue   bool       `json:"overdue" example:"false"`
+	Escalated bool       `json:"escalated" example:"false"`
+	CreatedAt time.Time  `json:"created_at" example:"2025-01-01T09:00:00Z"`
+}
+
+// Разрешения, которые выдаются ролям
+const (
+	PermissionWorkflowManage      = "workflow.manage"       // процедуры согласования, правила маршрутизации, импорт и экспорт
+	PermissionDirectoryAssign     = "directory.assign"      // назначение процедур директориям и доступа пользователей к директориям и файлам
+	PermissionUserManage          = "user.manage"           // пользователи
+	PermissionRoleManage          = "role.manage"           // роли и их разрешения
+	PermissionDelegationManage    = "delegation.manage"     // замещения других пользователей
+	PermissionApprovalViewAny     = "approval.view.any"     // журналы, обсуждения и комплекты любых согласований
+	PermissionApprovalFinalizeAny = "approval.finalize.any" // завершение чужих согласований: отзыв, закрытие обсуждений, комплекты
+)
+
+// PermissionInfo godoc
+// @Description Разрешение, которое можно выдать роли
+type PermissionInfo struct {
+	Permission  string `json:"permission" example:"workflow.manage"`
+	Description string `json:"description" example:"Управление процедурами согласования и правилами маршрутизации"`
+}
+
+// Permissions - все разрешения в порядке отображения
+var Permissions = []PermissionInfo{
+	{Permission: PermissionWorkflowManage, Description: "Управление процедурами согласования, правилами маршрутизации, импорт и экспорт"},
+	{Permission: PermissionDirectoryAssign, Description: "Назначение процедур директориям и доступа пользователей к директориям и файлам"},
+	{Permission: PermissionUserManage, Description: "Управление пользователями"},
+	{Permission: PermissionRoleManage, Description: "Управление ролями и их разрешениями"},
+	{Permission: PermissionDelegationManage, Description: "Управление замещениями других пользователей"},
+	{Permission: PermissionApprovalViewAny, Description: "Просмотр журналов, обсуждений и комплектов любых согласований"},
+	{Permission: PermissionApprovalFinalizeAny, Description: "Завершение чужих согласований: отзыв, закрытие обсуждений, изменение комплектов"},
+}
+
+// IsPermission проверяет, что permission - известное разрешение
+func IsPermission(permission string) bool {
+	for _, info := range Permissions {
+		if info.Permission == permission {
+			return true
+		}
+	}
+	return false
+}
+
+// Уровни доступа пользователя к директории или файлу; каждый следующий включает предыдущие.
+// Доступ к директории распространяется на вложенные директории и файлы, которым не выдан свой уровень
+const (
+	AccessLevelDeny    = "deny"    // запрет доступа, унаследованного от родительских директорий
+	AccessLevelRead    = "read"    // просмотр и скачивание
+	AccessLevelWrite   = "write"   // создание, изменение и удаление содержимого
+	AccessLevelApprove = "approve" // отправка файлов на согласование
+	AccessLevelManage  = "manage"  // удаление директории и выдача доступа другим пользователям
+)
+
+// IsAccessLevel проверяет, что level - известный уровень доступа
+func IsAccessLevel(level string) bool {
+	switch level {
+	case AccessLevelDeny, AccessLevelRead, AccessLevelWrite, AccessLevelApprove, AccessLevelManage:
+		return true
+	}
+	return false
+}
+
+// ObjectAccess godoc
+// @Description Уровень доступа пользователя к директории или файлу
+type ObjectAccess struct {
+	ID          uint   `json:"id" example:"1"`
+	AccessLevel string `json:"access_level" example:"write"` // deny, read, write, approve или manage
+}
+
+// Разделы списка согласований пользователя
+const (
+	InboxStatusPending   = "pending"   // ожидают подписи пользователя
+	InboxStatusSigned    = "signed"    // подписаны пользователем
+	InboxStatusFinalized = "finalized" // согласованы, пользователь участвовал
+	InboxStatusAnnotated = "annotated" // возвращены на доработку, пользователь участвовал
+)
+
+// Сортировки списка согласований пользователя
+const (
+	ApprovalSortNewest = "-created_at" // сначала новые
+	ApprovalSortOldest = "created_at"  // сначала старые
+	ApprovalSortDue    = "due_at"      // сначала с ближайшим сроком этапа, без срока - в конце
+)
+
+// ApprovalFilter - фильтры, сортировка и страница списка согласований пользователя
+type ApprovalFilter struct {
+	Status      string    // InboxStatus*, пустой - InboxStatusPending
+	From        time.Time // созданы не раньше From (нулевое - без ограничения)
+	To          time.Time // созданы раньше To (нулевое - без ограничения)
+	WorkflowID  uint
+	DirectoryID uint   // файлы директории и вложенных директорий
+	Search      string // подстрока имени файла, номера или названия комплекта
+	Sort        string // ApprovalSort*, пустая - ApprovalSortNewest
+	Cursor      string // курсор следующей страницы из предыдущего ответа
+	Limit       int    // размер страницы, 0 - без ограничения
+
+	// Заполняет usecase: разобранный Cursor и файлы, подходящие под DirectoryID и Search
+	After   *ApprovalCursor
+	FileIDs []uint32
+}
+
+// ApprovalCounts - количество согласований пользователя в каждом разделе
+type ApprovalCounts struct {
+	Pending   int64 `json:"pending" example:"4"`
+	Signed    int64 `json:"signed" example:"12"`
+	Finalized int64 `json:"finalized" example:"7"`
+	Annotated int64 `json:"annotated" example:"1"`
+}
+
+// ApprovalCursor - позиция в списке согласований: значение ключа сортировки и ID
+// последнего согласования страницы
+type ApprovalCursor struct {
+	Sort string
+	Key  string // created_at или срок этапа в RFC 3339, "infinity" - срок этапа не задан
+	ID   uint
+}
+
+// NewApprovalCursor возвращает курсор, указывающий на согласование approval в сортировке sort
+func NewApprovalCursor(sort string, approval ApprovalResponse) ApprovalCursor {
+	key := approval.CreatedAt.UTC().Format(time.RFC3339Nano)
+	if sort == ApprovalSortDue {
+		key = "infinity"
+		if approval.DueAt != nil {
+			key = approval.DueAt.UTC().Format(time.RFC3339Nano)
+		}
+	}
+	return ApprovalCursor{Sort: sort, Key: key, ID: approval.ID}
+}
+
+// Encode кодирует курсор в строку для ответа API
+func (c ApprovalCursor) Encode() string {
+	raw := fmt.Sprintf("%s|%s|%d", c.Sort, c.Key, c.ID)
+	return base64.RawURLEncoding.EncodeToString([]byte(raw))
+}
+
+// ParseApprovalCursor разбирает курсор, полученный от Encode
+// Кастомные ошибки: ErrInvalidCursor
+func ParseApprovalCursor(cursor string) (ApprovalCursor, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(cursor)
+	if err != nil {
+		return ApprovalCursor{}, ErrInvalidCursor
+	}
+
+	parts := strings.Split(string(raw), "|")
+	if len(parts) != 3 {
+		return ApprovalCursor{}, ErrInvalidCursor
+	}
+
+	switch parts[0] {
+	case ApprovalSortNewest, ApprovalSortOldest, ApprovalSortDue:
+	default:
+		return ApprovalCursor{}, ErrInvalidCursor
+	}
+
+	if parts[1] != "infinity" || parts[0] != ApprovalSortDue {
+		if _, err := time.Parse(time.RFC3339Nano, parts[1]); err != nil {
+			return ApprovalCursor{}, ErrInvalidCursor
+		}
+	}
+
+	id, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return ApprovalCursor{}, ErrInvalidCursor
+	}
+
+	return ApprovalCursor{Sort: parts[0], Key: parts[1], ID: uint(id)}, nil
+}
+
+// BulkItemResult - результат пакетной операции для одного файла или согласования.
+// Если элемент не обработан, Err содержит причину, а Error - её код для ответа API
+type BulkItemResult struct {
+	ApprovalID uint   `json:"approval_id,omitempty" example:"101"`
+	FileID     uint   `json:"file_id" example:"789"`
+	FileName   string `json:"file_name" example:"report.pdf"`
+	Action     string `json:"action,omitempty" example:"submit"` // выполненное действие (ApprovalAction*)
+	Error      string `json:"error,omitempty" example:"ALREADY_SIGNED"`
+	Err        error  `json:"-"`
+}
+
+type BulkResult struct {
+	Succeeded int              `json:"succeeded" example:"9"`
+	Failed    int              `json:"failed" example:"1"`
+	Items     []BulkItemResult `json:"items"`
+}
+
+// OverdueApproval - просроченное Approval, которое обрабатывает планировщик сроков
+type OverdueApproval struct {
+	ID               uint
+	FileID           uint
+	WorkflowOrder    int
+	DueAt            time.Time
+	RemindedAt       *time.Time
+	EscalatedAt      *time.Time
+	EscalationUserID uint
+	EscalationRoleID uint
+}
+
+// Действия, которые фиксируются в журнале согласования
+const (
+	ApprovalActionSubmit   = "submit"   // файл отправлен на согласование
+	ApprovalActionResubmit = "resubmit" // исправленная версия файла отправлена повторно
+	ApprovalActionSign     = "sign"     // подпись на промежуточном этапе
+	ApprovalActionAnnotate = "annotate" // запрос изменений, файл возвращён в черновик
+	ApprovalActionReject   = "reject"   // окончательный отказ в согласовании
+	ApprovalActionFinalize = "finalize" // подпись на последнем этапе
+	ApprovalActionWithdraw = "withdraw" // согласование отозвано
+	ApprovalActionEscalate = "escalate" // срок этапа истёк, согласование передано резервному подписанту
+)
+
+// FileStatusForApproval возвращает статус, который должен иметь файл при данном статусе
+// его последнего Approval. Для неизвестного статуса возвращает пустую строку
+func FileStatusForApproval(approvalStatus string) string {
+	switch approvalStatus {
+	case "on approval":
+		return "approving"
+	case "annotated", "withdrawn":
+		return "draft"
+	case "rejected":
+		return "rejected"
+	case "approved":
+		return "approved"
+	default:
+		return ""
+	}
+}
+
+// FileStatusMismatch - расхождение статуса файла в file-service со статусом его последнего Approval
+type FileStatusMismatch struct {
+	FileID         uint   `json:"file_id"`
+	ApprovalID     uint   `json:"approval_id"`
+	ApprovalStatus string `json:"approval_status"`
+	FileStatus     string `json:"file_status"`
+	ExpectedStatus string `json:"expected_status"`
+}
+
+// SubmissionResponse godoc
+// @Description Согласование, отправленное пользователем, с ходом прохождения этапов
+type SubmissionResponse struct {
+	ApprovalResponse
+	StageStartedAt time.Time `json:"stage_started_at" example:"2025-01-01T12:00:00Z"`
+
+	WaitingOn   []PendingSlot           `json:"waiting_on" gorm:"-"`  // места текущего этапа, которые еще не подписаны
+	Stages      []StagePeriod           `json:"stages" gorm:"-"`      // периоды прохождения этапов в хронологическом порядке
+	Annotations []ApprovalEventResponse `json:"annotations" gorm:"-"` // запросы изменений и отказы с замечаниями
+}
+
+// PendingSlot godoc
+// @Description Место на текущем этапе согласования, подпись которого ещё не получена
+type PendingSlot struct {
+	ApprovalID uint   `json:"-"`
+	UserID     uint   `json:"user_id,omitempty" example:"3"`
+	UserLogin  string `json:"user_login,omitempty" example:"john_doe"`
+	RoleID     uint   `json:"role_id,omitempty" example:"2"` // за роль подписывает любой один её участник
+	RoleName   string `json:"role_name,omitempty" example:"engineer"`
+	Escalation bool   `json:"escalation,omitempty" example:"false"` // резервный подписант эскалированного этапа
+}
+
+// StagePeriod godoc
+// @Description Период прохождения этапа согласования. После повторной отправки этап, по которому
+// @Description были запрошены изменения, проходится заново новым периодом
+type StagePeriod struct {
+	Order          int        `json:"order" example:"2"`
+	StartedAt      time.Time  `json:"started_at" example:"2025-01-01T12:00:00Z"`
+	EndedAt        *time.Time `json:"ended_at,omitempty" example:"2025-01-02T09:30:00Z"` // nil - этап проходится сейчас
+	ElapsedSeconds int64      `json:"elapsed_seconds" example:"77400"`
+	Outcome        string     `json:"outcome,omitempty" example:"sign"` // действие, завершившее период (ApprovalAction*)
+}
+
+// ApprovalEventResponse godoc
+// @Description Запись журнала согласования
+type ApprovalEventResponse struct {
+	ID            uint      `json:"event_id" example:"12"`
+	ApprovalID    uint      `json:"approval_id" example:"101"`
+	FileID        uint      `json:"file_id" example:"789"`
+	Action        string    `json:"action" example:"sign"`
+	ActorID       uint      `json:"actor_id" example:"3"`
+	ActorLogin    string    `json:"actor_login" example:"john_doe"`
+	OnBehalfOfID  uint      `json:"on_behalf_of_id,omitempty" example:"4"`
+	OnBehalfOf    string    `json:"on_behalf_of_login,omitempty" example:"jane_doe"`
+	WorkflowOrder int       `json:"workflow_order" example:"2"`
+	FileVersion   int       `json:"file_version" example:"3"`
+	Comment       string    `json:"comment,omitempty" example:"Исправьте штамп"`
+	CreatedAt     time.Time `json:"created_at" example:"2025-01-01T12:00:00Z"`
+}
+
+// DelegationResponse godoc
+// @Description Замещение согласующего
+type DelegationResponse struct {
+	ID             uint      `json:"delegation_id" example:"5"`
+	DelegatorID    uint      `json:"delegator_id" example:"4"`
+	DelegatorLogin string    `json:"delegator_login" example:"jane_doe"`
+	DelegateID     uint      `json:"delegate_id" example:"3"`
+	DelegateLogin  string    `json:"delegate_login" example:"john_doe"`
+	StartsAt       time.Time `json:"starts_at" example:"2025-01-01T00:00:00Z"`
+	EndsAt         time.Time `json:"ends_at" example:"2025-01-15T00:00:00Z"`
+	Active         bool      `json:"active" example:"true"`
+}
+
+// CommentResponse godoc
+// @Description Комментарий обсуждения согласования с ответами
+type CommentResponse struct {
+	ID          uint              `json:"comment_id" example:"12"`
+	ApprovalID  uint              `json:"approval_id" example:"101"`
+	ParentID    uint              `json:"parent_id,omitempty" example:"0"`
+	FileID      uint              `json:"file_id" example:"789"`
+	FileVersion int               `json:"file_version" example:"2"`
+	AuthorID    uint              `json:"author_id" example:"3"`
+	AuthorLogin string            `json:"author_login" example:"john_doe"`
+	Text        string            `json:"text" example:"Проверьте отметки на листе 3"`
+	Mentions    []uint            `json:"mentions" gorm:"serializer:json"`
+	Resolved    bool              `json:"resolved" example:"false"`
+	ResolvedBy  uint              `json:"resolved_by,omitempty" example:"4"`
+	ResolvedAt  *time.Time        `json:"resolved_at,omitempty"`
+	CreatedAt   time.Time         `json:"created_at" example:"2025-01-02T12:00:00Z"`
+	Replies     []CommentResponse `json:"replies,omitempty" gorm:"-"`
+}
+
+// CommentInput - новый комментарий или ответ
+type CommentInput struct {
+	Text     string `json:"text" binding:"required" example:"Проверьте отметки на листе 3"`
+	ParentID uint   `json:"parent_id" example:"0"`  // комментарий, на который дается ответ (0 - новое обсуждение)
+	FileID   uint   `json:"file_id" example:"789"`  // файл комплекта (0 - файл согласования или комплект целиком)
+	Mentions []uint `json:"mentions" example:"4,5"` // ID упомянутых пользователей
+}
+
+// RoutingRuleSpec - условия и действия правила маршрутизации
+type RoutingRuleSpec struct {
+	Name     string `json:"name" example:"STEP models"`
+	Priority int    `json:"priority" example:"10"`
+
+	SourceWorkflowID uint   `json:"source_workflow_id,omitempty"`
+	ContentType      string `json:"content_type,omitempty" example:"model/step"`
+	NamePattern      string `json:"name_pattern,omitempty" example:"*.stp"`
+	MinSize          int64  `json:"min_size,omitempty"`
+	MaxSize          int64  `json:"max_size,omitempty"`
+	MinDepth         int    `json:"min_depth,omitempty"`
+	MaxDepth         int    `json:"max_depth,omitempty"`
+
+	WorkflowID uint  `json:"workflow_id,omitempty" example:"3"`
+	SkipOrders []int `json:"skip_orders,omitempty"`
+}
+
+type RoutingRuleResponse struct {
+	ID uint `json:"rule_id" example:"1"`
+	RoutingRuleSpec
+}
+
+// TransmittalResponse godoc
+// @Description Комплект файлов, согласуемых как единое целое
+type TransmittalResponse struct {
+	ID           uint      `json:"transmittal_id" example:"12"`
+	Number       string    `json:"number" example:"TR-000012"`
+	Title        string    `json:"title" example:"Комплект КЖ, раздел 3"`
+	WorkflowID   uint      `json:"workflow_id" example:"3"`
+	WorkflowName string    `json:"workflow_name" example:"Согласование КЖ"`
+	CreatedBy    uint      `json:"created_by" example:"4"`
+	CreatorLogin string    `json:"creator_login" example:"jane_doe"`
+	ApprovalID   uint      `json:"approval_id,omitempty" example:"101"`
+	Status       string    `json:"status" example:"on approval"` // "draft" или статус последнего Approval комплекта
+	FileCount    int       `json:"file_count" example:"5"`
+	CreatedAt    time.Time `json:"created_at" example:"2025-01-01T12:00:00Z"`
+}
+
+// TransmittalFileResponse - файл комплекта
+type TransmittalFileResponse struct {
+	FileID      uint   `json:"file_id" example:"789"`
+	FileName    string `json:"file_name" example:"KZh-3-01.pdf"`
+	FileVersion int    `json:"file_version" example:"2"` // версия, отправленная на согласование в составе комплекта
+	Status      string `json:"status" example:"approved"`
+}
+
+// TransmittalDetails godoc
+// @Description Комплект и его файлы
+type TransmittalDetails struct {
+	TransmittalResponse
+	Files []TransmittalFileResponse `json:"files"`
+}
+
+// TransmittalSignature - подпись в манифесте комплекта
+type TransmittalSignature struct {
+	WorkflowOrder int       `json:"workflow_order" example:"1"`
+	Login         string    `json:"login" example:"john_doe"`
+	OnBehalfOf    string    `json:"on_behalf_of,omitempty" example:"jane_doe"`
+	SignedAt      time.Time `json:"signed_at" example:"2025-01-02T12:00:00Z"`
+}
+
+// TransmittalManifest godoc
+// @Description Манифест комплекта: состав и подписи последнего согласования
+type TransmittalManifest struct {
+	Number       string                    `json:"number" example:"TR-000012"`
+	Title        string                    `json:"title" example:"Комплект КЖ, раздел 3"`
+	Status       string                    `json:"status" example:"approved"`
+	WorkflowName string                    `json:"workflow_name" example:"Согласование КЖ"`
+	CreatedBy    string                    `json:"created_by" example:"jane_doe"`
+	CreatedAt    time.Time                 `json:"created_at" example:"2025-01-01T12:00:00Z"`
+	GeneratedAt  time.Time                 `json:"generated_at" example:"2025-01-03T12:00:00Z"`
+	Files        []TransmittalFileResponse `json:"files"`
+	Signatures   []TransmittalSignature    `json:"signatures"`
+}
+
+// ApprovalRoute - маршрут, по которому пойдет согласование файла
+type ApprovalRoute struct {
+	FileID       uint            `json:"file_id"`
+	ContentType  string          `json:"content_type"`
+	Size         int64           `json:"size"`
+	Depth        int             `json:"depth"`
+	RuleID       uint            `json:"rule_id"` // 0 - ни одно правило не подошло
+	RuleName     string          `json:"rule_name,omitempty"`
+	WorkflowID   uint            `json:"workflow_id"`
+	WorkflowName string          `json:"workflow_name"`
+	SkipOrders   []int           `json:"skip_orders"`
+	Stages       []WorkflowStage `json:"stages"`
+
+	// Процедура директории файла унаследована от родительской директории (false, если процедуру задало правило)
+	WorkflowInherited bool `json:"workflow_inherited"`
+}
+
+// Matches проверяет, подходит ли файл под все условия правила
+func (r RoutingRule) Matches(file *File) bool {
+	if r.SourceWorkflowID != 0 && r.SourceWorkflowID != file.Directory.WorkflowID {
+		return false
+	}
+
+	if r.ContentType != "" {
+		contentType := strings.ToLower(file.ContentType)
+		if i := strings.IndexByte(contentType, ';'); i >= 0 {
+			contentType = strings.TrimSpace(contentType[:i])
+		}
+		pattern := strings.ToLower(r.ContentType)
+		if prefix, ok := strings.CutSuffix(pattern, "/*"); ok {
+			if !strings.HasPrefix(contentType, prefix+"/") {
+				return false
+			}
+		} else if contentType != pattern {
+			return false
+		}
+	}
+
+	if r.NamePattern != "" {
+		matched, err := path.Match(strings.ToLower(r.NamePattern), strings.ToLower(file.Name))
+		if err != nil || !matched {
+			return false
+		}
+	}
+
+	if file.Size < r.MinSize || (r.MaxSize != 0 && file.Size > r.MaxSize) {
+		return false
+	}
+
+	depth := file.Directory.Depth
+	if depth < r.MinDepth || (r.MaxDepth != 0 && depth > r.MaxDepth) {
+		return false
+	}
+
+	return true
+}
+
+type WorkflowResponse struct {
+	WorkflowID        uint   `json:"workflow_id"`
+	WorkflowName      string `json:"workflow_name"`
+	WorkflowLength    int    `json:"workflow_length"`
+	Revision          int    `json:"revision"`
+	OutdatedApprovals int64  `json:"outdated_approvals"` // незавершенные согласования по более старым ревизиям
+}
+
+type ExtendedWorkflowResponse struct {
+	WorkflowName      string          `json:"workflow_name"`
+	Revision          int             `json:"revision"`
+	OutdatedApprovals int64           `json:"outdated_approvals"`
+	Stages            []WorkflowStage `json:"stages"`
+	WorkflowOptions
+}
+
+// WorkflowOptions - настройки процедуры согласования, общие для всех этапов
+type WorkflowOptions struct {
+	// Завершить согласование нельзя, пока в нем есть неразрешенные обсуждения
+	RequireResolvedComments bool `json:"require_resolved_comments"`
+}
+
+// Правила прохождения этапа согласования
+const (
+	StageRuleAll    = "all"    // подписать должны все участники этапа
+	StageRuleAny    = "any"    // достаточно одной подписи
+	StageRuleQuorum = "quorum" // достаточно Quorum подписей из всех участников
+)
+
+// WorkflowStage - этап согласования. Этап может содержать нескольких подписантов,
+// которые подписывают параллельно, пока не будет выполнено правило этапа.
+// Подписантом может быть конкретный пользователь (UserIDs) или роль (RoleIDs):
+// за роль подписывает любой один её участник
+type WorkflowStage struct {
+	Order   int    `json:"order"`
+	UserID  uint   `json:"user_id,omitempty"` // одиночный подписант (совместимость со старым форматом)
+	UserIDs []uint `json:"user_ids"`
+	RoleIDs []uint `json:"role_ids"`
+	Rule    string `json:"rule" example:"all"`
+	Quorum  int    `json:"quorum,omitempty" example:"2"`
+
+	// Срок этапа в часах (0 - без срока). После истечения срока и льготного периода этап
+	// передается резервному подписанту: пользователю EscalationUserID или роли EscalationRoleID
+	DueHours         int  `json:"due_hours,omitempty" example:"48"`
+	EscalationUserID uint `json:"escalation_user_id,omitempty"`
+	EscalationRoleID uint `json:"escalation_role_id,omitempty"`
+}
+
+// RequiredSignatures возвращает количество подписей, необходимое для завершения этапа
+func (s WorkflowStage) RequiredSignatures() int {
+	switch s.Rule {
+	case StageRuleAny:
+		return 1
+	case StageRuleQuorum:
+		return s.Quorum
+	default:
+		return s.Signers()
+	}
+}
+
+// Signers возвращает количество мест подписантов этапа: каждый пользователь и каждая роль
+// занимают по одному месту
+func (s WorkflowStage) Signers() int {
+	return len(s.UserIDs) + len(s.RoleIDs)
+}
+
+type RoleResponse struct {
+	RoleID      uint     `json:"role_id"`
+	RoleName    string   `json:"role_name"`
+	Permissions []string `json:"permissions" gorm:"-"`
+}
+
+type RoleData struct {
+	RoleName string     `json:"role_name"`
+	Users    []UserData `json:"users"`
+}
+
+type UserData struct {
+	UserID uint   `json:"user_id"`
+	Login  string `json:"login"`
+}
+
+// Directory модель
+type Directory struct {
+	ID           uint
+	ParentPathID *uint
+	Name         string
+	Status       string
+	Version      int
+	ParentPath   *Directory
+	WorkflowID   uint // действующая процедура: назначенная директории или унаследованная от родителя
+	Depth        int  // уровень вложенности, у корневой директории 1
+
+	WorkflowInherited bool // WorkflowID унаследована от родительской директории
+
+	Files []File
+}
+
+// DirectoryPath - директория с путем от корня (имена директорий, разделенные "/")
+type DirectoryPath struct {
+	ID         uint
+	ParentID   uint // 0 - корневая директория
+	Path       string
+	WorkflowID uint
+}
+
+// File модель
+type File struct {
+	ID          uint
+	DirectoryID uint
+	Name        string
+	Status      string
+	Version     int
+	Size        int64
+	ContentType string
+
+	Directory *Directory
+}
+
+// FileContentHash - хэш содержимого версии файла в хранилище file-service
+type FileContentHash struct {
+	FileID  uint   `json:"file_id" example:"789"`
+	Version int    `json:"version" example:"2"`
+	SHA256  string `json:"sha256" example:"9f86d081884c7d659a2feaa0c55ad015a3bf4f1b2b0b822cd15d6c15b0f00a08"`
+}
+
+// SealFunc подписывает решение согласования: заполняет содержимое, ключ и подпись signature.
+// Вызывается в транзакции решения, approval - заблокированное Approval до перехода
+type SealFunc func(approval *Approval, signature *DecisionSignature) error
+
+// signaturePayloadVersion - версия формата подписываемых данных
+const signaturePayloadVersion = "constructflow/approval-decision/v1"
+
+// Payload возвращает данные, над которыми вычисляется подпись решения
+func (s DecisionSignature) Payload() []byte {
+	var b strings.Builder
+	fmt.Fprintf(&b, "%s\n", signaturePayloadVersion)
+	fmt.Fprintf(&b, "approval_id=%d\n", s.ApprovalID)
+	fmt.Fprintf(&b, "decision=%s\n", s.Decision)
+	fmt.Fprintf(&b, "workflow_order=%d\n", s.WorkflowOrder)
+	fmt.Fprintf(&b, "signer_id=%d\n", s.SignerID)
+	fmt.Fprintf(&b, "on_behalf_of_id=%d\n", s.OnBehalfOfID)
+	fmt.Fprintf(&b, "key_id=%d\n", s.KeyID)
+	fmt.Fprintf(&b, "signed_at=%s\n", s.SignedAt.UTC().Format(time.RFC3339Nano))
+	fmt.Fprintf(&b, "prev=%s\n", s.PrevHash)
+	for _, content := range s.Contents {
+		fmt.Fprintf(&b, "content=%d:%d:%s\n", content.FileID, content.Version, content.SHA256)
+	}
+	return []byte(b.String())
+}
+
+// Hash возвращает хэш подписи, который следующая подпись цепочки включает как PrevHash
+func (s DecisionSignature) Hash() string {
+	sum := sha256.Sum256(s.Signature)
+	return hex.EncodeToString(sum[:])
+}
+
+// SignatureCheck godoc
+// @Description Результат проверки одной подписи цепочки
+type SignatureCheck struct {
+	SignatureID   uint              `json:"signature_id" example:"7"`
+	Decision      string            `json:"decision" example:"sign"`
+	WorkflowOrder int               `json:"workflow_order" example:"1"`
+	SignerID      uint              `json:"signer_id" example:"3"`
+	OnBehalfOfID  uint              `json:"on_behalf_of_id,omitempty" example:"4"`
+	KeyID         uint              `json:"key_id" example:"2"`
+	PublicKey     []byte            `json:"public_key"` // открытый ключ Ed25519 (base64)
+	SignedAt      time.Time         `json:"signed_at" example:"2025-01-02T12:00:00Z"`
+	Contents      []FileContentHash `json:"contents"`
+	Signature     []byte            `json:"signature"` // подпись Ed25519 (base64)
+	Valid         bool              `json:"valid" example:"true"`
+	Errors        []string          `json:"errors,omitempty" example:"CONTENT_MISMATCH"`
+}
+
+// SignatureVerification godoc
+// @Description Результат проверки цепочки подписей Approval по объектам в хранилище
+type SignatureVerification struct {
+	ApprovalID uint             `json:"approval_id" example:"101"`
+	Valid      bool             `json:"valid" example:"true"` // все подписи и связи цепочки верны
+	Signatures []SignatureCheck `json:"signatures"`
+}
+
+// Ошибки проверки подписи
+const (
+	SignatureErrBrokenChain      = "BROKEN_CHAIN"      // PrevHash не совпадает с хэшем предыдущей подписи
+	SignatureErrInvalidSignature = "INVALID_SIGNATURE" // подпись не соответствует данным и ключу
+	SignatureErrKeyNotFound      = "KEY_NOT_FOUND"
+	SignatureErrContentMismatch  = "CONTENT_MISMATCH" // содержимое версии файла изменилось
+	SignatureErrContentMissing   = "CONTENT_MISSING"  // версия файла не найдена в хранилище
+)
+
+// WorkflowBundleVersion - версия формата документа с процедурами согласования
+const WorkflowBundleVersion = 1
+
+// WorkflowBundle godoc
+// @Description Документ с ролями, процедурами согласования и их назначением директориям для хранения
+// @Description в git и переноса между окружениями. Пользователи указываются логинами, роли и процедуры -
+// @Description именами, директории - путями от корня
+type WorkflowBundle struct {
+	Version   int                  `json:"version" yaml:"version" example:"1"`
+	Roles     []string             `json:"roles" yaml:"roles"`
+	Workflows []WorkflowDefinition `json:"workflows" yaml:"workflows"`
+}
+
+// WorkflowDefinition - процедура согласования в документе. Порядок этапов - порядок в списке
+type WorkflowDefinition struct {
+	Name                    string            `json:"name" yaml:"name" example:"Рабочая документация"`
+	RequireResolvedComments bool              `json:"require_resolved_comments,omitempty" yaml:"require_resolved_comments,omitempty"`
+	Stages                  []StageDefinition `json:"stages" yaml:"stages"`
+	Directories             []string          `json:"directories,omitempty" yaml:"directories,omitempty" example:"Проект/Чертежи"`
+}
+
+// StageDefinition - этап процедуры согласования в документе
+type StageDefinition struct {
+	Users          []string `json:"users,omitempty" yaml:"users,omitempty" example:"john_doe"`
+	Roles          []string `json:"roles,omitempty" yaml:"roles,omitempty" example:"engineer"`
+	Rule           string   `json:"rule,omitempty" yaml:"rule,omitempty" example:"all"`
+	Quorum         int      `json:"quorum,omitempty" yaml:"quorum,omitempty"`
+	DueHours       int      `json:"due_hours,omitempty" yaml:"due_hours,omitempty" example:"48"`
+	EscalationUser string   `json:"escalation_user,omitempty" yaml:"escalation_user,omitempty"`
+	EscalationRole string   `json:"escalation_role,omitempty" yaml:"escalation_role,omitempty"`
+}
+
+// FieldError godoc
+// @Description Ошибка в поле документа или запроса
+type FieldError struct {
+	Field   string `json:"field" example:"workflows[0].stages[1].users[0]"`
+	Message string `json:"message" example:"user not found"`
+}
+
+// Изменения, которые вносит импорт документа с процедурами согласования
+const (
+	ImportActionCreate = "create" // роль или процедура будет создана
+	ImportActionUpdate = "update" // будет создана новая ревизия процедуры
+	ImportActionAssign = "assign" // процедура будет назначена директории
+)
+
+// ImportChange godoc
+// @Description Изменение, которое вносит импорт документа. Если назначение директории не выполнено,
+// @Description error содержит код причины
+type ImportChange struct {
+	Kind   string `json:"kind" example:"workflow"` // role, workflow или directory
+	Name   string `json:"name" example:"Рабочая документация"`
+	Action string `json:"action" example:"update"` // ImportAction*
+	Detail string `json:"detail,omitempty" example:"revision 3"`
+	Error  string `json:"error,omitempty" example:"DIRECTORY_NOT_FOUND"`
+	Err    error  `json:"-"`
+}
+
+// ImportResult godoc
+// @Description Результат проверки или импорта документа с процедурами согласования
+type ImportResult struct {
+	Valid   bool           `json:"valid" example:"true"`
+	Applied bool           `json:"applied" example:"false"`      // false - только проверка и предпросмотр изменений
+	Failed  int            `json:"failed,omitempty" example:"0"` // сколько назначений директорий не выполнено
+	Errors  []FieldError   `json:"errors,omitempty"`
+	Changes []ImportChange `json:"changes"`
+}
+
+// WorkflowImport - процедура документа импорта для записи: новая (ID = 0) или существующая,
+// для которой при Update создается новая ревизия
+type WorkflowImport struct {
+	ID      uint
+	Name    string
+	Options WorkflowOptions
+	Stages  []WorkflowStage
+	Update  bool
+}
+
+// SimulationFileLimit - сколько файлов перечисляется в отчете моделирования, остальные только считаются
+const SimulationFileLimit = 500
+
+// WorkflowSimulation godoc
+// @Description Отчет моделирования назначения процедуры согласования директориям: какие директории и
+// @Description файлы получат процедуру, кто будет подписывать на каждом этапе и какие незавершенные
+// @Description согласования затронуты. Моделирование ничего не изменяет
+type WorkflowSimulation struct {
+	WorkflowID     uint                 `json:"workflow_id" example:"2"`
+	WorkflowName   string               `json:"workflow_name" example:"Рабочая документация"`
+	Revision       int                  `json:"revision" example:"3"`
+	Directories    []SimulatedDirectory `json:"directories"`
+	FilesTotal     int                  `json:"files_total" example:"120"`
+	FilesTruncated bool                 `json:"files_truncated" example:"false"` // в Files перечислены не все файлы
+	Files          []SimulatedFile      `json:"files"`
+	Routes         []SimulatedRoute     `json:"routes"`
+	OpenApprovals  []AffectedApproval   `json:"open_approvals"`
+}
+
+// SimulatedDirectory godoc
+// @Description Директория, действующая процедура которой определяется назначением
+type SimulatedDirectory struct {
+	DirectoryID       uint   `json:"directory_id" example:"7"`
+	Path              string `json:"path" example:"Проект/Чертежи"`
+	CurrentWorkflowID uint   `json:"current_workflow_id" example:"1"`   // 0 - процедуры нет
+	WorkflowInherited bool   `json:"workflow_inherited" example:"true"` // процедура будет унаследована от выбранной директории
+	Changed           bool   `json:"changed" example:"true"`            // действующая процедура директории изменится
+}
+
+// SimulatedFile godoc
+// @Description Файл, который будет согласовываться по маршруту Routes[Route]
+type SimulatedFile struct {
+	FileID      uint   `json:"file_id" example:"789"`
+	FileName    string `json:"file_name" example:"plan.pdf"`
+	Status      string `json:"status" example:"draft"`
+	DirectoryID uint   `json:"directory_id" example:"7"`
+	Route       int    `json:"route" example:"0"`                 // индекс маршрута в Routes
+	ApprovalID  uint   `json:"approval_id,omitempty" example:"0"` // незавершенное согласование файла
+}
+
+// SimulatedRoute godoc
+// @Description Маршрут согласования файлов после назначения: процедура директории или процедура,
+// @Description заданная правилом маршрутизации, с этапами и подписантами
+type SimulatedRoute struct {
+	RuleID       uint             `json:"rule_id" example:"0"` // 0 - ни одно правило не подошло
+	RuleName     string           `json:"rule_name,omitempty"`
+	WorkflowID   uint             `json:"workflow_id" example:"2"`
+	WorkflowName string           `json:"workflow_name" example:"Рабочая документация"`
+	SkipOrders   []int            `json:"skip_orders"`
+	Files        int              `json:"files" example:"118"` // количество файлов с этим маршрутом
+	Stages       []SimulatedStage `json:"stages"`
+	Warnings     []string         `json:"warnings,omitempty"`
+}
+
+// SimulatedStage godoc
+// @Description Этап маршрута с подписантами и их доступностью
+type SimulatedStage struct {
+	Order            int               `json:"order" example:"1"`
+	Rule             string            `json:"rule" example:"all"`
+	Required         int               `json:"required" example:"2"` // подписей для завершения этапа
+	DueHours         int               `json:"due_hours,omitempty" example:"48"`
+	EscalationUserID uint              `json:"escalation_user_id,omitempty"`
+	EscalationRoleID uint              `json:"escalation_role_id,omitempty"`
+	Signers          []SimulatedSigner `json:"signers"`
+	Warnings         []string          `json:"warnings,omitempty"`
+}
+
+// SimulatedSigner godoc
+// @Description Пользователь, которого попросят подписать этап: лично или как участника роли.
+// @Description Недоступный пользователь замещается DelegateID, если замещение действует
+type SimulatedSigner struct {
+	UserID        uint   `json:"user_id" example:"3"`
+	Login         string `json:"login" example:"john_doe"`
+	RoleID        uint   `json:"role_id,omitempty" example:"0"` // роль, за которую подписывает пользователь
+	RoleName      string `json:"role_name,omitempty"`
+	Available     bool   `json:"available" example:"true"`
+	DelegateID    uint   `json:"delegate_id,omitempty" example:"0"`
+	DelegateLogin string `json:"delegate_login,omitempty"`
+}
+
+// AffectedApproval godoc
+// @Description Незавершенное согласование файла из назначения. Согласование продолжается по своей копии
+// @Description этапов, новый маршрут применится при следующей отправке файла на согласование
+type AffectedApproval struct {
+	ApprovalID       uint   `json:"approval_id" example:"101"`
+	FileID           uint   `json:"file_id" example:"789"`
+	FileName         string `json:"file_name" example:"plan.pdf"`
+	Status           string `json:"status" example:"on approval"`
+	WorkflowID       uint   `json:"workflow_id" example:"1"`
+	WorkflowRevision int    `json:"workflow_revision" example:"2"`
+	WorkflowOrder    int    `json:"workflow_order" example:"1"`   // текущий этап
+	RouteChanged     bool   `json:"route_changed" example:"true"` // при повторной отправке файл пойдет по другой процедуре
+}
@@ -1,49 +1,114 @@
-package domain
-
-import "errors"
-
-var (
-	ErrInternal  = errors.New("internal error")
-	ErrInvalidID = errors.New("invalid identifier")
-)
-
-var (
-	ErrUserNotFound = errors.New("user not found")
-
-	ErrUserAlreadyExists = errors.New("user already exists")
-)
-
-var (
-	ErrRoleNotFound = errors.New("role not found")
-	ErrRoleInUse    = errors.New("role in use")
-
-	ErrRoleAlreadyExists = errors.New("role already exists")
-)
-
-var (
-	ErrInvalidCredentials = errors.New("invalid login or password")
-)
-
-var (
-	ErrFileNotFound                   = errors.New("file not found")
-	ErrInvalidFileStatus              = errors.New("file is not in a draft state")
-	ErrDirectoryContainsNonDraftFiles = errors.New("directory contains files with status other than 'draft'")
-	ErrCannotDeleteNonDraftFile       = errors.New("cannot delete file with status other than 'draft'")
-
-	ErrDirectoryNotFound = errors.New("directory not found")
-)
-
-var (
-	ErrAccessDenied = errors.New("access denied")
-)
-
-var (
-	ErrApprovalNotFound = errors.New("approval not found")
-	ErrNoPermission     = errors.New("user has no permission to sign this approval")
-)
-
-var (
-	ErrWorkflowNotFound = errors.New("workflow not found")
-	ErrWorkflowInUse    = errors.New("this workflow in use")
-	ErrCannotDeleteUser = errors.New("cannot delete user if he is in workflow")
-)
+package domain
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	ErrInternal  = errors.New("internal error")
+	ErrInvalidID = errors.New("invalid identifier")
+)
+
+var (
+	ErrUserNotFound = errors.New("user not found")
+
+	ErrUserAlreadyExists = errors.New("user already exists")
+)
+
+var (
+	ErrRoleNotFound = errors.New("role not found")
+	ErrRoleInUse    = errors.New("role in use")
+
+	ErrRoleAlreadyExists = errors.New("role already exists")
+
+	ErrUnknownPermission = errors.New("unknown permission")
+)
+
+var (
+	ErrInvalidCredentials  = errors.New("invalid login or password")
+	ErrInvalidRefreshToken = errors.New("invalid or expired refresh token")
+	ErrRefreshTokenReused  = errors.New("refresh token has already been used")
+)
+
+var (
+	ErrFileNotFound                   = errors.New("file not found")
+	ErrInvalidFileStatus              = errors.New("file is not in a draft state")
+	ErrDirectoryContainsNonDraftFiles = errors.New("directory contains files with status other than 'draft'")
+	ErrCannotDeleteNonDraftFile       = errors.New("cannot delete file with status other than 'draft'")
+
+	ErrDirectoryNotFound = errors.New("directory not found")
+
+	ErrInvalidAccessLevel = errors.New("invalid access level")
+)
+
+var (
+	ErrAccessDenied = errors.New("access denied")
+)
+
+var (
+	ErrApprovalNotFound  = errors.New("approval not found")
+	ErrNoPermission      = errors.New("user has no permission to sign this approval")
+	ErrAlreadySigned     = errors.New("user has already signed this approval stage")
+	ErrRoleSlotSigned    = errors.New("another member of the role has already signed this approval stage")
+	ErrFileNotUpdated    = errors.New("file must be updated before resubmission")
+	ErrBulkLimitExceeded = errors.New("too many items in bulk request")
+	ErrInvalidFilter     = errors.New("invalid approvals filter")
+	ErrInvalidCursor     = errors.New("invalid page cursor")
+	ErrFilterTooBroad    = errors.New("approvals filter matches too many files")
+)
+
+var (
+	ErrWorkflowNotFound  = errors.New("workflow not found")
+	ErrWorkflowInUse     = errors.New("this workflow in use")
+	ErrCannotDeleteUser  = errors.New("cannot delete user if he is in workflow")
+	ErrInvalidStage      = errors.New("invalid workflow stage")
+	ErrInvalidWorkflow   = errors.New("invalid workflow")
+	ErrWorkflowNameTaken = errors.New("workflow name is already taken")
+)
+
+// WorkflowValidationError - ошибки полей процедуры согласования. errors.Is(err, ErrInvalidWorkflow) = true
+type WorkflowValidationError struct {
+	Fields []FieldError
+}
+
+func (e *WorkflowValidationError) Error() string {
+	if len(e.Fields) == 0 {
+		return ErrInvalidWorkflow.Error()
+	}
+	return fmt.Sprintf("%s: %s: %s", ErrInvalidWorkflow, e.Fields[0].Field, e.Fields[0].Message)
+}
+
+func (e *WorkflowValidationError) Unwrap() error {
+	return ErrInvalidWorkflow
+}
+
+var (
+	ErrDelegationNotFound = errors.New("delegation not found")
+	ErrInvalidDelegation  = errors.New("invalid delegation")
+	ErrDelegationOverlap  = errors.New("delegation overlaps an existing delegation")
+)
+
+var (
+	ErrRoutingRuleNotFound = errors.New("routing rule not found")
+	ErrInvalidRoutingRule  = errors.New("invalid routing rule")
+	ErrEmptyRoute          = errors.New("routing rule skips all workflow stages")
+)
+
+var (
+	ErrTransmittalNotFound      = errors.New("transmittal not found")
+	ErrInvalidTransmittal       = errors.New("invalid transmittal")
+	ErrInvalidTransmittalStatus = errors.New("transmittal cannot be changed in its current status")
+	ErrFileInUse                = errors.New("file is already on approval or in an open transmittal")
+)
+
+var (
+	ErrCommentNotFound    = errors.New("comment not found")
+	ErrInvalidComment     = errors.New("invalid comment")
+	ErrUnresolvedComments = errors.New("approval has unresolved comments")
+)
+
+var (
+	ErrSigningKeyNotFound = errors.New("signing key not found")
+	ErrTokenKeyNotFound   = errors.New("token key not found")
+)
@@ -1,53 +1,149 @@
-package interfaces
-
-import (
-	"context"
-	"service-core/internal/domain"
-)
-
-type UserRepository interface {
-	GetUserByID(ctx context.Context, userID uint) (user domain.User, err error)
-	GetUserByLogin(ctx context.Context, login string) (user domain.User, err error)
-	GetUserRole(ctx context.Context, userID uint) (role string, err error)
-
-	SaveUser(ctx context.Context, login string, passHash []byte, roleID uint) error
-	CheckUsersExist(ctx context.Context, userIDs []uint) (bool, error)
-	CheckUsersWithRole(ctx context.Context, roleID uint) (bool, error)
-	GetUsersGroupedByRoles(ctx context.Context) ([]domain.RoleData, error)
-	UpdateUser(ctx context.Context, login string, hashedPassword []byte, roleID, userID uint) error
-	DeleteUser(ctx context.Context, userID uint) error
-}
-
-type ApprovalRepository interface {
-	CreateApproval(ctx context.Context, approval *domain.Approval) error
-	FindApprovalsByUser(ctx context.Context, userID uint) ([]domain.ApprovalResponse, error)
-
-	IsLastUserInWorkflow(ctx context.Context, approvalID, userID uint) (*domain.Approval, error)
-	CheckUserPermission(ctx context.Context, approvalID, userID uint) (*domain.Approval, error)
-
-	IncrementApprovalOrder(ctx context.Context, approvalID uint) error
-	AnnotateApproval(ctx context.Context, approvalID uint, message string) error
-	FinalizeApproval(ctx context.Context, approvalID uint) error
-}
-
-type WorkflowRepository interface {
-	GetWorkflows(ctx context.Context) (workflows []domain.WorkflowResponse, err error)
-	GetWorkflowByID(ctx context.Context, workflowID uint) (workflow domain.ExtendedWorkflowResponse, err error)
-
-	CreateWorkflow(ctx context.Context, name string, stages []domain.WorkflowStage) error
-	UpdateWorkflow(ctx context.Context, workflowID uint, name string, stages []domain.WorkflowStage) error
-	DeleteWorkflow(ctx context.Context, workflowID uint) error
-	CheckWorkflow(ctx context.Context, workflowID uint) (bool, error)
-	CheckUserInWorkflow(ctx context.Context, userID uint) (bool, error)
-}
-
-type RoleRepository interface {
-	GetRoles(ctx context.Context) (roles []domain.RoleResponse, err error)
-	GetRoleByID(ctx context.Context, roleID uint) (roleName string, err error)
-	CreateRole(ctx context.Context, roleName string) error
-	UpdateRole(ctx context.Context, roleID uint, roleName string) error
-	DeleteRole(ctx context.Context, roleID uint) error
-
-	CheckRole(ctx context.Context, roleID uint) (bool, error)
-	CheckRoleByName(ctx context.Context, roleName string) (bool, error)
-}
+package interfaces
+
+import (
+	"context"
+	"service-core/internal/domain"
+	"time"
+)
+
+type UserRepository interface {
+	GetUserByID(ctx context.Context, userID uint) (user domain.User, err error)
+	GetUserByLogin(ctx context.Context, login string) (user domain.User, err error)
+
+	SaveUser(ctx context.Context, login string, passHash []byte, roleID uint) error
+	CheckUsersExist(ctx context.Context, userIDs []uint) (bool, error)
+	CheckUsersWithRole(ctx context.Context, roleID uint) (bool, error)
+	GetUsersGroupedByRoles(ctx context.Context) ([]domain.RoleData, error)
+	UpdateUser(ctx context.Context, login string, hashedPassword []byte, roleID, userID uint) error
+	DeleteUser(ctx context.Context, userID uint) error
+}
+
+type SessionRepository interface {
+	CreateSession(ctx context.Context, session *domain.AuthSession, token *domain.RefreshToken) error
+	RotateRefreshToken(ctx context.Context, tokenHash string, next *domain.RefreshToken, reuseGrace time.Duration) (*domain.AuthSession, *domain.RefreshToken, error)
+	RevokeSessionByToken(ctx context.Context, tokenHash string) (*domain.AuthSession, error)
+	IsSessionActive(ctx context.Context, sessionID string) (bool, error)
+
+	GetUnsyncedRevocations(ctx context.Context, revokedAfter time.Time, limit int) ([]domain.AuthSession, error)
+	MarkRevocationsSynced(ctx context.Context, sessionIDs []string, at time.Time) error
+}
+
+type ApprovalRepository interface {
+	CreateApproval(ctx context.Context, approval *domain.Approval, skipOrders []int, actorID uint) error
+	GetApprovalByID(ctx context.Context, approvalID uint) (*domain.Approval, error)
+	GetApprovalStage(ctx context.Context, approvalID uint, order int) (stage domain.WorkflowStage, err error)
+	FindApprovalsByUser(ctx context.Context, userID uint, filter domain.ApprovalFilter) ([]domain.ApprovalResponse, error)
+	CountApprovalsByUser(ctx context.Context, userID uint, filter domain.ApprovalFilter) (domain.ApprovalCounts, error)
+	FindApprovalsBySubmitter(ctx context.Context, submitterID uint) ([]domain.SubmissionResponse, error)
+	FindPendingSlots(ctx context.Context, approvalIDs []uint) ([]domain.PendingSlot, error)
+
+	IsLastUserInWorkflow(ctx context.Context, approvalID, userID uint) (*domain.Approval, error)
+	CheckUserPermission(ctx context.Context, approvalID, userID uint) (*domain.Approval, error)
+
+	SignStage(ctx context.Context, approvalID, userID uint, order, required int, isLastStage bool, seal domain.SealFunc) (stageCompleted bool, err error)
+	AnnotateApproval(ctx context.Context, approvalID, userID uint, message string) error
+	RejectApproval(ctx context.Context, approvalID, userID uint, message string) error
+	WithdrawApproval(ctx context.Context, approvalID, userID uint) error
+	FindAnnotatedApproval(ctx context.Context, fileID uint) (*domain.Approval, error)
+	ResumeApproval(ctx context.Context, approvalID, userID uint, fileVersion int) error
+
+	GetApprovalHistory(ctx context.Context, approvalID uint) ([]domain.ApprovalEventResponse, error)
+	GetApprovalsHistory(ctx context.Context, approvalIDs []uint) ([]domain.ApprovalEventResponse, error)
+	GetFileHistory(ctx context.Context, fileID uint) ([]domain.ApprovalEventResponse, error)
+	CountBlockingComments(ctx context.Context, approvalID uint) (count int64, err error)
+	GetApprovalContents(ctx context.Context, approval *domain.Approval) ([]domain.FileContentHash, error)
+	GetDecisionSignatures(ctx context.Context, approvalID uint) ([]domain.DecisionSignature, error)
+	CheckApprovalParticipant(ctx context.Context, userID uint, approvalIDs []uint) (bool, error)
+
+	FindOverdueApprovals(ctx context.Context, now time.Time) ([]domain.OverdueApproval, error)
+	FindPendingSigners(ctx context.Context, approvalID uint) (userIDs []uint, err error)
+	MarkReminded(ctx context.Context, approvalID uint, order int, at time.Time) error
+	EscalateApproval(ctx context.Context, approvalID uint, order int, at time.Time) (escalated bool, err error)
+
+	FindLatestApprovals(ctx context.Context) ([]domain.Approval, error)
+}
+
+type OutboxRepository interface {
+	EnqueueFileStatus(ctx context.Context, approvalID, fileID uint, fileStatus string) error
+	ClaimOutboxMessages(ctx context.Context, fileID uint, limit int, lease time.Duration) ([]domain.OutboxMessage, error)
+	MarkOutboxDelivered(ctx context.Context, messageID uint, at time.Time) error
+	MarkOutboxFailed(ctx context.Context, messageID uint, nextAttemptAt time.Time, dead bool, lastError string) error
+	GetPendingFileIDs(ctx context.Context) (fileIDs []uint, err error)
+}
+
+type WorkflowRepository interface {
+	GetWorkflows(ctx context.Context) (workflows []domain.WorkflowResponse, err error)
+	GetWorkflowByID(ctx context.Context, workflowID uint) (workflow domain.ExtendedWorkflowResponse, err error)
+
+	CreateWorkflow(ctx context.Context, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage) (workflowID uint, err error)
+	UpdateWorkflow(ctx context.Context, workflowID uint, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage) error
+	ImportWorkflows(ctx context.Context, newRoles map[uint]string, workflows []domain.WorkflowImport) (workflowIDs []uint, err error)
+	DeleteWorkflow(ctx context.Context, workflowID uint) error
+	CheckWorkflow(ctx context.Context, workflowID uint) (bool, error)
+	CheckWorkflowName(ctx context.Context, name string, exceptID uint) (taken bool, err error)
+	CheckUserInWorkflow(ctx context.Context, userID uint) (bool, error)
+	CheckRoleInWorkflow(ctx context.Context, roleID uint) (bool, error)
+	GetOpenApprovals(ctx context.Context, fileIDs []uint) ([]domain.Approval, error)
+}
+
+type RoleRepository interface {
+	GetRoles(ctx context.Context) (roles []domain.RoleResponse, err error)
+	GetRoleByID(ctx context.Context, roleID uint) (roleName string, err error)
+	CreateRole(ctx context.Context, roleName string) error
+	UpdateRole(ctx context.Context, roleID uint, roleName string) error
+	DeleteRole(ctx context.Context, roleID uint) error
+
+	CheckRole(ctx context.Context, roleID uint) (bool, error)
+	CheckRoleByName(ctx context.Context, roleName string) (bool, error)
+
+	GetRolePermissions(ctx context.Context, roleIDs []uint) (map[uint][]string, error)
+	SetRolePermissions(ctx context.Context, roleID uint, permissions []string) error
+	GetUserPermissions(ctx context.Context, userID uint) ([]string, error)
+	CountPermissionHolders(ctx context.Context, permission string, excludeUserID uint) (int64, error)
+}
+
+type DelegationRepository interface {
+	CreateDelegation(ctx context.Context, delegation *domain.Delegation) error
+	GetDelegationByID(ctx context.Context, delegationID uint) (*domain.Delegation, error)
+	GetDelegations(ctx context.Context, userID uint) ([]domain.DelegationResponse, error)
+	DeleteDelegation(ctx context.Context, delegationID uint) error
+}
+
+type RoutingRuleRepository interface {
+	GetRoutingRules(ctx context.Context) (rules []domain.RoutingRule, err error)
+	GetRoutingRuleByID(ctx context.Context, ruleID uint) (*domain.RoutingRule, error)
+	CreateRoutingRule(ctx context.Context, rule *domain.RoutingRule) error
+	UpdateRoutingRule(ctx context.Context, rule *domain.RoutingRule) error
+	DeleteRoutingRule(ctx context.Context, ruleID uint) error
+}
+
+type TransmittalRepository interface {
+	CreateTransmittal(ctx context.Context, transmittal *domain.Transmittal, files []domain.TransmittalFile) error
+	GetTransmittals(ctx context.Context, userID uint) (transmittals []domain.TransmittalResponse, err error)
+	GetTransmittalByID(ctx context.Context, transmittalID uint) (*domain.TransmittalResponse, error)
+	GetTransmittalFiles(ctx context.Context, transmittalID uint) (files []domain.TransmittalFile, err error)
+
+	SubmitTransmittal(ctx context.Context, transmittalID, prevApprovalID uint, files []domain.TransmittalFile, approval *domain.Approval, actorID uint) error
+	ResumeTransmittal(ctx context.Context, transmittalID, approvalID uint, files []domain.TransmittalFile, revision int, actorID uint) error
+	DeleteTransmittal(ctx context.Context, transmittalID uint) error
+}
+
+type CommentRepository interface {
+	CreateComment(ctx context.Context, comment *domain.ApprovalComment) error
+	GetCommentByID(ctx context.Context, commentID uint) (*domain.ApprovalComment, error)
+	GetComments(ctx context.Context, approvalID uint, fileVersion int) (comments []domain.CommentResponse, err error)
+	SetCommentResolved(ctx context.Context, commentID, userID uint, resolved bool) error
+}
+
+type TokenKeyRepository interface {
+	GetActiveTokenKey(ctx context.Context) (*domain.TokenKey, error)
+	GetTokenKeyByID(ctx context.Context, keyID string) (*domain.TokenKey, error)
+	GetPublishedTokenKeys(ctx context.Context, retiredAfter time.Time) ([]domain.TokenKey, error)
+	RotateTokenKey(ctx context.Context, key *domain.TokenKey, rotateBefore time.Time) (*domain.TokenKey, error)
+}
+
+type SigningKeyRepository interface {
+	GetSigningKeyByUserID(ctx context.Context, userID uint) (*domain.SigningKey, error)
+	GetSigningKeyByID(ctx context.Context, keyID uint) (*domain.SigningKey, error)
+	CreateSigningKey(ctx context.Context, key *domain.SigningKey) (*domain.SigningKey, error)
+}
@@ -1,18 +1,50 @@
-package interfaces
-
-import (
-	"context"
-	"service-core/internal/domain"
-)
-
-type FileService interface {
-	GetFileWithDirectory(ctx context.Context, fileID uint) (*domain.File, error)
-	UpdateFileStatus(ctx context.Context, fileID uint, status string) error
-	GetFilesInfo(ctx context.Context, fileIDs []uint32) (map[uint32]string, error)
-
-	CheckWorkflow(ctx context.Context, workflowID uint) (bool, error)
-	AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint32) error
-
-	DeleteUserRelations(ctx context.Context, userID uint) error
-	AssignUser(ctx context.Context, userID uint, directoryIDs []uint32, fileIDs []uint32) error
-}
+package interfaces
+
+import (
+	"context"
+	"crypto/ed25519"
+	"service-core/internal/domain"
+	"time"
+)
+
+type FileService interface {
+	GetFileWithDirectory(ctx context.Context, fileID uint) (*domain.File, error)
+	UpdateFileStatus(ctx context.Context, fileID uint, status, idempotencyKey string) error
+	GetFilesInfo(ctx context.Context, fileIDs []uint32) (map[uint32]string, error)
+	GetFileStatuses(ctx context.Context, fileIDs []uint32) (map[uint32]string, error)
+	GetFilesWithDirectory(ctx context.Context, fileIDs []uint32) ([]domain.File, error)
+	GetDirectoryFiles(ctx context.Context, directoryID uint, status string) ([]uint32, error)
+	FindFiles(ctx context.Context, directoryID uint, nameQuery string, limit int) ([]uint32, error)
+	GetFileContentHash(ctx context.Context, fileID uint, version int) (domain.FileContentHash, error)
+
+	CheckWorkflow(ctx context.Context, workflowID uint) (bool, error)
+	AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint32) error
+	GetDirectoryPaths(ctx context.Context) ([]domain.DirectoryPath, error)
+
+	DeleteUserRelations(ctx context.Context, userID uint) error
+	AssignUser(ctx context.Context, userID uint, directories []domain.ObjectAccess, files []domain.ObjectAccess) error
+	CheckFileAccess(ctx context.Context, userID uint, fileIDs []uint32, accessLevel string) (bool, error)
+
+	RevokeSessions(ctx context.Context, sessionIDs []string, expiresAt time.Time) error
+}
+
+// DecisionSigner подписывает решения согласования ключами пользователей и проверяет подписи
+type DecisionSigner interface {
+	SigningKey(ctx context.Context, userID uint) (keyID uint, err error)
+	Sign(ctx context.Context, keyID uint, payload []byte) (signature []byte, err error)
+	Verify(ctx context.Context, keyID uint, payload, signature []byte) (bool, error)
+	PublicKey(ctx context.Context, keyID uint) ([]byte, error)
+}
+
+// TokenKeyManager выдает ключ подписи access-токенов, ротирует его и публикует открытые ключи
+type TokenKeyManager interface {
+	SigningKey(ctx context.Context) (keyID string, key ed25519.PrivateKey, err error)
+	PublicKey(ctx context.Context, keyID string) (ed25519.PublicKey, error)
+	JWKS(ctx context.Context) (domain.JWKS, error)
+	RotateKey(ctx context.Context) error
+}
+
+// Notifier доставляет уведомления пользователям
+type Notifier interface {
+	Notify(ctx context.Context, userIDs []uint, subject, message string) error
+}
@@ -1,47 +1,119 @@
-package interfaces
-
-import (
-	"context"
-	"service-core/internal/domain"
-)
-
-type AuthUsecase interface {
-	Login(ctx context.Context, login, password string) (token string, err error)
-	GetCurrentUser(ctx context.Context, userID uint) (userInfo domain.GetCurrentUserResponse, err error)
-}
-
-type ApprovalUsecase interface {
-	ApproveFile(ctx context.Context, fileID uint) error
-	GetApprovalsByUserID(ctx context.Context, userID uint) (approvals []domain.ApprovalResponse, err error)
-	SignApproval(ctx context.Context, approvalID, userID uint) error
-	AnnotateApproval(ctx context.Context, approvalID, userID uint, message string) error
-	FinalizeApproval(ctx context.Context, approvalID, userID uint) error
-}
-
-type WorkflowUsecase interface {
-	GetWorkflows(ctx context.Context, userID uint) (workflows []domain.WorkflowResponse, err error)
-	GetWorkflowByID(ctx context.Context, workflowID, userID uint) (domain.ExtendedWorkflowResponse, error)
-
-	CreateWorkflow(ctx context.Context, name string, stages []domain.WorkflowStage, userID uint) error
-	UpdateWorkflow(ctx context.Context, workflowID uint, name string, stages []domain.WorkflowStage, userID uint) error
-	DeleteWorkflow(ctx context.Context, workflowID uint, userID uint) error
-
-	AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint, userID uint) error
-}
-
-type RoleUsecase interface {
-	GetRoles(ctx context.Context, userID uint) (workflows []domain.RoleResponse, err error)
-	GetRoleByID(ctx context.Context, roleID uint, userID uint) (role string, err error)
-	RegisterRole(ctx context.Context, roleName string, userID uint) error
-	UpdateRole(ctx context.Context, roleID uint, roleName string, userID uint) error
-	DeleteRole(ctx context.Context, roleID uint, userID uint) error
-}
-
-type UserUsecase interface {
-	GetUsersGrouped(ctx context.Context, userID uint) (users []domain.RoleData, err error)
-	RegisterUser(ctx context.Context, login, password string, roleID, userID uint) error
-	UpdateUser(ctx context.Context, login, password string, roleID, userID, actorID uint) error
-	DeleteUser(ctx context.Context, userID, actorID uint) error
-
-	AssignUser(ctx context.Context, userID uint, directoryIDs []uint, fileIDs []uint, actorID uint) error
-}
+package interfaces
+
+import (
+	"context"
+	"service-core/internal/domain"
+	"time"
+)
+
+type AuthUsecase interface {
+	Login(ctx context.Context, login, password string) (tokens domain.AuthTokens, err error)
+	Refresh(ctx context.Context, refreshToken string) (tokens domain.AuthTokens, err error)
+	Logout(ctx context.Context, refreshToken string) error
+	GetJWKS(ctx context.Context) (domain.JWKS, error)
+	GetCurrentUser(ctx context.Context, userID uint) (userInfo domain.GetCurrentUserResponse, err error)
+}
+
+type ApprovalUsecase interface {
+	ApproveFile(ctx context.Context, fileID, userID uint) error
+	GetApprovalsByUserID(ctx context.Context, userID uint, filter domain.ApprovalFilter) (approvals []domain.ApprovalResponse, nextCursor string, err error)
+	CountApprovalsByUserID(ctx context.Context, userID uint, filter domain.ApprovalFilter) (counts domain.ApprovalCounts, err error)
+	GetSubmissionsByUserID(ctx context.Context, userID uint) (submissions []domain.SubmissionResponse, err error)
+	SignApproval(ctx context.Context, approvalID, userID uint) error
+	AnnotateApproval(ctx context.Context, approvalID, userID uint, message string) error
+	RejectApproval(ctx context.Context, approvalID, userID uint, message string) error
+	WithdrawApproval(ctx context.Context, approvalID, userID uint) error
+	FinalizeApproval(ctx context.Context, approvalID, userID uint) error
+
+	SubmitDirectory(ctx context.Context, directoryID, userID uint) (result domain.BulkResult, err error)
+	SignApprovals(ctx context.Context, approvalIDs []uint, userID uint) (result domain.BulkResult, err error)
+
+	GetApprovalHistory(ctx context.Context, approvalID, userID uint) (events []domain.ApprovalEventResponse, err error)
+	GetFileHistory(ctx context.Context, fileID, userID uint) (events []domain.ApprovalEventResponse, err error)
+	VerifySignatures(ctx context.Context, approvalID, userID uint) (*domain.SignatureVerification, error)
+}
+
+type DeadlineUsecase interface {
+	ProcessDeadlines(ctx context.Context) error
+}
+
+type OutboxUsecase interface {
+	DispatchPending(ctx context.Context) error
+	DispatchFile(ctx context.Context, fileID uint) error
+	Reconcile(ctx context.Context, fix bool) (mismatches []domain.FileStatusMismatch, err error)
+}
+
+type RoutingUsecase interface {
+	GetRoutingRules(ctx context.Context, actorID uint) (rules []domain.RoutingRuleResponse, err error)
+	CreateRoutingRule(ctx context.Context, spec domain.RoutingRuleSpec, actorID uint) error
+	UpdateRoutingRule(ctx context.Context, ruleID uint, spec domain.RoutingRuleSpec, actorID uint) error
+	DeleteRoutingRule(ctx context.Context, ruleID, actorID uint) error
+
+	ResolveRoute(ctx context.Context, file *domain.File) (route domain.ApprovalRoute, err error)
+	DryRunRoute(ctx context.Context, fileID, actorID uint) (route domain.ApprovalRoute, err error)
+}
+
+type WorkflowUsecase interface {
+	GetWorkflows(ctx context.Context, userID uint) (workflows []domain.WorkflowResponse, err error)
+	GetWorkflowByID(ctx context.Context, workflowID, userID uint) (domain.ExtendedWorkflowResponse, error)
+
+	CreateWorkflow(ctx context.Context, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage, userID uint) error
+	UpdateWorkflow(ctx context.Context, workflowID uint, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage, userID uint) error
+	DeleteWorkflow(ctx context.Context, workflowID uint, userID uint) error
+
+	AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint, userID uint) error
+	InheritWorkflow(ctx context.Context, directoryIDs []uint, userID uint) error
+	SimulateWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint, userID uint) (domain.WorkflowSimulation, error)
+
+	ExportWorkflows(ctx context.Context, userID uint) (domain.WorkflowBundle, error)
+	ImportWorkflows(ctx context.Context, bundle domain.WorkflowBundle, dryRun bool, userID uint) (domain.ImportResult, error)
+}
+
+type RoleUsecase interface {
+	GetRoles(ctx context.Context, userID uint) (workflows []domain.RoleResponse, err error)
+	GetRoleByID(ctx context.Context, roleID uint, userID uint) (role string, err error)
+	RegisterRole(ctx context.Context, roleName string, userID uint) error
+	UpdateRole(ctx context.Context, roleID uint, roleName string, userID uint) error
+	DeleteRole(ctx context.Context, roleID uint, userID uint) error
+	SetRolePermissions(ctx context.Context, roleID uint, permissions []string, userID uint) error
+	GetPermissions(ctx context.Context, userID uint) ([]domain.PermissionInfo, error)
+}
+
+// Authorizer проверяет разрешения пользователя, выданные его роли
+type Authorizer interface {
+	Authorize(ctx context.Context, userID uint, permission string) error
+	UserPermissions(ctx context.Context, userID uint) ([]string, error)
+	Invalidate()
+}
+
+type UserUsecase interface {
+	GetUsersGrouped(ctx context.Context, userID uint) (users []domain.RoleData, err error)
+	RegisterUser(ctx context.Context, login, password string, roleID, userID uint) error
+	UpdateUser(ctx context.Context, login, password string, roleID, userID, actorID uint) error
+	DeleteUser(ctx context.Context, userID, actorID uint) error
+
+	AssignUser(ctx context.Context, userID uint, directories []domain.ObjectAccess, files []domain.ObjectAccess, actorID uint) error
+}
+
+type DelegationUsecase interface {
+	CreateDelegation(ctx context.Context, delegatorID, delegateID uint, startsAt, endsAt time.Time, actorID uint) error
+	GetDelegations(ctx context.Context, userID uint) (delegations []domain.DelegationResponse, err error)
+	GetAllDelegations(ctx context.Context, actorID uint) (delegations []domain.DelegationResponse, err error)
+	DeleteDelegation(ctx context.Context, delegationID, actorID uint) error
+}
+
+type CommentUsecase interface {
+	GetComments(ctx context.Context, approvalID uint, fileVersion int, userID uint) (comments []domain.CommentResponse, err error)
+	AddComment(ctx context.Context, approvalID uint, input domain.CommentInput, userID uint) (commentID uint, err error)
+	ResolveComment(ctx context.Context, approvalID, commentID uint, resolved bool, userID uint) error
+}
+
+type TransmittalUsecase interface {
+	CreateTransmittal(ctx context.Context, title string, workflowID uint, fileIDs []uint, userID uint) (transmittalID uint, err error)
+	GetTransmittals(ctx context.Context, userID uint) (transmittals []domain.TransmittalResponse, err error)
+	GetTransmittal(ctx context.Context, transmittalID, userID uint) (transmittal domain.TransmittalDetails, err error)
+	SubmitTransmittal(ctx context.Context, transmittalID, userID uint) error
+	DeleteTransmittal(ctx context.Context, transmittalID, userID uint) error
+
+	ExportManifest(ctx context.Context, transmittalID, userID uint) (manifest domain.TransmittalManifest, err error)
+}
@@ -1,36 +1,309 @@
-package domain
-
-import "gorm.io/gorm"
-
-// Role модель
-type Role struct {
-	gorm.Model
-	RoleName string `gorm:"not null"`
-}
-
-// User модель
-type User struct {
-	gorm.Model
-	Login    string `json:"login" gorm:"unique;not null"`
-	PassHash []byte `json:"pass_hash" gorm:"not null"`
-	RoleID   uint   `gorm:"not null"`
-}
-
-// Approval модель
-type Approval struct {
-	gorm.Model
-	FileID         uint   `json:"file_id" gorm:"not null;index"`
-	Status         string `json:"status" gorm:"not null"`
-	WorkflowID     uint   `json:"workflow_id" gorm:"not null"`
-	WorkflowOrder  int    `json:"workflow_order" gorm:"not null"`
-	AnnotationText string `json:"annotation_text"`
-}
-
-// Workflow модель
-type Workflow struct {
-	gorm.Model
-	WorkflowID    uint   `json:"workflow_id" gorm:"primaryKey;autoIncrement:true"`
-	WorkflowName  string `json:"workflow_name" gorm:"not null"`
-	UserID        uint   `json:"user_id" gorm:"not null;index"`
-	WorkflowOrder int    `json:"workflow_order" gorm:"not null"`
-}
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Role модель
+type Role struct {
+	gorm.Model
+	RoleName string `gorm:"not null"`
+}
+
+// RolePermission модель - разрешение, выданное роли. Пользователь имеет разрешения своей роли
+type RolePermission struct {
+	RoleID     uint   `gorm:"primaryKey"`
+	Permission string `gorm:"primaryKey;size:64"`
+}
+
+// User модель
+type User struct {
+	gorm.Model
+	Login    string `json:"login" gorm:"unique;not null"`
+	PassHash []byte `json:"pass_hash" gorm:"not null"`
+	RoleID   uint   `gorm:"not null"`
+}
+
+// Approval модель
+type Approval struct {
+	gorm.Model
+	FileID           uint   `json:"file_id" gorm:"not null;index"`
+	Status           string `json:"status" gorm:"not null"`
+	WorkflowID       uint   `json:"workflow_id" gorm:"not null"`
+	WorkflowOrder    int    `json:"workflow_order" gorm:"not null"`
+	WorkflowRevision int    `json:"workflow_revision" gorm:"not null;default:1"`    // ревизия процедуры, этапы которой скопированы в ApprovalStage
+	FileVersion      int    `json:"file_version" gorm:"not null;default:1"`         // версия файла, отправленная на согласование
+	SubmitterID      uint   `json:"submitter_id" gorm:"not null;default:0;index"`   // пользователь, отправивший файл на согласование
+	RoutingRuleID    uint   `json:"routing_rule_id" gorm:"not null;default:0"`      // правило, выбравшее маршрут (0 - процедура директории)
+	TransmittalID    uint   `json:"transmittal_id" gorm:"not null;default:0;index"` // комплект, согласуемый целиком (0 - согласование одного файла)
+	AnnotationText   string `json:"annotation_text"`
+
+	// Сроки текущего этапа: время начала этапа, последнего напоминания и эскалации
+	StageStartedAt time.Time  `json:"stage_started_at" gorm:"not null;default:CURRENT_TIMESTAMP"`
+	RemindedAt     *time.Time `json:"reminded_at"`
+	EscalatedAt    *time.Time `json:"escalated_at"`
+}
+
+// ApprovalSignature модель - подпись участника на этапе согласования
+type ApprovalSignature struct {
+	gorm.Model
+	ApprovalID    uint `json:"approval_id" gorm:"not null;index"`
+	WorkflowOrder int  `json:"workflow_order" gorm:"not null"`
+	UserID        uint `json:"user_id" gorm:"not null;index"`
+	RoleID        uint `json:"role_id" gorm:"not null;default:0"`     // роль, за которую подписал пользователь (0 - личная подпись)
+	DelegateID    uint `json:"delegate_id" gorm:"not null;default:0"` // заместитель, подписавший за UserID (0 - подписал сам)
+}
+
+// ApprovalComment модель - комментарий обсуждения Approval. Комментарий с ParentID = 0 открывает
+// обсуждение версии файла, остальные - ответы в нем. Разрешается обсуждение целиком: ResolvedAt
+// заполняется только у открывающего комментария
+type ApprovalComment struct {
+	gorm.Model
+	ApprovalID  uint       `json:"approval_id" gorm:"not null;index"`
+	ParentID    uint       `json:"parent_id" gorm:"not null;default:0;index"`
+	FileID      uint       `json:"file_id" gorm:"not null"`
+	FileVersion int        `json:"file_version" gorm:"not null"`
+	AuthorID    uint       `json:"author_id" gorm:"not null"`
+	Text        string     `json:"text" gorm:"type:text;not null"`
+	Mentions    []uint     `json:"mentions" gorm:"type:jsonb;serializer:json"` // упомянутые пользователи
+	ResolvedBy  uint       `json:"resolved_by" gorm:"not null;default:0"`
+	ResolvedAt  *time.Time `json:"resolved_at"`
+}
+
+// ApprovalStage модель - неизменяемая копия строки Workflow, сделанная при создании Approval.
+// Согласование идёт по этой копии, поэтому изменение процедуры не влияет на начатые Approvals
+type ApprovalStage struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"created_at" gorm:"not null"`
+	ApprovalID    uint      `json:"approval_id" gorm:"not null;index"`
+	WorkflowOrder int       `json:"workflow_order" gorm:"not null"`
+	UserID        uint      `json:"user_id" gorm:"not null;index"`
+	RoleID        uint      `json:"role_id" gorm:"not null;default:0;index"`
+	StageRule     string    `json:"stage_rule" gorm:"not null;default:'all'"`
+	StageQuorum   int       `json:"stage_quorum" gorm:"not null;default:0"`
+
+	StageDueHours    int  `json:"stage_due_hours" gorm:"not null;default:0"`
+	EscalationUserID uint `json:"escalation_user_id" gorm:"not null;default:0"`
+	EscalationRoleID uint `json:"escalation_role_id" gorm:"not null;default:0"`
+
+	RequireResolvedComments bool `json:"require_resolved_comments" gorm:"not null;default:false"`
+}
+
+// Delegation модель - замещение: в период [StartsAt, EndsAt) DelegateID может подписывать
+// согласования вместо DelegatorID
+type Delegation struct {
+	gorm.Model
+	DelegatorID uint      `json:"delegator_id" gorm:"not null;index"`
+	DelegateID  uint      `json:"delegate_id" gorm:"not null;index"`
+	StartsAt    time.Time `json:"starts_at" gorm:"not null"`
+	EndsAt      time.Time `json:"ends_at" gorm:"not null"`
+	CreatedBy   uint      `json:"created_by" gorm:"not null"`
+}
+
+// RoutingRule модель - правило маршрутизации согласования. Правила проверяются по возрастанию
+// Priority, срабатывает первое правило, под все условия которого подходит файл.
+// Пустое (нулевое) условие не ограничивает выбор
+type RoutingRule struct {
+	gorm.Model
+	Name     string `gorm:"not null"`
+	Priority int    `gorm:"not null;default:0;index"`
+
+	// Условия
+	SourceWorkflowID uint   `gorm:"not null;default:0"`  // процедура директории файла
+	ContentType      string `gorm:"not null;default:''"` // MIME-тип, "model/*" - любой подтип
+	NamePattern      string `gorm:"not null;default:''"` // шаблон имени файла, например "*.stp"
+	MinSize          int64  `gorm:"not null;default:0"`  // размер файла в байтах
+	MaxSize          int64  `gorm:"not null;default:0"`
+	MinDepth         int    `gorm:"not null;default:0"` // уровень вложенности директории файла
+	MaxDepth         int    `gorm:"not null;default:0"`
+
+	// Действия: процедура вместо процедуры директории и пропускаемые этапы
+	WorkflowID uint  `gorm:"not null;default:0"`
+	SkipOrders []int `gorm:"type:jsonb;serializer:json"`
+}
+
+// Transmittal модель - комплект файлов, согласуемых как единое целое. Комплект согласуется одним
+// Approval (FileID = 0, FileVersion - ревизия комплекта), подписание которого меняет статус всех файлов
+type Transmittal struct {
+	gorm.Model
+	Number     string `json:"number" gorm:"not null;uniqueIndex"` // номер комплекта, например "TR-000042"
+	Title      string `json:"title" gorm:"not null"`
+	WorkflowID uint   `json:"workflow_id" gorm:"not null"`
+	CreatedBy  uint   `json:"created_by" gorm:"not null;index"`
+	ApprovalID uint   `json:"approval_id" gorm:"not null;default:0;index"` // последнее Approval комплекта (0 - черновик)
+}
+
+// TransmittalFile модель - файл комплекта и его версия на момент последней отправки комплекта
+type TransmittalFile struct {
+	ID            uint `json:"id" gorm:"primarykey"`
+	TransmittalID uint `json:"transmittal_id" gorm:"not null;uniqueIndex:idx_transmittal_file"`
+	FileID        uint `json:"file_id" gorm:"not null;uniqueIndex:idx_transmittal_file;index"`
+	FileVersion   int  `json:"file_version" gorm:"not null;default:1"`
+}
+
+// AuthSession модель - сессия пользователя, открытая входом. Access-токены сессии содержат её ID
+// (claim sid), поэтому отзыв сессии сразу делает недействительными все её токены
+type AuthSession struct {
+	ID           string     `json:"session_id" gorm:"primaryKey;size:32"`
+	CreatedAt    time.Time  `json:"created_at" gorm:"not null"`
+	UserID       uint       `json:"user_id" gorm:"not null;index"`
+	ExpiresAt    time.Time  `json:"expires_at" gorm:"not null"` // срок действия последнего refresh-токена
+	RevokedAt    *time.Time `json:"revoked_at" gorm:"index"`
+	RevokeReason string     `json:"revoke_reason"` // logout или reuse
+
+	// Отзыв передан в file-service; до этого его повторяет планировщик
+	RevocationSyncedAt *time.Time `json:"revocation_synced_at"`
+}
+
+// RefreshToken модель - refresh-токен сессии, хранится только SHA-256 хэш. При обновлении токен
+// помечается использованным и заменяется новым. Повторное предъявление использованного токена
+// вскоре после обновления (одновременные запросы нескольких вкладок) возвращает тот же новый токен,
+// позже - означает, что токен украден, и отзывает всю сессию
+type RefreshToken struct {
+	ID        uint       `json:"id" gorm:"primarykey"`
+	CreatedAt time.Time  `json:"created_at" gorm:"not null"`
+	SessionID string     `json:"session_id" gorm:"not null;size:32;index"`
+	TokenHash string     `json:"-" gorm:"not null;uniqueIndex"`
+	ExpiresAt time.Time  `json:"expires_at" gorm:"not null"`
+	UsedAt    *time.Time `json:"used_at"`
+
+	// Токен, выданный вместо этого, и сам токен, зашифрованный ключом из предыдущего токена:
+	// расшифровать его может только предъявивший предыдущий токен
+	SuccessorID *uint  `json:"successor_id"`
+	SealedToken []byte `json:"-"`
+}
+
+// TokenKey модель - ключ Ed25519, которым core-service подписывает access-токены (kid в заголовке JWT).
+// Активен ключ без RetiredAt; выведенный из ротации ключ публикуется в JWKS, пока не истекут
+// подписанные им токены. Закрытый ключ хранится зашифрованным мастер-ключом
+type TokenKey struct {
+	ID         string     `json:"kid" gorm:"primaryKey;size:32"`
+	CreatedAt  time.Time  `json:"created_at" gorm:"not null"`
+	PublicKey  []byte     `json:"public_key" gorm:"not null"`
+	PrivateKey []byte     `json:"-" gorm:"not null"` // nonce AES-GCM и зашифрованный закрытый ключ
+	RetiredAt  *time.Time `json:"retired_at,omitempty" gorm:"index"`
+}
+
+// SigningKey модель - ключ Ed25519 пользователя для подписи решений согласования.
+// Ключ создается сервером при первой подписи, закрытый ключ хранится зашифрованным мастер-ключом
+type SigningKey struct {
+	gorm.Model
+	UserID     uint   `json:"user_id" gorm:"not null;uniqueIndex"`
+	PublicKey  []byte `json:"public_key" gorm:"not null"`
+	PrivateKey []byte `json:"-" gorm:"not null"` // nonce AES-GCM и зашифрованный закрытый ключ
+}
+
+// DecisionSignature модель - отсоединенная подпись Ed25519 решения согласования (sign или finalize)
+// над хэшами содержимого подписанных версий файлов. Подписи Approval образуют цепочку: каждая
+// подпись включает хэш предыдущей. Записи не изменяются и не удаляются
+type DecisionSignature struct {
+	ID            uint              `json:"signature_id" gorm:"primarykey"`
+	CreatedAt     time.Time         `json:"created_at" gorm:"not null"`
+	ApprovalID    uint              `json:"approval_id" gorm:"not null;index"`
+	EventID       uint              `json:"event_id" gorm:"not null"` // запись журнала с этим решением
+	Decision      string            `json:"decision" gorm:"not null"`
+	WorkflowOrder int               `json:"workflow_order" gorm:"not null"`
+	SignerID      uint              `json:"signer_id" gorm:"not null"`
+	OnBehalfOfID  uint              `json:"on_behalf_of_id" gorm:"not null;default:0"`
+	KeyID         uint              `json:"key_id" gorm:"not null"`
+	Contents      []FileContentHash `json:"contents" gorm:"type:jsonb;serializer:json"`
+	PrevHash      string            `json:"prev_hash" gorm:"not null;default:''"`
+	SignedAt      time.Time         `json:"signed_at" gorm:"not null"`
+	Signature     []byte            `json:"signature" gorm:"not null"`
+}
+
+// ApprovalEvent модель - запись журнала согласования. Журнал только дополняется:
+// записи не изменяются и не удаляются, поэтому модель не содержит UpdatedAt и DeletedAt
+type ApprovalEvent struct {
+	ID            uint      `json:"id" gorm:"primarykey"`
+	CreatedAt     time.Time `json:"created_at" gorm:"not null;index"`
+	ApprovalID    uint      `json:"approval_id" gorm:"not null;index"`
+	FileID        uint      `json:"file_id" gorm:"not null;index"`
+	Action        string    `json:"action" gorm:"not null"`
+	ActorID       uint      `json:"actor_id" gorm:"not null"`
+	OnBehalfOfID  uint      `json:"on_behalf_of_id" gorm:"not null;default:0"` // за кого действовал заместитель ActorID
+	WorkflowOrder int       `json:"workflow_order" gorm:"not null"`
+	FileVersion   int       `json:"file_version" gorm:"not null;default:1"`
+	Comment       string    `json:"comment"`
+}
+
+// OutboxMessage модель - изменение статуса файла, которое нужно доставить в file-service.
+// Записывается в одной транзакции с переходом Approval и доставляется диспетчером с повторами.
+// По IdempotencyKey file-service отбрасывает повторную доставку
+type OutboxMessage struct {
+	ID             uint       `json:"id" gorm:"primarykey"`
+	CreatedAt      time.Time  `json:"created_at" gorm:"not null"`
+	IdempotencyKey string     `json:"idempotency_key" gorm:"not null;uniqueIndex"`
+	ApprovalID     uint       `json:"approval_id" gorm:"not null;index"`
+	FileID         uint       `json:"file_id" gorm:"not null;index"`
+	FileStatus     string     `json:"file_status" gorm:"not null"`
+	Attempts       int        `json:"attempts" gorm:"not null;default:0"`
+	NextAttemptAt  time.Time  `json:"next_attempt_at" gorm:"not null;index"`
+	DeliveredAt    *time.Time `json:"delivered_at"`
+	DeadAt         *time.Time `json:"dead_at"` // доставка прекращена: файл не найден или исчерпаны попытки
+	LastError      string     `json:"last_error"`
+}
+
+// WorkflowHeader модель - процедура согласования. Этапы (WorkflowStageRecord) и их подписанты
+// (WorkflowSigner) принадлежат процедуре и заменяются целиком при каждой новой ревизии.
+// Имя уникально среди неудаленных процедур (индекс idx_workflow_headers_name в миграторе)
+type WorkflowHeader struct {
+	gorm.Model
+	Name     string `json:"name" gorm:"not null;check:chk_workflow_headers_name,name <> ''"`
+	Revision int    `json:"revision" gorm:"not null;default:1;check:chk_workflow_headers_revision,revision >= 1"` // увеличивается при каждом изменении процедуры
+
+	RequireResolvedComments bool `json:"require_resolved_comments" gorm:"not null;default:false"` // см. WorkflowOptions
+
+	Stages []WorkflowStageRecord `json:"stages" gorm:"foreignKey:WorkflowID;constraint:OnDelete:CASCADE"`
+}
+
+// WorkflowStageRecord модель - этап процедуры согласования. Номера этапов процедуры уникальны
+// и начинаются с 1, отсутствие пропусков проверяется при сохранении процедуры
+type WorkflowStageRecord struct {
+	ID          uint   `json:"id" gorm:"primarykey"`
+	WorkflowID  uint   `json:"workflow_id" gorm:"not null;uniqueIndex:idx_workflow_stage_order"`
+	StageOrder  int    `json:"stage_order" gorm:"not null;uniqueIndex:idx_workflow_stage_order;check:chk_workflow_stages_order,stage_order >= 1"`
+	StageRule   string `json:"stage_rule" gorm:"not null;default:'all';check:chk_workflow_stages_rule,stage_rule IN ('all', 'any', 'quorum')"`
+	StageQuorum int    `json:"stage_quorum" gorm:"not null;default:0;check:chk_workflow_stages_quorum,stage_quorum >= 0"`
+
+	StageDueHours    int  `json:"stage_due_hours" gorm:"not null;default:0;check:chk_workflow_stages_due_hours,stage_due_hours >= 0"` // срок этапа в часах (0 - без срока)
+	EscalationUserID uint `json:"escalation_user_id" gorm:"not null;default:0"`
+	EscalationRoleID uint `json:"escalation_role_id" gorm:"not null;default:0;check:chk_workflow_stages_escalation,escalation_user_id = 0 OR escalation_role_id = 0"`
+
+	Signers []WorkflowSigner `json:"signers" gorm:"foreignKey:StageID;constraint:OnDelete:CASCADE"`
+}
+
+func (WorkflowStageRecord) TableName() string {
+	return "workflow_stages"
+}
+
+// WorkflowSigner модель - подписант этапа процедуры: пользователь UserID или роль RoleID
+type WorkflowSigner struct {
+	ID      uint `json:"id" gorm:"primarykey"`
+	StageID uint `json:"stage_id" gorm:"not null;uniqueIndex:idx_workflow_signer"`
+	UserID  uint `json:"user_id" gorm:"not null;default:0;uniqueIndex:idx_workflow_signer;index"`
+	RoleID  uint `json:"role_id" gorm:"not null;default:0;uniqueIndex:idx_workflow_signer;index;check:chk_workflow_signers_signer,(user_id <> 0) <> (role_id <> 0)"`
+}
+
+// Workflow - строка представления workflows над WorkflowHeader, WorkflowStageRecord и WorkflowSigner:
+// один подписант этапа вместе с полями этапа и процедуры. Этап с несколькими подписантами дает
+// несколько строк с одинаковым WorkflowOrder. Представление используется только для чтения
+type Workflow struct {
+	gorm.Model
+	WorkflowID    uint   `json:"workflow_id"`
+	WorkflowName  string `json:"workflow_name"`
+	UserID        uint   `json:"user_id"`
+	RoleID        uint   `json:"role_id"`
+	WorkflowOrder int    `json:"workflow_order"`
+	StageRule     string `json:"stage_rule"`
+	StageQuorum   int    `json:"stage_quorum"`
+	Revision      int    `json:"revision"`
+
+	RequireResolvedComments bool `json:"require_resolved_comments"`
+
+	StageDueHours    int  `json:"stage_due_hours"`
+	EscalationUserID uint `json:"escalation_user_id"`
+	EscalationRoleID uint `json:"escalation_role_id"`
+}
@@ -1,170 +1,366 @@
-package grpc
-
-import (
-	"context"
-	"fmt"
-	"log"
-	"service-core/internal/domain"
-	"service-core/pkg/config"
-
-	pb "service-core/internal/proto"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/metadata"
-	"google.golang.org/grpc/status"
-)
-
-type FileGRPCClient struct {
-	client pb.FileServiceClient
-}
-
-func NewFileGRPCClient(cfg *config.Config) (*FileGRPCClient, error) {
-	apiKeyInterceptor := createAPIKeyInterceptor(cfg.SecretKeys.KeyGrpc)
-
-	conn, err := grpc.Dial(cfg.GRPCAddress, grpc.WithInsecure(), grpc.WithUnaryInterceptor(apiKeyInterceptor)) // Используйте TLS в production
-	if err != nil {
-		return nil, err
-	}
-	client := pb.NewFileServiceClient(conn)
-	return &FileGRPCClient{client: client}, nil
-}
-
-func createAPIKeyInterceptor(apiKey string) grpc.UnaryClientInterceptor {
-	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
-		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", apiKey)
-		return invoker(ctx, method, req, reply, cc, opts...)
-	}
-}
-
-func (c *FileGRPCClient) GetFileWithDirectory(ctx context.Context, fileID uint) (*domain.File, error) {
-	const op = "infrastructure.grpc.fileclient.GetFileWithDirectory"
-
-	req := &pb.GetFileRequest{
-		FileId: uint32(fileID),
-	}
-
-	resp, err := c.client.GetFileByID(ctx, req)
-	if err != nil {
-		st, _ := status.FromError(err)
-		log.Printf("gRPC error: %v", st.Message())
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	file := &domain.File{
-		ID:          uint(resp.Id),
-		DirectoryID: uint(resp.DirectoryId),
-		Name:        resp.Name,
-		Status:      resp.Status,
-		Version:     int(resp.Version),
-		Directory: &domain.Directory{
-			ID:           uint(resp.Directory.Id),
-			ParentPathID: uintPtrOrNil(resp.Directory.ParentPathId),
-			Name:         resp.Directory.Name,
-			Status:       resp.Directory.Status,
-			WorkflowID:   uint(resp.Directory.WorkflowId),
-		},
-	}
-	return file, nil
-}
-
-func (c *FileGRPCClient) UpdateFileStatus(ctx context.Context, fileID uint, fileStatus string) error {
-	const op = "infrastructure.grpc.fileclient.UpdateFileStatus"
-
-	req := &pb.UpdateFileStatusRequest{
-		FileId: uint32(fileID),
-		Status: fileStatus,
-	}
-
-	_, err := c.client.UpdateFileStatus(ctx, req)
-	if err != nil {
-		st, _ := status.FromError(err)
-		log.Printf("gRPC error: %v", st.Message())
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (c *FileGRPCClient) GetFilesInfo(ctx context.Context, fileIDs []uint32) (map[uint32]string, error) {
-	const op = "infrastructure.grpc.fileclient.GetFilesInfo"
-
-	req := &pb.GetFilesRequest{
-		FileIds: fileIDs,
-	}
-	resp, err := c.client.GetFilesInfo(ctx, req)
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-	return resp.FileNames, nil
-}
-
-func (c *FileGRPCClient) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
-	const op = "infrastructure.grpc.fileclient.CheckWorkflow"
-
-	req := &pb.CheckWorkflowRequest{
-		WorkflowId: uint32(workflowID),
-	}
-
-	resp, err := c.client.CheckWorkflow(ctx, req)
-	if err != nil {
-		return false, nil
-	}
-
-	return resp.Exists, nil
-}
-
-func (c *FileGRPCClient) AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint32) error {
-	const op = "infrastructure.grpc.fileclient.AssignWorkflow"
-
-	req := &pb.AssignWorkflowRequest{
-		WorkflowId:   uint32(workflowID),
-		DirectoryIds: directoryIDs,
-	}
-
-	_, err := c.client.AssignWorkflow(ctx, req)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (c *FileGRPCClient) DeleteUserRelations(ctx context.Context, userID uint) error {
-	const op = "infrastructure.grpc.fileclient.DeleteUserRelations"
-
-	req := &pb.DeleteUserRelationsRequest{
-		UserId: uint32(userID),
-	}
-
-	_, err := c.client.DeleteUserRelations(ctx, req)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (c *FileGRPCClient) AssignUser(ctx context.Context, userID uint, directoryIDs []uint32, fileIDs []uint32) error {
-	const op = "infrastructure.grpc.fileclient.AssignUser"
-
-	req := &pb.AssignUserRequest{
-		UserId:       uint32(userID),
-		DirectoryIds: directoryIDs,
-		FileIds:      fileIDs,
-	}
-
-	_, err := c.client.AssignUser(ctx, req)
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func uintPtrOrNil(value uint32) *uint {
-	if value == 0 {
-		return nil
-	}
-	v := uint(value)
-	return &v
-}
+package grpc
+
+import (
+	"context"
+	"fmt"
+	"log"
+	"service-core/internal/domain"
+	"service-core/pkg/config"
+	"time"
+
+	pb "service-core/internal/proto"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type FileGRPCClient struct {
+	client pb.FileServiceClient
+}
+
+func NewFileGRPCClient(cfg *config.Config) (*FileGRPCClient, error) {
+	apiKeyInterceptor := createAPIKeyInterceptor(cfg.SecretKeys.KeyGrpc)
+
+	conn, err := grpc.Dial(cfg.GRPCAddress, grpc.WithInsecure(), grpc.WithUnaryInterceptor(apiKeyInterceptor)) // Используйте TLS в production
+	if err != nil {
+		return nil, err
+	}
+	client := pb.NewFileServiceClient(conn)
+	return &FileGRPCClient{client: client}, nil
+}
+
+func createAPIKeyInterceptor(apiKey string) grpc.UnaryClientInterceptor {
+	return func(ctx context.Context, method string, req, reply interface{}, cc *grpc.ClientConn, invoker grpc.UnaryInvoker, opts ...grpc.CallOption) error {
+		ctx = metadata.AppendToOutgoingContext(ctx, "x-api-key", apiKey)
+		return invoker(ctx, method, req, reply, cc, opts...)
+	}
+}
+
+func (c *FileGRPCClient) GetFileWithDirectory(ctx context.Context, fileID uint) (*domain.File, error) {
+	const op = "infrastructure.grpc.fileclient.GetFileWithDirectory"
+
+	req := &pb.GetFileRequest{
+		FileId: uint32(fileID),
+	}
+
+	resp, err := c.client.GetFileByID(ctx, req)
+	if err != nil {
+		st, _ := status.FromError(err)
+		log.Printf("gRPC error: %v", st.Message())
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fileFromResponse(resp), nil
+}
+
+// GetFilesWithDirectory возвращает файлы вместе с директориями одним запросом GetFilesInfo.
+// Ненайденные файлы в результат не попадают
+func (c *FileGRPCClient) GetFilesWithDirectory(ctx context.Context, fileIDs []uint32) ([]domain.File, error) {
+	const op = "infrastructure.grpc.fileclient.GetFilesWithDirectory"
+
+	req := &pb.GetFilesRequest{
+		FileIds: fileIDs,
+	}
+	resp, err := c.client.GetFilesInfo(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	files := make([]domain.File, 0, len(resp.Files))
+	for _, fileResp := range resp.Files {
+		files = append(files, *fileFromResponse(fileResp))
+	}
+	return files, nil
+}
+
+// GetDirectoryFiles возвращает ID файлов директории и всех вложенных директорий в статусе
+// fileStatus (пустой - в любом статусе)
+func (c *FileGRPCClient) GetDirectoryFiles(ctx context.Context, directoryID uint, fileStatus string) ([]uint32, error) {
+	const op = "infrastructure.grpc.fileclient.GetDirectoryFiles"
+
+	req := &pb.GetDirectoryFilesRequest{
+		DirectoryId: uint32(directoryID),
+		Status:      fileStatus,
+	}
+	resp, err := c.client.GetDirectoryFiles(ctx, req)
+	if err != nil {
+		st, _ := status.FromError(err)
+		if st.Code() == codes.NotFound {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return resp.FileIds, nil
+}
+
+// FindFiles возвращает ID файлов директории directoryID и всех вложенных директорий (0 - всех файлов),
+// имя которых содержит nameQuery без учета регистра (пустой - любое имя). Возвращается не больше limit
+// файлов (0 - без ограничения)
+func (c *FileGRPCClient) FindFiles(ctx context.Context, directoryID uint, nameQuery string, limit int) ([]uint32, error) {
+	const op = "infrastructure.grpc.fileclient.FindFiles"
+
+	req := &pb.FindFilesRequest{
+		DirectoryId: uint32(directoryID),
+		NameQuery:   nameQuery,
+		Limit:       uint32(limit),
+	}
+	resp, err := c.client.FindFiles(ctx, req)
+	if err != nil {
+		st, _ := status.FromError(err)
+		if st.Code() == codes.NotFound {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return resp.FileIds, nil
+}
+
+// GetFileContentHash возвращает SHA-256 содержимого версии version файла в хранилище
+// (0 - текущая версия)
+func (c *FileGRPCClient) GetFileContentHash(ctx context.Context, fileID uint, version int) (domain.FileContentHash, error) {
+	const op = "infrastructure.grpc.fileclient.GetFileContentHash"
+
+	req := &pb.GetFileContentHashRequest{
+		FileId:  uint32(fileID),
+		Version: int32(version),
+	}
+	resp, err := c.client.GetFileContentHash(ctx, req)
+	if err != nil {
+		st, _ := status.FromError(err)
+		if st.Code() == codes.NotFound {
+			return domain.FileContentHash{}, fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		}
+		return domain.FileContentHash{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return domain.FileContentHash{
+		FileID:  uint(resp.FileId),
+		Version: int(resp.Version),
+		SHA256:  resp.Sha256,
+	}, nil
+}
+
+func fileFromResponse(resp *pb.FileResponse) *domain.File {
+	file := &domain.File{
+		ID:          uint(resp.Id),
+		DirectoryID: uint(resp.DirectoryId),
+		Name:        resp.Name,
+		Status:      resp.Status,
+		Version:     int(resp.Version),
+		Size:        resp.Size,
+		ContentType: resp.ContentType,
+	}
+	if resp.Directory != nil {
+		file.Directory = &domain.Directory{
+			ID:           uint(resp.Directory.Id),
+			ParentPathID: uintPtrOrNil(resp.Directory.ParentPathId),
+			Name:         resp.Directory.Name,
+			Status:       resp.Directory.Status,
+			WorkflowID:   uint(resp.Directory.WorkflowId),
+			Depth:        int(resp.Directory.Depth),
+
+			WorkflowInherited: resp.Directory.WorkflowInherited,
+		}
+	}
+	return file
+}
+
+// UpdateFileStatus обновляет статус файла. Повторный вызов с тем же idempotencyKey
+// не применяется повторно
+func (c *FileGRPCClient) UpdateFileStatus(ctx context.Context, fileID uint, fileStatus, idempotencyKey string) error {
+	const op = "infrastructure.grpc.fileclient.UpdateFileStatus"
+
+	req := &pb.UpdateFileStatusRequest{
+		FileId:         uint32(fileID),
+		Status:         fileStatus,
+		IdempotencyKey: idempotencyKey,
+	}
+
+	_, err := c.client.UpdateFileStatus(ctx, req)
+	if err != nil {
+		st, _ := status.FromError(err)
+		log.Printf("gRPC error: %v", st.Message())
+		if st.Code() == codes.NotFound {
+			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (c *FileGRPCClient) GetFilesInfo(ctx context.Context, fileIDs []uint32) (map[uint32]string, error) {
+	const op = "infrastructure.grpc.fileclient.GetFilesInfo"
+
+	req := &pb.GetFilesRequest{
+		FileIds: fileIDs,
+	}
+	resp, err := c.client.GetFilesInfo(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return resp.FileNames, nil
+}
+
+func (c *FileGRPCClient) GetFileStatuses(ctx context.Context, fileIDs []uint32) (map[uint32]string, error) {
+	const op = "infrastructure.grpc.fileclient.GetFileStatuses"
+
+	req := &pb.GetFilesRequest{
+		FileIds: fileIDs,
+	}
+	resp, err := c.client.GetFileStatuses(ctx, req)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	return resp.FileStatuses, nil
+}
+
+func (c *FileGRPCClient) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
+	const op = "infrastructure.grpc.fileclient.CheckWorkflow"
+
+	req := &pb.CheckWorkflowRequest{
+		WorkflowId: uint32(workflowID),
+	}
+
+	resp, err := c.client.CheckWorkflow(ctx, req)
+	if err != nil {
+		return false, nil
+	}
+
+	return resp.Exists, nil
+}
+
+func (c *FileGRPCClient) AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint32) error {
+	const op = "infrastructure.grpc.fileclient.AssignWorkflow"
+
+	req := &pb.AssignWorkflowRequest{
+		WorkflowId:   uint32(workflowID),
+		DirectoryIds: directoryIDs,
+	}
+
+	_, err := c.client.AssignWorkflow(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetDirectoryPaths возвращает все директории с путями от корня и назначенными процедурами согласования
+func (c *FileGRPCClient) GetDirectoryPaths(ctx context.Context) ([]domain.DirectoryPath, error) {
+	const op = "infrastructure.grpc.fileclient.GetDirectoryPaths"
+
+	resp, err := c.client.GetDirectoryPaths(ctx, &emptypb.Empty{})
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	directories := make([]domain.DirectoryPath, len(resp.Directories))
+	for i, directory := range resp.Directories {
+		directories[i] = domain.DirectoryPath{
+			ID:         uint(directory.DirectoryId),
+			Path:       directory.Path,
+			WorkflowID: uint(directory.WorkflowId),
+			ParentID:   uint(directory.ParentId),
+		}
+	}
+	return directories, nil
+}
+
+func (c *FileGRPCClient) DeleteUserRelations(ctx context.Context, userID uint) error {
+	const op = "infrastructure.grpc.fileclient.DeleteUserRelations"
+
+	req := &pb.DeleteUserRelationsRequest{
+		UserId: uint32(userID),
+	}
+
+	_, err := c.client.DeleteUserRelations(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (c *FileGRPCClient) AssignUser(ctx context.Context, userID uint, directories []domain.ObjectAccess, files []domain.ObjectAccess) error {
+	const op = "infrastructure.grpc.fileclient.AssignUser"
+
+	req := &pb.AssignUserRequest{
+		UserId:      uint32(userID),
+		Directories: objectAccessToProto(directories),
+		Files:       objectAccessToProto(files),
+	}
+
+	_, err := c.client.AssignUser(ctx, req)
+	if err != nil {
+		st, _ := status.FromError(err)
+		switch {
+		case st.Code() == codes.InvalidArgument:
+			return fmt.Errorf("%s: %w", op, domain.ErrInvalidAccessLevel)
+		case st.Code() == codes.NotFound && st.Message() == "directory not found":
+			return fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+		case st.Code() == codes.NotFound:
+			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CheckFileAccess проверяет, что у пользователя есть уровень доступа не ниже accessLevel ко всем файлам
+func (c *FileGRPCClient) CheckFileAccess(ctx context.Context, userID uint, fileIDs []uint32, accessLevel string) (bool, error) {
+	const op = "infrastructure.grpc.fileclient.CheckFileAccess"
+
+	req := &pb.CheckFileAccessRequest{
+		UserId:      uint32(userID),
+		FileIds:     fileIDs,
+		AccessLevel: accessLevel,
+	}
+
+	resp, err := c.client.CheckFileAccess(ctx, req)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return resp.Allowed, nil
+}
+
+func objectAccessToProto(objects []domain.ObjectAccess) []*pb.ObjectAccess {
+	result := make([]*pb.ObjectAccess, len(objects))
+	for i, object := range objects {
+		result[i] = &pb.ObjectAccess{
+			Id:          uint32(object.ID),
+			AccessLevel: object.AccessLevel,
+		}
+	}
+	return result
+}
+
+// RevokeSessions передает в file-service отозванные сессии: их токены отклоняются до expiresAt
+func (c *FileGRPCClient) RevokeSessions(ctx context.Context, sessionIDs []string, expiresAt time.Time) error {
+	const op = "infrastructure.grpc.fileclient.RevokeSessions"
+
+	req := &pb.RevokeSessionsRequest{
+		SessionIds: sessionIDs,
+		ExpiresAt:  expiresAt.Unix(),
+	}
+
+	_, err := c.client.RevokeSessions(ctx, req)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func uintPtrOrNil(value uint32) *uint {
+	if value == 0 {
+		return nil
+	}
+	v := uint(value)
+	return &v
+}
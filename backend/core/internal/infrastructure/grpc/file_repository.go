@@ -1,46 +1,79 @@
-// В internal/infrastructure/grpc/file_repository.go
-package grpc
-
-import (
-	"context"
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-)
-
-type FileRepositoryImpl struct {
-	client *FileGRPCClient
-}
-
-// TODO: убрать костыль
-
-func NewFileService(client *FileGRPCClient) interfaces.FileService {
-	return &FileRepositoryImpl{client: client}
-}
-
-func (r *FileRepositoryImpl) GetFileWithDirectory(ctx context.Context, fileID uint) (*domain.File, error) {
-	return r.client.GetFileWithDirectory(ctx, fileID)
-}
-
-func (r *FileRepositoryImpl) UpdateFileStatus(ctx context.Context, fileID uint, status string) error {
-	return r.client.UpdateFileStatus(ctx, fileID, status)
-}
-
-func (r *FileRepositoryImpl) GetFilesInfo(ctx context.Context, fileIDs []uint32) (map[uint32]string, error) {
-	return r.client.GetFilesInfo(ctx, fileIDs)
-}
-
-func (r *FileRepositoryImpl) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
-	return r.client.CheckWorkflow(ctx, workflowID)
-}
-
-func (r *FileRepositoryImpl) AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint32) error {
-	return r.client.AssignWorkflow(ctx, workflowID, directoryIDs)
-}
-
-func (r *FileRepositoryImpl) DeleteUserRelations(ctx context.Context, userID uint) error {
-	return r.client.DeleteUserRelations(ctx, userID)
-}
-
-func (r *FileRepositoryImpl) AssignUser(ctx context.Context, userID uint, directoryIDs []uint32, fileIDs []uint32) error {
-	return r.client.AssignUser(ctx, userID, directoryIDs, fileIDs)
-}
+// В internal/infrastructure/grpc/file_repository.go
+package grpc
+
+import (
+	"context"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"time"
+)
+
+type FileRepositoryImpl struct {
+	client *FileGRPCClient
+}
+
+// TODO: убрать костыль
+
+func NewFileService(client *FileGRPCClient) interfaces.FileService {
+	return &FileRepositoryImpl{client: client}
+}
+
+func (r *FileRepositoryImpl) GetFileWithDirectory(ctx context.Context, fileID uint) (*domain.File, error) {
+	return r.client.GetFileWithDirectory(ctx, fileID)
+}
+
+func (r *FileRepositoryImpl) UpdateFileStatus(ctx context.Context, fileID uint, status, idempotencyKey string) error {
+	return r.client.UpdateFileStatus(ctx, fileID, status, idempotencyKey)
+}
+
+func (r *FileRepositoryImpl) GetFilesInfo(ctx context.Context, fileIDs []uint32) (map[uint32]string, error) {
+	return r.client.GetFilesInfo(ctx, fileIDs)
+}
+
+func (r *FileRepositoryImpl) GetFileStatuses(ctx context.Context, fileIDs []uint32) (map[uint32]string, error) {
+	return r.client.GetFileStatuses(ctx, fileIDs)
+}
+
+func (r *FileRepositoryImpl) GetFilesWithDirectory(ctx context.Context, fileIDs []uint32) ([]domain.File, error) {
+	return r.client.GetFilesWithDirectory(ctx, fileIDs)
+}
+
+func (r *FileRepositoryImpl) GetDirectoryFiles(ctx context.Context, directoryID uint, status string) ([]uint32, error) {
+	return r.client.GetDirectoryFiles(ctx, directoryID, status)
+}
+
+func (r *FileRepositoryImpl) FindFiles(ctx context.Context, directoryID uint, nameQuery string, limit int) ([]uint32, error) {
+	return r.client.FindFiles(ctx, directoryID, nameQuery, limit)
+}
+
+func (r *FileRepositoryImpl) GetFileContentHash(ctx context.Context, fileID uint, version int) (domain.FileContentHash, error) {
+	return r.client.GetFileContentHash(ctx, fileID, version)
+}
+
+func (r *FileRepositoryImpl) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
+	return r.client.CheckWorkflow(ctx, workflowID)
+}
+
+func (r *FileRepositoryImpl) AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint32) error {
+	return r.client.AssignWorkflow(ctx, workflowID, directoryIDs)
+}
+
+func (r *FileRepositoryImpl) GetDirectoryPaths(ctx context.Context) ([]domain.DirectoryPath, error) {
+	return r.client.GetDirectoryPaths(ctx)
+}
+
+func (r *FileRepositoryImpl) DeleteUserRelations(ctx context.Context, userID uint) error {
+	return r.client.DeleteUserRelations(ctx, userID)
+}
+
+func (r *FileRepositoryImpl) AssignUser(ctx context.Context, userID uint, directories []domain.ObjectAccess, files []domain.ObjectAccess) error {
+	return r.client.AssignUser(ctx, userID, directories, files)
+}
+
+func (r *FileRepositoryImpl) CheckFileAccess(ctx context.Context, userID uint, fileIDs []uint32, accessLevel string) (bool, error) {
+	return r.client.CheckFileAccess(ctx, userID, fileIDs, accessLevel)
+}
+
+func (r *FileRepositoryImpl) RevokeSessions(ctx context.Context, sessionIDs []string, expiresAt time.Time) error {
+	return r.client.RevokeSessions(ctx, sessionIDs, expiresAt)
+}
@@ -0,0 +1,31 @@
+package notifier
+
+import (
+	"context"
+	"log/slog"
+)
+
+// LogNotifier пишет уведомления в лог сервиса. Используется, пока не подключен
+// внешний канал доставки (почта, мессенджер)
+type LogNotifier struct {
+	log *slog.Logger
+}
+
+func NewLogNotifier(log *slog.Logger) *LogNotifier {
+	return &LogNotifier{log: log}
+}
+
+func (n *LogNotifier) Notify(ctx context.Context, userIDs []uint, subject, message string) error {
+	const op = "infrastructure.notifier.Notify"
+
+	for _, userID := range userIDs {
+		n.log.Info("notification",
+			slog.String("op", op),
+			slog.Any("user_id", userID),
+			slog.String("subject", subject),
+			slog.String("message", message),
+		)
+	}
+
+	return nil
+}
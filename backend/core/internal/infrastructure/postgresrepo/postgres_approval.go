@@ -1,182 +1,1332 @@
-package postgresrepo
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"service-core/internal/domain"
-
-	"gorm.io/gorm"
-)
-
-type ApprovalRepository struct {
-	db *gorm.DB
-}
-
-func NewApprovalRepository(db *Database) *ApprovalRepository {
-	return &ApprovalRepository{db: db.db}
-}
-
-func (r *ApprovalRepository) CreateApproval(ctx context.Context, approval *domain.Approval) error {
-	return r.db.Create(approval).Error
-}
-
-// FindApprovalsByUser находит Approvals через связь с Workflow
-func (r *ApprovalRepository) FindApprovalsByUser(ctx context.Context, userID uint) ([]domain.ApprovalResponse, error) {
-	const op = "infrastructure.postgresrepo.approval.FindApprovalsByUser"
-
-	var approvals []domain.ApprovalResponse
-
-	err := r.db.WithContext(ctx).
-		Table("approvals").
-		Select(`
-            approvals.id,
-            approvals.file_id,
-            approvals.status,
-            approvals.workflow_order,
-            (SELECT MAX(workflow_order) FROM workflows WHERE workflows.workflow_id = approvals.workflow_id) AS workflow_user_count
-        `).
-		Joins("JOIN workflows ON workflows.workflow_id = approvals.workflow_id AND workflows.workflow_order = approvals.workflow_order").
-		Where("workflows.user_id = ?", userID).
-		Where("approvals.status = ?", "on approval").
-		Where("workflows.deleted_at IS NULL").
-		Scan(&approvals).Error
-
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return approvals, nil
-}
-
-// CheckUserPermission проверяет, имеет ли пользователь право подписывать Approval
-// Кастомные ошибки: ErrApprovalNotFound
-func (r *ApprovalRepository) CheckUserPermission(ctx context.Context, approvalID, userID uint) (*domain.Approval, error) {
-	const op = "infrastructure.postgresrepo.approval.CheckUserPermission"
-
-	var approval domain.Approval
-
-	err := r.db.WithContext(ctx).
-		Joins("JOIN workflows ON workflows.workflow_id = approvals.workflow_id AND workflows.workflow_order = approvals.workflow_order").
-		Where("approvals.id = ? AND workflows.user_id = ?", approvalID, userID).
-		Where("approvals.status = ?", "on approval").
-		First(&approval).Error
-
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return &domain.Approval{}, fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
-		}
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return &approval, nil
-}
-
-// IsLastUserInWorkflow - проверка на то, является ли пользователь крайним в цепочке согласования
-func (r *ApprovalRepository) IsLastUserInWorkflow(ctx context.Context, approvalID, userID uint) (*domain.Approval, error) {
-	const op = "infrastructure.postgresrepo.approval.IsLastUserInWorkflow"
-
-	var approval domain.Approval
-
-	err := r.db.WithContext(ctx).
-		Joins("JOIN workflows ON workflows.workflow_id = approvals.workflow_id AND workflows.workflow_order = approvals.workflow_order").
-		Where("approvals.id = ?", approvalID).
-		Where("workflows.user_id = ?", userID).
-		Where("workflows.workflow_order = (SELECT MAX(workflow_order) FROM workflows WHERE workflow_id = approvals.workflow_id)").
-		Where("approvals.workflow_order = (SELECT MAX(workflow_order) FROM workflows WHERE workflow_id = approvals.workflow_id)").
-		First(&approval).Error
-
-	if err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return &domain.Approval{}, nil
-		}
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return &approval, nil
-}
-
-// IncrementApprovalOrder увеличивает поле order у Approval
-func (r *ApprovalRepository) IncrementApprovalOrder(ctx context.Context, approvalID uint) error {
-	const op = "infrastructure.postgresrepo.approval.IncrementApprovalOrder"
-
-	err := r.db.WithContext(ctx).
-		Model(&domain.Approval{}).
-		Where("id = ?", approvalID).
-		Update("workflow_order", gorm.Expr("workflow_order + 1")).
-		Error
-
-	if err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-// AnnotateApproval обновляет Approval
-func (r *ApprovalRepository) AnnotateApproval(ctx context.Context, approvalID uint, message string) error {
-	const op = "infrastructure.postgresrepo.approval.AnnotateApproval"
-
-	tx := r.db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	var approval domain.Approval
-	if err := tx.First(&approval, approvalID).Error; err != nil {
-		tx.Rollback()
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
-		}
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	approval.Status = "annotated"
-	approval.AnnotationText = message
-	if err := tx.Save(&approval).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-// FinalizeApproval обновляет Approval и связанный File
-func (r *ApprovalRepository) FinalizeApproval(ctx context.Context, approvalID uint) error {
-	const op = "infrastructure.postgresrepo.approval.FinalizeApproval"
-
-	tx := r.db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	var approval domain.Approval
-	if err := tx.First(&approval, approvalID).Error; err != nil {
-		tx.Rollback()
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
-		}
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	// Обновляем Approval
-	approval.Status = "approved"
-	if err := tx.Save(&approval).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
+package postgresrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+	"sort"
+	"strings"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type ApprovalRepository struct {
+	db *gorm.DB
+}
+
+func NewApprovalRepository(db *Database) *ApprovalRepository {
+	return &ApprovalRepository{db: db.db}
+}
+
+// CreateApproval создает Approval с копией текущей ревизии процедуры согласования, записывает
+// в журнал отправку файла на согласование и добавляет в outbox новый статус файла
+// Кастомные ошибки: ErrWorkflowNotFound
+func (r *ApprovalRepository) CreateApproval(ctx context.Context, approval *domain.Approval, skipOrders []int, actorID uint) error {
+	const op = "infrastructure.postgresrepo.approval.CreateApproval"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := createApproval(tx, approval, skipOrders, actorID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// createApproval создает Approval с копией этапов процедуры (без этапов skipOrders), записывает
+// отправку в журнал и добавляет в outbox новые статусы файлов в рамках транзакции tx
+func createApproval(tx *gorm.DB, approval *domain.Approval, skipOrders []int, actorID uint) error {
+	var rows []domain.Workflow
+	if err := tx.Where("workflow_id = ?", approval.WorkflowID).
+		Order("workflow_order ASC, id ASC").
+		Find(&rows).Error; err != nil {
+		return err
+	}
+	if len(rows) == 0 {
+		return domain.ErrWorkflowNotFound
+	}
+
+	approval.WorkflowRevision = rows[0].Revision
+	rows = skipStageRows(rows, skipOrders)
+	if len(rows) == 0 {
+		return domain.ErrEmptyRoute
+	}
+
+	if err := tx.Create(approval).Error; err != nil {
+		return err
+	}
+
+	stages := snapshotRows(approval.ID, rows)
+	if err := tx.Create(&stages).Error; err != nil {
+		return err
+	}
+
+	if err := createEvent(tx, approval, domain.ApprovalActionSubmit, actorID, ""); err != nil {
+		return err
+	}
+
+	return enqueueFileStatus(tx, approval)
+}
+
+// GetApprovalByID возвращает Approval по ID
+// Кастомные ошибки: ErrApprovalNotFound
+func (r *ApprovalRepository) GetApprovalByID(ctx context.Context, approvalID uint) (*domain.Approval, error) {
+	const op = "infrastructure.postgresrepo.approval.GetApprovalByID"
+
+	var approval domain.Approval
+	if err := r.db.WithContext(ctx).First(&approval, approvalID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &approval, nil
+}
+
+// stageEscalationMatch - пользователь u является резервным подписантом этапа w, и этап эскалирован
+const stageEscalationMatch = `(approvals.escalated_at IS NOT NULL
+            AND (w.escalation_user_id = u.id OR (w.escalation_role_id <> 0 AND w.escalation_role_id = u.role_id)))`
+
+// actingUserMatch - пользователь u является пользователем @user_id или тем, кого @user_id
+// сейчас замещает по активному замещению
+const actingUserMatch = `(u.id = @user_id OR u.id IN (
+        SELECT d.delegator_id FROM delegations d
+        WHERE d.delegate_id = @user_id AND d.deleted_at IS NULL
+            AND d.starts_at <= NOW() AND d.ends_at > NOW()))`
+
+// stageMemberCondition - условие "пользователь @user_id является подписантом текущего этапа Approval":
+// он (или замещаемый им пользователь) указан в этапе лично, его роль указана в этапе
+// или он резервный подписант эскалированного этапа
+const stageMemberCondition = `EXISTS (
+    SELECT 1 FROM approval_stages w
+    JOIN users u ON ` + actingUserMatch + `
+    WHERE w.approval_id = approvals.id
+        AND w.workflow_order = approvals.workflow_order
+        AND (w.user_id = u.id OR (w.role_id <> 0 AND w.role_id = u.role_id)
+            OR ` + stageEscalationMatch + `)
+)`
+
+// stageOpenSlotCondition - условие "у пользователя @user_id есть свободное место на текущем этапе Approval":
+// он (или замещаемый им пользователь) ещё не подписал этап и указан в этапе лично, за его роль ещё
+// никто не подписал или он резервный подписант эскалированного этапа
+const stageOpenSlotCondition = `EXISTS (
+    SELECT 1 FROM approval_stages w
+    JOIN users u ON ` + actingUserMatch + `
+    WHERE w.approval_id = approvals.id
+        AND w.workflow_order = approvals.workflow_order
+        AND NOT EXISTS (
+            SELECT 1 FROM approval_signatures s
+            WHERE s.approval_id = approvals.id AND s.workflow_order = approvals.workflow_order
+                AND s.user_id = u.id AND s.deleted_at IS NULL)
+        AND (w.user_id = u.id OR (w.role_id <> 0 AND w.role_id = u.role_id AND NOT EXISTS (
+            SELECT 1 FROM approval_signatures s
+            WHERE s.approval_id = approvals.id AND s.workflow_order = approvals.workflow_order
+                AND s.role_id = w.role_id AND s.deleted_at IS NULL))
+            OR ` + stageEscalationMatch + `)
+)`
+
+// stageDueAtExpr - срок текущего этапа Approval (NULL, если срок этапа не задан)
+const stageDueAtExpr = `(SELECT CASE WHEN MAX(w.stage_due_hours) > 0
+            THEN approvals.stage_started_at + MAX(w.stage_due_hours) * INTERVAL '1 hour'
+        END
+        FROM approval_stages w
+        WHERE w.approval_id = approvals.id AND w.workflow_order = approvals.workflow_order)`
+
+// approvalParticipantCondition - условие "пользователь @user_id участвует в Approval": он есть
+// в журнале или в одном из этапов процедуры согласования (лично или через роль)
+const approvalParticipantCondition = `(EXISTS (
+        SELECT 1 FROM approval_events e
+        WHERE e.approval_id = approvals.id AND e.actor_id = @user_id
+    ) OR EXISTS (
+        SELECT 1 FROM approval_stages w
+        JOIN users u ON u.id = @user_id
+        WHERE w.approval_id = approvals.id
+            AND (w.user_id = u.id OR (w.role_id <> 0 AND w.role_id = u.role_id))
+    ))`
+
+// inboxConditions - условия разделов списка согласований пользователя @user_id
+var inboxConditions = map[string]string{
+	// Approvals, на текущем этапе которых у пользователя есть свободное место для подписи
+	domain.InboxStatusPending: `approvals.status = 'on approval' AND ` + stageOpenSlotCondition,
+	domain.InboxStatusSigned: `EXISTS (
+        SELECT 1 FROM approval_events e
+        WHERE e.approval_id = approvals.id AND e.actor_id = @user_id
+            AND e.action IN ('` + domain.ApprovalActionSign + `', '` + domain.ApprovalActionFinalize + `'))`,
+	domain.InboxStatusFinalized: `approvals.status = 'approved' AND ` + approvalParticipantCondition,
+	domain.InboxStatusAnnotated: `approvals.status = 'annotated' AND ` + approvalParticipantCondition,
+}
+
+// approvalSortKeys - выражения ключей сортировки списка согласований
+var approvalSortKeys = map[string]string{
+	domain.ApprovalSortNewest: "approvals.created_at",
+	domain.ApprovalSortOldest: "approvals.created_at",
+	domain.ApprovalSortDue:    "COALESCE(" + stageDueAtExpr + ", 'infinity')",
+}
+
+// approvalColumns - столбцы domain.ApprovalResponse в запросе approvals LEFT JOIN transmittals
+const approvalColumns = `
+            approvals.id,
+            approvals.file_id,
+            approvals.transmittal_id,
+            COALESCE(transmittals.number, '') AS transmittal_number,
+            approvals.status,
+            approvals.workflow_order,
+            (SELECT MAX(st.workflow_order) FROM approval_stages st
+                WHERE st.approval_id = approvals.id) AS workflow_user_count,
+            (SELECT COUNT(*) FROM approval_signatures s
+                WHERE s.approval_id = approvals.id AND s.workflow_order = approvals.workflow_order AND s.deleted_at IS NULL) AS stage_signed_count,
+            (SELECT CASE MAX(w.stage_rule)
+                    WHEN 'any' THEN 1
+                    WHEN 'quorum' THEN MAX(w.stage_quorum)
+                    ELSE COUNT(*)
+                END
+                FROM approval_stages w
+                WHERE w.approval_id = approvals.id AND w.workflow_order = approvals.workflow_order) AS stage_required_count,
+            ` + stageDueAtExpr + ` AS due_at,
+            COALESCE(` + stageDueAtExpr + ` < NOW(), FALSE) AS overdue,
+            approvals.escalated_at IS NOT NULL AS escalated,
+            approvals.created_at
+        `
+
+// FindApprovalsByUser находит Approvals раздела filter.Status списка согласований пользователя.
+// В разделе InboxStatusPending Approvals находятся по копии их процедуры согласования: лично, через роль
+// пользователя, по активному замещению или как резервного подписанта эскалированного этапа. Approvals,
+// на текущем этапе которых у пользователя не осталось свободных мест для подписи, не возвращаются.
+// Возвращает не больше filter.Limit Approvals после filter.After в сортировке filter.Sort
+func (r *ApprovalRepository) FindApprovalsByUser(ctx context.Context, userID uint, filter domain.ApprovalFilter) ([]domain.ApprovalResponse, error) {
+	const op = "infrastructure.postgresrepo.approval.FindApprovalsByUser"
+
+	var approvals []domain.ApprovalResponse
+
+	query := r.inbox(ctx, userID, filter, filter.Status).Select(approvalColumns)
+
+	key := approvalSortKeys[filter.Sort]
+	direction, comparison := "ASC", ">"
+	if filter.Sort == domain.ApprovalSortNewest {
+		direction, comparison = "DESC", "<"
+	}
+	if filter.After != nil {
+		query = query.Where(
+			fmt.Sprintf("(%s, approvals.id) %s (?::timestamptz, ?)", key, comparison),
+			filter.After.Key, filter.After.ID,
+		)
+	}
+	query = query.Order(fmt.Sprintf("%s %s, approvals.id %s", key, direction, direction))
+	if filter.Limit > 0 {
+		query = query.Limit(filter.Limit)
+	}
+
+	if err := query.Scan(&approvals).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return approvals, nil
+}
+
+// CountApprovalsByUser считает Approvals каждого раздела списка согласований пользователя
+// с фильтрами filter (кроме раздела, сортировки и страницы)
+func (r *ApprovalRepository) CountApprovalsByUser(ctx context.Context, userID uint, filter domain.ApprovalFilter) (domain.ApprovalCounts, error) {
+	const op = "infrastructure.postgresrepo.approval.CountApprovalsByUser"
+
+	var counts domain.ApprovalCounts
+	targets := map[string]*int64{
+		domain.InboxStatusPending:   &counts.Pending,
+		domain.InboxStatusSigned:    &counts.Signed,
+		domain.InboxStatusFinalized: &counts.Finalized,
+		domain.InboxStatusAnnotated: &counts.Annotated,
+	}
+
+	for status, count := range targets {
+		if err := r.inbox(ctx, userID, filter, status).Count(count).Error; err != nil {
+			return domain.ApprovalCounts{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return counts, nil
+}
+
+// FindApprovalsBySubmitter находит Approvals, отправленные пользователем submitterID, начиная с новых
+func (r *ApprovalRepository) FindApprovalsBySubmitter(ctx context.Context, submitterID uint) ([]domain.SubmissionResponse, error) {
+	const op = "infrastructure.postgresrepo.approval.FindApprovalsBySubmitter"
+
+	var submissions []domain.SubmissionResponse
+
+	err := r.db.WithContext(ctx).
+		Table("approvals").
+		Select(approvalColumns+", approvals.stage_started_at").
+		Joins("LEFT JOIN transmittals ON transmittals.id = approvals.transmittal_id").
+		Where("approvals.deleted_at IS NULL").
+		Where("approvals.submitter_id = ?", submitterID).
+		Order("approvals.created_at DESC, approvals.id DESC").
+		Scan(&submissions).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return submissions, nil
+}
+
+// FindPendingSlots возвращает неподписанные места текущих этапов Approvals на согласовании: подписантов,
+// которые ещё не подписали этап, роли, за которые ещё никто не подписал, и резервного подписанта
+// эскалированного этапа
+func (r *ApprovalRepository) FindPendingSlots(ctx context.Context, approvalIDs []uint) ([]domain.PendingSlot, error) {
+	const op = "infrastructure.postgresrepo.approval.FindPendingSlots"
+
+	if len(approvalIDs) == 0 {
+		return nil, nil
+	}
+
+	var slots []domain.PendingSlot
+
+	// Пользователь или роль могли быть удалены, поэтому LEFT JOIN без фильтра по deleted_at
+	err := r.db.WithContext(ctx).Raw(`
+        SELECT DISTINCT slots.approval_id, slots.user_id, COALESCE(users.login, '') AS user_login,
+            slots.role_id, COALESCE(roles.role_name, '') AS role_name, slots.escalation
+        FROM (
+            SELECT w.approval_id, CASE WHEN w.role_id = 0 THEN w.user_id ELSE 0 END AS user_id,
+                w.role_id, FALSE AS escalation
+            FROM approval_stages w
+            JOIN approvals ON approvals.id = w.approval_id AND w.workflow_order = approvals.workflow_order
+            WHERE approvals.id IN @ids AND approvals.status = 'on approval'
+                AND NOT EXISTS (
+                    SELECT 1 FROM approval_signatures s
+                    WHERE s.approval_id = approvals.id AND s.workflow_order = approvals.workflow_order
+                        AND s.deleted_at IS NULL
+                        AND (CASE WHEN w.role_id = 0 THEN s.user_id = w.user_id ELSE s.role_id = w.role_id END))
+            UNION
+            SELECT w.approval_id, w.escalation_user_id, w.escalation_role_id, TRUE
+            FROM approval_stages w
+            JOIN approvals ON approvals.id = w.approval_id AND w.workflow_order = approvals.workflow_order
+            WHERE approvals.id IN @ids AND approvals.status = 'on approval'
+                AND approvals.escalated_at IS NOT NULL
+                AND (w.escalation_user_id <> 0 OR w.escalation_role_id <> 0)
+        ) slots
+        LEFT JOIN users ON users.id = slots.user_id
+        LEFT JOIN roles ON roles.id = slots.role_id
+        ORDER BY slots.approval_id, slots.escalation, slots.role_id, slots.user_id
+    `, sql.Named("ids", approvalIDs)).Scan(&slots).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return slots, nil
+}
+
+// inbox - запрос Approvals раздела status списка согласований пользователя userID
+// с фильтрами filter по дате создания, процедуре, файлам и комплектам
+func (r *ApprovalRepository) inbox(ctx context.Context, userID uint, filter domain.ApprovalFilter, status string) *gorm.DB {
+	query := r.db.WithContext(ctx).
+		Table("approvals").
+		Joins("LEFT JOIN transmittals ON transmittals.id = approvals.transmittal_id").
+		Where("approvals.deleted_at IS NULL").
+		Where(inboxConditions[status], sql.Named("user_id", userID))
+
+	if !filter.From.IsZero() {
+		query = query.Where("approvals.created_at >= ?", filter.From)
+	}
+	if !filter.To.IsZero() {
+		query = query.Where("approvals.created_at < ?", filter.To)
+	}
+	if filter.WorkflowID != 0 {
+		query = query.Where("approvals.workflow_id = ?", filter.WorkflowID)
+	}
+
+	if filter.DirectoryID == 0 && filter.Search == "" {
+		return query
+	}
+
+	// Approval комплекта подходит, если подходит хотя бы один файл комплекта
+	files := r.db.Where("approvals.transmittal_id = 0 AND approvals.file_id IN ?", filter.FileIDs).
+		Or(`approvals.transmittal_id <> 0 AND EXISTS (
+            SELECT 1 FROM transmittal_files tf
+            WHERE tf.transmittal_id = approvals.transmittal_id AND tf.file_id IN ?)`, filter.FileIDs)
+	if filter.DirectoryID == 0 {
+		// Символы шаблона LIKE в запросе ищутся как обычные символы
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(filter.Search)
+		pattern := "%" + escaped + "%"
+		files = files.Or("transmittals.number ILIKE ? OR transmittals.title ILIKE ?", pattern, pattern)
+	}
+
+	return query.Where(files)
+}
+
+// GetApprovalStage возвращает этап order из копии процедуры согласования Approval
+// Кастомные ошибки: ErrWorkflowNotFound
+func (r *ApprovalRepository) GetApprovalStage(ctx context.Context, approvalID uint, order int) (domain.WorkflowStage, error) {
+	const op = "infrastructure.postgresrepo.approval.GetApprovalStage"
+
+	var stages []domain.ApprovalStage
+	err := r.db.WithContext(ctx).
+		Where("approval_id = ? AND workflow_order = ?", approvalID, order).
+		Order("id ASC").
+		Find(&stages).Error
+
+	if err != nil {
+		return domain.WorkflowStage{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(stages) == 0 {
+		return domain.WorkflowStage{}, fmt.Errorf("%s: %w", op, domain.ErrWorkflowNotFound)
+	}
+
+	return groupStages(workflowRows(stages))[0], nil
+}
+
+// CheckUserPermission проверяет, имеет ли пользователь право подписывать Approval (лично, через роль
+// или по активному замещению)
+// Кастомные ошибки: ErrApprovalNotFound
+func (r *ApprovalRepository) CheckUserPermission(ctx context.Context, approvalID, userID uint) (*domain.Approval, error) {
+	const op = "infrastructure.postgresrepo.approval.CheckUserPermission"
+
+	var approval domain.Approval
+
+	err := r.db.WithContext(ctx).
+		Where("approvals.id = ?", approvalID).
+		Where("approvals.status = ?", "on approval").
+		Where(stageMemberCondition, sql.Named("user_id", userID)).
+		First(&approval).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.Approval{}, fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &approval, nil
+}
+
+// IsLastUserInWorkflow - проверка на то, является ли пользователь (лично, через роль или по активному
+// замещению) крайним в цепочке согласования
+func (r *ApprovalRepository) IsLastUserInWorkflow(ctx context.Context, approvalID, userID uint) (*domain.Approval, error) {
+	const op = "infrastructure.postgresrepo.approval.IsLastUserInWorkflow"
+
+	var approval domain.Approval
+
+	err := r.db.WithContext(ctx).
+		Where("approvals.id = ?", approvalID).
+		Where(stageMemberCondition, sql.Named("user_id", userID)).
+		Where(`approvals.workflow_order = (SELECT MAX(st.workflow_order) FROM approval_stages st
+            WHERE st.approval_id = approvals.id)`).
+		First(&approval).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.Approval{}, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &approval, nil
+}
+
+// SignStage фиксирует подпись пользователя на этапе order и возвращает true, если эта подпись
+// набрала необходимое количество подписей required. Если пользователь указан в этапе лично,
+// подпись занимает его личное место, иначе - место его роли. Подпись резервного подписанта
+// эскалированного этапа завершает этап. Если этап не последний, Approval в той же транзакции
+// переводится на следующий этап, если последний - получает статус approved, а новый статус файла
+// добавляется в outbox. Решение в той же транзакции подписывается функцией seal
+// и добавляется в цепочку подписей Approval
+// Кастомные ошибки: ErrApprovalNotFound, ErrAlreadySigned, ErrRoleSlotSigned
+func (r *ApprovalRepository) SignStage(
+	ctx context.Context,
+	approvalID, userID uint,
+	order, required int,
+	isLastStage bool,
+	seal domain.SealFunc,
+) (bool, error) {
+	const op = "infrastructure.postgresrepo.approval.SignStage"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Блокируем Approval, чтобы параллельные подписи не перевели этап дважды
+	var approval domain.Approval
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ? AND workflow_order = ?", approvalID, "on approval", order).
+		First(&approval).Error
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Пользователь подписывает сначала за себя, затем за тех, кого он замещает
+	var identities []domain.User
+	err = tx.Table("users u").
+		Where(actingUserMatch, sql.Named("user_id", userID)).
+		Order("u.id ASC").
+		Find(&identities).Error
+	if err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	sort.SliceStable(identities, func(i, j int) bool {
+		return identities[i].ID == userID && identities[j].ID != userID
+	})
+
+	var stages []domain.ApprovalStage
+	if err := tx.Where("approval_id = ? AND workflow_order = ?", approvalID, order).
+		Find(&stages).Error; err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+	rows := workflowRows(stages)
+
+	var (
+		signer   *domain.User
+		roleID   uint
+		fallback bool
+		slotErr  = domain.ErrApprovalNotFound
+	)
+	for i := range identities {
+		identityRoleID, identityFallback, ok := stageSlot(rows, identities[i], approval.EscalatedAt != nil)
+		if !ok {
+			continue
+		}
+
+		err := checkSlotFree(tx, approvalID, order, identities[i].ID, identityRoleID)
+		if errors.Is(err, domain.ErrAlreadySigned) || errors.Is(err, domain.ErrRoleSlotSigned) {
+			slotErr = err
+			continue
+		}
+		if err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+
+		signer, roleID, fallback = &identities[i], identityRoleID, identityFallback
+		break
+	}
+	if signer == nil {
+		tx.Rollback()
+		return false, fmt.Errorf("%s: %w", op, slotErr)
+	}
+
+	// Если пользователь подписывает за замещаемого, место и подпись принадлежат замещаемому
+	var delegateID uint
+	if signer.ID != userID {
+		delegateID = userID
+	}
+
+	signature := domain.ApprovalSignature{
+		ApprovalID:    approvalID,
+		WorkflowOrder: order,
+		UserID:        signer.ID,
+		RoleID:        roleID,
+		DelegateID:    delegateID,
+	}
+	if err := tx.Create(&signature).Error; err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	action := domain.ApprovalActionSign
+	if isLastStage {
+		action = domain.ApprovalActionFinalize
+	}
+	event := newEvent(&approval, action, userID, "")
+	if delegateID != 0 {
+		event.OnBehalfOfID = signer.ID
+	}
+	if err := tx.Create(&event).Error; err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := sealDecision(tx, &approval, &event, seal); err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var count int64
+	if err := tx.Model(&domain.ApprovalSignature{}).
+		Where("approval_id = ? AND workflow_order = ?", approvalID, order).
+		Count(&count).Error; err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Подпись резервного подписанта завершает эскалированный этап независимо от правила этапа
+	completed := count == int64(required) || fallback
+	if completed && !isLastStage {
+		if err := tx.Model(&domain.Approval{}).
+			Where("id = ?", approvalID).
+			Updates(map[string]interface{}{
+				"workflow_order":   gorm.Expr("workflow_order + 1"),
+				"stage_started_at": time.Now(),
+				"reminded_at":      nil,
+				"escalated_at":     nil,
+			}).Error; err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if completed && isLastStage {
+		approval.Status = "approved"
+		if err := tx.Save(&approval).Error; err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := enqueueFileStatus(tx, &approval); err != nil {
+			tx.Rollback()
+			return false, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return completed, nil
+}
+
+// AnnotateApproval обновляет Approval, записывает замечание пользователя в журнал, открывает
+// по нему обсуждение и добавляет в outbox новый статус файла
+// Кастомные ошибки: ErrApprovalNotFound
+func (r *ApprovalRepository) AnnotateApproval(ctx context.Context, approvalID, userID uint, message string) error {
+	const op = "infrastructure.postgresrepo.approval.AnnotateApproval"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var approval domain.Approval
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", approvalID, "on approval").
+		First(&approval).Error
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Событие пишется до смены статуса, чтобы в журнал попал этап, на котором сделано замечание
+	if err := createEvent(tx, &approval, domain.ApprovalActionAnnotate, userID, message); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	approval.Status = "annotated"
+	approval.AnnotationText = message
+	if err := tx.Save(&approval).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	comment := domain.ApprovalComment{
+		ApprovalID:  approval.ID,
+		FileID:      approval.FileID,
+		FileVersion: approval.FileVersion,
+		AuthorID:    userID,
+		Text:        message,
+	}
+	if err := tx.Create(&comment).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := enqueueFileStatus(tx, &approval); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RejectApproval окончательно отклоняет Approval, записывает причину отказа в журнал
+// и добавляет в outbox новый статус файла
+// Кастомные ошибки: ErrApprovalNotFound
+func (r *ApprovalRepository) RejectApproval(ctx context.Context, approvalID, userID uint, message string) error {
+	const op = "infrastructure.postgresrepo.approval.RejectApproval"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var approval domain.Approval
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", approvalID, "on approval").
+		First(&approval).Error
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := createEvent(tx, &approval, domain.ApprovalActionReject, userID, message); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	approval.Status = "rejected"
+	approval.AnnotationText = message
+	if err := tx.Save(&approval).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := enqueueFileStatus(tx, &approval); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// WithdrawApproval отзывает незавершённое Approval (на согласовании или ожидающее исправлений)
+// и записывает отзыв в журнал. Новый статус файла добавляется в outbox
+// Кастомные ошибки: ErrApprovalNotFound
+func (r *ApprovalRepository) WithdrawApproval(ctx context.Context, approvalID, userID uint) error {
+	const op = "infrastructure.postgresrepo.approval.WithdrawApproval"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var approval domain.Approval
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status IN ?", approvalID, []string{"on approval", "annotated"}).
+		First(&approval).Error
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := createEvent(tx, &approval, domain.ApprovalActionWithdraw, userID, ""); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	approval.Status = "withdrawn"
+	if err := tx.Save(&approval).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := enqueueFileStatus(tx, &approval); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// FindAnnotatedApproval возвращает последнее Approval файла, по которому были запрошены изменения.
+// Если такого нет, возвращает пустой Approval
+func (r *ApprovalRepository) FindAnnotatedApproval(ctx context.Context, fileID uint) (*domain.Approval, error) {
+	const op = "infrastructure.postgresrepo.approval.FindAnnotatedApproval"
+
+	var approval domain.Approval
+	err := r.db.WithContext(ctx).
+		Where("file_id = ? AND status = ?", fileID, "annotated").
+		Order("id DESC").
+		First(&approval).Error
+
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return &domain.Approval{}, nil
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &approval, nil
+}
+
+// ResumeApproval возвращает Approval на согласование с того этапа, на котором были запрошены изменения.
+// Подписи этого этапа сбрасываются: этап заново подписывается по новой версии файла.
+// Подписи предыдущих этапов и журнал сохраняются. Новый статус файла добавляется в outbox
+// Кастомные ошибки: ErrApprovalNotFound
+func (r *ApprovalRepository) ResumeApproval(ctx context.Context, approvalID, userID uint, fileVersion int) error {
+	const op = "infrastructure.postgresrepo.approval.ResumeApproval"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := resumeApproval(tx, approvalID, userID, fileVersion); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// resumeApproval возвращает Approval в статусе "annotated" на согласование в рамках транзакции tx
+func resumeApproval(tx *gorm.DB, approvalID, userID uint, fileVersion int) error {
+	var approval domain.Approval
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ?", approvalID, "annotated").
+		First(&approval).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrApprovalNotFound
+		}
+		return err
+	}
+
+	if err := tx.Where("approval_id = ? AND workflow_order = ?", approvalID, approval.WorkflowOrder).
+		Delete(&domain.ApprovalSignature{}).Error; err != nil {
+		return err
+	}
+
+	approval.Status = "on approval"
+	approval.FileVersion = fileVersion
+	approval.StageStartedAt = time.Now()
+	approval.RemindedAt = nil
+	approval.EscalatedAt = nil
+	if err := tx.Save(&approval).Error; err != nil {
+		return err
+	}
+
+	if err := enqueueFileStatus(tx, &approval); err != nil {
+		return err
+	}
+
+	return createEvent(tx, &approval, domain.ApprovalActionResubmit, userID, "")
+}
+
+// GetApprovalHistory возвращает журнал Approval в хронологическом порядке
+func (r *ApprovalRepository) GetApprovalHistory(ctx context.Context, approvalID uint) ([]domain.ApprovalEventResponse, error) {
+	const op = "infrastructure.postgresrepo.approval.GetApprovalHistory"
+
+	events, err := r.history(ctx, "approval_events.approval_id = ?", approvalID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+// GetApprovalsHistory возвращает журналы Approvals approvalIDs в хронологическом порядке
+func (r *ApprovalRepository) GetApprovalsHistory(ctx context.Context, approvalIDs []uint) ([]domain.ApprovalEventResponse, error) {
+	const op = "infrastructure.postgresrepo.approval.GetApprovalsHistory"
+
+	if len(approvalIDs) == 0 {
+		return nil, nil
+	}
+
+	events, err := r.history(ctx, "approval_events.approval_id IN ?", approvalIDs)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+// GetFileHistory возвращает журнал всех Approvals файла в хронологическом порядке
+func (r *ApprovalRepository) GetFileHistory(ctx context.Context, fileID uint) ([]domain.ApprovalEventResponse, error) {
+	const op = "infrastructure.postgresrepo.approval.GetFileHistory"
+
+	events, err := r.history(ctx, `approval_events.file_id = ? OR approval_events.approval_id IN (
+            SELECT a.id FROM approvals a
+            JOIN transmittal_files tf ON tf.transmittal_id = a.transmittal_id
+            WHERE a.transmittal_id <> 0 AND tf.file_id = ?)`, fileID, fileID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return events, nil
+}
+
+// CheckApprovalParticipant проверяет, участвует ли пользователь хотя бы в одном из Approvals
+func (r *ApprovalRepository) CheckApprovalParticipant(ctx context.Context, userID uint, approvalIDs []uint) (bool, error) {
+	const op = "infrastructure.postgresrepo.approval.CheckApprovalParticipant"
+
+	if len(approvalIDs) == 0 {
+		return false, nil
+	}
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.Approval{}).
+		Where("approvals.id IN (?)", approvalIDs).
+		Where(approvalParticipantCondition, sql.Named("user_id", userID)).
+		Count(&count).Error
+
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count > 0, nil
+}
+
+// FindOverdueApprovals возвращает Approvals на согласовании, срок текущего этапа которых истёк к моменту now
+func (r *ApprovalRepository) FindOverdueApprovals(ctx context.Context, now time.Time) ([]domain.OverdueApproval, error) {
+	const op = "infrastructure.postgresrepo.approval.FindOverdueApprovals"
+
+	var approvals []domain.OverdueApproval
+
+	err := r.db.WithContext(ctx).
+		Table("approvals").
+		Select(`
+            approvals.id,
+            approvals.file_id,
+            approvals.workflow_order,
+            approvals.reminded_at,
+            approvals.escalated_at,
+            stage.due_at,
+            stage.escalation_user_id,
+            stage.escalation_role_id
+        `).
+		Joins(`JOIN LATERAL (
+            SELECT approvals.stage_started_at + MAX(w.stage_due_hours) * INTERVAL '1 hour' AS due_at,
+                MAX(w.escalation_user_id) AS escalation_user_id,
+                MAX(w.escalation_role_id) AS escalation_role_id
+            FROM approval_stages w
+            WHERE w.approval_id = approvals.id AND w.workflow_order = approvals.workflow_order
+                AND w.stage_due_hours > 0
+            HAVING COUNT(*) > 0
+        ) stage ON TRUE`).
+		Where("approvals.status = ?", "on approval").
+		Where("approvals.deleted_at IS NULL").
+		Where("stage.due_at < ?", now).
+		Scan(&approvals).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return approvals, nil
+}
+
+// FindPendingSigners возвращает ID пользователей, которые могут подписать текущий этап Approval,
+// но ещё не подписали его (с учётом ролей и резервного подписанта эскалированного этапа)
+func (r *ApprovalRepository) FindPendingSigners(ctx context.Context, approvalID uint) ([]uint, error) {
+	const op = "infrastructure.postgresrepo.approval.FindPendingSigners"
+
+	var userIDs []uint
+
+	err := r.db.WithContext(ctx).
+		Table("users u").
+		Joins("JOIN approvals ON approvals.id = ?", approvalID).
+		Where("u.deleted_at IS NULL").
+		Where(`EXISTS (
+            SELECT 1 FROM approval_stages w
+            WHERE w.approval_id = approvals.id
+                AND w.workflow_order = approvals.workflow_order
+                AND (w.user_id = u.id OR (w.role_id <> 0 AND w.role_id = u.role_id AND NOT EXISTS (
+                    SELECT 1 FROM approval_signatures s
+                    WHERE s.approval_id = approvals.id AND s.workflow_order = approvals.workflow_order
+                        AND s.role_id = w.role_id AND s.deleted_at IS NULL))
+                    OR `+stageEscalationMatch+`)
+        )`).
+		Where(`NOT EXISTS (SELECT 1 FROM approval_signatures s
+            WHERE s.approval_id = approvals.id AND s.workflow_order = approvals.workflow_order AND s.user_id = u.id AND s.deleted_at IS NULL)`).
+		Order("u.id").
+		Pluck("u.id", &userIDs).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userIDs, nil
+}
+
+// MarkReminded фиксирует время напоминания по этапу order. Если Approval уже ушло с этого этапа,
+// ничего не меняется
+func (r *ApprovalRepository) MarkReminded(ctx context.Context, approvalID uint, order int, at time.Time) error {
+	const op = "infrastructure.postgresrepo.approval.MarkReminded"
+
+	err := r.db.WithContext(ctx).
+		Model(&domain.Approval{}).
+		Where("id = ? AND status = ? AND workflow_order = ?", approvalID, "on approval", order).
+		Update("reminded_at", at).Error
+
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// EscalateApproval передает этап order резервному подписанту и записывает эскалацию в журнал
+// от имени системы (actor_id = 0). Возвращает false, если Approval уже ушло с этого этапа
+// или уже эскалировано
+func (r *ApprovalRepository) EscalateApproval(ctx context.Context, approvalID uint, order int, at time.Time) (bool, error) {
+	const op = "infrastructure.postgresrepo.approval.EscalateApproval"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var approval domain.Approval
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ? AND status = ? AND workflow_order = ? AND escalated_at IS NULL", approvalID, "on approval", order).
+		First(&approval).Error
+	if err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, nil
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Model(&approval).Update("escalated_at", at).Error; err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := createEvent(tx, &approval, domain.ApprovalActionEscalate, 0, ""); err != nil {
+		tx.Rollback()
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return true, nil
+}
+
+// FindLatestApprovals возвращает последнее Approval каждого файла. Approval комплекта
+// возвращается для каждого файла комплекта с FileID этого файла
+func (r *ApprovalRepository) FindLatestApprovals(ctx context.Context) ([]domain.Approval, error) {
+	const op = "infrastructure.postgresrepo.approval.FindLatestApprovals"
+
+	var approvals []domain.Approval
+	err := r.db.WithContext(ctx).
+		Raw(`
+            SELECT DISTINCT ON (m.file_id) approvals.id, m.file_id, approvals.status, approvals.transmittal_id
+            FROM approvals
+            JOIN LATERAL (
+                SELECT approvals.file_id WHERE approvals.transmittal_id = 0
+                UNION ALL
+                SELECT tf.file_id FROM transmittal_files tf WHERE tf.transmittal_id = approvals.transmittal_id
+            ) m ON TRUE
+            WHERE approvals.deleted_at IS NULL
+            ORDER BY m.file_id, approvals.id DESC
+        `).
+		Scan(&approvals).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return approvals, nil
+}
+
+func (r *ApprovalRepository) history(ctx context.Context, query string, args ...interface{}) ([]domain.ApprovalEventResponse, error) {
+	var events []domain.ApprovalEventResponse
+
+	// Пользователь мог быть удален, поэтому LEFT JOIN без фильтра по deleted_at
+	err := r.db.WithContext(ctx).
+		Table("approval_events").
+		Select(`
+            approval_events.id,
+            approval_events.approval_id,
+            approval_events.file_id,
+            approval_events.action,
+            approval_events.actor_id,
+            COALESCE(users.login, '') AS actor_login,
+            approval_events.on_behalf_of_id,
+            COALESCE(principals.login, '') AS on_behalf_of,
+            approval_events.workflow_order,
+            approval_events.file_version,
+            approval_events.comment,
+            approval_events.created_at
+        `).
+		Joins("LEFT JOIN users ON users.id = approval_events.actor_id").
+		Joins("LEFT JOIN users principals ON principals.id = approval_events.on_behalf_of_id").
+		Where(query, args...).
+		Order("approval_events.created_at ASC, approval_events.id ASC").
+		Scan(&events).Error
+
+	if err != nil {
+		return nil, err
+	}
+
+	return events, nil
+}
+
+// CountBlockingComments возвращает количество неразрешенных обсуждений Approval, если процедура
+// согласования требует разрешить их до завершения, иначе 0
+func (r *ApprovalRepository) CountBlockingComments(ctx context.Context, approvalID uint) (int64, error) {
+	const op = "infrastructure.postgresrepo.approval.CountBlockingComments"
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.ApprovalComment{}).
+		Where("approval_id = ? AND parent_id = 0 AND resolved_at IS NULL", approvalID).
+		Where(`EXISTS (
+            SELECT 1 FROM approval_stages s
+            WHERE s.approval_id = approval_comments.approval_id AND s.require_resolved_comments
+        )`).
+		Count(&count).Error
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count, nil
+}
+
+// GetApprovalContents возвращает файлы и версии, которые согласуются Approval: файл Approval
+// или файлы комплекта в отправленных версиях. Хэши содержимого не заполняются
+func (r *ApprovalRepository) GetApprovalContents(ctx context.Context, approval *domain.Approval) ([]domain.FileContentHash, error) {
+	const op = "infrastructure.postgresrepo.approval.GetApprovalContents"
+
+	if approval.TransmittalID == 0 {
+		return []domain.FileContentHash{{FileID: approval.FileID, Version: approval.FileVersion}}, nil
+	}
+
+	var contents []domain.FileContentHash
+	if err := r.db.WithContext(ctx).
+		Model(&domain.TransmittalFile{}).
+		Select("file_id, file_version AS version").
+		Where("transmittal_id = ?", approval.TransmittalID).
+		Order("id ASC").
+		Scan(&contents).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return contents, nil
+}
+
+// GetDecisionSignatures возвращает цепочку подписей решений Approval в порядке подписания
+func (r *ApprovalRepository) GetDecisionSignatures(ctx context.Context, approvalID uint) ([]domain.DecisionSignature, error) {
+	const op = "infrastructure.postgresrepo.approval.GetDecisionSignatures"
+
+	var signatures []domain.DecisionSignature
+	if err := r.db.WithContext(ctx).
+		Where("approval_id = ?", approvalID).
+		Order("id ASC").
+		Find(&signatures).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return signatures, nil
+}
+
+// sealDecision подписывает решение event функцией seal и добавляет подпись в цепочку Approval.
+// Approval должно быть заблокировано в транзакции tx, чтобы цепочка не разветвилась
+func sealDecision(tx *gorm.DB, approval *domain.Approval, event *domain.ApprovalEvent, seal domain.SealFunc) error {
+	var prev []domain.DecisionSignature
+	if err := tx.Where("approval_id = ?", approval.ID).
+		Order("id DESC").
+		Limit(1).
+		Find(&prev).Error; err != nil {
+		return err
+	}
+
+	signature := domain.DecisionSignature{
+		ApprovalID:    approval.ID,
+		EventID:       event.ID,
+		Decision:      event.Action,
+		WorkflowOrder: event.WorkflowOrder,
+		SignerID:      event.ActorID,
+		OnBehalfOfID:  event.OnBehalfOfID,
+		// Postgres хранит время с точностью до микросекунд, подписанное время должно совпасть с сохраненным
+		SignedAt: time.Now().UTC().Truncate(time.Microsecond),
+	}
+	if len(prev) > 0 {
+		signature.PrevHash = prev[0].Hash()
+	}
+
+	if err := seal(approval, &signature); err != nil {
+		return err
+	}
+	return tx.Create(&signature).Error
+}
+
+// createEvent добавляет запись в журнал согласования в рамках транзакции tx
+func createEvent(tx *gorm.DB, approval *domain.Approval, action string, actorID uint, comment string) error {
+	event := newEvent(approval, action, actorID, comment)
+	return tx.Create(&event).Error
+}
+
+func newEvent(approval *domain.Approval, action string, actorID uint, comment string) domain.ApprovalEvent {
+	return domain.ApprovalEvent{
+		ApprovalID:    approval.ID,
+		FileID:        approval.FileID,
+		Action:        action,
+		ActorID:       actorID,
+		WorkflowOrder: approval.WorkflowOrder,
+		FileVersion:   approval.FileVersion,
+		Comment:       comment,
+	}
+}
+
+// checkSlotFree проверяет, что пользователь userID ещё не подписал этап order и что место роли roleID
+// (если подпись за роль) ещё не занято
+func checkSlotFree(tx *gorm.DB, approvalID uint, order int, userID, roleID uint) error {
+	var signed int64
+	if err := tx.Model(&domain.ApprovalSignature{}).
+		Where("approval_id = ? AND workflow_order = ? AND user_id = ?", approvalID, order, userID).
+		Count(&signed).Error; err != nil {
+		return err
+	}
+	if signed > 0 {
+		return domain.ErrAlreadySigned
+	}
+
+	if roleID == 0 {
+		return nil
+	}
+
+	if err := tx.Model(&domain.ApprovalSignature{}).
+		Where("approval_id = ? AND workflow_order = ? AND role_id = ?", approvalID, order, roleID).
+		Count(&signed).Error; err != nil {
+		return err
+	}
+	if signed > 0 {
+		return domain.ErrRoleSlotSigned
+	}
+
+	return nil
+}
+
+// snapshotRows копирует строки процедуры согласования в этапы Approval
+func snapshotRows(approvalID uint, rows []domain.Workflow) []domain.ApprovalStage {
+	stages := make([]domain.ApprovalStage, 0, len(rows))
+	for _, row := range rows {
+		stages = append(stages, domain.ApprovalStage{
+			ApprovalID:    approvalID,
+			WorkflowOrder: row.WorkflowOrder,
+			UserID:        row.UserID,
+			RoleID:        row.RoleID,
+			StageRule:     row.StageRule,
+			StageQuorum:   row.StageQuorum,
+
+			StageDueHours:    row.StageDueHours,
+			EscalationUserID: row.EscalationUserID,
+			EscalationRoleID: row.EscalationRoleID,
+
+			RequireResolvedComments: row.RequireResolvedComments,
+		})
+	}
+	return stages
+}
+
+// skipStageRows убирает из строк процедуры этапы skipOrders и нумерует оставшиеся этапы заново с 1
+func skipStageRows(rows []domain.Workflow, skipOrders []int) []domain.Workflow {
+	if len(skipOrders) == 0 {
+		return rows
+	}
+
+	skip := make(map[int]struct{}, len(skipOrders))
+	for _, order := range skipOrders {
+		skip[order] = struct{}{}
+	}
+
+	kept := make([]domain.Workflow, 0, len(rows))
+	newOrder, prevOrder := 0, 0
+	for _, row := range rows {
+		if _, ok := skip[row.WorkflowOrder]; ok {
+			continue
+		}
+		if row.WorkflowOrder != prevOrder {
+			prevOrder = row.WorkflowOrder
+			newOrder++
+		}
+		row.WorkflowOrder = newOrder
+		kept = append(kept, row)
+	}
+	return kept
+}
+
+// workflowRows преобразует этапы Approval обратно в строки процедуры согласования
+func workflowRows(stages []domain.ApprovalStage) []domain.Workflow {
+	rows := make([]domain.Workflow, 0, len(stages))
+	for _, stage := range stages {
+		rows = append(rows, domain.Workflow{
+			WorkflowOrder: stage.WorkflowOrder,
+			UserID:        stage.UserID,
+			RoleID:        stage.RoleID,
+			StageRule:     stage.StageRule,
+			StageQuorum:   stage.StageQuorum,
+
+			StageDueHours:    stage.StageDueHours,
+			EscalationUserID: stage.EscalationUserID,
+			EscalationRoleID: stage.EscalationRoleID,
+
+			RequireResolvedComments: stage.RequireResolvedComments,
+		})
+	}
+	return rows
+}
+
+// stageSlot определяет место пользователя на этапе по строкам этапа rows: личное место (roleID = 0)
+// имеет приоритет над местом роли. Если ни того, ни другого нет, а этап эскалирован, пользователь
+// может подписать этап как резервный подписант (fallback = true)
+func stageSlot(rows []domain.Workflow, user domain.User, escalated bool) (roleID uint, fallback bool, ok bool) {
+	for _, row := range rows {
+		if row.RoleID == 0 && row.UserID == user.ID {
+			return 0, false, true
+		}
+	}
+
+	for _, row := range rows {
+		if row.RoleID != 0 && row.RoleID == user.RoleID {
+			return row.RoleID, false, true
+		}
+	}
+
+	if escalated && len(rows) > 0 {
+		row := rows[0]
+		if row.EscalationUserID == user.ID || (row.EscalationRoleID != 0 && row.EscalationRoleID == user.RoleID) {
+			return 0, true, true
+		}
+	}
+
+	return 0, false, false
+}
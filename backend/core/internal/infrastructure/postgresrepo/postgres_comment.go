@@ -0,0 +1,111 @@
+package postgresrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+type CommentRepository struct {
+	db *gorm.DB
+}
+
+func NewCommentRepository(db *Database) *CommentRepository {
+	return &CommentRepository{db: db.db}
+}
+
+func (commentRepo *CommentRepository) CreateComment(ctx context.Context, comment *domain.ApprovalComment) error {
+	const op = "infrastructure.postgresrepo.comment.CreateComment"
+
+	if err := commentRepo.db.WithContext(ctx).Create(comment).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetCommentByID возвращает комментарий по ID
+// Кастомные ошибки: ErrCommentNotFound
+func (commentRepo *CommentRepository) GetCommentByID(ctx context.Context, commentID uint) (*domain.ApprovalComment, error) {
+	const op = "infrastructure.postgresrepo.comment.GetCommentByID"
+
+	var comment domain.ApprovalComment
+	if err := commentRepo.db.WithContext(ctx).First(&comment, commentID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrCommentNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &comment, nil
+}
+
+// GetComments возвращает комментарии Approval в порядке добавления. Если fileVersion не 0,
+// возвращаются только обсуждения этой версии
+func (commentRepo *CommentRepository) GetComments(ctx context.Context, approvalID uint, fileVersion int) ([]domain.CommentResponse, error) {
+	const op = "infrastructure.postgresrepo.comment.GetComments"
+
+	query := commentRepo.db.WithContext(ctx).
+		Table("approval_comments").
+		Select(`
+            approval_comments.id,
+            approval_comments.approval_id,
+            approval_comments.parent_id,
+            approval_comments.file_id,
+            approval_comments.file_version,
+            approval_comments.author_id,
+            COALESCE(users.login, '') AS author_login,
+            approval_comments.text,
+            approval_comments.mentions,
+            approval_comments.resolved_at IS NOT NULL AS resolved,
+            approval_comments.resolved_by,
+            approval_comments.resolved_at,
+            approval_comments.created_at
+        `).
+		Joins("LEFT JOIN users ON users.id = approval_comments.author_id").
+		Where("approval_comments.approval_id = ?", approvalID).
+		Where("approval_comments.deleted_at IS NULL")
+	if fileVersion != 0 {
+		query = query.Where("approval_comments.file_version = ?", fileVersion)
+	}
+
+	var comments []domain.CommentResponse
+	if err := query.Order("approval_comments.id ASC").Scan(&comments).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return comments, nil
+}
+
+// SetCommentResolved разрешает обсуждение commentID от имени пользователя userID или, если
+// resolved = false, открывает его снова
+// Кастомные ошибки: ErrCommentNotFound
+func (commentRepo *CommentRepository) SetCommentResolved(ctx context.Context, commentID, userID uint, resolved bool) error {
+	const op = "infrastructure.postgresrepo.comment.SetCommentResolved"
+
+	updates := map[string]interface{}{
+		"resolved_by": uint(0),
+		"resolved_at": nil,
+	}
+	if resolved {
+		updates["resolved_by"] = userID
+		updates["resolved_at"] = time.Now()
+	}
+
+	result := commentRepo.db.WithContext(ctx).
+		Model(&domain.ApprovalComment{}).
+		Where("id = ? AND parent_id = 0", commentID).
+		Updates(updates)
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", op, result.Error)
+	}
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, domain.ErrCommentNotFound)
+	}
+
+	return nil
+}
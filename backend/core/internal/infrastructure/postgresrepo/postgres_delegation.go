@@ -0,0 +1,136 @@
+package postgresrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DelegationRepository struct {
+	db *gorm.DB
+}
+
+func NewDelegationRepository(db *Database) *DelegationRepository {
+	return &DelegationRepository{db: db.db}
+}
+
+// CreateDelegation сохраняет замещение, если у замещаемого нет действующего или будущего замещения,
+// пересекающегося с ним по периоду. Строка замещаемого блокируется, чтобы одновременные запросы
+// не создали два пересекающихся замещения
+// Кастомные ошибки: ErrUserNotFound, ErrDelegationOverlap
+func (delegationRepo *DelegationRepository) CreateDelegation(ctx context.Context, delegation *domain.Delegation) error {
+	const op = "infrastructure.postgresrepo.delegation.CreateDelegation"
+
+	tx := delegationRepo.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	var delegator domain.User
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&delegator, delegation.DelegatorID).Error; err != nil {
+		tx.Rollback()
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return fmt.Errorf("%s: %w", op, domain.ErrUserNotFound)
+		}
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	var overlapping int64
+	err := tx.Model(&domain.Delegation{}).
+		Where("delegator_id = ?", delegation.DelegatorID).
+		Where("starts_at < ? AND ends_at > ?", delegation.EndsAt, delegation.StartsAt).
+		Where("ends_at > NOW()").
+		Count(&overlapping).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if overlapping > 0 {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrDelegationOverlap)
+	}
+
+	if err := tx.Create(delegation).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetDelegationByID возвращает замещение по ID
+// Кастомные ошибки: ErrDelegationNotFound
+func (delegationRepo *DelegationRepository) GetDelegationByID(ctx context.Context, delegationID uint) (*domain.Delegation, error) {
+	const op = "infrastructure.postgresrepo.delegation.GetDelegationByID"
+
+	var delegation domain.Delegation
+	if err := delegationRepo.db.WithContext(ctx).First(&delegation, delegationID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrDelegationNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &delegation, nil
+}
+
+// GetDelegations возвращает замещения, в которых пользователь userID замещаемый или заместитель.
+// Если userID = 0, возвращаются все замещения
+func (delegationRepo *DelegationRepository) GetDelegations(ctx context.Context, userID uint) ([]domain.DelegationResponse, error) {
+	const op = "infrastructure.postgresrepo.delegation.GetDelegations"
+
+	var delegations []domain.DelegationResponse
+
+	query := delegationRepo.db.WithContext(ctx).
+		Table("delegations").
+		Select(`
+            delegations.id,
+            delegations.delegator_id,
+            COALESCE(delegators.login, '') AS delegator_login,
+            delegations.delegate_id,
+            COALESCE(delegates.login, '') AS delegate_login,
+            delegations.starts_at,
+            delegations.ends_at,
+            delegations.starts_at <= NOW() AND delegations.ends_at > NOW() AS active
+        `).
+		Joins("LEFT JOIN users delegators ON delegators.id = delegations.delegator_id").
+		Joins("LEFT JOIN users delegates ON delegates.id = delegations.delegate_id").
+		Where("delegations.deleted_at IS NULL")
+
+	if userID != 0 {
+		query = query.Where("delegations.delegator_id = ? OR delegations.delegate_id = ?", userID, userID)
+	}
+
+	if err := query.Order("delegations.starts_at DESC").Scan(&delegations).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return delegations, nil
+}
+
+// DeleteDelegation отменяет замещение
+// Кастомные ошибки: ErrDelegationNotFound
+func (delegationRepo *DelegationRepository) DeleteDelegation(ctx context.Context, delegationID uint) error {
+	const op = "infrastructure.postgresrepo.delegation.DeleteDelegation"
+
+	result := delegationRepo.db.WithContext(ctx).Delete(&domain.Delegation{}, delegationID)
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", op, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, domain.ErrDelegationNotFound)
+	}
+
+	return nil
+}
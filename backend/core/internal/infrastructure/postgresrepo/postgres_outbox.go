@@ -0,0 +1,200 @@
+package postgresrepo
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+	"fmt"
+	"service-core/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type OutboxRepository struct {
+	db *gorm.DB
+}
+
+func NewOutboxRepository(db *Database) *OutboxRepository {
+	return &OutboxRepository{db: db.db}
+}
+
+// EnqueueFileStatus добавляет в outbox изменение статуса файла вне перехода Approval
+// (используется при сверке статусов)
+func (r *OutboxRepository) EnqueueFileStatus(ctx context.Context, approvalID, fileID uint, fileStatus string) error {
+	const op = "infrastructure.postgresrepo.outbox.EnqueueFileStatus"
+
+	message, err := newOutboxMessage(approvalID, fileID, fileStatus)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := r.db.WithContext(ctx).Create(&message).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ClaimOutboxMessages выбирает до limit сообщений, готовых к доставке, и откладывает их
+// следующую попытку на lease, чтобы параллельные диспетчеры не доставляли их одновременно.
+// Сообщения одного файла доставляются строго по порядку: сообщение не выбирается, пока
+// не доставлены предыдущие сообщения этого файла. Если fileID = 0, выбираются сообщения всех файлов
+func (r *OutboxRepository) ClaimOutboxMessages(ctx context.Context, fileID uint, limit int, lease time.Duration) ([]domain.OutboxMessage, error) {
+	const op = "infrastructure.postgresrepo.outbox.ClaimOutboxMessages"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	now := time.Now()
+
+	query := tx.Clauses(clause.Locking{Strength: "UPDATE", Options: "SKIP LOCKED"}).
+		Where("delivered_at IS NULL AND dead_at IS NULL AND next_attempt_at <= ?", now).
+		Where(`NOT EXISTS (
+            SELECT 1 FROM outbox_messages prev
+            WHERE prev.file_id = outbox_messages.file_id
+              AND prev.id < outbox_messages.id
+              AND prev.delivered_at IS NULL
+              AND prev.dead_at IS NULL
+        )`)
+	if fileID != 0 {
+		query = query.Where("file_id = ?", fileID)
+	}
+
+	var messages []domain.OutboxMessage
+	if err := query.Order("id ASC").Limit(limit).Find(&messages).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(messages) == 0 {
+		tx.Rollback()
+		return messages, nil
+	}
+
+	messageIDs := make([]uint, 0, len(messages))
+	for _, message := range messages {
+		messageIDs = append(messageIDs, message.ID)
+	}
+
+	if err := tx.Model(&domain.OutboxMessage{}).
+		Where("id IN ?", messageIDs).
+		Update("next_attempt_at", now.Add(lease)).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return messages, nil
+}
+
+func (r *OutboxRepository) MarkOutboxDelivered(ctx context.Context, messageID uint, at time.Time) error {
+	const op = "infrastructure.postgresrepo.outbox.MarkOutboxDelivered"
+
+	err := r.db.WithContext(ctx).
+		Model(&domain.OutboxMessage{}).
+		Where("id = ?", messageID).
+		Updates(map[string]interface{}{
+			"delivered_at": at,
+			"attempts":     gorm.Expr("attempts + 1"),
+			"last_error":   "",
+		}).Error
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// MarkOutboxFailed фиксирует неудачную попытку доставки. Если dead = true, доставка прекращается
+func (r *OutboxRepository) MarkOutboxFailed(ctx context.Context, messageID uint, nextAttemptAt time.Time, dead bool, lastError string) error {
+	const op = "infrastructure.postgresrepo.outbox.MarkOutboxFailed"
+
+	updates := map[string]interface{}{
+		"attempts":        gorm.Expr("attempts + 1"),
+		"next_attempt_at": nextAttemptAt,
+		"last_error":      lastError,
+	}
+	if dead {
+		updates["dead_at"] = time.Now()
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&domain.OutboxMessage{}).
+		Where("id = ?", messageID).
+		Updates(updates).Error
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetPendingFileIDs возвращает файлы, у которых есть недоставленные изменения статуса
+func (r *OutboxRepository) GetPendingFileIDs(ctx context.Context) ([]uint, error) {
+	const op = "infrastructure.postgresrepo.outbox.GetPendingFileIDs"
+
+	var fileIDs []uint
+	err := r.db.WithContext(ctx).
+		Model(&domain.OutboxMessage{}).
+		Where("delivered_at IS NULL AND dead_at IS NULL").
+		Distinct().
+		Pluck("file_id", &fileIDs).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fileIDs, nil
+}
+
+// enqueueFileStatus добавляет в outbox статус файла, соответствующий текущему статусу Approval.
+// Для Approval комплекта статус добавляется каждому файлу комплекта.
+// Вызывается в транзакции перехода Approval
+func enqueueFileStatus(tx *gorm.DB, approval *domain.Approval) error {
+	fileIDs := []uint{approval.FileID}
+	if approval.TransmittalID != 0 {
+		fileIDs = nil
+		if err := tx.Model(&domain.TransmittalFile{}).
+			Where("transmittal_id = ?", approval.TransmittalID).
+			Order("id ASC").
+			Pluck("file_id", &fileIDs).Error; err != nil {
+			return err
+		}
+	}
+
+	fileStatus := domain.FileStatusForApproval(approval.Status)
+	for _, fileID := range fileIDs {
+		message, err := newOutboxMessage(approval.ID, fileID, fileStatus)
+		if err != nil {
+			return err
+		}
+		if err := tx.Create(&message).Error; err != nil {
+			return err
+		}
+	}
+
+	return nil
+}
+
+func newOutboxMessage(approvalID, fileID uint, fileStatus string) (domain.OutboxMessage, error) {
+	key := make([]byte, 16)
+	if _, err := rand.Read(key); err != nil {
+		return domain.OutboxMessage{}, err
+	}
+
+	return domain.OutboxMessage{
+		IdempotencyKey: hex.EncodeToString(key),
+		ApprovalID:     approvalID,
+		FileID:         fileID,
+		FileStatus:     fileStatus,
+		NextAttemptAt:  time.Now(),
+	}, nil
+}
@@ -0,0 +1,101 @@
+package postgresrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+
+	"gorm.io/gorm"
+)
+
+type RoutingRuleRepository struct {
+	db *gorm.DB
+}
+
+func NewRoutingRuleRepository(db *Database) *RoutingRuleRepository {
+	return &RoutingRuleRepository{db: db.db}
+}
+
+// GetRoutingRules возвращает правила маршрутизации в порядке их проверки
+func (routingRepo *RoutingRuleRepository) GetRoutingRules(ctx context.Context) ([]domain.RoutingRule, error) {
+	const op = "infrastructure.postgresrepo.routing.GetRoutingRules"
+
+	var rules []domain.RoutingRule
+	if err := routingRepo.db.WithContext(ctx).
+		Order("priority ASC, id ASC").
+		Find(&rules).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return rules, nil
+}
+
+// GetRoutingRuleByID возвращает правило маршрутизации по ID
+// Кастомные ошибки: ErrRoutingRuleNotFound
+func (routingRepo *RoutingRuleRepository) GetRoutingRuleByID(ctx context.Context, ruleID uint) (*domain.RoutingRule, error) {
+	const op = "infrastructure.postgresrepo.routing.GetRoutingRuleByID"
+
+	var rule domain.RoutingRule
+	if err := routingRepo.db.WithContext(ctx).First(&rule, ruleID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrRoutingRuleNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &rule, nil
+}
+
+func (routingRepo *RoutingRuleRepository) CreateRoutingRule(ctx context.Context, rule *domain.RoutingRule) error {
+	const op = "infrastructure.postgresrepo.routing.CreateRoutingRule"
+
+	if err := routingRepo.db.WithContext(ctx).Create(rule).Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateRoutingRule перезаписывает условия и действия правила rule.ID, включая нулевые значения
+// Кастомные ошибки: ErrRoutingRuleNotFound
+func (routingRepo *RoutingRuleRepository) UpdateRoutingRule(ctx context.Context, rule *domain.RoutingRule) error {
+	const op = "infrastructure.postgresrepo.routing.UpdateRoutingRule"
+
+	result := routingRepo.db.WithContext(ctx).
+		Model(&domain.RoutingRule{}).
+		Where("id = ?", rule.ID).
+		Select(
+			"name", "priority",
+			"source_workflow_id", "content_type", "name_pattern",
+			"min_size", "max_size", "min_depth", "max_depth",
+			"workflow_id", "skip_orders",
+		).
+		Updates(rule)
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", op, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, domain.ErrRoutingRuleNotFound)
+	}
+
+	return nil
+}
+
+// DeleteRoutingRule удаляет правило маршрутизации. Уже созданные по нему Approvals не меняются
+// Кастомные ошибки: ErrRoutingRuleNotFound
+func (routingRepo *RoutingRuleRepository) DeleteRoutingRule(ctx context.Context, ruleID uint) error {
+	const op = "infrastructure.postgresrepo.routing.DeleteRoutingRule"
+
+	result := routingRepo.db.WithContext(ctx).Delete(&domain.RoutingRule{}, ruleID)
+	if result.Error != nil {
+		return fmt.Errorf("%s: %w", op, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		return fmt.Errorf("%s: %w", op, domain.ErrRoutingRuleNotFound)
+	}
+
+	return nil
+}
@@ -0,0 +1,275 @@
+package postgresrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+// Причины отзыва сессии
+const (
+	revokeReasonLogout = "logout"
+	revokeReasonReuse  = "reuse"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *Database) *SessionRepository {
+	return &SessionRepository{db: db.db}
+}
+
+// CreateSession создает сессию с первым refresh-токеном
+func (r *SessionRepository) CreateSession(ctx context.Context, session *domain.AuthSession, token *domain.RefreshToken) error {
+	const op = "infrastructure.postgresrepo.session.CreateSession"
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		if err := tx.Create(session).Error; err != nil {
+			return err
+		}
+		token.SessionID = session.ID
+		return tx.Create(token).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// RotateRefreshToken помечает refresh-токен с хэшем tokenHash использованным и выдает вместо него next,
+// продлевая сессию до next.ExpiresAt. Возвращает сессию и действующий токен: next или, если токен
+// предъявлен повторно не позже reuseGrace после обновления, ранее выданный вместо него и еще не
+// использованный токен. Иначе повторное предъявление отзывает сессию, она возвращается вместе
+// с ErrRefreshTokenReused
+// Кастомные ошибки: ErrInvalidRefreshToken, ErrRefreshTokenReused
+func (r *SessionRepository) RotateRefreshToken(ctx context.Context, tokenHash string, next *domain.RefreshToken, reuseGrace time.Duration) (*domain.AuthSession, *domain.RefreshToken, error) {
+	const op = "infrastructure.postgresrepo.session.RotateRefreshToken"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	token, session, err := lockRefreshToken(tx, tokenHash)
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	now := time.Now()
+	if session.RevokedAt != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Errorf("%s: %w", op, domain.ErrInvalidRefreshToken)
+	}
+
+	if token.UsedAt != nil {
+		successor, err := graceSuccessor(tx, token, reuseGrace, now)
+		if err != nil {
+			tx.Rollback()
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if successor != nil {
+			tx.Rollback()
+			return session, successor, nil
+		}
+
+		if err := revokeSession(tx, session, revokeReasonReuse, now); err != nil {
+			tx.Rollback()
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if err := tx.Commit().Error; err != nil {
+			return nil, nil, fmt.Errorf("%s: %w", op, err)
+		}
+		return session, nil, fmt.Errorf("%s: %w", op, domain.ErrRefreshTokenReused)
+	}
+
+	if !token.ExpiresAt.After(now) {
+		tx.Rollback()
+		return nil, nil, fmt.Errorf("%s: %w", op, domain.ErrInvalidRefreshToken)
+	}
+
+	next.SessionID = session.ID
+	if err := tx.Create(next).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.Model(token).Updates(map[string]any{
+		"used_at":      now,
+		"successor_id": next.ID,
+	}).Error
+	if err != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Model(session).Update("expires_at", next.ExpiresAt).Error; err != nil {
+		tx.Rollback()
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, next, nil
+}
+
+// RevokeSessionByToken отзывает сессию, которой принадлежит refresh-токен с хэшем tokenHash
+// (в том числе использованный или истекший). Повторный отзыв не меняет сессию
+// Кастомные ошибки: ErrInvalidRefreshToken
+func (r *SessionRepository) RevokeSessionByToken(ctx context.Context, tokenHash string) (*domain.AuthSession, error) {
+	const op = "infrastructure.postgresrepo.session.RevokeSessionByToken"
+
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	_, session, err := lockRefreshToken(tx, tokenHash)
+	if err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if session.RevokedAt == nil {
+		if err := revokeSession(tx, session, revokeReasonLogout, time.Now()); err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return session, nil
+}
+
+// IsSessionActive проверяет, что сессия не отозвана и не истекла
+func (r *SessionRepository) IsSessionActive(ctx context.Context, sessionID string) (bool, error) {
+	const op = "infrastructure.postgresrepo.session.IsSessionActive"
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.AuthSession{}).
+		Where("id = ? AND revoked_at IS NULL AND expires_at > ?", sessionID, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count > 0, nil
+}
+
+// GetUnsyncedRevocations возвращает до limit сессий, отозванных после revokedAfter,
+// отзыв которых еще не передан в file-service
+func (r *SessionRepository) GetUnsyncedRevocations(ctx context.Context, revokedAfter time.Time, limit int) ([]domain.AuthSession, error) {
+	const op = "infrastructure.postgresrepo.session.GetUnsyncedRevocations"
+
+	var sessions []domain.AuthSession
+	err := r.db.WithContext(ctx).
+		Where("revoked_at > ? AND revocation_synced_at IS NULL", revokedAfter).
+		Order("revoked_at ASC").
+		Limit(limit).
+		Find(&sessions).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return sessions, nil
+}
+
+// MarkRevocationsSynced отмечает, что отзыв сессий передан в file-service
+func (r *SessionRepository) MarkRevocationsSynced(ctx context.Context, sessionIDs []string, at time.Time) error {
+	const op = "infrastructure.postgresrepo.session.MarkRevocationsSynced"
+
+	if len(sessionIDs) == 0 {
+		return nil
+	}
+
+	err := r.db.WithContext(ctx).
+		Model(&domain.AuthSession{}).
+		Where("id IN ?", sessionIDs).
+		Update("revocation_synced_at", at).Error
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// lockRefreshToken блокирует refresh-токен и его сессию в транзакции tx
+// Кастомные ошибки: ErrInvalidRefreshToken
+func lockRefreshToken(tx *gorm.DB, tokenHash string) (*domain.RefreshToken, *domain.AuthSession, error) {
+	var token domain.RefreshToken
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("token_hash = ?", tokenHash).
+		First(&token).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	var session domain.AuthSession
+	err = tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		Where("id = ?", token.SessionID).
+		First(&session).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil, domain.ErrInvalidRefreshToken
+		}
+		return nil, nil, err
+	}
+
+	return &token, &session, nil
+}
+
+// graceSuccessor возвращает токен, выданный вместо использованного token, если token предъявлен
+// не позже reuseGrace после обновления, а выданный токен еще не использован и не истек. Иначе nil
+func graceSuccessor(tx *gorm.DB, token *domain.RefreshToken, reuseGrace time.Duration, now time.Time) (*domain.RefreshToken, error) {
+	if token.SuccessorID == nil || now.Sub(*token.UsedAt) > reuseGrace {
+		return nil, nil
+	}
+
+	var successor domain.RefreshToken
+	if err := tx.First(&successor, *token.SuccessorID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, nil
+		}
+		return nil, err
+	}
+	if successor.UsedAt != nil || !successor.ExpiresAt.After(now) {
+		return nil, nil
+	}
+
+	return &successor, nil
+}
+
+// revokeSession отзывает сессию с причиной reason и обновляет session
+func revokeSession(tx *gorm.DB, session *domain.AuthSession, reason string, at time.Time) error {
+	err := tx.Model(session).Updates(map[string]any{
+		"revoked_at":    at,
+		"revoke_reason": reason,
+	}).Error
+	if err != nil {
+		return err
+	}
+
+	session.RevokedAt = &at
+	session.RevokeReason = reason
+	return nil
+}
@@ -0,0 +1,71 @@
+package postgresrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SigningKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewSigningKeyRepository(db *Database) *SigningKeyRepository {
+	return &SigningKeyRepository{db: db.db}
+}
+
+// GetSigningKeyByUserID возвращает ключ подписи пользователя
+// Кастомные ошибки: ErrSigningKeyNotFound
+func (keyRepo *SigningKeyRepository) GetSigningKeyByUserID(ctx context.Context, userID uint) (*domain.SigningKey, error) {
+	const op = "infrastructure.postgresrepo.signing.GetSigningKeyByUserID"
+
+	var key domain.SigningKey
+	if err := keyRepo.db.WithContext(ctx).Where("user_id = ?", userID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrSigningKeyNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+// GetSigningKeyByID возвращает ключ подписи по ID
+// Кастомные ошибки: ErrSigningKeyNotFound
+func (keyRepo *SigningKeyRepository) GetSigningKeyByID(ctx context.Context, keyID uint) (*domain.SigningKey, error) {
+	const op = "infrastructure.postgresrepo.signing.GetSigningKeyByID"
+
+	// Подписи проверяются и после удаления ключа, поэтому Unscoped
+	var key domain.SigningKey
+	if err := keyRepo.db.WithContext(ctx).Unscoped().First(&key, keyID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrSigningKeyNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+// CreateSigningKey сохраняет ключ пользователя, если у него еще нет ключа, и возвращает
+// ключ пользователя из базы: при одновременном создании сохраняется только один ключ
+func (keyRepo *SigningKeyRepository) CreateSigningKey(ctx context.Context, key *domain.SigningKey) (*domain.SigningKey, error) {
+	const op = "infrastructure.postgresrepo.signing.CreateSigningKey"
+
+	if err := keyRepo.db.WithContext(ctx).
+		Clauses(clause.OnConflict{Columns: []clause.Column{{Name: "user_id"}}, DoNothing: true}).
+		Create(key).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	stored, err := keyRepo.GetSigningKeyByUserID(ctx, key.UserID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return stored, nil
+}
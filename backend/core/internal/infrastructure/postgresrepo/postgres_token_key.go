@@ -0,0 +1,132 @@
+package postgresrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// tokenKeyRotationLock - ключ advisory-блокировки ротации: экземпляры core-service ротируют ключ по очереди
+const tokenKeyRotationLock = 7_340_221
+
+type TokenKeyRepository struct {
+	db *gorm.DB
+}
+
+func NewTokenKeyRepository(db *Database) *TokenKeyRepository {
+	return &TokenKeyRepository{db: db.db}
+}
+
+// GetActiveTokenKey возвращает ключ, которым подписываются новые access-токены
+// Кастомные ошибки: ErrTokenKeyNotFound
+func (keyRepo *TokenKeyRepository) GetActiveTokenKey(ctx context.Context) (*domain.TokenKey, error) {
+	const op = "infrastructure.postgresrepo.tokenkey.GetActiveTokenKey"
+
+	key, err := activeTokenKey(keyRepo.db.WithContext(ctx))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// GetTokenKeyByID возвращает ключ по kid, в том числе выведенный из ротации
+// Кастомные ошибки: ErrTokenKeyNotFound
+func (keyRepo *TokenKeyRepository) GetTokenKeyByID(ctx context.Context, keyID string) (*domain.TokenKey, error) {
+	const op = "infrastructure.postgresrepo.tokenkey.GetTokenKeyByID"
+
+	var key domain.TokenKey
+	if err := keyRepo.db.WithContext(ctx).Where("id = ?", keyID).First(&key).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrTokenKeyNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &key, nil
+}
+
+// GetPublishedTokenKeys возвращает активный ключ и ключи, выведенные из ротации после retiredAfter,
+// от новых к старым
+func (keyRepo *TokenKeyRepository) GetPublishedTokenKeys(ctx context.Context, retiredAfter time.Time) ([]domain.TokenKey, error) {
+	const op = "infrastructure.postgresrepo.tokenkey.GetPublishedTokenKeys"
+
+	var keys []domain.TokenKey
+	err := keyRepo.db.WithContext(ctx).
+		Where("retired_at IS NULL OR retired_at > ?", retiredAfter).
+		Order("created_at DESC").
+		Find(&keys).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return keys, nil
+}
+
+// RotateTokenKey делает key активным ключом, выводя из ротации текущий, если тот создан до rotateBefore
+// или активного ключа нет. Иначе key не сохраняется и возвращается текущий активный ключ:
+// так ключ ротирует только один из одновременно обратившихся экземпляров
+func (keyRepo *TokenKeyRepository) RotateTokenKey(ctx context.Context, key *domain.TokenKey, rotateBefore time.Time) (*domain.TokenKey, error) {
+	const op = "infrastructure.postgresrepo.tokenkey.RotateTokenKey"
+
+	tx := keyRepo.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Exec("SELECT pg_advisory_xact_lock(?)", tokenKeyRotationLock).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	active, err := activeTokenKey(tx)
+	if err != nil && !errors.Is(err, domain.ErrTokenKeyNotFound) {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if active != nil && active.CreatedAt.After(rotateBefore) {
+		tx.Rollback()
+		return active, nil
+	}
+
+	now := time.Now()
+	if active != nil {
+		if err := tx.Model(active).Update("retired_at", now).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	key.CreatedAt = now
+	if err := tx.Create(key).Error; err != nil {
+		tx.Rollback()
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key, nil
+}
+
+// activeTokenKey возвращает самый новый невыведенный ключ
+// Кастомные ошибки: ErrTokenKeyNotFound
+func activeTokenKey(db *gorm.DB) (*domain.TokenKey, error) {
+	var key domain.TokenKey
+	err := db.Where("retired_at IS NULL").Order("created_at DESC").First(&key).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTokenKeyNotFound
+		}
+		return nil, err
+	}
+
+	return &key, nil
+}
@@ -0,0 +1,341 @@
+package postgresrepo
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type TransmittalRepository struct {
+	db *gorm.DB
+}
+
+func NewTransmittalRepository(db *Database) *TransmittalRepository {
+	return &TransmittalRepository{db: db.db}
+}
+
+// closedTransmittalStatuses - статусы завершенного согласования комплекта. Комплект в любом другом
+// статусе (в том числе черновик и отозванный) открыт: его можно отправить на согласование
+var closedTransmittalStatuses = []string{"approved", "rejected"}
+
+// CreateTransmittal создает комплект с файлами files и присваивает ему номер вида "TR-000042".
+// Файлы не должны быть на отдельном согласовании или в другом открытом комплекте
+// Кастомные ошибки: ErrFileInUse
+func (transmittalRepo *TransmittalRepository) CreateTransmittal(ctx context.Context, transmittal *domain.Transmittal, files []domain.TransmittalFile) error {
+	const op = "infrastructure.postgresrepo.transmittal.CreateTransmittal"
+
+	tx := transmittalRepo.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	fileIDs := make([]uint, len(files))
+	for i, file := range files {
+		fileIDs[i] = file.FileID
+	}
+
+	var busy int64
+	err := tx.Raw(`
+        SELECT COUNT(*) FROM approvals
+        WHERE deleted_at IS NULL AND transmittal_id = 0 AND file_id IN @files AND status IN @open
+    `, sql.Named("files", fileIDs), sql.Named("open", openApprovalStatuses)).Scan(&busy).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if busy == 0 {
+		err = tx.Raw(`
+            SELECT COUNT(*) FROM transmittal_files tf
+            JOIN transmittals t ON t.id = tf.transmittal_id AND t.deleted_at IS NULL
+            LEFT JOIN approvals a ON a.id = t.approval_id
+            WHERE tf.file_id IN @files AND COALESCE(a.status, 'draft') NOT IN @closed
+        `, sql.Named("files", fileIDs), sql.Named("closed", closedTransmittalStatuses)).Scan(&busy).Error
+		if err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	if busy > 0 {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrFileInUse)
+	}
+
+	var transmittalID uint
+	if err := tx.Raw("SELECT nextval('transmittals_id_seq')").Scan(&transmittalID).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	transmittal.ID = transmittalID
+	transmittal.Number = fmt.Sprintf("TR-%06d", transmittalID)
+	if err := tx.Create(transmittal).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range files {
+		files[i].TransmittalID = transmittalID
+	}
+	if err := tx.Create(&files).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// GetTransmittals возвращает комплекты, созданные пользователем userID (0 - все комплекты), начиная с новых
+func (transmittalRepo *TransmittalRepository) GetTransmittals(ctx context.Context, userID uint) ([]domain.TransmittalResponse, error) {
+	const op = "infrastructure.postgresrepo.transmittal.GetTransmittals"
+
+	query := transmittalRepo.transmittals(ctx)
+	if userID != 0 {
+		query = query.Where("transmittals.created_by = ?", userID)
+	}
+
+	var transmittals []domain.TransmittalResponse
+	if err := query.Order("transmittals.id DESC").Scan(&transmittals).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return transmittals, nil
+}
+
+// GetTransmittalByID возвращает комплект по ID
+// Кастомные ошибки: ErrTransmittalNotFound
+func (transmittalRepo *TransmittalRepository) GetTransmittalByID(ctx context.Context, transmittalID uint) (*domain.TransmittalResponse, error) {
+	const op = "infrastructure.postgresrepo.transmittal.GetTransmittalByID"
+
+	var transmittals []domain.TransmittalResponse
+	if err := transmittalRepo.transmittals(ctx).
+		Where("transmittals.id = ?", transmittalID).
+		Scan(&transmittals).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(transmittals) == 0 {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrTransmittalNotFound)
+	}
+
+	return &transmittals[0], nil
+}
+
+// GetTransmittalFiles возвращает файлы комплекта в порядке добавления
+func (transmittalRepo *TransmittalRepository) GetTransmittalFiles(ctx context.Context, transmittalID uint) ([]domain.TransmittalFile, error) {
+	const op = "infrastructure.postgresrepo.transmittal.GetTransmittalFiles"
+
+	var files []domain.TransmittalFile
+	if err := transmittalRepo.db.WithContext(ctx).
+		Where("transmittal_id = ?", transmittalID).
+		Order("id ASC").
+		Find(&files).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return files, nil
+}
+
+// SubmitTransmittal отправляет комплект на согласование новым Approval: запоминает отправленные
+// версии файлов, создает Approval комплекта и связывает его с комплектом. Если комплект уже был
+// отправлен кем-то другим (его последнее Approval не prevApprovalID), возвращает ошибку
+// Кастомные ошибки: ErrTransmittalNotFound, ErrInvalidTransmittalStatus, ErrWorkflowNotFound
+func (transmittalRepo *TransmittalRepository) SubmitTransmittal(
+	ctx context.Context,
+	transmittalID, prevApprovalID uint,
+	files []domain.TransmittalFile,
+	approval *domain.Approval,
+	actorID uint,
+) error {
+	const op = "infrastructure.postgresrepo.transmittal.SubmitTransmittal"
+
+	tx := transmittalRepo.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	transmittal, err := lockTransmittal(tx, transmittalID)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if transmittal.ApprovalID != prevApprovalID {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrInvalidTransmittalStatus)
+	}
+
+	if err := updateTransmittalVersions(tx, transmittalID, files); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	approval.TransmittalID = transmittalID
+	if err := createApproval(tx, approval, nil, actorID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Model(&domain.Transmittal{}).
+		Where("id = ?", transmittalID).
+		Update("approval_id", approval.ID).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ResumeTransmittal возвращает на согласование комплект, по которому были запрошены изменения:
+// запоминает новые версии файлов и продолжает Approval комплекта с ревизией revision
+// Кастомные ошибки: ErrTransmittalNotFound, ErrApprovalNotFound
+func (transmittalRepo *TransmittalRepository) ResumeTransmittal(
+	ctx context.Context,
+	transmittalID, approvalID uint,
+	files []domain.TransmittalFile,
+	revision int,
+	actorID uint,
+) error {
+	const op = "infrastructure.postgresrepo.transmittal.ResumeTransmittal"
+
+	tx := transmittalRepo.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if _, err := lockTransmittal(tx, transmittalID); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := updateTransmittalVersions(tx, transmittalID, files); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := resumeApproval(tx, approvalID, actorID, revision); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// DeleteTransmittal удаляет комплект, который не был отправлен на согласование или согласование
+// которого отозвано. Журнал согласований комплекта сохраняется
+// Кастомные ошибки: ErrTransmittalNotFound, ErrInvalidTransmittalStatus
+func (transmittalRepo *TransmittalRepository) DeleteTransmittal(ctx context.Context, transmittalID uint) error {
+	const op = "infrastructure.postgresrepo.transmittal.DeleteTransmittal"
+
+	tx := transmittalRepo.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	transmittal, err := lockTransmittal(tx, transmittalID)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if transmittal.ApprovalID != 0 {
+		var status string
+		if err := tx.Model(&domain.Approval{}).
+			Where("id = ?", transmittal.ApprovalID).
+			Pluck("status", &status).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if status != "withdrawn" {
+			tx.Rollback()
+			return fmt.Errorf("%s: %w", op, domain.ErrInvalidTransmittalStatus)
+		}
+	}
+
+	if err := tx.Delete(&domain.Transmittal{}, transmittalID).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// transmittals - запрос комплектов со статусом, процедурой, автором и количеством файлов.
+// Статус комплекта - статус его последнего Approval или "draft", если комплект не отправлялся
+func (transmittalRepo *TransmittalRepository) transmittals(ctx context.Context) *gorm.DB {
+	// Процедура и пользователь могли быть удалены, поэтому LEFT JOIN без фильтра по deleted_at
+	return transmittalRepo.db.WithContext(ctx).
+		Table("transmittals").
+		Select(`
+            transmittals.id,
+            transmittals.number,
+            transmittals.title,
+            transmittals.workflow_id,
+            COALESCE((SELECT h.name FROM workflow_headers h
+                WHERE h.id = transmittals.workflow_id), '') AS workflow_name,
+            transmittals.created_by,
+            COALESCE(users.login, '') AS creator_login,
+            transmittals.approval_id,
+            COALESCE(approvals.status, 'draft') AS status,
+            (SELECT COUNT(*) FROM transmittal_files tf
+                WHERE tf.transmittal_id = transmittals.id) AS file_count,
+            transmittals.created_at
+        `).
+		Joins("LEFT JOIN users ON users.id = transmittals.created_by").
+		Joins("LEFT JOIN approvals ON approvals.id = transmittals.approval_id").
+		Where("transmittals.deleted_at IS NULL")
+}
+
+// lockTransmittal блокирует строку комплекта до конца транзакции tx
+func lockTransmittal(tx *gorm.DB, transmittalID uint) (*domain.Transmittal, error) {
+	var transmittal domain.Transmittal
+	err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).
+		First(&transmittal, transmittalID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrTransmittalNotFound
+		}
+		return nil, err
+	}
+
+	return &transmittal, nil
+}
+
+// updateTransmittalVersions запоминает версии файлов, отправленные на согласование в составе комплекта
+func updateTransmittalVersions(tx *gorm.DB, transmittalID uint, files []domain.TransmittalFile) error {
+	for _, file := range files {
+		if err := tx.Model(&domain.TransmittalFile{}).
+			Where("transmittal_id = ? AND file_id = ?", transmittalID, file.FileID).
+			Update("file_version", file.FileVersion).Error; err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -1,221 +1,547 @@
-package postgresrepo
-
-import (
-	"context"
-	"fmt"
-	"service-core/internal/domain"
-
-	"gorm.io/gorm"
-)
-
-type WorkflowRepository struct {
-	db *gorm.DB
-}
-
-func NewWorkflowRepository(db *Database) *WorkflowRepository {
-	return &WorkflowRepository{db: db.db}
-}
-
-func (workflowRepo *WorkflowRepository) GetWorkflows(ctx context.Context) ([]domain.WorkflowResponse, error) {
-	const op = "infrastructure.postgresrepo.workflow.GetWorkflows"
-
-	var workflows []domain.WorkflowResponse
-	err := workflowRepo.db.WithContext(ctx).
-		Table("workflows").
-		Select(`
-        workflow_id, 
-        MAX(workflow_name) AS workflow_name, 
-        MAX(workflow_order) AS workflow_length
-    `).
-		Where("deleted_at IS NULL").
-		Group("workflow_id").
-		Having("COUNT(CASE WHEN deleted_at IS NOT NULL THEN 1 END) = 0").
-		Order("workflow_name ASC").
-		Scan(&workflows).Error
-
-	if err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return workflows, nil
-}
-
-func (workflowRepo *WorkflowRepository) GetWorkflowByID(ctx context.Context, workflowID uint) (domain.ExtendedWorkflowResponse, error) {
-	const op = "infrastructure.postgresrepo.workflow.GetWorkflowByID"
-
-	type WorkflowStageRow struct {
-		UserID       uint   `json:"user_id"`
-		Order        int    `json:"order"`
-		WorkflowName string `json:"workflow_name"`
-	}
-
-	var rows []WorkflowStageRow
-
-	err := workflowRepo.db.WithContext(ctx).
-		Table("workflows").
-		Select("workflows.user_id, workflows.workflow_order AS order, workflows.workflow_name").
-		Where("workflows.workflow_id = ?", workflowID).
-		Order("workflows.workflow_order ASC").
-		Scan(&rows).Error
-
-	if err != nil {
-		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	if len(rows) == 0 {
-		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, domain.ErrWorkflowNotFound)
-	}
-
-	var workflow domain.ExtendedWorkflowResponse
-	workflow.WorkflowName = rows[0].WorkflowName
-	workflow.Stages = make([]domain.WorkflowStage, len(rows))
-
-	for i, row := range rows {
-		workflow.Stages[i] = domain.WorkflowStage{
-			UserID: row.UserID,
-			Order:  row.Order,
-		}
-	}
-
-	return workflow, nil
-}
-
-func (workflowRepo *WorkflowRepository) CreateWorkflow(ctx context.Context, name string, stages []domain.WorkflowStage) error {
-	const op = "infrastructure.postgresrepo.workflow.CreateWorkflow"
-
-	tx := workflowRepo.db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	var newWorkflowID uint
-	err := tx.Raw("SELECT nextval('workflows_workflow_id_seq')").Scan(&newWorkflowID).Error
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	var workflows []domain.Workflow
-	for _, stage := range stages {
-		workflows = append(workflows, domain.Workflow{
-			WorkflowID:    newWorkflowID,
-			WorkflowName:  name,
-			UserID:        stage.UserID,
-			WorkflowOrder: stage.Order,
-		})
-	}
-
-	if err := tx.Create(&workflows).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (workflowRepo *WorkflowRepository) DeleteWorkflow(ctx context.Context, workflowID uint) error {
-	const op = "infrastructure.postgresrepo.workflow.DeleteWorkflow"
-
-	tx := workflowRepo.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return fmt.Errorf("%s: failed to begin transaction: %w", op, tx.Error)
-	}
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	result := tx.Where("workflow_id = ?", workflowID).Delete(&domain.Workflow{})
-	if result.Error != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, result.Error)
-	}
-
-	if result.RowsAffected == 0 {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, domain.ErrWorkflowNotFound)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (workflowRepo *WorkflowRepository) UpdateWorkflow(ctx context.Context, workflowID uint, name string, stages []domain.WorkflowStage) error {
-	const op = "infrastructure.postgresrepo.workflow.UpdateWorkflow"
-
-	tx := workflowRepo.db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	if err := tx.Where("workflow_id = ?", workflowID).Delete(&domain.Workflow{}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	var workflows []domain.Workflow
-	for _, stage := range stages {
-		workflows = append(workflows, domain.Workflow{
-			WorkflowID:    workflowID,
-			WorkflowName:  name,
-			UserID:        stage.UserID,
-			WorkflowOrder: stage.Order,
-		})
-	}
-
-	if err := tx.Create(&workflows).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (r *WorkflowRepository) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
-	const op = "infrastructure.postgresrepo.workflow.CheckWorkflow"
-
-	var count int64
-	err := r.db.WithContext(ctx).
-		Model(&domain.Workflow{}).
-		Where("workflow_id = ?", workflowID).
-		Count(&count).Error
-
-	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return count > 0, nil
-}
-
-func (workflowRepo *WorkflowRepository) CheckUserInWorkflow(ctx context.Context, userID uint) (bool, error) {
-	const op = "infrastructure.postgresrepo.workflow.CheckUserInWorkflow"
-
-	var count int64
-	err := workflowRepo.db.WithContext(ctx).
-		Model(&domain.Workflow{}).
-		Where("user_id = ?", userID).
-		Count(&count).Error
-
-	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return count > 0, nil
-}
+package postgresrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type WorkflowRepository struct {
+	db *gorm.DB
+}
+
+func NewWorkflowRepository(db *Database) *WorkflowRepository {
+	return &WorkflowRepository{db: db.db}
+}
+
+// openApprovalStatuses - статусы незавершенных согласований, которые идут по своей копии процедуры
+var openApprovalStatuses = []string{"on approval", "annotated"}
+
+// GetWorkflows возвращает процедуры согласования с текущей ревизией и количеством незавершенных
+// согласований, начатых по более старым ревизиям
+func (workflowRepo *WorkflowRepository) GetWorkflows(ctx context.Context) ([]domain.WorkflowResponse, error) {
+	const op = "infrastructure.postgresrepo.workflow.GetWorkflows"
+
+	var workflows []domain.WorkflowResponse
+	err := workflowRepo.db.WithContext(ctx).
+		Table("workflow_headers h").
+		Select(`
+        h.id AS workflow_id,
+        h.name AS workflow_name,
+        (SELECT COALESCE(MAX(s.stage_order), 0) FROM workflow_stages s
+            WHERE s.workflow_id = h.id) AS workflow_length,
+        h.revision,
+        (SELECT COUNT(*) FROM approvals a
+            WHERE a.workflow_id = h.id AND a.deleted_at IS NULL
+                AND a.status IN (?) AND a.workflow_revision < h.revision) AS outdated_approvals
+    `, openApprovalStatuses).
+		Where("h.deleted_at IS NULL").
+		Order("h.name ASC").
+		Scan(&workflows).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workflows, nil
+}
+
+// GetWorkflowByID возвращает процедуру согласования с этапами
+// Кастомные ошибки: ErrWorkflowNotFound
+func (workflowRepo *WorkflowRepository) GetWorkflowByID(ctx context.Context, workflowID uint) (domain.ExtendedWorkflowResponse, error) {
+	const op = "infrastructure.postgresrepo.workflow.GetWorkflowByID"
+
+	header, err := loadWorkflow(workflowRepo.db.WithContext(ctx), workflowID)
+	if err != nil {
+		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	var workflow domain.ExtendedWorkflowResponse
+	workflow.WorkflowName = header.Name
+	workflow.Revision = header.Revision
+	workflow.RequireResolvedComments = header.RequireResolvedComments
+	workflow.Stages = headerStages(header)
+
+	err = workflowRepo.db.WithContext(ctx).
+		Model(&domain.Approval{}).
+		Where("workflow_id = ? AND status IN ? AND workflow_revision < ?", workflowID, openApprovalStatuses, workflow.Revision).
+		Count(&workflow.OutdatedApprovals).Error
+	if err != nil {
+		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workflow, nil
+}
+
+// CreateWorkflow создает процедуру согласования с этапами и возвращает её ID
+// Кастомные ошибки: ErrWorkflowNameTaken
+func (workflowRepo *WorkflowRepository) CreateWorkflow(ctx context.Context, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage) (uint, error) {
+	const op = "infrastructure.postgresrepo.workflow.CreateWorkflow"
+
+	tx := workflowRepo.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	workflowID, err := createWorkflow(tx, name, options, stages)
+	if err != nil {
+		tx.Rollback()
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workflowID, nil
+}
+
+func (workflowRepo *WorkflowRepository) DeleteWorkflow(ctx context.Context, workflowID uint) error {
+	const op = "infrastructure.postgresrepo.workflow.DeleteWorkflow"
+
+	tx := workflowRepo.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, tx.Error)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Правила маршрутизации, ссылающиеся на процедуру, нужно сначала изменить или удалить
+	var ruleCount int64
+	if err := tx.Model(&domain.RoutingRule{}).
+		Where("workflow_id = ? OR source_workflow_id = ?", workflowID, workflowID).
+		Count(&ruleCount).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if ruleCount > 0 {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrWorkflowInUse)
+	}
+
+	// Комплекты согласуются по своей процедуре
+	var transmittalCount int64
+	if err := tx.Model(&domain.Transmittal{}).
+		Where("workflow_id = ?", workflowID).
+		Count(&transmittalCount).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if transmittalCount > 0 {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrWorkflowInUse)
+	}
+
+	// Этапы удаленной процедуры остаются: по ним показывается имя процедуры в старых согласованиях
+	result := tx.Delete(&domain.WorkflowHeader{}, workflowID)
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrWorkflowNotFound)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateWorkflow заменяет этапы процедуры согласования новой ревизией. Начатые согласования
+// продолжают идти по копии той ревизии, с которой они начались
+// Кастомные ошибки: ErrWorkflowNotFound, ErrWorkflowNameTaken
+func (workflowRepo *WorkflowRepository) UpdateWorkflow(ctx context.Context, workflowID uint, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage) error {
+	const op = "infrastructure.postgresrepo.workflow.UpdateWorkflow"
+
+	tx := workflowRepo.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := updateWorkflow(tx, workflowID, name, options, stages); err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// ImportWorkflows в одной транзакции создает роли newRoles и создает или обновляет процедуры workflows.
+// Роли newRoles указаны в этапах процедур временными ID (ключи newRoles), они заменяются ID созданных
+// ролей. Возвращает ID процедур в порядке workflows
+// Кастомные ошибки: ErrRoleAlreadyExists, ErrWorkflowNotFound, ErrWorkflowNameTaken
+func (workflowRepo *WorkflowRepository) ImportWorkflows(ctx context.Context, newRoles map[uint]string, workflows []domain.WorkflowImport) ([]uint, error) {
+	const op = "infrastructure.postgresrepo.workflow.ImportWorkflows"
+
+	tx := workflowRepo.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	roleIDs := make(map[uint]uint, len(newRoles))
+	for tempID, name := range newRoles {
+		var count int64
+		if err := tx.Model(&domain.Role{}).Where("role_name = ?", name).Count(&count).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if count > 0 {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrRoleAlreadyExists)
+		}
+
+		role := domain.Role{RoleName: name}
+		if err := tx.Create(&role).Error; err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		roleIDs[tempID] = role.ID
+	}
+
+	workflowIDs := make([]uint, len(workflows))
+	for i, workflow := range workflows {
+		stages := replaceRoleIDs(workflow.Stages, roleIDs)
+
+		var err error
+		switch {
+		case workflow.ID == 0:
+			workflowIDs[i], err = createWorkflow(tx, workflow.Name, workflow.Options, stages)
+		case workflow.Update:
+			workflowIDs[i], err = workflow.ID, updateWorkflow(tx, workflow.ID, workflow.Name, workflow.Options, stages)
+		default:
+			workflowIDs[i] = workflow.ID
+		}
+		if err != nil {
+			tx.Rollback()
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return workflowIDs, nil
+}
+
+// createWorkflow создает процедуру согласования в транзакции tx
+// Кастомные ошибки: ErrWorkflowNameTaken
+func createWorkflow(tx *gorm.DB, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage) (uint, error) {
+	if err := checkWorkflowName(tx, name, 0); err != nil {
+		return 0, err
+	}
+
+	header := domain.WorkflowHeader{
+		Name:                    name,
+		Revision:                1,
+		RequireResolvedComments: options.RequireResolvedComments,
+		Stages:                  stageRecords(0, stages),
+	}
+	if err := tx.Create(&header).Error; err != nil {
+		return 0, err
+	}
+
+	return header.ID, nil
+}
+
+// updateWorkflow заменяет этапы процедуры согласования новой ревизией в транзакции tx
+// Кастомные ошибки: ErrWorkflowNotFound, ErrWorkflowNameTaken
+func updateWorkflow(tx *gorm.DB, workflowID uint, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage) error {
+	var header domain.WorkflowHeader
+	if err := tx.Clauses(clause.Locking{Strength: "UPDATE"}).First(&header, workflowID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return domain.ErrWorkflowNotFound
+		}
+		return err
+	}
+
+	if err := checkWorkflowName(tx, name, workflowID); err != nil {
+		return err
+	}
+
+	// Подписанты удаляются вместе с этапами (ON DELETE CASCADE)
+	if err := tx.Where("workflow_id = ?", workflowID).Delete(&domain.WorkflowStageRecord{}).Error; err != nil {
+		return err
+	}
+
+	records := stageRecords(workflowID, stages)
+	if err := tx.Create(&records).Error; err != nil {
+		return err
+	}
+
+	return tx.Model(&header).Updates(map[string]interface{}{
+		"name":                      name,
+		"revision":                  header.Revision + 1,
+		"require_resolved_comments": options.RequireResolvedComments,
+	}).Error
+}
+
+// replaceRoleIDs возвращает копию этапов, в которой роли с ID из ключей roleIDs заменены значениями
+func replaceRoleIDs(stages []domain.WorkflowStage, roleIDs map[uint]uint) []domain.WorkflowStage {
+	replace := func(id uint) uint {
+		if replacement, exists := roleIDs[id]; exists {
+			return replacement
+		}
+		return id
+	}
+
+	replaced := make([]domain.WorkflowStage, len(stages))
+	for i, stage := range stages {
+		stage.RoleIDs = make([]uint, len(stages[i].RoleIDs))
+		for j, id := range stages[i].RoleIDs {
+			stage.RoleIDs[j] = replace(id)
+		}
+		if stage.EscalationRoleID != 0 {
+			stage.EscalationRoleID = replace(stage.EscalationRoleID)
+		}
+		replaced[i] = stage
+	}
+	return replaced
+}
+
+func (r *WorkflowRepository) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
+	const op = "infrastructure.postgresrepo.workflow.CheckWorkflow"
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.WorkflowHeader{}).
+		Where("id = ?", workflowID).
+		Count(&count).Error
+
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count > 0, nil
+}
+
+// CheckWorkflowName проверяет, занято ли имя другой процедурой согласования (не exceptID)
+func (workflowRepo *WorkflowRepository) CheckWorkflowName(ctx context.Context, name string, exceptID uint) (bool, error) {
+	const op = "infrastructure.postgresrepo.workflow.CheckWorkflowName"
+
+	err := checkWorkflowName(workflowRepo.db.WithContext(ctx), name, exceptID)
+	if errors.Is(err, domain.ErrWorkflowNameTaken) {
+		return true, nil
+	}
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return false, nil
+}
+
+// CheckUserInWorkflow проверяет, указан ли пользователь в процедуре согласования
+// или в копии процедуры незавершенного согласования
+func (workflowRepo *WorkflowRepository) CheckUserInWorkflow(ctx context.Context, userID uint) (bool, error) {
+	const op = "infrastructure.postgresrepo.workflow.CheckUserInWorkflow"
+
+	exists, err := workflowRepo.checkSignerInUse(ctx, "user_id", userID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return exists, nil
+}
+
+// CheckRoleInWorkflow проверяет, указана ли роль в процедуре согласования
+// или в копии процедуры незавершенного согласования
+func (workflowRepo *WorkflowRepository) CheckRoleInWorkflow(ctx context.Context, roleID uint) (bool, error) {
+	const op = "infrastructure.postgresrepo.workflow.CheckRoleInWorkflow"
+
+	exists, err := workflowRepo.checkSignerInUse(ctx, "role_id", roleID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return exists, nil
+}
+
+// GetOpenApprovals возвращает незавершенные согласования файлов fileIDs
+func (workflowRepo *WorkflowRepository) GetOpenApprovals(ctx context.Context, fileIDs []uint) ([]domain.Approval, error) {
+	const op = "infrastructure.postgresrepo.workflow.GetOpenApprovals"
+
+	var approvals []domain.Approval
+	if len(fileIDs) == 0 {
+		return approvals, nil
+	}
+
+	err := workflowRepo.db.WithContext(ctx).
+		Where("file_id IN ? AND status IN ?", fileIDs, openApprovalStatuses).
+		Order("id ASC").
+		Find(&approvals).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return approvals, nil
+}
+
+// checkSignerInUse проверяет, встречается ли подписант (column = user_id или role_id)
+// в процедурах согласования или в копиях процедур незавершенных согласований
+func (workflowRepo *WorkflowRepository) checkSignerInUse(ctx context.Context, column string, id uint) (bool, error) {
+	var count int64
+	err := workflowRepo.db.WithContext(ctx).
+		Model(&domain.Workflow{}).
+		Where(column+" = ?", id).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+	if count > 0 {
+		return true, nil
+	}
+
+	err = workflowRepo.db.WithContext(ctx).
+		Table("approval_stages st").
+		Joins("JOIN approvals a ON a.id = st.approval_id").
+		Where("st."+column+" = ?", id).
+		Where("a.status IN ? AND a.deleted_at IS NULL", openApprovalStatuses).
+		Count(&count).Error
+	if err != nil {
+		return false, err
+	}
+
+	return count > 0, nil
+}
+
+// stageRecords переводит этапы в записи этапов процедуры workflowID с подписантами
+func stageRecords(workflowID uint, stages []domain.WorkflowStage) []domain.WorkflowStageRecord {
+	records := make([]domain.WorkflowStageRecord, 0, len(stages))
+	for _, stage := range stages {
+		record := domain.WorkflowStageRecord{
+			WorkflowID:  workflowID,
+			StageOrder:  stage.Order,
+			StageRule:   stage.Rule,
+			StageQuorum: stage.Quorum,
+
+			StageDueHours:    stage.DueHours,
+			EscalationUserID: stage.EscalationUserID,
+			EscalationRoleID: stage.EscalationRoleID,
+		}
+		for _, userID := range stage.UserIDs {
+			record.Signers = append(record.Signers, domain.WorkflowSigner{UserID: userID})
+		}
+		for _, roleID := range stage.RoleIDs {
+			record.Signers = append(record.Signers, domain.WorkflowSigner{RoleID: roleID})
+		}
+		records = append(records, record)
+	}
+	return records
+}
+
+// headerStages собирает этапы процедуры из её записей
+func headerStages(header *domain.WorkflowHeader) []domain.WorkflowStage {
+	stages := make([]domain.WorkflowStage, 0, len(header.Stages))
+	for _, record := range header.Stages {
+		stage := domain.WorkflowStage{
+			Order:  record.StageOrder,
+			Rule:   record.StageRule,
+			Quorum: record.StageQuorum,
+
+			DueHours:         record.StageDueHours,
+			EscalationUserID: record.EscalationUserID,
+			EscalationRoleID: record.EscalationRoleID,
+		}
+		for _, signer := range record.Signers {
+			if signer.RoleID != 0 {
+				stage.RoleIDs = append(stage.RoleIDs, signer.RoleID)
+			} else {
+				stage.UserIDs = append(stage.UserIDs, signer.UserID)
+			}
+		}
+
+		// Для этапов с одним подписантом заполняем user_id, чтобы не ломать старых клиентов
+		if len(stage.UserIDs) == 1 && len(stage.RoleIDs) == 0 {
+			stage.UserID = stage.UserIDs[0]
+		}
+		stages = append(stages, stage)
+	}
+	return stages
+}
+
+// loadWorkflow загружает неудаленную процедуру согласования с этапами и подписантами
+func loadWorkflow(db *gorm.DB, workflowID uint) (*domain.WorkflowHeader, error) {
+	var header domain.WorkflowHeader
+	err := db.
+		Preload("Stages", func(db *gorm.DB) *gorm.DB { return db.Order("stage_order ASC") }).
+		Preload("Stages.Signers", func(db *gorm.DB) *gorm.DB { return db.Order("id ASC") }).
+		First(&header, workflowID).Error
+	if err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, domain.ErrWorkflowNotFound
+		}
+		return nil, err
+	}
+
+	return &header, nil
+}
+
+// checkWorkflowName возвращает ErrWorkflowNameTaken, если имя занято неудаленной процедурой, кроме exceptID.
+// При гонке двух сохранений вторую запись отклонит уникальный индекс idx_workflow_headers_name
+func checkWorkflowName(db *gorm.DB, name string, exceptID uint) error {
+	var count int64
+	if err := db.Model(&domain.WorkflowHeader{}).
+		Where("name = ? AND id <> ?", name, exceptID).
+		Count(&count).Error; err != nil {
+		return err
+	}
+	if count > 0 {
+		return domain.ErrWorkflowNameTaken
+	}
+	return nil
+}
+
+// groupStages собирает строки таблицы workflows, отсортированные по workflow_order, обратно в этапы
+func groupStages(rows []domain.Workflow) []domain.WorkflowStage {
+	var stages []domain.WorkflowStage
+	for _, row := range rows {
+		if len(stages) == 0 || stages[len(stages)-1].Order != row.WorkflowOrder {
+			stages = append(stages, domain.WorkflowStage{
+				Order:  row.WorkflowOrder,
+				Rule:   row.StageRule,
+				Quorum: row.StageQuorum,
+
+				DueHours:         row.StageDueHours,
+				EscalationUserID: row.EscalationUserID,
+				EscalationRoleID: row.EscalationRoleID,
+			})
+		}
+		last := &stages[len(stages)-1]
+		if row.RoleID != 0 {
+			last.RoleIDs = append(last.RoleIDs, row.RoleID)
+		} else {
+			last.UserIDs = append(last.UserIDs, row.UserID)
+		}
+	}
+
+	// Для этапов с одним подписантом заполняем user_id, чтобы не ломать старых клиентов
+	for i := range stages {
+		if len(stages[i].UserIDs) == 1 && len(stages[i].RoleIDs) == 0 {
+			stages[i].UserID = stages[i].UserIDs[0]
+		}
+	}
+
+	return stages
+}
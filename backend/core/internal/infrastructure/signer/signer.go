@@ -0,0 +1,182 @@
+package signer
+
+import (
+	"context"
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+)
+
+// Ed25519Signer подписывает решения ключами Ed25519 пользователей. Ключ пользователя создается
+// при первой подписи, закрытый ключ хранится в базе зашифрованным AES-256-GCM мастер-ключом
+type Ed25519Signer struct {
+	keyRepo interfaces.SigningKeyRepository
+	aead    cipher.AEAD
+}
+
+func NewEd25519Signer(keyRepo interfaces.SigningKeyRepository, masterKey string) (*Ed25519Signer, error) {
+	const op = "infrastructure.signer.NewEd25519Signer"
+
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Ed25519Signer{keyRepo: keyRepo, aead: aead}, nil
+}
+
+// SigningKey возвращает ID ключа пользователя userID, создавая ключ при первом обращении
+func (s *Ed25519Signer) SigningKey(ctx context.Context, userID uint) (uint, error) {
+	const op = "infrastructure.signer.SigningKey"
+
+	key, err := s.keyRepo.GetSigningKeyByUserID(ctx, userID)
+	if errors.Is(err, domain.ErrSigningKeyNotFound) {
+		key, err = s.createKey(ctx, userID)
+	}
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return key.ID, nil
+}
+
+// Sign подписывает payload закрытым ключом keyID
+// Кастомные ошибки: ErrSigningKeyNotFound
+func (s *Ed25519Signer) Sign(ctx context.Context, keyID uint, payload []byte) ([]byte, error) {
+	const op = "infrastructure.signer.Sign"
+
+	key, err := s.keyRepo.GetSigningKeyByID(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	privateKey, err := s.decrypt(key)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ed25519.Sign(privateKey, payload), nil
+}
+
+// Verify проверяет подпись payload открытым ключом keyID
+// Кастомные ошибки: ErrSigningKeyNotFound
+func (s *Ed25519Signer) Verify(ctx context.Context, keyID uint, payload, signature []byte) (bool, error) {
+	const op = "infrastructure.signer.Verify"
+
+	publicKey, err := s.PublicKey(ctx, keyID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return ed25519.Verify(publicKey, payload, signature), nil
+}
+
+// PublicKey возвращает открытый ключ keyID
+// Кастомные ошибки: ErrSigningKeyNotFound
+func (s *Ed25519Signer) PublicKey(ctx context.Context, keyID uint) ([]byte, error) {
+	const op = "infrastructure.signer.PublicKey"
+
+	key, err := s.keyRepo.GetSigningKeyByID(ctx, keyID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(key.PublicKey) != ed25519.PublicKeySize {
+		return nil, fmt.Errorf("%s: invalid public key size %d", op, len(key.PublicKey))
+	}
+
+	return key.PublicKey, nil
+}
+
+// createKey создает ключ пользователя. Если ключ одновременно создан другим запросом,
+// возвращается сохраненный ключ
+func (s *Ed25519Signer) createKey(ctx context.Context, userID uint) (*domain.SigningKey, error) {
+	publicKey, sealed, err := generateSealedKey(s.aead)
+	if err != nil {
+		return nil, err
+	}
+
+	return s.keyRepo.CreateSigningKey(ctx, &domain.SigningKey{
+		UserID:     userID,
+		PublicKey:  publicKey,
+		PrivateKey: sealed,
+	})
+}
+
+// decrypt расшифровывает закрытый ключ пользователя
+func (s *Ed25519Signer) decrypt(key *domain.SigningKey) (ed25519.PrivateKey, error) {
+	privateKey, err := openSealedKey(s.aead, key.PublicKey, key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("signing key %d: %w", key.ID, err)
+	}
+
+	return privateKey, nil
+}
+
+// minMasterKeySize - минимальная длина мастер-ключа после декодирования base64
+const minMasterKeySize = 32
+
+// newAEAD создает шифр AES-256-GCM для закрытых ключей из мастер-ключа. Мастер-ключ - base64 не менее
+// чем от 32 случайных байт, без него сервис не запускается. Ключ шифра по-прежнему выводится из строки
+// мастер-ключа, чтобы ключи, зашифрованные ранее, оставались читаемыми
+func newAEAD(masterKey string) (cipher.AEAD, error) {
+	if masterKey == "" {
+		return nil, errors.New("signing master key is not set")
+	}
+	decoded, err := base64.StdEncoding.DecodeString(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("signing master key is not valid base64: %w", err)
+	}
+	if len(decoded) < minMasterKeySize {
+		return nil, fmt.Errorf("signing master key must decode to at least %d bytes, got %d", minMasterKeySize, len(decoded))
+	}
+
+	key := sha256.Sum256([]byte(masterKey))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
+
+// generateSealedKey создает ключ Ed25519 и возвращает открытый ключ и nonce вместе с зашифрованным seed
+// закрытого ключа
+func generateSealedKey(aead cipher.AEAD) (ed25519.PublicKey, []byte, error) {
+	publicKey, privateKey, err := ed25519.GenerateKey(rand.Reader)
+	if err != nil {
+		return nil, nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, nil, err
+	}
+
+	return publicKey, aead.Seal(nonce, nonce, privateKey.Seed(), publicKey), nil
+}
+
+// openSealedKey расшифровывает закрытый ключ. Открытый ключ служит дополнительными данными AEAD,
+// поэтому подмена открытого ключа в базе обнаруживается
+func openSealedKey(aead cipher.AEAD, publicKey, sealed []byte) (ed25519.PrivateKey, error) {
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return nil, errors.New("key is corrupted")
+	}
+
+	seed, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], publicKey)
+	if err != nil {
+		return nil, err
+	}
+	if len(seed) != ed25519.SeedSize {
+		return nil, errors.New("key is corrupted")
+	}
+
+	return ed25519.NewKeyFromSeed(seed), nil
+}
@@ -0,0 +1,261 @@
+package signer
+
+import (
+	"context"
+	"crypto/cipher"
+	"crypto/ed25519"
+	"encoding/base64"
+	"errors"
+	"fmt"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/utils"
+	"sync"
+	"time"
+)
+
+// tokenKeyGrace - сверх времени жизни access-токена выведенный ключ принимается и публикуется еще столько,
+// чтобы покрыть расхождение часов и кэши экземпляров, которые еще подписывают старым ключом
+const tokenKeyGrace = 5 * time.Minute
+
+// missingTokenKeyTTL - столько kid, не найденный в базе, считается отсутствующим без повторного запроса.
+// Не больше maxMissingTokenKeys таких kid хранится одновременно
+const (
+	missingTokenKeyTTL  = 30 * time.Second
+	maxMissingTokenKeys = 1024
+)
+
+// cachedTokenKey - открытый ключ из кэша и время его вывода из ротации
+type cachedTokenKey struct {
+	publicKey ed25519.PublicKey
+	retiredAt *time.Time
+}
+
+// activeTokenKey - ключ, которым экземпляр подписывает новые токены
+type activeTokenKey struct {
+	id         string
+	privateKey ed25519.PrivateKey
+	createdAt  time.Time
+}
+
+// Ed25519TokenKeys подписывает access-токены ключами Ed25519 (EdDSA) с ротацией через kid.
+// Ключи хранятся в базе, закрытые - зашифрованными мастер-ключом; ключи кэшируются в памяти
+type Ed25519TokenKeys struct {
+	keyRepo  interfaces.TokenKeyRepository
+	aead     cipher.AEAD
+	rotation time.Duration
+	tokenTTL time.Duration
+
+	mu          sync.RWMutex
+	active      *activeTokenKey
+	publicKeys  map[string]cachedTokenKey
+	missingKeys map[string]time.Time // kid, не найденные в базе, и время проверки
+}
+
+func NewEd25519TokenKeys(keyRepo interfaces.TokenKeyRepository, masterKey string, rotation, tokenTTL time.Duration) (*Ed25519TokenKeys, error) {
+	const op = "infrastructure.signer.NewEd25519TokenKeys"
+
+	aead, err := newAEAD(masterKey)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return &Ed25519TokenKeys{
+		keyRepo:     keyRepo,
+		aead:        aead,
+		rotation:    rotation,
+		tokenTTL:    tokenTTL,
+		publicKeys:  make(map[string]cachedTokenKey),
+		missingKeys: make(map[string]time.Time),
+	}, nil
+}
+
+// SigningKey возвращает kid и закрытый ключ для подписи нового токена. Если ключа еще нет
+// или срок его ротации прошел, ключ ротируется
+func (k *Ed25519TokenKeys) SigningKey(ctx context.Context) (string, ed25519.PrivateKey, error) {
+	const op = "infrastructure.signer.SigningKey"
+
+	k.mu.RLock()
+	active := k.active
+	k.mu.RUnlock()
+
+	if active == nil || k.rotationDue(active.createdAt) {
+		var err error
+		active, err = k.loadActive(ctx)
+		if err != nil {
+			return "", nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	return active.id, active.privateKey, nil
+}
+
+// PublicKey возвращает открытый ключ kid, если подписанные им токены еще могут быть действительны.
+// Неизвестный kid повторно ищется в базе не раньше чем через missingTokenKeyTTL
+// Кастомные ошибки: ErrTokenKeyNotFound
+func (k *Ed25519TokenKeys) PublicKey(ctx context.Context, keyID string) (ed25519.PublicKey, error) {
+	const op = "infrastructure.signer.PublicKey"
+
+	now := time.Now()
+	k.mu.RLock()
+	cached, ok := k.publicKeys[keyID]
+	checkedAt, missing := k.missingKeys[keyID]
+	k.mu.RUnlock()
+
+	if !ok && missing && now.Sub(checkedAt) < missingTokenKeyTTL {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrTokenKeyNotFound)
+	}
+
+	if !ok {
+		key, err := k.keyRepo.GetTokenKeyByID(ctx, keyID)
+		if errors.Is(err, domain.ErrTokenKeyNotFound) {
+			k.rememberMissing(keyID, now)
+		}
+		if err != nil {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if len(key.PublicKey) != ed25519.PublicKeySize {
+			return nil, fmt.Errorf("%s: invalid public key size %d", op, len(key.PublicKey))
+		}
+
+		cached = cachedTokenKey{publicKey: key.PublicKey, retiredAt: key.RetiredAt}
+		k.mu.Lock()
+		k.publicKeys[keyID] = cached
+		delete(k.missingKeys, keyID)
+		k.mu.Unlock()
+	}
+
+	if !k.published(cached.retiredAt, now) {
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrTokenKeyNotFound)
+	}
+
+	return cached.publicKey, nil
+}
+
+// rememberMissing запоминает, что kid не найден в базе. Если запомнено слишком много kid,
+// устаревшие забываются, а при переполнении набор очищается целиком
+func (k *Ed25519TokenKeys) rememberMissing(keyID string, now time.Time) {
+	k.mu.Lock()
+	defer k.mu.Unlock()
+
+	if len(k.missingKeys) >= maxMissingTokenKeys {
+		for id, checkedAt := range k.missingKeys {
+			if now.Sub(checkedAt) >= missingTokenKeyTTL {
+				delete(k.missingKeys, id)
+			}
+		}
+		if len(k.missingKeys) >= maxMissingTokenKeys {
+			clear(k.missingKeys)
+		}
+	}
+	k.missingKeys[keyID] = now
+}
+
+// JWKS возвращает открытые ключи, которыми могут быть подписаны действующие токены
+func (k *Ed25519TokenKeys) JWKS(ctx context.Context) (domain.JWKS, error) {
+	const op = "infrastructure.signer.JWKS"
+
+	// Активный ключ создается при первом обращении, поэтому набор не бывает пустым
+	if _, _, err := k.SigningKey(ctx); err != nil {
+		return domain.JWKS{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	keys, err := k.keyRepo.GetPublishedTokenKeys(ctx, time.Now().Add(-k.tokenTTL-tokenKeyGrace))
+	if err != nil {
+		return domain.JWKS{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	jwks := domain.JWKS{Keys: make([]domain.JWK, 0, len(keys))}
+	for _, key := range keys {
+		jwks.Keys = append(jwks.Keys, domain.JWK{
+			Kty: "OKP",
+			Crv: "Ed25519",
+			X:   base64.RawURLEncoding.EncodeToString(key.PublicKey),
+			Kid: key.ID,
+			Alg: "EdDSA",
+			Use: "sig",
+		})
+	}
+
+	return jwks, nil
+}
+
+// RotateKey ротирует ключ, если срок ротации прошел, и обновляет кэш: ключ, ротированный другим
+// экземпляром, подхватывается без ожидания собственного срока ротации
+func (k *Ed25519TokenKeys) RotateKey(ctx context.Context) error {
+	const op = "infrastructure.signer.RotateKey"
+
+	// Ключи без retiredAt перечитываются из базы: их мог вывести из ротации другой экземпляр
+	k.mu.Lock()
+	now := time.Now()
+	for keyID, cached := range k.publicKeys {
+		if cached.retiredAt == nil || !k.published(cached.retiredAt, now) {
+			delete(k.publicKeys, keyID)
+		}
+	}
+	k.mu.Unlock()
+
+	if _, err := k.loadActive(ctx); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// loadActive читает активный ключ из базы, ротируя его при необходимости, и кэширует его
+func (k *Ed25519TokenKeys) loadActive(ctx context.Context) (*activeTokenKey, error) {
+	key, err := k.keyRepo.GetActiveTokenKey(ctx)
+	if err != nil && !errors.Is(err, domain.ErrTokenKeyNotFound) {
+		return nil, err
+	}
+
+	if key == nil || k.rotationDue(key.CreatedAt) {
+		key, err = k.rotate(ctx)
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	privateKey, err := openSealedKey(k.aead, key.PublicKey, key.PrivateKey)
+	if err != nil {
+		return nil, fmt.Errorf("token key %s: %w", key.ID, err)
+	}
+
+	active := &activeTokenKey{id: key.ID, privateKey: privateKey, createdAt: key.CreatedAt}
+	k.mu.Lock()
+	k.active = active
+	k.publicKeys[key.ID] = cachedTokenKey{publicKey: key.PublicKey}
+	k.mu.Unlock()
+
+	return active, nil
+}
+
+// rotate создает новый ключ и делает его активным. Если ключ уже ротирован другим экземпляром,
+// возвращается его ключ
+func (k *Ed25519TokenKeys) rotate(ctx context.Context) (*domain.TokenKey, error) {
+	keyID, err := utils.NewToken(16)
+	if err != nil {
+		return nil, err
+	}
+
+	publicKey, sealed, err := generateSealedKey(k.aead)
+	if err != nil {
+		return nil, err
+	}
+
+	return k.keyRepo.RotateTokenKey(ctx, &domain.TokenKey{
+		ID:         keyID,
+		PublicKey:  publicKey,
+		PrivateKey: sealed,
+	}, time.Now().Add(-k.rotation))
+}
+
+// rotationDue проверяет, что ключ, созданный в createdAt, пора ротировать
+func (k *Ed25519TokenKeys) rotationDue(createdAt time.Time) bool {
+	return !createdAt.After(time.Now().Add(-k.rotation))
+}
+
+// published проверяет, что токены ключа, выведенного из ротации в retiredAt, еще могут быть действительны
+func (k *Ed25519TokenKeys) published(retiredAt *time.Time, now time.Time) bool {
+	return retiredAt == nil || retiredAt.Add(k.tokenTTL+tokenKeyGrace).After(now)
+}
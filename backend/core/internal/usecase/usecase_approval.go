@@ -1,242 +1,738 @@
-package usecase
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"log/slog"
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-	"service-core/pkg/logger/slogger"
-)
-
-type ApprovalUsecase struct {
-	approvalRepo interfaces.ApprovalRepository
-	fileService  interfaces.FileService
-	log          *slog.Logger
-}
-
-func NewApprovalUsecase(approvalRepo interfaces.ApprovalRepository, fileService interfaces.FileService, log *slog.Logger) *ApprovalUsecase {
-	return &ApprovalUsecase{
-		approvalRepo: approvalRepo,
-		fileService:  fileService,
-		log:          log,
-	}
-}
-
-// GetApprovalsByUserID получает все Approvals для пользователя через Workflow
-func (u *ApprovalUsecase) GetApprovalsByUserID(ctx context.Context, userID uint) ([]domain.ApprovalResponse, error) {
-	const op = "usecase.approval.GetApprovalsByUserID"
-
-	log := u.log.With(slog.String("op", op), slog.Any("user_id", userID))
-	log.Info("getting user approvals")
-
-	approvals, err := u.approvalRepo.FindApprovalsByUser(ctx, userID)
-	if err != nil {
-		log.Error("failed to get approvals", slogger.Err(err))
-		return []domain.ApprovalResponse{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	var fileIDs []uint32
-	fileIDSet := make(map[uint32]struct{})
-	for _, a := range approvals {
-		fileID := uint32(a.FileID)
-		if _, exists := fileIDSet[fileID]; !exists {
-			fileIDSet[fileID] = struct{}{}
-			fileIDs = append(fileIDs, fileID)
-		}
-	}
-
-	// Получаем имена файлов из файлового сервиса
-	log.Debug("getting file names")
-	fileNames, err := u.fileService.GetFilesInfo(ctx, fileIDs)
-	if err != nil {
-		log.Error("failed to get file names from file service", slogger.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	// Обновляем fileName в каждом approval
-	for i := range approvals {
-		fileID := uint32(approvals[i].FileID)
-		if name, exists := fileNames[fileID]; exists {
-			approvals[i].FileName = name
-		} else {
-			log.Warn("file not found in file service", slog.Uint64("file_id", uint64(fileID)))
-			approvals[i].FileName = "unknown" // Или пропустить такие записи
-		}
-	}
-
-	log.Info("approvals got successfully")
-
-	return approvals, nil
-}
-
-// ApproveFile создает новую сущность Approval и обновляет статус файла на "approving"
-// Кастомные ошибки: ErrInvalidFileStatus, ErrFileNotFound
-func (u *ApprovalUsecase) ApproveFile(ctx context.Context, fileID uint) error {
-	const op = "usecase.approval.ApproveFile"
-
-	log := u.log.With(slog.String("op", op), slog.Any("file_id", fileID))
-	log.Info("starting file approval process")
-
-	log.Debug("fetching file with directory from file service")
-	file, err := u.fileService.GetFileWithDirectory(ctx, fileID)
-	if err != nil {
-		if errors.Is(err, domain.ErrFileNotFound) {
-			log.Error("file not found", slogger.Err(err))
-			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
-		}
-		log.Error("failed to get file", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking file status", slog.String("current_status", file.Status))
-	if file.Status != "draft" {
-		log.Warn("invalid file status for approval", slog.String("status", file.Status))
-		return fmt.Errorf("%s: %w", op, domain.ErrInvalidFileStatus)
-	}
-
-	log.Debug("creating approval entity")
-	approval := &domain.Approval{
-		FileID:        file.ID,
-		Status:        "on approval",
-		WorkflowID:    file.Directory.WorkflowID,
-		WorkflowOrder: 1,
-	}
-	if err := u.approvalRepo.CreateApproval(ctx, approval); err != nil {
-		log.Error("failed to create approval", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("updating file status")
-	err = u.fileService.UpdateFileStatus(ctx, fileID, "approving")
-	if err != nil {
-		if errors.Is(err, domain.ErrFileNotFound) {
-			log.Error("file not found", slogger.Err(err))
-			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
-		}
-		log.Error("failed to get file", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("file approval process completed successfully")
-	return nil
-}
-
-// SignApproval изменяет order в сущности Approval на +1
-// Кастомные ошибки: ErrNoPermission, ErrApprovalNotFound
-func (u *ApprovalUsecase) SignApproval(ctx context.Context, approvalID, userID uint) error {
-	const op = "usecase.approval.SignApproval"
-	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
-	log.Info("starting approval signing")
-
-	log.Debug("checking if user is last in workflow")
-	approval, err := u.approvalRepo.IsLastUserInWorkflow(ctx, approvalID, userID)
-	if err != nil {
-		log.Error("failed to check workflow position", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if approval.ID != 0 {
-		log.Error("expected finalize but got sign", slogger.Err(domain.ErrNoPermission))
-		return fmt.Errorf("%s: %w", op, domain.ErrNoPermission)
-	}
-
-	log.Debug("checking user permissions")
-	_, err = u.approvalRepo.CheckUserPermission(ctx, approvalID, userID)
-	if err != nil {
-		if errors.Is(err, domain.ErrApprovalNotFound) {
-			log.Error("permission check failed", slogger.Err(domain.ErrApprovalNotFound))
-			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
-		}
-		log.Error("permission check failed", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("incrementing approval order")
-	if err := u.approvalRepo.IncrementApprovalOrder(ctx, approvalID); err != nil {
-		log.Error("failed to increment approval order", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("approval signed successfully")
-	return nil
-}
-
-// AnnotateApproval меняет статус и сообщение в сущности Approval и возвращает файлу статус "draft"
-// Кастомные ошибки: ErrNoPermission, ErrApprovalNotFound
-func (u *ApprovalUsecase) AnnotateApproval(ctx context.Context, approvalID, userID uint, message string) error {
-	const op = "usecase.approval.AnnotateApproval"
-
-	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
-	log.Info("adding annotation to approval")
-
-	log.Debug("checking user permissions")
-	approval, err := u.approvalRepo.CheckUserPermission(ctx, approvalID, userID)
-	if err != nil {
-		if errors.Is(err, domain.ErrApprovalNotFound) {
-			log.Error("permission check failed", slogger.Err(domain.ErrApprovalNotFound))
-			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
-		}
-		log.Error("permission check failed", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("updating approval")
-	if err := u.approvalRepo.AnnotateApproval(ctx, approvalID, message); err != nil {
-		log.Error("annotation update failed", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("updating file status")
-	err = u.fileService.UpdateFileStatus(ctx, approval.FileID, "draft")
-	if err != nil {
-		if errors.Is(err, domain.ErrFileNotFound) {
-			log.Error("file not found", slogger.Err(err))
-			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
-		}
-		log.Error("failed to get file", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("annotation added successfully")
-	return nil
-}
-
-// FinalizeApproval меняет статус сущностей Approval и File на approved
-// Кастомные ошибки: ErrNoPermission
-func (u *ApprovalUsecase) FinalizeApproval(ctx context.Context, approvalID, userID uint) error {
-	const op = "usecase.approval.FinalizeApproval"
-	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
-	log.Info("finalizing approval")
-
-	log.Debug("checking if user is last in workflow")
-	approval, err := u.approvalRepo.IsLastUserInWorkflow(ctx, approvalID, userID)
-	if err != nil {
-		log.Error("workflow position check failed", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if approval.ID == 0 {
-		log.Error("cannot finalize this approval", slogger.Err(domain.ErrNoPermission))
-		return fmt.Errorf("%s: %w", op, domain.ErrNoPermission)
-	}
-
-	log.Debug("finalizing approval status")
-	if err := u.approvalRepo.FinalizeApproval(ctx, approvalID); err != nil {
-		log.Error("finalization failed", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("updating file status")
-	err = u.fileService.UpdateFileStatus(ctx, approval.FileID, "approved")
-	if err != nil {
-		if errors.Is(err, domain.ErrFileNotFound) {
-			log.Error("file not found", slogger.Err(err))
-			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
-		}
-		log.Error("failed to get file", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("approval finalized successfully")
-	return nil
-}
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/logger/slogger"
+	"strings"
+	"time"
+)
+
+type ApprovalUsecase struct {
+	approvalRepo interfaces.ApprovalRepository
+	userRepo     interfaces.UserRepository
+	fileService  interfaces.FileService
+	routing      interfaces.RoutingUsecase
+	outbox       interfaces.OutboxUsecase
+	signer       interfaces.DecisionSigner
+	authorizer   interfaces.Authorizer
+	log          *slog.Logger
+}
+
+func NewApprovalUsecase(
+	approvalRepo interfaces.ApprovalRepository,
+	userRepo interfaces.UserRepository,
+	fileService interfaces.FileService,
+	routing interfaces.RoutingUsecase,
+	outbox interfaces.OutboxUsecase,
+	signer interfaces.DecisionSigner,
+	authorizer interfaces.Authorizer,
+	log *slog.Logger,
+) *ApprovalUsecase {
+	return &ApprovalUsecase{
+		approvalRepo: approvalRepo,
+		userRepo:     userRepo,
+		fileService:  fileService,
+		routing:      routing,
+		outbox:       outbox,
+		signer:       signer,
+		authorizer:   authorizer,
+		log:          log,
+	}
+}
+
+// inboxMaxLimit - максимальный размер страницы списка согласований пользователя
+const inboxMaxLimit = 500
+
+// inboxMaxFilterFiles - максимальное число файлов, подходящих под фильтры по директории и имени.
+// ID файлов передаются в запрос к базе параметрами, их число ограничено
+const inboxMaxFilterFiles = 10000
+
+// GetApprovalsByUserID получает Approvals раздела filter.Status списка согласований пользователя
+// с фильтрами filter. Если после страницы есть еще Approvals, возвращает курсор следующей страницы
+// Кастомные ошибки: ErrInvalidFilter, ErrInvalidCursor, ErrFilterTooBroad, ErrDirectoryNotFound
+func (u *ApprovalUsecase) GetApprovalsByUserID(ctx context.Context, userID uint, filter domain.ApprovalFilter) ([]domain.ApprovalResponse, string, error) {
+	const op = "usecase.approval.GetApprovalsByUserID"
+
+	log := u.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("getting user approvals")
+
+	filter, err := u.prepareFilter(ctx, filter)
+	if err != nil {
+		log.Error("invalid approvals filter", slogger.Err(err))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Запрашиваем на одно Approval больше, чтобы узнать, есть ли следующая страница
+	if filter.Limit > 0 {
+		filter.Limit++
+	}
+
+	log.Debug("finding approvals")
+	approvals, err := u.approvalRepo.FindApprovalsByUser(ctx, userID, filter)
+	if err != nil {
+		log.Error("failed to get approvals", slogger.Err(err))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	var nextCursor string
+	if filter.Limit > 0 && len(approvals) == filter.Limit {
+		approvals = approvals[:filter.Limit-1]
+		nextCursor = domain.NewApprovalCursor(filter.Sort, approvals[len(approvals)-1]).Encode()
+	}
+
+	refs := make([]*domain.ApprovalResponse, len(approvals))
+	for i := range approvals {
+		refs[i] = &approvals[i]
+	}
+	if err := u.setFileNames(ctx, log, refs); err != nil {
+		log.Error("failed to get file names from file service", slogger.Err(err))
+		return nil, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("approvals got successfully")
+
+	return approvals, nextCursor, nil
+}
+
+// CountApprovalsByUserID считает Approvals каждого раздела списка согласований пользователя
+// с фильтрами filter. Раздел, сортировка и страница filter не учитываются
+// Кастомные ошибки: ErrInvalidFilter, ErrFilterTooBroad, ErrDirectoryNotFound
+func (u *ApprovalUsecase) CountApprovalsByUserID(ctx context.Context, userID uint, filter domain.ApprovalFilter) (domain.ApprovalCounts, error) {
+	const op = "usecase.approval.CountApprovalsByUserID"
+
+	log := u.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("counting user approvals")
+
+	filter.Status, filter.Sort, filter.Cursor, filter.Limit = "", "", "", 0
+	filter, err := u.prepareFilter(ctx, filter)
+	if err != nil {
+		log.Error("invalid approvals filter", slogger.Err(err))
+		return domain.ApprovalCounts{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("counting approvals")
+	counts, err := u.approvalRepo.CountApprovalsByUser(ctx, userID, filter)
+	if err != nil {
+		log.Error("failed to count approvals", slogger.Err(err))
+		return domain.ApprovalCounts{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("approvals counted successfully")
+
+	return counts, nil
+}
+
+// prepareFilter проверяет фильтр списка согласований, подставляет раздел и сортировку по умолчанию,
+// разбирает курсор и находит в file-service файлы, подходящие под фильтры по директории и имени.
+// Если таких файлов больше inboxMaxFilterFiles, возвращает ErrFilterTooBroad
+func (u *ApprovalUsecase) prepareFilter(ctx context.Context, filter domain.ApprovalFilter) (domain.ApprovalFilter, error) {
+	if filter.Status == "" {
+		filter.Status = domain.InboxStatusPending
+	}
+	switch filter.Status {
+	case domain.InboxStatusPending, domain.InboxStatusSigned, domain.InboxStatusFinalized, domain.InboxStatusAnnotated:
+	default:
+		return filter, domain.ErrInvalidFilter
+	}
+
+	if filter.Sort == "" {
+		filter.Sort = domain.ApprovalSortNewest
+	}
+	switch filter.Sort {
+	case domain.ApprovalSortNewest, domain.ApprovalSortOldest, domain.ApprovalSortDue:
+	default:
+		return filter, domain.ErrInvalidFilter
+	}
+
+	if filter.Limit < 0 || filter.Limit > inboxMaxLimit {
+		return filter, domain.ErrInvalidFilter
+	}
+	if !filter.From.IsZero() && !filter.To.IsZero() && !filter.To.After(filter.From) {
+		return filter, domain.ErrInvalidFilter
+	}
+
+	if filter.Cursor != "" {
+		cursor, err := domain.ParseApprovalCursor(filter.Cursor)
+		if err != nil {
+			return filter, err
+		}
+		// Курсор действителен только в той сортировке, в которой был выдан
+		if cursor.Sort != filter.Sort {
+			return filter, domain.ErrInvalidCursor
+		}
+		filter.After = &cursor
+	}
+
+	filter.Search = strings.TrimSpace(filter.Search)
+	if filter.DirectoryID != 0 || filter.Search != "" {
+		fileIDs, err := u.fileService.FindFiles(ctx, filter.DirectoryID, filter.Search, inboxMaxFilterFiles+1)
+		if err != nil {
+			return filter, err
+		}
+		if len(fileIDs) > inboxMaxFilterFiles {
+			return filter, domain.ErrFilterTooBroad
+		}
+		filter.FileIDs = fileIDs
+	}
+
+	return filter, nil
+}
+
+// setFileNames заполняет имена файлов Approvals по данным файлового сервиса
+func (u *ApprovalUsecase) setFileNames(ctx context.Context, log *slog.Logger, approvals []*domain.ApprovalResponse) error {
+	var fileIDs []uint32
+	fileIDSet := make(map[uint32]struct{})
+	for _, a := range approvals {
+		if a.TransmittalID != 0 {
+			continue
+		}
+		fileID := uint32(a.FileID)
+		if _, exists := fileIDSet[fileID]; !exists {
+			fileIDSet[fileID] = struct{}{}
+			fileIDs = append(fileIDs, fileID)
+		}
+	}
+
+	// Получаем имена файлов из файлового сервиса
+	log.Debug("getting file names")
+	fileNames, err := u.fileService.GetFilesInfo(ctx, fileIDs)
+	if err != nil {
+		return err
+	}
+
+	// Обновляем fileName в каждом approval
+	for _, a := range approvals {
+		// Approval комплекта не относится к одному файлу
+		if a.TransmittalID != 0 {
+			continue
+		}
+		fileID := uint32(a.FileID)
+		if name, exists := fileNames[fileID]; exists {
+			a.FileName = name
+		} else {
+			log.Warn("file not found in file service", slog.Uint64("file_id", uint64(fileID)))
+			a.FileName = "unknown" // Или пропустить такие записи
+		}
+	}
+
+	return nil
+}
+
+// ApproveFile создает новую сущность Approval и обновляет статус файла на "approving".
+// Если по файлу ранее были запрошены изменения, то вместо нового Approval продолжается прежнее
+// с того этапа, на котором были запрошены изменения. Для этого версия файла должна быть обновлена.
+// Процедуру и пропускаемые этапы нового Approval выбирают правила маршрутизации.
+// Отправить файл может пользователь с уровнем доступа approve к нему
+// Кастомные ошибки: ErrAccessDenied, ErrInvalidFileStatus, ErrFileNotFound, ErrFileNotUpdated, ErrWorkflowNotFound, ErrEmptyRoute
+func (u *ApprovalUsecase) ApproveFile(ctx context.Context, fileID, userID uint) error {
+	const op = "usecase.approval.ApproveFile"
+
+	log := u.log.With(slog.String("op", op), slog.Any("file_id", fileID), slog.Any("user_id", userID))
+	log.Info("starting file approval process")
+
+	log.Debug("fetching file with directory from file service")
+	file, err := u.fileService.GetFileWithDirectory(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			log.Error("file not found", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		}
+		log.Error("failed to get file", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking file access level")
+	if err := checkApproveAccess(ctx, u.fileService, userID, []uint{fileID}); err != nil {
+		log.Warn("failed file access check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if _, _, err := u.submitFile(ctx, file, userID); err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("dispatching file status")
+	if err := u.outbox.DispatchFile(ctx, fileID); err != nil {
+		log.Warn("file status delivery postponed", slogger.Err(err))
+	}
+
+	log.Info("file approval process completed successfully")
+	return nil
+}
+
+// submitFile отправляет файл на согласование: продолжает Approval, по которому были запрошены
+// изменения, или создает новое по маршруту, выбранному правилами маршрутизации.
+// Возвращает ID Approval и выполненное действие (ApprovalActionSubmit или ApprovalActionResubmit).
+// Уровень доступа approve к файлу проверяет вызывающий, один раз для всех отправляемых файлов
+// Кастомные ошибки: ErrInvalidFileStatus, ErrFileNotUpdated, ErrWorkflowNotFound, ErrEmptyRoute
+func (u *ApprovalUsecase) submitFile(ctx context.Context, file *domain.File, userID uint) (approvalID uint, action string, err error) {
+	const op = "usecase.approval.submitFile"
+
+	log := u.log.With(slog.String("op", op), slog.Any("file_id", file.ID), slog.Any("user_id", userID))
+
+	log.Debug("checking file status", slog.String("current_status", file.Status))
+	if file.Status != "draft" {
+		log.Warn("invalid file status for approval", slog.String("status", file.Status))
+		return 0, "", fmt.Errorf("%s: %w", op, domain.ErrInvalidFileStatus)
+	}
+
+	log.Debug("checking for approval waiting for resubmission")
+	annotated, err := u.approvalRepo.FindAnnotatedApproval(ctx, file.ID)
+	if err != nil {
+		log.Error("failed to find annotated approval", slogger.Err(err))
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	if annotated.ID != 0 {
+		log.Debug("resuming approval", slog.Any("approval_id", annotated.ID), slog.Int("workflow_order", annotated.WorkflowOrder))
+		if file.Version <= annotated.FileVersion {
+			log.Warn("file was not updated since changes were requested", slog.Int("version", file.Version))
+			return 0, "", fmt.Errorf("%s: %w", op, domain.ErrFileNotUpdated)
+		}
+
+		if err := u.approvalRepo.ResumeApproval(ctx, annotated.ID, userID, file.Version); err != nil {
+			log.Error("failed to resume approval", slogger.Err(err))
+			return 0, "", fmt.Errorf("%s: %w", op, err)
+		}
+		return annotated.ID, domain.ApprovalActionResubmit, nil
+	}
+
+	log.Debug("resolving approval route")
+	route, err := u.routing.ResolveRoute(ctx, file)
+	if err != nil {
+		log.Error("failed to resolve approval route", slogger.Err(err))
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("creating approval entity", slog.Any("workflow_id", route.WorkflowID), slog.Any("routing_rule_id", route.RuleID))
+	approval := &domain.Approval{
+		FileID:        file.ID,
+		Status:        "on approval",
+		WorkflowID:    route.WorkflowID,
+		WorkflowOrder: 1,
+		FileVersion:   file.Version,
+		SubmitterID:   userID,
+		RoutingRuleID: route.RuleID,
+
+		StageStartedAt: time.Now(),
+	}
+	if err := u.approvalRepo.CreateApproval(ctx, approval, route.SkipOrders, userID); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrWorkflowNotFound):
+			log.Error("route workflow not found", slogger.Err(err))
+			return 0, "", fmt.Errorf("%s: %w", op, domain.ErrWorkflowNotFound)
+		case errors.Is(err, domain.ErrEmptyRoute):
+			log.Error("route has no stages", slogger.Err(err))
+			return 0, "", fmt.Errorf("%s: %w", op, domain.ErrEmptyRoute)
+		}
+		log.Error("failed to create approval", slogger.Err(err))
+		return 0, "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	return approval.ID, domain.ApprovalActionSubmit, nil
+}
+
+// SignApproval добавляет подпись пользователя на текущем этапе. Когда правило этапа
+// выполнено, Approval переходит на следующий этап. Решение подписывается ключом пользователя
+// вместе с хэшем содержимого согласуемой версии
+// Кастомные ошибки: ErrNoPermission, ErrApprovalNotFound, ErrAlreadySigned, ErrRoleSlotSigned
+func (u *ApprovalUsecase) SignApproval(ctx context.Context, approvalID, userID uint) error {
+	const op = "usecase.approval.SignApproval"
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
+	log.Info("starting approval signing")
+
+	log.Debug("checking if user is last in workflow")
+	approval, err := u.approvalRepo.IsLastUserInWorkflow(ctx, approvalID, userID)
+	if err != nil {
+		log.Error("failed to check workflow position", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if approval.ID != 0 {
+		log.Error("expected finalize but got sign", slogger.Err(domain.ErrNoPermission))
+		return fmt.Errorf("%s: %w", op, domain.ErrNoPermission)
+	}
+
+	log.Debug("checking user permissions")
+	approval, err = u.approvalRepo.CheckUserPermission(ctx, approvalID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			log.Error("permission check failed", slogger.Err(domain.ErrApprovalNotFound))
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("permission check failed", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting current workflow stage", slog.Int("workflow_order", approval.WorkflowOrder))
+	stage, err := u.approvalRepo.GetApprovalStage(ctx, approval.ID, approval.WorkflowOrder)
+	if err != nil {
+		log.Error("failed to get workflow stage", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("hashing approved content")
+	seal, err := u.sealer(ctx, approval, userID)
+	if err != nil {
+		log.Error("failed to prepare decision signature", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("signing workflow stage", slog.String("rule", stage.Rule), slog.Int("required", stage.RequiredSignatures()))
+	completed, err := u.approvalRepo.SignStage(ctx, approvalID, userID, approval.WorkflowOrder, stage.RequiredSignatures(), false, seal)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAlreadySigned):
+			log.Warn("user has already signed this stage", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrAlreadySigned)
+		case errors.Is(err, domain.ErrRoleSlotSigned):
+			log.Warn("role has already signed this stage", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrRoleSlotSigned)
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			log.Error("approval moved to another stage", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("failed to sign workflow stage", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("approval signed successfully", slog.Bool("stage_completed", completed))
+	return nil
+}
+
+// AnnotateApproval запрашивает изменения: меняет статус и сообщение в сущности Approval и возвращает
+// файлу статус "draft". После обновления файла согласование продолжится с текущего этапа
+// Кастомные ошибки: ErrNoPermission, ErrApprovalNotFound
+func (u *ApprovalUsecase) AnnotateApproval(ctx context.Context, approvalID, userID uint, message string) error {
+	const op = "usecase.approval.AnnotateApproval"
+
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
+	log.Info("adding annotation to approval")
+
+	log.Debug("checking user permissions")
+	approval, err := u.approvalRepo.CheckUserPermission(ctx, approvalID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			log.Error("permission check failed", slogger.Err(domain.ErrApprovalNotFound))
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("permission check failed", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("updating approval")
+	if err := u.approvalRepo.AnnotateApproval(ctx, approvalID, userID, message); err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			log.Error("approval is not on approval anymore", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("annotation update failed", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("dispatching file status")
+	if err := u.dispatchStatus(ctx, approval); err != nil {
+		log.Warn("file status delivery postponed", slogger.Err(err))
+	}
+
+	log.Info("annotation added successfully")
+	return nil
+}
+
+// RejectApproval окончательно отклоняет согласование: Approval и File получают статус "rejected",
+// повторная отправка файла на согласование невозможна
+// Кастомные ошибки: ErrNoPermission, ErrApprovalNotFound
+func (u *ApprovalUsecase) RejectApproval(ctx context.Context, approvalID, userID uint, message string) error {
+	const op = "usecase.approval.RejectApproval"
+
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
+	log.Info("rejecting approval")
+
+	log.Debug("checking user permissions")
+	approval, err := u.approvalRepo.CheckUserPermission(ctx, approvalID, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			// CheckUserPermission не различает отсутствующее согласование и пользователя вне текущего этапа
+			existing, getErr := u.approvalRepo.GetApprovalByID(ctx, approvalID)
+			if getErr == nil && existing.Status == "on approval" {
+				log.Error("user is not a participant of the current stage", slogger.Err(domain.ErrNoPermission))
+				return fmt.Errorf("%s: %w", op, domain.ErrNoPermission)
+			}
+			if getErr != nil && !errors.Is(getErr, domain.ErrApprovalNotFound) {
+				log.Error("failed to get approval", slogger.Err(getErr))
+				return fmt.Errorf("%s: %w", op, getErr)
+			}
+			log.Error("permission check failed", slogger.Err(domain.ErrApprovalNotFound))
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("permission check failed", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("updating approval")
+	if err := u.approvalRepo.RejectApproval(ctx, approvalID, userID, message); err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			log.Error("approval is not on approval anymore", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("rejection failed", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("dispatching file status")
+	if err := u.dispatchStatus(ctx, approval); err != nil {
+		log.Warn("file status delivery postponed", slogger.Err(err))
+	}
+
+	log.Info("approval rejected successfully")
+	return nil
+}
+
+// WithdrawApproval отзывает незавершённое согласование: Approval получает статус "withdrawn",
+// файл возвращается в статус "draft" и пропадает из очередей подписантов. Отозвать согласование
+// может пользователь, отправивший файл, или пользователь с разрешением approval.finalize.any
+// Кастомные ошибки: ErrApprovalNotFound, ErrAccessDenied
+func (u *ApprovalUsecase) WithdrawApproval(ctx context.Context, approvalID, userID uint) error {
+	const op = "usecase.approval.WithdrawApproval"
+
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
+	log.Info("withdrawing approval")
+
+	log.Debug("getting approval")
+	approval, err := u.approvalRepo.GetApprovalByID(ctx, approvalID)
+	if err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			log.Error("approval not found", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("failed to get approval", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if approval.SubmitterID != userID {
+		log.Debug("checking user permission")
+		if err := u.authorizer.Authorize(ctx, userID, domain.PermissionApprovalFinalizeAny); err != nil {
+			log.Error("failed permission check", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("updating approval")
+	if err := u.approvalRepo.WithdrawApproval(ctx, approvalID, userID); err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			log.Error("approval is already completed", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("withdrawal failed", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("dispatching file status")
+	if err := u.dispatchStatus(ctx, approval); err != nil {
+		log.Warn("file status delivery postponed", slogger.Err(err))
+	}
+
+	log.Info("approval withdrawn successfully")
+	return nil
+}
+
+// FinalizeApproval добавляет подпись на последнем этапе. Когда правило последнего этапа
+// выполнено, меняет статус сущностей Approval и File на approved. Решение подписывается так же,
+// как в SignApproval. Если процедура требует разрешать обсуждения, завершение невозможно, пока
+// в согласовании есть неразрешенные обсуждения
+// Кастомные ошибки: ErrNoPermission, ErrApprovalNotFound, ErrAlreadySigned, ErrRoleSlotSigned, ErrUnresolvedComments
+func (u *ApprovalUsecase) FinalizeApproval(ctx context.Context, approvalID, userID uint) error {
+	const op = "usecase.approval.FinalizeApproval"
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
+	log.Info("finalizing approval")
+
+	log.Debug("checking if user is last in workflow")
+	approval, err := u.approvalRepo.IsLastUserInWorkflow(ctx, approvalID, userID)
+	if err != nil {
+		log.Error("workflow position check failed", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if approval.ID == 0 {
+		log.Error("cannot finalize this approval", slogger.Err(domain.ErrNoPermission))
+		return fmt.Errorf("%s: %w", op, domain.ErrNoPermission)
+	}
+
+	log.Debug("checking unresolved comments")
+	unresolved, err := u.approvalRepo.CountBlockingComments(ctx, approvalID)
+	if err != nil {
+		log.Error("failed to count unresolved comments", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if unresolved > 0 {
+		log.Warn("approval has unresolved comments", slog.Int64("unresolved", unresolved))
+		return fmt.Errorf("%s: %w", op, domain.ErrUnresolvedComments)
+	}
+
+	log.Debug("getting last workflow stage", slog.Int("workflow_order", approval.WorkflowOrder))
+	stage, err := u.approvalRepo.GetApprovalStage(ctx, approval.ID, approval.WorkflowOrder)
+	if err != nil {
+		log.Error("failed to get workflow stage", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("hashing approved content")
+	seal, err := u.sealer(ctx, approval, userID)
+	if err != nil {
+		log.Error("failed to prepare decision signature", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("signing last workflow stage", slog.String("rule", stage.Rule), slog.Int("required", stage.RequiredSignatures()))
+	completed, err := u.approvalRepo.SignStage(ctx, approvalID, userID, approval.WorkflowOrder, stage.RequiredSignatures(), true, seal)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrAlreadySigned):
+			log.Warn("user has already signed this stage", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrAlreadySigned)
+		case errors.Is(err, domain.ErrRoleSlotSigned):
+			log.Warn("role has already signed this stage", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrRoleSlotSigned)
+		case errors.Is(err, domain.ErrApprovalNotFound):
+			log.Error("approval is not on approval anymore", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("failed to sign workflow stage", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !completed {
+		log.Info("signature added, waiting for other signers of the last stage")
+		return nil
+	}
+
+	log.Debug("dispatching file status")
+	if err := u.dispatchStatus(ctx, approval); err != nil {
+		log.Warn("file status delivery postponed", slogger.Err(err))
+	}
+
+	log.Info("approval finalized successfully")
+	return nil
+}
+
+// GetApprovalHistory возвращает журнал действий по Approval. Журнал доступен участникам
+// согласования и пользователям с разрешением approval.view.any
+// Кастомные ошибки: ErrApprovalNotFound, ErrAccessDenied
+func (u *ApprovalUsecase) GetApprovalHistory(ctx context.Context, approvalID, userID uint) ([]domain.ApprovalEventResponse, error) {
+	const op = "usecase.approval.GetApprovalHistory"
+
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
+	log.Info("getting approval history")
+
+	log.Debug("checking approval existence")
+	if _, err := u.approvalRepo.GetApprovalByID(ctx, approvalID); err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			log.Error("approval not found", slogger.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("failed to get approval", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking history access")
+	if err := u.checkHistoryAccess(ctx, userID, []uint{approvalID}); err != nil {
+		log.Error("failed history access check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting approval events")
+	events, err := u.approvalRepo.GetApprovalHistory(ctx, approvalID)
+	if err != nil {
+		log.Error("failed to get approval history", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("approval history got successfully")
+	return events, nil
+}
+
+// GetFileHistory возвращает журнал действий по всем Approvals файла. Журнал доступен участникам
+// хотя бы одного согласования файла и пользователям с разрешением approval.view.any
+// Кастомные ошибки: ErrAccessDenied
+func (u *ApprovalUsecase) GetFileHistory(ctx context.Context, fileID, userID uint) ([]domain.ApprovalEventResponse, error) {
+	const op = "usecase.approval.GetFileHistory"
+
+	log := u.log.With(slog.String("op", op), slog.Any("file_id", fileID), slog.Any("user_id", userID))
+	log.Info("getting file approval history")
+
+	log.Debug("getting file approval events")
+	events, err := u.approvalRepo.GetFileHistory(ctx, fileID)
+	if err != nil {
+		log.Error("failed to get file history", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(events) == 0 {
+		log.Info("file has no approval history")
+		return []domain.ApprovalEventResponse{}, nil
+	}
+
+	approvalIDs := make([]uint, 0, len(events))
+	approvalSet := make(map[uint]struct{})
+	for _, event := range events {
+		if _, exists := approvalSet[event.ApprovalID]; !exists {
+			approvalSet[event.ApprovalID] = struct{}{}
+			approvalIDs = append(approvalIDs, event.ApprovalID)
+		}
+	}
+
+	log.Debug("checking history access")
+	if err := u.checkHistoryAccess(ctx, userID, approvalIDs); err != nil {
+		log.Error("failed history access check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("file approval history got successfully")
+	return events, nil
+}
+
+func (u *ApprovalUsecase) checkHistoryAccess(ctx context.Context, userID uint, approvalIDs []uint) error {
+	err := u.authorizer.Authorize(ctx, userID, domain.PermissionApprovalViewAny)
+	if !errors.Is(err, domain.ErrAccessDenied) {
+		return err
+	}
+
+	participant, err := u.approvalRepo.CheckApprovalParticipant(ctx, userID, approvalIDs)
+	if err != nil {
+		return err
+	}
+	if !participant {
+		return domain.ErrAccessDenied
+	}
+	return nil
+}
+
+// dispatchStatus сразу доставляет в file-service статусы файлов, записанные в outbox вместе с переходом
+// Approval, поэтому ошибка доставки не отменяет переход: если file-service недоступен, изменения доставит
+// диспетчер. Статусы файлов комплекта доставляются общим проходом диспетчера
+func (u *ApprovalUsecase) dispatchStatus(ctx context.Context, approval *domain.Approval) error {
+	if approval.TransmittalID != 0 {
+		return u.outbox.DispatchPending(ctx)
+	}
+	return u.outbox.DispatchFile(ctx, approval.FileID)
+}
+
+// checkApproveAccess проверяет одним запросом к file-service, что у пользователя есть уровень доступа
+// approve ко всем файлам fileIDs
+// Кастомные ошибки: ErrAccessDenied
+func checkApproveAccess(ctx context.Context, fileService interfaces.FileService, userID uint, fileIDs []uint) error {
+	ids := make([]uint32, len(fileIDs))
+	for i, fileID := range fileIDs {
+		ids[i] = uint32(fileID)
+	}
+
+	allowed, err := fileService.CheckFileAccess(ctx, userID, ids, domain.AccessLevelApprove)
+	if err != nil {
+		return err
+	}
+	if !allowed {
+		return domain.ErrAccessDenied
+	}
+	return nil
+}
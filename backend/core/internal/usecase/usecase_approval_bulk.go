@@ -0,0 +1,197 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/pkg/logger/slogger"
+)
+
+const (
+	bulkMaxItems      = 500 // максимум файлов или согласований в одном пакетном запросе
+	bulkFileBatchSize = 100 // столько файлов запрашивается у file-service за один вызов
+)
+
+// SubmitDirectory отправляет на согласование все черновики директории и вложенных директорий.
+// Метаданные файлов запрашиваются у file-service пакетами, ошибка по одному файлу не прерывает
+// обработку остальных и возвращается в результате этого файла. Уровень доступа approve нужен ко всем
+// черновикам и проверяется одним запросом до отправки
+// Кастомные ошибки: ErrDirectoryNotFound, ErrBulkLimitExceeded, ErrAccessDenied
+func (u *ApprovalUsecase) SubmitDirectory(ctx context.Context, directoryID, userID uint) (domain.BulkResult, error) {
+	const op = "usecase.approval.SubmitDirectory"
+
+	log := u.log.With(slog.String("op", op), slog.Any("directory_id", directoryID), slog.Any("user_id", userID))
+	log.Info("submitting directory files")
+
+	log.Debug("getting draft files of directory subtree")
+	fileIDs, err := u.fileService.GetDirectoryFiles(ctx, directoryID, "draft")
+	if err != nil {
+		if errors.Is(err, domain.ErrDirectoryNotFound) {
+			log.Error("directory not found", slogger.Err(err))
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+		}
+		log.Error("failed to get directory files", slogger.Err(err))
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(fileIDs) > bulkMaxItems {
+		log.Warn("too many files in directory", slog.Int("files", len(fileIDs)))
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, domain.ErrBulkLimitExceeded)
+	}
+
+	log.Debug("checking access level to directory files")
+	ids := make([]uint, len(fileIDs))
+	for i, fileID := range fileIDs {
+		ids[i] = uint(fileID)
+	}
+	if err := checkApproveAccess(ctx, u.fileService, userID, ids); err != nil {
+		log.Warn("failed file access check", slogger.Err(err))
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	items := make([]domain.BulkItemResult, 0, len(fileIDs))
+	for start := 0; start < len(fileIDs); start += bulkFileBatchSize {
+		batch := fileIDs[start:min(start+bulkFileBatchSize, len(fileIDs))]
+
+		log.Debug("fetching files batch from file service", slog.Int("files", len(batch)))
+		files, err := u.fileService.GetFilesWithDirectory(ctx, batch)
+		if err != nil {
+			log.Error("failed to get files", slogger.Err(err))
+			return domain.BulkResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		filesByID := make(map[uint]*domain.File, len(files))
+		for i := range files {
+			filesByID[files[i].ID] = &files[i]
+		}
+
+		for _, fileID := range batch {
+			item := domain.BulkItemResult{FileID: uint(fileID)}
+
+			file, ok := filesByID[uint(fileID)]
+			if !ok {
+				item.Err = domain.ErrFileNotFound
+				items = append(items, item)
+				continue
+			}
+			item.FileName = file.Name
+
+			item.ApprovalID, item.Action, item.Err = u.submitFile(ctx, file, userID)
+			items = append(items, item)
+		}
+	}
+
+	// Статусы файлов записаны в outbox, их доставит фоновый диспетчер: запрос не ждет доставки
+	// всех накопившихся изменений
+	result := bulkResult(items)
+	log.Info("directory files submitted", slog.Int("succeeded", result.Succeeded), slog.Int("failed", result.Failed))
+	return result, nil
+}
+
+// SignApprovals подписывает список согласований. Если пользователь подписывает последний этап,
+// согласование завершается, как при FinalizeApproval. Ошибка по одному согласованию не прерывает
+// обработку остальных и возвращается в результате этого согласования
+// Кастомные ошибки: ErrBulkLimitExceeded
+func (u *ApprovalUsecase) SignApprovals(ctx context.Context, approvalIDs []uint, userID uint) (domain.BulkResult, error) {
+	const op = "usecase.approval.SignApprovals"
+
+	log := u.log.With(slog.String("op", op), slog.Any("user_id", userID), slog.Int("approvals", len(approvalIDs)))
+	log.Info("signing approvals")
+
+	if len(approvalIDs) > bulkMaxItems {
+		log.Warn("too many approvals in request")
+		return domain.BulkResult{}, fmt.Errorf("%s: %w", op, domain.ErrBulkLimitExceeded)
+	}
+
+	items := make([]domain.BulkItemResult, 0, len(approvalIDs))
+	seen := make(map[uint]struct{}, len(approvalIDs))
+	for _, approvalID := range approvalIDs {
+		if _, ok := seen[approvalID]; ok {
+			continue
+		}
+		seen[approvalID] = struct{}{}
+
+		items = append(items, u.signOne(ctx, approvalID, userID))
+	}
+
+	log.Debug("getting file names")
+	if err := u.fillFileNames(ctx, items); err != nil {
+		log.Warn("failed to get file names", slogger.Err(err))
+	}
+
+	result := bulkResult(items)
+	log.Info("approvals signed", slog.Int("succeeded", result.Succeeded), slog.Int("failed", result.Failed))
+	return result, nil
+}
+
+// signOne подписывает одно согласование пакета: последний этап завершается через FinalizeApproval,
+// остальные подписываются через SignApproval
+func (u *ApprovalUsecase) signOne(ctx context.Context, approvalID, userID uint) domain.BulkItemResult {
+	item := domain.BulkItemResult{ApprovalID: approvalID}
+
+	approval, err := u.approvalRepo.GetApprovalByID(ctx, approvalID)
+	if err != nil {
+		item.Err = err
+		return item
+	}
+	item.FileID = approval.FileID
+
+	last, err := u.approvalRepo.IsLastUserInWorkflow(ctx, approvalID, userID)
+	if err != nil {
+		item.Err = err
+		return item
+	}
+
+	if last.ID != 0 {
+		item.Action = domain.ApprovalActionFinalize
+		item.Err = u.FinalizeApproval(ctx, approvalID, userID)
+	} else {
+		item.Action = domain.ApprovalActionSign
+		item.Err = u.SignApproval(ctx, approvalID, userID)
+	}
+	if item.Err != nil {
+		item.Action = ""
+	}
+	return item
+}
+
+// fillFileNames заполняет имена файлов результатов, запрашивая их у file-service пакетами
+func (u *ApprovalUsecase) fillFileNames(ctx context.Context, items []domain.BulkItemResult) error {
+	var fileIDs []uint32
+	seen := make(map[uint]struct{}, len(items))
+	for _, item := range items {
+		if _, ok := seen[item.FileID]; ok || item.FileID == 0 {
+			continue
+		}
+		seen[item.FileID] = struct{}{}
+		fileIDs = append(fileIDs, uint32(item.FileID))
+	}
+
+	fileNames := make(map[uint32]string, len(fileIDs))
+	for start := 0; start < len(fileIDs); start += bulkFileBatchSize {
+		names, err := u.fileService.GetFilesInfo(ctx, fileIDs[start:min(start+bulkFileBatchSize, len(fileIDs))])
+		if err != nil {
+			return err
+		}
+		for fileID, name := range names {
+			fileNames[fileID] = name
+		}
+	}
+
+	for i := range items {
+		items[i].FileName = fileNames[uint32(items[i].FileID)]
+	}
+	return nil
+}
+
+func bulkResult(items []domain.BulkItemResult) domain.BulkResult {
+	result := domain.BulkResult{Items: items}
+	for _, item := range items {
+		if item.Err != nil {
+			result.Failed++
+		} else {
+			result.Succeeded++
+		}
+	}
+	return result
+}
@@ -0,0 +1,173 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/pkg/logger/slogger"
+)
+
+// VerifySignatures проверяет цепочку подписей решений Approval: связь каждой подписи с предыдущей,
+// подпись открытым ключом подписанта и совпадение подписанных хэшей с содержимым версий файлов
+// в хранилище. Проверка доступна участникам согласования и пользователям
+// с разрешением approval.view.any
+// Кастомные ошибки: ErrApprovalNotFound, ErrAccessDenied
+func (u *ApprovalUsecase) VerifySignatures(ctx context.Context, approvalID, userID uint) (*domain.SignatureVerification, error) {
+	const op = "usecase.approval.VerifySignatures"
+
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
+	log.Info("verifying approval signatures")
+
+	log.Debug("checking approval existence")
+	if _, err := u.approvalRepo.GetApprovalByID(ctx, approvalID); err != nil {
+		if errors.Is(err, domain.ErrApprovalNotFound) {
+			log.Error("approval not found", slogger.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrApprovalNotFound)
+		}
+		log.Error("failed to get approval", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking history access")
+	if err := u.checkHistoryAccess(ctx, userID, []uint{approvalID}); err != nil {
+		log.Error("failed history access check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting decision signatures")
+	signatures, err := u.approvalRepo.GetDecisionSignatures(ctx, approvalID)
+	if err != nil {
+		log.Error("failed to get decision signatures", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := &domain.SignatureVerification{
+		ApprovalID: approvalID,
+		Valid:      true,
+		Signatures: make([]domain.SignatureCheck, 0, len(signatures)),
+	}
+
+	// Одна версия файла обычно подписана несколькими решениями, хранилище читается один раз
+	hashes := make(map[domain.FileContentHash]string)
+	prevHash := ""
+	for _, signature := range signatures {
+		log.Debug("verifying signature", slog.Any("signature_id", signature.ID))
+		check, err := u.verifySignature(ctx, signature, prevHash, hashes)
+		if err != nil {
+			log.Error("failed to verify signature", slog.Any("signature_id", signature.ID), slogger.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		result.Valid = result.Valid && check.Valid
+		result.Signatures = append(result.Signatures, check)
+		prevHash = signature.Hash()
+	}
+
+	log.Info("approval signatures verified", slog.Bool("valid", result.Valid))
+	return result, nil
+}
+
+// sealer хэширует содержимое, которое согласует Approval, и возвращает функцию, подписывающую
+// решение пользователя userID в транзакции SignStage. Если пока содержимое хэшировалось, Approval
+// получило новую версию, решение не подписывается
+func (u *ApprovalUsecase) sealer(ctx context.Context, approval *domain.Approval, userID uint) (domain.SealFunc, error) {
+	contents, err := u.approvalRepo.GetApprovalContents(ctx, approval)
+	if err != nil {
+		return nil, err
+	}
+	for i := range contents {
+		hash, err := u.fileService.GetFileContentHash(ctx, contents[i].FileID, contents[i].Version)
+		if err != nil {
+			return nil, err
+		}
+		contents[i].SHA256 = hash.SHA256
+	}
+
+	keyID, err := u.signer.SigningKey(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	fileVersion := approval.FileVersion
+	return func(locked *domain.Approval, signature *domain.DecisionSignature) error {
+		if locked.FileVersion != fileVersion {
+			return domain.ErrApprovalNotFound
+		}
+
+		signature.Contents = contents
+		signature.KeyID = keyID
+		signed, err := u.signer.Sign(ctx, keyID, signature.Payload())
+		if err != nil {
+			return err
+		}
+		signature.Signature = signed
+		return nil
+	}, nil
+}
+
+// verifySignature проверяет одну подпись цепочки. hashes - уже прочитанные хэши версий файлов
+func (u *ApprovalUsecase) verifySignature(
+	ctx context.Context,
+	signature domain.DecisionSignature,
+	prevHash string,
+	hashes map[domain.FileContentHash]string,
+) (domain.SignatureCheck, error) {
+	check := domain.SignatureCheck{
+		SignatureID:   signature.ID,
+		Decision:      signature.Decision,
+		WorkflowOrder: signature.WorkflowOrder,
+		SignerID:      signature.SignerID,
+		OnBehalfOfID:  signature.OnBehalfOfID,
+		KeyID:         signature.KeyID,
+		SignedAt:      signature.SignedAt,
+		Contents:      signature.Contents,
+		Signature:     signature.Signature,
+	}
+
+	if signature.PrevHash != prevHash {
+		check.Errors = append(check.Errors, domain.SignatureErrBrokenChain)
+	}
+
+	publicKey, err := u.signer.PublicKey(ctx, signature.KeyID)
+	switch {
+	case errors.Is(err, domain.ErrSigningKeyNotFound):
+		check.Errors = append(check.Errors, domain.SignatureErrKeyNotFound)
+	case err != nil:
+		return domain.SignatureCheck{}, err
+	default:
+		check.PublicKey = publicKey
+		valid, err := u.signer.Verify(ctx, signature.KeyID, signature.Payload(), signature.Signature)
+		if err != nil {
+			return domain.SignatureCheck{}, err
+		}
+		if !valid {
+			check.Errors = append(check.Errors, domain.SignatureErrInvalidSignature)
+		}
+	}
+
+	for _, content := range signature.Contents {
+		key := domain.FileContentHash{FileID: content.FileID, Version: content.Version}
+		actual, cached := hashes[key]
+		if !cached {
+			hash, err := u.fileService.GetFileContentHash(ctx, content.FileID, content.Version)
+			if err != nil && !errors.Is(err, domain.ErrFileNotFound) {
+				return domain.SignatureCheck{}, err
+			}
+			actual = hash.SHA256
+			hashes[key] = actual
+		}
+
+		switch actual {
+		case "":
+			check.Errors = append(check.Errors, domain.SignatureErrContentMissing)
+		case content.SHA256:
+		default:
+			check.Errors = append(check.Errors, domain.SignatureErrContentMismatch)
+		}
+	}
+
+	check.Valid = len(check.Errors) == 0
+	return check, nil
+}
@@ -0,0 +1,150 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/pkg/logger/slogger"
+	"time"
+)
+
+// GetSubmissionsByUserID получает Approvals, отправленные пользователем, с ходом согласования:
+// неподписанными местами текущего этапа, периодами прохождения этапов и замечаниями согласующих
+func (u *ApprovalUsecase) GetSubmissionsByUserID(ctx context.Context, userID uint) ([]domain.SubmissionResponse, error) {
+	const op = "usecase.approval.GetSubmissionsByUserID"
+
+	log := u.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("getting user submissions")
+
+	log.Debug("finding submitted approvals")
+	submissions, err := u.approvalRepo.FindApprovalsBySubmitter(ctx, userID)
+	if err != nil {
+		log.Error("failed to get submitted approvals", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(submissions) == 0 {
+		log.Info("user has no submissions")
+		return []domain.SubmissionResponse{}, nil
+	}
+
+	approvalIDs := make([]uint, len(submissions))
+	refs := make([]*domain.ApprovalResponse, len(submissions))
+	for i := range submissions {
+		approvalIDs[i] = submissions[i].ID
+		refs[i] = &submissions[i].ApprovalResponse
+	}
+
+	if err := u.setFileNames(ctx, log, refs); err != nil {
+		log.Error("failed to get file names from file service", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("finding pending stage slots")
+	slots, err := u.approvalRepo.FindPendingSlots(ctx, approvalIDs)
+	if err != nil {
+		log.Error("failed to get pending slots", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	slotsByApproval := make(map[uint][]domain.PendingSlot)
+	for _, slot := range slots {
+		slotsByApproval[slot.ApprovalID] = append(slotsByApproval[slot.ApprovalID], slot)
+	}
+
+	log.Debug("getting approval events")
+	events, err := u.approvalRepo.GetApprovalsHistory(ctx, approvalIDs)
+	if err != nil {
+		log.Error("failed to get approval events", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	eventsByApproval := make(map[uint][]domain.ApprovalEventResponse)
+	for _, event := range events {
+		eventsByApproval[event.ApprovalID] = append(eventsByApproval[event.ApprovalID], event)
+	}
+
+	now := time.Now()
+	for i := range submissions {
+		submission := &submissions[i]
+
+		submission.WaitingOn = slotsByApproval[submission.ID]
+		if submission.WaitingOn == nil {
+			submission.WaitingOn = []domain.PendingSlot{}
+		}
+
+		submission.Stages = stageTimeline(*submission, eventsByApproval[submission.ID], now)
+
+		submission.Annotations = []domain.ApprovalEventResponse{}
+		for _, event := range eventsByApproval[submission.ID] {
+			if event.Action == domain.ApprovalActionAnnotate || event.Action == domain.ApprovalActionReject {
+				submission.Annotations = append(submission.Annotations, event)
+			}
+		}
+	}
+
+	log.Info("submissions got successfully")
+
+	return submissions, nil
+}
+
+// stageTimeline восстанавливает по журналу Approval периоды прохождения этапов. Этап завершается
+// последней подписью перед переходом Approval на следующий этап, поэтому переход определяется
+// по смене этапа в следующей записи журнала или, для текущего этапа, по самому Approval
+func stageTimeline(submission domain.SubmissionResponse, events []domain.ApprovalEventResponse, now time.Time) []domain.StagePeriod {
+	periods := []domain.StagePeriod{}
+	open := -1 // индекс периода, который проходится сейчас
+	var lastSign time.Time
+
+	start := func(order int, at time.Time) {
+		periods = append(periods, domain.StagePeriod{Order: order, StartedAt: at})
+		open = len(periods) - 1
+	}
+	finish := func(at time.Time, outcome string) {
+		if open < 0 {
+			return
+		}
+		endedAt := at
+		periods[open].EndedAt = &endedAt
+		periods[open].Outcome = outcome
+		open = -1
+	}
+	// advance закрывает текущий период последней подписью, если Approval уже перешло на этап order
+	advance := func(order int) {
+		if open >= 0 && periods[open].Order != order {
+			finish(lastSign, domain.ApprovalActionSign)
+			start(order, lastSign)
+		}
+	}
+
+	for _, event := range events {
+		switch event.Action {
+		case domain.ApprovalActionSubmit, domain.ApprovalActionResubmit:
+			finish(event.CreatedAt, event.Action)
+			start(event.WorkflowOrder, event.CreatedAt)
+		case domain.ApprovalActionSign:
+			advance(event.WorkflowOrder)
+			lastSign = event.CreatedAt
+		case domain.ApprovalActionEscalate:
+			advance(event.WorkflowOrder)
+		case domain.ApprovalActionFinalize, domain.ApprovalActionAnnotate,
+			domain.ApprovalActionReject, domain.ApprovalActionWithdraw:
+			advance(event.WorkflowOrder)
+			finish(event.CreatedAt, event.Action)
+		}
+	}
+
+	// Текущий этап мог начаться после последней записи журнала
+	if open >= 0 && submission.Status == "on approval" && periods[open].Order != submission.WorkflowOrder {
+		finish(lastSign, domain.ApprovalActionSign)
+		start(submission.WorkflowOrder, submission.StageStartedAt)
+	}
+
+	for i := range periods {
+		end := now
+		if periods[i].EndedAt != nil {
+			end = *periods[i].EndedAt
+		}
+		periods[i].ElapsedSeconds = int64(end.Sub(periods[i].StartedAt).Seconds())
+	}
+
+	return periods
+}
@@ -1,100 +1,361 @@
-package usecase
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"log/slog"
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-	"service-core/pkg/config"
-	"service-core/pkg/logger/slogger"
-	"service-core/pkg/utils"
-)
-
-type AuthUsecase struct {
-	userRepo interfaces.UserRepository
-	roleRepo interfaces.RoleRepository
-	cfg      *config.Config
-	log      *slog.Logger
-}
-
-func NewAuthUsecase(userRepo interfaces.UserRepository, roleRepo interfaces.RoleRepository, cfg *config.Config, log *slog.Logger) *AuthUsecase {
-	return &AuthUsecase{
-		userRepo: userRepo,
-		roleRepo: roleRepo,
-		cfg:      cfg,
-		log:      log,
-	}
-}
-
-// Login - проверяет, существует ли пользователь, если есть - то логинит
-func (u *AuthUsecase) Login(ctx context.Context, login, password string) (string, error) {
-	const op = "usecase.auth.Login"
-
-	log := u.log.With(slog.String("op", op), slog.String("login", login))
-	log.Info("logging user in")
-
-	log.Debug("getting user by login")
-	user, err := u.userRepo.GetUserByLogin(ctx, login)
-	if err != nil {
-		if errors.Is(err, domain.ErrUserNotFound) {
-			u.log.Warn("user not found", slogger.Err(err))
-			return "", fmt.Errorf("%s: %w", op, domain.ErrUserNotFound)
-		}
-
-		u.log.Error("failed to get user", slogger.Err(err))
-		return "", fmt.Errorf("%s: %w", op, domain.ErrInvalidCredentials)
-	}
-
-	log.Debug("comparing pass and hash")
-	if err := utils.CheckPassword(user.PassHash, password); err != nil {
-		u.log.Error("invalid credentials", slogger.Err(err))
-		return "", fmt.Errorf("%s: %w", op, domain.ErrInvalidCredentials)
-	}
-
-	log.Debug("generating JWT")
-	token, err := utils.GenerateJWT(user, u.cfg.SecretKeys.KeyJwt, u.cfg.SecretKeys.TokenTTL)
-	if err != nil {
-		u.log.Error("failed to generate jwt", slogger.Err(err))
-		return "", fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("user logged in successfully")
-	return token, nil
-}
-
-// GetCurrentUser возвращает информацию о пользователе по ID
-func (u *AuthUsecase) GetCurrentUser(ctx context.Context, userID uint) (domain.GetCurrentUserResponse, error) {
-	const op = "usecase.auth.GetCurrentUser"
-
-	log := u.log.With(slog.String("op", op), slog.Any("userID", userID))
-	log.Info("getting current user info")
-
-	log.Debug("getting user by ID")
-	user, err := u.userRepo.GetUserByID(ctx, userID)
-	if err != nil {
-		if errors.Is(err, domain.ErrUserNotFound) {
-			u.log.Warn("user not found", slogger.Err(err))
-			return domain.GetCurrentUserResponse{}, fmt.Errorf("%s: %w", op, domain.ErrUserNotFound)
-		}
-		return domain.GetCurrentUserResponse{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("getting role by ID")
-	roleName, err := u.roleRepo.GetRoleByID(ctx, user.RoleID)
-	if err != nil {
-		if errors.Is(err, domain.ErrRoleNotFound) {
-			u.log.Warn("role not found", slogger.Err(err))
-			return domain.GetCurrentUserResponse{}, fmt.Errorf("%s: %w", op, domain.ErrRoleNotFound)
-		}
-		return domain.GetCurrentUserResponse{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("user info got successfully")
-	return domain.GetCurrentUserResponse{
-		ID:    user.ID,
-		Login: user.Login,
-		Role:  roleName,
-	}, nil
-}
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/config"
+	"service-core/pkg/logger/slogger"
+	"service-core/pkg/utils"
+	"time"
+)
+
+// revocationSyncBatch - столько отозванных сессий передается в file-service за один вызов
+const revocationSyncBatch = 100
+
+type AuthUsecase struct {
+	userRepo    interfaces.UserRepository
+	roleRepo    interfaces.RoleRepository
+	sessionRepo interfaces.SessionRepository
+	fileService interfaces.FileService
+	tokenKeys   interfaces.TokenKeyManager
+	authorizer  interfaces.Authorizer
+	cfg         *config.Config
+	log         *slog.Logger
+}
+
+func NewAuthUsecase(
+	userRepo interfaces.UserRepository,
+	roleRepo interfaces.RoleRepository,
+	sessionRepo interfaces.SessionRepository,
+	fileService interfaces.FileService,
+	tokenKeys interfaces.TokenKeyManager,
+	authorizer interfaces.Authorizer,
+	cfg *config.Config,
+	log *slog.Logger,
+) *AuthUsecase {
+	return &AuthUsecase{
+		userRepo:    userRepo,
+		roleRepo:    roleRepo,
+		sessionRepo: sessionRepo,
+		fileService: fileService,
+		tokenKeys:   tokenKeys,
+		authorizer:  authorizer,
+		cfg:         cfg,
+		log:         log,
+	}
+}
+
+// Login - проверяет, существует ли пользователь, если есть - то открывает сессию
+// и выдает access- и refresh-токены
+// Кастомные ошибки: ErrUserNotFound, ErrInvalidCredentials
+func (u *AuthUsecase) Login(ctx context.Context, login, password string) (domain.AuthTokens, error) {
+	const op = "usecase.auth.Login"
+
+	log := u.log.With(slog.String("op", op), slog.String("login", login))
+	log.Info("logging user in")
+
+	log.Debug("getting user by login")
+	user, err := u.userRepo.GetUserByLogin(ctx, login)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			u.log.Warn("user not found", slogger.Err(err))
+			return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, domain.ErrUserNotFound)
+		}
+
+		u.log.Error("failed to get user", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, domain.ErrInvalidCredentials)
+	}
+
+	log.Debug("comparing pass and hash")
+	if err := utils.CheckPassword(user.PassHash, password); err != nil {
+		u.log.Error("invalid credentials", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, domain.ErrInvalidCredentials)
+	}
+
+	log.Debug("generating refresh token")
+	refreshToken, record, err := u.newRefreshToken()
+	if err != nil {
+		log.Error("failed to generate refresh token", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	sessionID, err := utils.NewToken(16)
+	if err != nil {
+		log.Error("failed to generate session id", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("creating session")
+	session := domain.AuthSession{ID: sessionID, UserID: user.ID, ExpiresAt: record.ExpiresAt}
+	if err := u.sessionRepo.CreateSession(ctx, &session, &record); err != nil {
+		log.Error("failed to create session", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("generating JWT")
+	tokens, err := u.issueTokens(ctx, user, sessionID, refreshToken, record.ExpiresAt)
+	if err != nil {
+		u.log.Error("failed to generate jwt", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user logged in successfully")
+	return tokens, nil
+}
+
+// Refresh обменивает refresh-токен на новую пару токенов той же сессии. Старый refresh-токен
+// становится использованным. Повторное предъявление в течение RefreshReuseGrace возвращает тот же новый
+// refresh-токен, позже - отзывает сессию
+// Кастомные ошибки: ErrInvalidRefreshToken, ErrRefreshTokenReused
+func (u *AuthUsecase) Refresh(ctx context.Context, refreshToken string) (domain.AuthTokens, error) {
+	const op = "usecase.auth.Refresh"
+
+	log := u.log.With(slog.String("op", op))
+	log.Info("refreshing tokens")
+
+	log.Debug("generating refresh token")
+	nextToken, record, err := u.newRefreshToken()
+	if err != nil {
+		log.Error("failed to generate refresh token", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Новый токен шифруется предъявленным: при повторном предъявлении в течение RefreshReuseGrace
+	// тот же новый токен возвращается другим вкладкам, обновлявшим сессию одновременно
+	record.SealedToken, err = utils.SealToken(refreshToken, nextToken)
+	if err != nil {
+		log.Error("failed to seal refresh token", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("rotating refresh token")
+	session, successor, err := u.sessionRepo.RotateRefreshToken(ctx, utils.HashToken(refreshToken), &record, u.cfg.SecretKeys.RefreshReuseGrace)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrRefreshTokenReused):
+			log.Warn("refresh token reused, session revoked", slog.String("session_id", session.ID), slog.Any("user_id", session.UserID))
+			u.pushRevocation(ctx, log, session)
+			return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, domain.ErrRefreshTokenReused)
+		case errors.Is(err, domain.ErrInvalidRefreshToken):
+			log.Warn("invalid refresh token", slogger.Err(err))
+			return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, domain.ErrInvalidRefreshToken)
+		}
+		log.Error("failed to rotate refresh token", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("session_id", session.ID), slog.Any("user_id", session.UserID))
+
+	if successor.TokenHash != record.TokenHash {
+		log.Info("refresh token presented again within grace period, returning issued token")
+		nextToken, err = utils.OpenToken(refreshToken, successor.SealedToken)
+		if err != nil {
+			log.Error("failed to open issued refresh token", slogger.Err(err))
+			return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("getting user by ID")
+	user, err := u.userRepo.GetUserByID(ctx, session.UserID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			log.Warn("session user not found", slogger.Err(err))
+			return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, domain.ErrInvalidRefreshToken)
+		}
+		log.Error("failed to get user", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("generating JWT")
+	tokens, err := u.issueTokens(ctx, user, session.ID, nextToken, successor.ExpiresAt)
+	if err != nil {
+		log.Error("failed to generate jwt", slogger.Err(err))
+		return domain.AuthTokens{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("tokens refreshed successfully")
+	return tokens, nil
+}
+
+// Logout отзывает сессию refresh-токена: refresh-токен и все access-токены сессии перестают
+// приниматься в core и file-service
+// Кастомные ошибки: ErrInvalidRefreshToken
+func (u *AuthUsecase) Logout(ctx context.Context, refreshToken string) error {
+	const op = "usecase.auth.Logout"
+
+	log := u.log.With(slog.String("op", op))
+	log.Info("logging user out")
+
+	log.Debug("revoking session")
+	session, err := u.sessionRepo.RevokeSessionByToken(ctx, utils.HashToken(refreshToken))
+	if err != nil {
+		if errors.Is(err, domain.ErrInvalidRefreshToken) {
+			log.Warn("invalid refresh token", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrInvalidRefreshToken)
+		}
+		log.Error("failed to revoke session", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log = log.With(slog.String("session_id", session.ID), slog.Any("user_id", session.UserID))
+	u.pushRevocation(ctx, log, session)
+
+	log.Info("user logged out successfully")
+	return nil
+}
+
+// SyncRevocations передает в file-service отозванные сессии, отзыв которых не удалось передать
+// сразу. Сессии, отозванные раньше времени жизни access-токена, не передаются: их токены истекли
+func (u *AuthUsecase) SyncRevocations(ctx context.Context) error {
+	const op = "usecase.auth.SyncRevocations"
+
+	log := u.log.With(slog.String("op", op))
+
+	now := time.Now()
+	sessions, err := u.sessionRepo.GetUnsyncedRevocations(ctx, now.Add(-u.cfg.SecretKeys.TokenTTL), revocationSyncBatch)
+	if err != nil {
+		log.Error("failed to get unsynced revocations", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if len(sessions) == 0 {
+		return nil
+	}
+
+	sessionIDs := make([]string, len(sessions))
+	for i, session := range sessions {
+		sessionIDs[i] = session.ID
+	}
+
+	log.Debug("sending revocations to file service", slog.Int("sessions", len(sessionIDs)))
+	if err := u.fileService.RevokeSessions(ctx, sessionIDs, now.Add(u.cfg.SecretKeys.TokenTTL)); err != nil {
+		log.Error("failed to send revocations", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.sessionRepo.MarkRevocationsSynced(ctx, sessionIDs, now); err != nil {
+		log.Error("failed to mark revocations synced", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("revocations synced", slog.Int("sessions", len(sessionIDs)))
+	return nil
+}
+
+// pushRevocation сразу передает отзыв сессии в file-service. При ошибке отзыв повторит SyncRevocations
+func (u *AuthUsecase) pushRevocation(ctx context.Context, log *slog.Logger, session *domain.AuthSession) {
+	if session.RevocationSyncedAt != nil || session.RevokedAt == nil {
+		return
+	}
+
+	log.Debug("sending revocation to file service")
+	err := u.fileService.RevokeSessions(ctx, []string{session.ID}, session.RevokedAt.Add(u.cfg.SecretKeys.TokenTTL))
+	if err != nil {
+		log.Warn("failed to send revocation, will be retried", slogger.Err(err))
+		return
+	}
+
+	if err := u.sessionRepo.MarkRevocationsSynced(ctx, []string{session.ID}, time.Now()); err != nil {
+		log.Warn("failed to mark revocation synced", slogger.Err(err))
+	}
+}
+
+// newRefreshToken генерирует refresh-токен и запись с его хэшем
+func (u *AuthUsecase) newRefreshToken() (string, domain.RefreshToken, error) {
+	token, err := utils.NewToken(32)
+	if err != nil {
+		return "", domain.RefreshToken{}, err
+	}
+
+	return token, domain.RefreshToken{
+		TokenHash: utils.HashToken(token),
+		ExpiresAt: time.Now().Add(u.cfg.SecretKeys.RefreshTokenTTL),
+	}, nil
+}
+
+// issueTokens выпускает access-токен сессии и собирает ответ с refresh-токеном
+func (u *AuthUsecase) issueTokens(ctx context.Context, user domain.User, sessionID, refreshToken string, refreshExpiresAt time.Time) (domain.AuthTokens, error) {
+	keyID, key, err := u.tokenKeys.SigningKey(ctx)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	expiresAt := time.Now().Add(u.cfg.SecretKeys.TokenTTL)
+	accessToken, err := utils.GenerateJWT(user, sessionID, keyID, key, expiresAt)
+	if err != nil {
+		return domain.AuthTokens{}, err
+	}
+
+	return domain.AuthTokens{
+		AccessToken:      accessToken,
+		ExpiresAt:        expiresAt,
+		RefreshToken:     refreshToken,
+		RefreshExpiresAt: refreshExpiresAt,
+	}, nil
+}
+
+// GetJWKS возвращает открытые ключи, которыми проверяются access-токены
+func (u *AuthUsecase) GetJWKS(ctx context.Context) (domain.JWKS, error) {
+	const op = "usecase.auth.GetJWKS"
+
+	log := u.log.With(slog.String("op", op))
+	log.Info("getting token keys")
+
+	jwks, err := u.tokenKeys.JWKS(ctx)
+	if err != nil {
+		log.Error("failed to get token keys", slogger.Err(err))
+		return domain.JWKS{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("token keys got successfully", slog.Int("keys", len(jwks.Keys)))
+	return jwks, nil
+}
+
+// GetCurrentUser возвращает информацию о пользователе по ID вместе с разрешениями его роли
+func (u *AuthUsecase) GetCurrentUser(ctx context.Context, userID uint) (domain.GetCurrentUserResponse, error) {
+	const op = "usecase.auth.GetCurrentUser"
+
+	log := u.log.With(slog.String("op", op), slog.Any("userID", userID))
+	log.Info("getting current user info")
+
+	log.Debug("getting user by ID")
+	user, err := u.userRepo.GetUserByID(ctx, userID)
+	if err != nil {
+		if errors.Is(err, domain.ErrUserNotFound) {
+			u.log.Warn("user not found", slogger.Err(err))
+			return domain.GetCurrentUserResponse{}, fmt.Errorf("%s: %w", op, domain.ErrUserNotFound)
+		}
+		return domain.GetCurrentUserResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting role by ID")
+	roleName, err := u.roleRepo.GetRoleByID(ctx, user.RoleID)
+	if err != nil {
+		if errors.Is(err, domain.ErrRoleNotFound) {
+			u.log.Warn("role not found", slogger.Err(err))
+			return domain.GetCurrentUserResponse{}, fmt.Errorf("%s: %w", op, domain.ErrRoleNotFound)
+		}
+		return domain.GetCurrentUserResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting user permissions")
+	permissions, err := u.authorizer.UserPermissions(ctx, userID)
+	if err != nil {
+		log.Error("failed to get user permissions", slogger.Err(err))
+		return domain.GetCurrentUserResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if permissions == nil {
+		permissions = []string{}
+	}
+
+	log.Info("user info got successfully")
+	return domain.GetCurrentUserResponse{
+		ID:          user.ID,
+		Login:       user.Login,
+		Role:        roleName,
+		Permissions: permissions,
+	}, nil
+}
@@ -0,0 +1,101 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"slices"
+	"sync"
+	"time"
+)
+
+// permissionCacheTTL - сколько живут разрешения пользователя в кэше Authorizer. Один запрос часто проверяет
+// несколько разрешений подряд, а изменения ролей на других экземплярах сервиса видны не позже чем через TTL
+const permissionCacheTTL = 5 * time.Second
+
+// Authorizer проверяет разрешения, выданные роли пользователя. Через него проходят все проверки
+// административного доступа
+type Authorizer struct {
+	roleRepo interfaces.RoleRepository
+
+	mu    sync.Mutex
+	cache map[uint]cachedPermissions
+}
+
+type cachedPermissions struct {
+	permissions []string
+	expiresAt   time.Time
+}
+
+func NewAuthorizer(roleRepo interfaces.RoleRepository) *Authorizer {
+	return &Authorizer{
+		roleRepo: roleRepo,
+		cache:    make(map[uint]cachedPermissions),
+	}
+}
+
+// Authorize проверяет, что роль пользователя имеет разрешение permission
+// Кастомные ошибки: ErrAccessDenied
+func (a *Authorizer) Authorize(ctx context.Context, userID uint, permission string) error {
+	const op = "usecase.authorizer.Authorize"
+
+	permissions, err := a.permissions(ctx, userID)
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !slices.Contains(permissions, permission) {
+		return fmt.Errorf("%s: %w", op, domain.ErrAccessDenied)
+	}
+
+	return nil
+}
+
+// UserPermissions возвращает разрешения роли пользователя
+func (a *Authorizer) UserPermissions(ctx context.Context, userID uint) ([]string, error) {
+	const op = "usecase.authorizer.UserPermissions"
+
+	permissions, err := a.permissions(ctx, userID)
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return slices.Clone(permissions), nil
+}
+
+// Invalidate сбрасывает кэш разрешений. Вызывается после изменения ролей и пользователей,
+// чтобы изменения сразу действовали на этом экземпляре сервиса
+func (a *Authorizer) Invalidate() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	clear(a.cache)
+}
+
+// permissions возвращает разрешения пользователя из кэша или загружает их из базы
+func (a *Authorizer) permissions(ctx context.Context, userID uint) ([]string, error) {
+	now := time.Now()
+
+	a.mu.Lock()
+	cached, ok := a.cache[userID]
+	a.mu.Unlock()
+	if ok && now.Before(cached.expiresAt) {
+		return cached.permissions, nil
+	}
+
+	permissions, err := a.roleRepo.GetUserPermissions(ctx, userID)
+	if err != nil {
+		return nil, err
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	for id, entry := range a.cache {
+		if !now.Before(entry.expiresAt) {
+			delete(a.cache, id)
+		}
+	}
+	a.cache[userID] = cachedPermissions{permissions: permissions, expiresAt: now.Add(permissionCacheTTL)}
+
+	return permissions, nil
+}
@@ -0,0 +1,285 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/logger/slogger"
+	"strings"
+)
+
+type CommentUsecase struct {
+	commentRepo  interfaces.CommentRepository
+	approvalRepo interfaces.ApprovalRepository
+	userRepo     interfaces.UserRepository
+	notifier     interfaces.Notifier
+	authorizer   interfaces.Authorizer
+	log          *slog.Logger
+}
+
+func NewCommentUsecase(
+	commentRepo interfaces.CommentRepository,
+	approvalRepo interfaces.ApprovalRepository,
+	userRepo interfaces.UserRepository,
+	notifier interfaces.Notifier,
+	authorizer interfaces.Authorizer,
+	log *slog.Logger,
+) *CommentUsecase {
+	return &CommentUsecase{
+		commentRepo:  commentRepo,
+		approvalRepo: approvalRepo,
+		userRepo:     userRepo,
+		notifier:     notifier,
+		authorizer:   authorizer,
+		log:          log,
+	}
+}
+
+// GetComments возвращает обсуждения Approval с ответами. Если fileVersion не 0, возвращаются
+// только обсуждения этой версии файла. Обсуждения доступны участникам согласования
+// и пользователям с разрешением approval.view.any
+// Кастомные ошибки: ErrApprovalNotFound, ErrAccessDenied
+func (u *CommentUsecase) GetComments(ctx context.Context, approvalID uint, fileVersion int, userID uint) ([]domain.CommentResponse, error) {
+	const op = "usecase.comment.GetComments"
+
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
+	log.Info("getting approval comments")
+
+	log.Debug("checking access")
+	if _, err := u.checkAccess(ctx, approvalID, userID); err != nil {
+		log.Error("failed access check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting comments", slog.Int("file_version", fileVersion))
+	comments, err := u.commentRepo.GetComments(ctx, approvalID, fileVersion)
+	if err != nil {
+		log.Error("failed to get comments", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("approval comments got successfully")
+	return commentThreads(comments), nil
+}
+
+// AddComment открывает обсуждение или отвечает в нем и уведомляет упомянутых пользователей.
+// Новое обсуждение относится к текущей версии файла согласования (для комплекта - к комплекту
+// целиком или к его файлу input.FileID), ответ - к версии обсуждения, в котором он дан
+// Кастомные ошибки: ErrApprovalNotFound, ErrAccessDenied, ErrCommentNotFound, ErrInvalidComment, ErrUserNotFound
+func (u *CommentUsecase) AddComment(ctx context.Context, approvalID uint, input domain.CommentInput, userID uint) (uint, error) {
+	const op = "usecase.comment.AddComment"
+
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("user_id", userID))
+	log.Info("adding comment")
+
+	log.Debug("checking access")
+	approval, err := u.checkAccess(ctx, approvalID, userID)
+	if err != nil {
+		log.Error("failed access check", slogger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	comment := domain.ApprovalComment{
+		ApprovalID: approvalID,
+		ParentID:   input.ParentID,
+		AuthorID:   userID,
+		Text:       strings.TrimSpace(input.Text),
+		Mentions:   uniqueIDs(input.Mentions),
+	}
+	if comment.Text == "" {
+		log.Warn("empty comment text")
+		return 0, fmt.Errorf("%s: %w", op, domain.ErrInvalidComment)
+	}
+
+	log.Debug("resolving commented file version")
+	if err := u.fillFileVersion(ctx, approval, &comment, input.FileID); err != nil {
+		log.Error("failed to resolve commented file version", slogger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if len(comment.Mentions) > 0 {
+		log.Debug("checking mentioned users existence")
+		exists, err := u.userRepo.CheckUsersExist(ctx, comment.Mentions)
+		if err != nil {
+			log.Error("failed to check mentioned users", slogger.Err(err))
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		if !exists {
+			log.Warn("mentioned users not found")
+			return 0, fmt.Errorf("%s: %w", op, domain.ErrUserNotFound)
+		}
+	}
+
+	log.Debug("saving comment")
+	if err := u.commentRepo.CreateComment(ctx, &comment); err != nil {
+		log.Error("failed to save comment", slogger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Комментарий уже сохранен, поэтому ошибка доставки уведомления не возвращается
+	var mentioned []uint
+	for _, mentionID := range comment.Mentions {
+		if mentionID != userID {
+			mentioned = append(mentioned, mentionID)
+		}
+	}
+	if len(mentioned) > 0 {
+		log.Debug("notifying mentioned users", slog.Any("user_ids", mentioned))
+		message := fmt.Sprintf("Вас упомянули в обсуждении согласования %d: %s", approvalID, comment.Text)
+		if err := u.notifier.Notify(ctx, mentioned, "Упоминание в обсуждении", message); err != nil {
+			log.Warn("failed to notify mentioned users", slogger.Err(err))
+		}
+	}
+
+	log.Info("comment added successfully", slog.Any("comment_id", comment.ID))
+	return comment.ID, nil
+}
+
+// ResolveComment разрешает обсуждение commentID (resolved = true) или открывает его снова.
+// Изменить состояние обсуждения может его автор, отправитель файла на согласование
+// и пользователь с разрешением approval.finalize.any
+// Кастомные ошибки: ErrApprovalNotFound, ErrAccessDenied, ErrCommentNotFound, ErrInvalidComment
+func (u *CommentUsecase) ResolveComment(ctx context.Context, approvalID, commentID uint, resolved bool, userID uint) error {
+	const op = "usecase.comment.ResolveComment"
+
+	log := u.log.With(slog.String("op", op), slog.Any("approval_id", approvalID), slog.Any("comment_id", commentID), slog.Any("user_id", userID))
+	log.Info("changing comment thread state", slog.Bool("resolved", resolved))
+
+	log.Debug("checking access")
+	approval, err := u.checkAccess(ctx, approvalID, userID)
+	if err != nil {
+		log.Error("failed access check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting comment")
+	comment, err := u.commentRepo.GetCommentByID(ctx, commentID)
+	if err != nil {
+		log.Error("failed to get comment", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if comment.ApprovalID != approvalID {
+		log.Warn("comment belongs to another approval")
+		return fmt.Errorf("%s: %w", op, domain.ErrCommentNotFound)
+	}
+	if comment.ParentID != 0 {
+		log.Warn("only thread can be resolved")
+		return fmt.Errorf("%s: %w", op, domain.ErrInvalidComment)
+	}
+
+	if comment.AuthorID != userID && approval.SubmitterID != userID {
+		log.Debug("checking user permission")
+		if err := u.authorizer.Authorize(ctx, userID, domain.PermissionApprovalFinalizeAny); err != nil {
+			log.Error("failed permission check", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("updating comment thread state")
+	if err := u.commentRepo.SetCommentResolved(ctx, commentID, userID, resolved); err != nil {
+		log.Error("failed to update comment thread state", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("comment thread state changed successfully")
+	return nil
+}
+
+// fillFileVersion заполняет файл и версию комментария: ответ наследует их от комментария parentID,
+// новое обсуждение получает файл fileID согласования в версии, отправленной на согласование
+func (u *CommentUsecase) fillFileVersion(ctx context.Context, approval *domain.Approval, comment *domain.ApprovalComment, fileID uint) error {
+	if comment.ParentID != 0 {
+		parent, err := u.commentRepo.GetCommentByID(ctx, comment.ParentID)
+		if err != nil {
+			return err
+		}
+		if parent.ApprovalID != approval.ID {
+			return domain.ErrCommentNotFound
+		}
+		comment.FileID, comment.FileVersion = parent.FileID, parent.FileVersion
+		return nil
+	}
+
+	if fileID == 0 || fileID == approval.FileID {
+		comment.FileID, comment.FileVersion = approval.FileID, approval.FileVersion
+		return nil
+	}
+
+	contents, err := u.approvalRepo.GetApprovalContents(ctx, approval)
+	if err != nil {
+		return err
+	}
+	for _, content := range contents {
+		if content.FileID == fileID {
+			comment.FileID, comment.FileVersion = content.FileID, content.Version
+			return nil
+		}
+	}
+	return domain.ErrInvalidComment
+}
+
+// checkAccess проверяет, что Approval существует и пользователь - участник согласования
+// или имеет разрешение approval.view.any
+func (u *CommentUsecase) checkAccess(ctx context.Context, approvalID, userID uint) (*domain.Approval, error) {
+	approval, err := u.approvalRepo.GetApprovalByID(ctx, approvalID)
+	if err != nil {
+		return nil, err
+	}
+
+	err = u.authorizer.Authorize(ctx, userID, domain.PermissionApprovalViewAny)
+	if err == nil {
+		return approval, nil
+	}
+	if !errors.Is(err, domain.ErrAccessDenied) {
+		return nil, err
+	}
+
+	participant, err := u.approvalRepo.CheckApprovalParticipant(ctx, userID, []uint{approvalID})
+	if err != nil {
+		return nil, err
+	}
+	if !participant {
+		return nil, domain.ErrAccessDenied
+	}
+	return approval, nil
+}
+
+// commentThreads собирает комментарии, отсортированные по ID, в обсуждения с вложенными ответами
+func commentThreads(comments []domain.CommentResponse) []domain.CommentResponse {
+	children := make(map[uint][]domain.CommentResponse)
+	for _, comment := range comments {
+		children[comment.ParentID] = append(children[comment.ParentID], comment)
+	}
+
+	var build func(parentID uint) []domain.CommentResponse
+	build = func(parentID uint) []domain.CommentResponse {
+		thread := children[parentID]
+		for i := range thread {
+			thread[i].Replies = build(thread[i].ID)
+		}
+		return thread
+	}
+
+	threads := build(0)
+	if threads == nil {
+		return []domain.CommentResponse{}
+	}
+	return threads
+}
+
+// uniqueIDs убирает повторы и нулевые ID, сохраняя порядок
+func uniqueIDs(ids []uint) []uint {
+	var unique []uint
+	seen := make(map[uint]struct{}, len(ids))
+	for _, id := range ids {
+		if _, ok := seen[id]; ok || id == 0 {
+			continue
+		}
+		seen[id] = struct{}{}
+		unique = append(unique, id)
+	}
+	return unique
+}
@@ -0,0 +1,116 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/config"
+	"service-core/pkg/logger/slogger"
+	"time"
+)
+
+type DeadlineUsecase struct {
+	approvalRepo interfaces.ApprovalRepository
+	notifier     interfaces.Notifier
+	cfg          *config.Config
+	log          *slog.Logger
+}
+
+func NewDeadlineUsecase(
+	approvalRepo interfaces.ApprovalRepository,
+	notifier interfaces.Notifier,
+	cfg *config.Config,
+	log *slog.Logger,
+) *DeadlineUsecase {
+	return &DeadlineUsecase{
+		approvalRepo: approvalRepo,
+		notifier:     notifier,
+		cfg:          cfg,
+		log:          log,
+	}
+}
+
+// ProcessDeadlines обрабатывает просроченные этапы согласования: напоминает подписантам
+// не чаще раза в ReminderInterval, а по истечении EscalationGrace после срока передает этап
+// резервному подписанту. Ошибки по отдельным Approvals логируются и не прерывают обработку
+func (u *DeadlineUsecase) ProcessDeadlines(ctx context.Context) error {
+	const op = "usecase.deadline.ProcessDeadlines"
+
+	log := u.log.With(slog.String("op", op))
+
+	now := time.Now()
+
+	log.Debug("getting overdue approvals")
+	approvals, err := u.approvalRepo.FindOverdueApprovals(ctx, now)
+	if err != nil {
+		log.Error("failed to get overdue approvals", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, approval := range approvals {
+		approvalLog := log.With(slog.Any("approval_id", approval.ID), slog.Int("workflow_order", approval.WorkflowOrder))
+
+		if u.shouldEscalate(approval, now) {
+			if err := u.escalate(ctx, approval, now); err != nil {
+				approvalLog.Error("failed to escalate approval", slogger.Err(err))
+			}
+			continue
+		}
+
+		if approval.RemindedAt == nil || now.Sub(*approval.RemindedAt) >= u.cfg.Scheduler.ReminderInterval {
+			if err := u.remind(ctx, approval, now); err != nil {
+				approvalLog.Error("failed to send reminder", slogger.Err(err))
+			}
+		}
+	}
+
+	return nil
+}
+
+func (u *DeadlineUsecase) shouldEscalate(approval domain.OverdueApproval, now time.Time) bool {
+	if approval.EscalatedAt != nil {
+		return false
+	}
+	if approval.EscalationUserID == 0 && approval.EscalationRoleID == 0 {
+		return false
+	}
+	return now.After(approval.DueAt.Add(u.cfg.Scheduler.EscalationGrace))
+}
+
+func (u *DeadlineUsecase) remind(ctx context.Context, approval domain.OverdueApproval, now time.Time) error {
+	signers, err := u.approvalRepo.FindPendingSigners(ctx, approval.ID)
+	if err != nil {
+		return err
+	}
+
+	if len(signers) > 0 {
+		message := fmt.Sprintf("Срок этапа %d согласования %d истёк %s", approval.WorkflowOrder, approval.ID, approval.DueAt.Format(time.RFC3339))
+		if err := u.notifier.Notify(ctx, signers, "Просрочено согласование", message); err != nil {
+			return err
+		}
+	}
+
+	return u.approvalRepo.MarkReminded(ctx, approval.ID, approval.WorkflowOrder, now)
+}
+
+func (u *DeadlineUsecase) escalate(ctx context.Context, approval domain.OverdueApproval, now time.Time) error {
+	escalated, err := u.approvalRepo.EscalateApproval(ctx, approval.ID, approval.WorkflowOrder, now)
+	if err != nil {
+		return err
+	}
+	if !escalated {
+		// Approval успели подписать или эскалировать параллельно
+		return nil
+	}
+
+	// После эскалации в число подписантов входит и резервный подписант
+	signers, err := u.approvalRepo.FindPendingSigners(ctx, approval.ID)
+	if err != nil {
+		return err
+	}
+
+	message := fmt.Sprintf("Этап %d согласования %d эскалирован: срок истёк %s", approval.WorkflowOrder, approval.ID, approval.DueAt.Format(time.RFC3339))
+	return u.notifier.Notify(ctx, signers, "Согласование эскалировано", message)
+}
@@ -0,0 +1,177 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/logger/slogger"
+	"time"
+)
+
+type DelegationUsecase struct {
+	delegationRepo interfaces.DelegationRepository
+	userRepo       interfaces.UserRepository
+	authorizer     interfaces.Authorizer
+	log            *slog.Logger
+}
+
+func NewDelegationUsecase(
+	delegationRepo interfaces.DelegationRepository,
+	userRepo interfaces.UserRepository,
+	authorizer interfaces.Authorizer,
+	log *slog.Logger,
+) *DelegationUsecase {
+	return &DelegationUsecase{
+		delegationRepo: delegationRepo,
+		userRepo:       userRepo,
+		authorizer:     authorizer,
+		log:            log,
+	}
+}
+
+// CreateDelegation назначает заместителя delegateID для пользователя delegatorID на период [startsAt, endsAt).
+// Если delegatorID = 0, заместитель назначается для самого actorID. Назначать заместителя другому
+// пользователю может только пользователь с разрешением delegation.manage. Период не должен пересекаться
+// с действующим или будущим замещением того же пользователя
+// Кастомные ошибки: ErrAccessDenied, ErrInvalidDelegation, ErrUserNotFound, ErrDelegationOverlap
+func (u *DelegationUsecase) CreateDelegation(ctx context.Context, delegatorID, delegateID uint, startsAt, endsAt time.Time, actorID uint) error {
+	const op = "usecase.delegation.CreateDelegation"
+
+	if delegatorID == 0 {
+		delegatorID = actorID
+	}
+
+	log := u.log.With(slog.String("op", op), slog.Any("delegator_id", delegatorID), slog.Any("delegate_id", delegateID), slog.Any("actor_id", actorID))
+	log.Info("creating delegation")
+
+	if delegatorID != actorID {
+		log.Debug("checking user permission")
+		if err := u.authorizer.Authorize(ctx, actorID, domain.PermissionDelegationManage); err != nil {
+			log.Error("failed permission check", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("validating delegation")
+	if delegateID == 0 || delegateID == delegatorID || !endsAt.After(startsAt) || !endsAt.After(time.Now()) {
+		log.Warn("invalid delegation", slog.Time("starts_at", startsAt), slog.Time("ends_at", endsAt))
+		return fmt.Errorf("%s: %w", op, domain.ErrInvalidDelegation)
+	}
+
+	log.Debug("checking users existence")
+	exists, err := u.userRepo.CheckUsersExist(ctx, []uint{delegatorID, delegateID})
+	if err != nil {
+		log.Error("failed to check users", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		log.Warn("user not found")
+		return fmt.Errorf("%s: %w", op, domain.ErrUserNotFound)
+	}
+
+	log.Debug("inserting delegation into db")
+	delegation := &domain.Delegation{
+		DelegatorID: delegatorID,
+		DelegateID:  delegateID,
+		StartsAt:    startsAt,
+		EndsAt:      endsAt,
+		CreatedBy:   actorID,
+	}
+	if err := u.delegationRepo.CreateDelegation(ctx, delegation); err != nil {
+		if errors.Is(err, domain.ErrDelegationOverlap) || errors.Is(err, domain.ErrUserNotFound) {
+			log.Warn("delegation rejected", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		log.Error("failed to create delegation", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("delegation created successfully", slog.Any("delegation_id", delegation.ID))
+	return nil
+}
+
+// GetDelegations возвращает замещения, в которых участвует пользователь
+func (u *DelegationUsecase) GetDelegations(ctx context.Context, userID uint) ([]domain.DelegationResponse, error) {
+	const op = "usecase.delegation.GetDelegations"
+
+	log := u.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("getting user delegations")
+
+	delegations, err := u.delegationRepo.GetDelegations(ctx, userID)
+	if err != nil {
+		log.Error("failed to get delegations", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("delegations got successfully")
+	return delegations, nil
+}
+
+// GetAllDelegations возвращает все замещения. Требуется разрешение delegation.manage
+// Кастомные ошибки: ErrAccessDenied
+func (u *DelegationUsecase) GetAllDelegations(ctx context.Context, actorID uint) ([]domain.DelegationResponse, error) {
+	const op = "usecase.delegation.GetAllDelegations"
+
+	log := u.log.With(slog.String("op", op), slog.Any("actor_id", actorID))
+	log.Info("getting all delegations")
+
+	log.Debug("checking user permission")
+	if err := u.authorizer.Authorize(ctx, actorID, domain.PermissionDelegationManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	delegations, err := u.delegationRepo.GetDelegations(ctx, 0)
+	if err != nil {
+		log.Error("failed to get delegations", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("delegations got successfully")
+	return delegations, nil
+}
+
+// DeleteDelegation отменяет замещение. Отменить замещение может замещаемый, тот, кто его назначил,
+// или пользователь с разрешением delegation.manage
+// Кастомные ошибки: ErrDelegationNotFound, ErrAccessDenied
+func (u *DelegationUsecase) DeleteDelegation(ctx context.Context, delegationID, actorID uint) error {
+	const op = "usecase.delegation.DeleteDelegation"
+
+	log := u.log.With(slog.String("op", op), slog.Any("delegation_id", delegationID), slog.Any("actor_id", actorID))
+	log.Info("deleting delegation")
+
+	log.Debug("getting delegation")
+	delegation, err := u.delegationRepo.GetDelegationByID(ctx, delegationID)
+	if err != nil {
+		if errors.Is(err, domain.ErrDelegationNotFound) {
+			log.Error("delegation not found", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrDelegationNotFound)
+		}
+		log.Error("failed to get delegation", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if delegation.DelegatorID != actorID && delegation.CreatedBy != actorID {
+		log.Debug("checking user permission")
+		if err := u.authorizer.Authorize(ctx, actorID, domain.PermissionDelegationManage); err != nil {
+			log.Error("failed permission check", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("deleting delegation from db")
+	if err := u.delegationRepo.DeleteDelegation(ctx, delegationID); err != nil {
+		if errors.Is(err, domain.ErrDelegationNotFound) {
+			log.Error("delegation not found", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrDelegationNotFound)
+		}
+		log.Error("failed to delete delegation", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("delegation deleted successfully")
+	return nil
+}
@@ -0,0 +1,221 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/config"
+	"service-core/pkg/logger/slogger"
+	"time"
+)
+
+const (
+	outboxBatchSize       = 100
+	outboxLease           = time.Minute      // на это время выбранное сообщение скрыто от других диспетчеров
+	outboxDeliveryTimeout = 10 * time.Second // таймаут одного вызова file-service
+)
+
+type OutboxUsecase struct {
+	outboxRepo   interfaces.OutboxRepository
+	approvalRepo interfaces.ApprovalRepository
+	fileService  interfaces.FileService
+	cfg          *config.Config
+	log          *slog.Logger
+}
+
+func NewOutboxUsecase(
+	outboxRepo interfaces.OutboxRepository,
+	approvalRepo interfaces.ApprovalRepository,
+	fileService interfaces.FileService,
+	cfg *config.Config,
+	log *slog.Logger,
+) *OutboxUsecase {
+	return &OutboxUsecase{
+		outboxRepo:   outboxRepo,
+		approvalRepo: approvalRepo,
+		fileService:  fileService,
+		cfg:          cfg,
+		log:          log,
+	}
+}
+
+// DispatchPending доставляет в file-service накопившиеся изменения статусов файлов.
+// Неудачная доставка откладывается с экспоненциальной задержкой и не прерывает обработку
+func (u *OutboxUsecase) DispatchPending(ctx context.Context) error {
+	const op = "usecase.outbox.DispatchPending"
+
+	log := u.log.With(slog.String("op", op))
+
+	log.Debug("claiming outbox messages")
+	messages, err := u.outboxRepo.ClaimOutboxMessages(ctx, 0, outboxBatchSize, outboxLease)
+	if err != nil {
+		log.Error("failed to claim outbox messages", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	for _, message := range messages {
+		if err := u.deliver(ctx, message); err != nil {
+			log.Warn("outbox message delivery failed", slog.Any("message_id", message.ID), slogger.Err(err))
+		}
+	}
+
+	return nil
+}
+
+// DispatchFile сразу доставляет в file-service изменения статуса одного файла по порядку.
+// При ошибке оставшиеся изменения доставит диспетчер
+func (u *OutboxUsecase) DispatchFile(ctx context.Context, fileID uint) error {
+	const op = "usecase.outbox.DispatchFile"
+
+	log := u.log.With(slog.String("op", op), slog.Any("file_id", fileID))
+
+	for {
+		log.Debug("claiming next outbox message")
+		messages, err := u.outboxRepo.ClaimOutboxMessages(ctx, fileID, 1, outboxLease)
+		if err != nil {
+			log.Error("failed to claim outbox message", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if len(messages) == 0 {
+			return nil
+		}
+
+		if err := u.deliver(ctx, messages[0]); err != nil {
+			log.Warn("outbox message delivery failed", slog.Any("message_id", messages[0].ID), slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+}
+
+// Reconcile сверяет статусы файлов в file-service со статусами их последних Approvals.
+// Файлы с недоставленными изменениями пропускаются. Если fix = true, для каждого
+// расхождения в outbox добавляется правильный статус и запускается доставка
+func (u *OutboxUsecase) Reconcile(ctx context.Context, fix bool) ([]domain.FileStatusMismatch, error) {
+	const op = "usecase.outbox.Reconcile"
+
+	log := u.log.With(slog.String("op", op), slog.Bool("fix", fix))
+	log.Info("reconciling file statuses")
+
+	log.Debug("getting latest approvals")
+	approvals, err := u.approvalRepo.FindLatestApprovals(ctx)
+	if err != nil {
+		log.Error("failed to get latest approvals", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting files with pending status changes")
+	pendingFileIDs, err := u.outboxRepo.GetPendingFileIDs(ctx)
+	if err != nil {
+		log.Error("failed to get pending files", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	pending := make(map[uint]struct{}, len(pendingFileIDs))
+	for _, fileID := range pendingFileIDs {
+		pending[fileID] = struct{}{}
+	}
+
+	var fileIDs []uint32
+	for _, approval := range approvals {
+		if _, ok := pending[approval.FileID]; ok {
+			continue
+		}
+		fileIDs = append(fileIDs, uint32(approval.FileID))
+	}
+	if len(fileIDs) == 0 {
+		log.Info("nothing to reconcile")
+		return []domain.FileStatusMismatch{}, nil
+	}
+
+	log.Debug("getting file statuses from file service")
+	fileStatuses, err := u.fileService.GetFileStatuses(ctx, fileIDs)
+	if err != nil {
+		log.Error("failed to get file statuses", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	mismatches := []domain.FileStatusMismatch{}
+	for _, approval := range approvals {
+		if _, ok := pending[approval.FileID]; ok {
+			continue
+		}
+
+		expected := domain.FileStatusForApproval(approval.Status)
+		fileStatus, exists := fileStatuses[uint32(approval.FileID)]
+		// Удаленные файлы и Approvals с неизвестным статусом не сверяются
+		if !exists || expected == "" || fileStatus == expected {
+			continue
+		}
+
+		mismatches = append(mismatches, domain.FileStatusMismatch{
+			FileID:         approval.FileID,
+			ApprovalID:     approval.ID,
+			ApprovalStatus: approval.Status,
+			FileStatus:     fileStatus,
+			ExpectedStatus: expected,
+		})
+	}
+
+	log.Info("file statuses compared", slog.Int("mismatches", len(mismatches)))
+	if !fix || len(mismatches) == 0 {
+		return mismatches, nil
+	}
+
+	for _, mismatch := range mismatches {
+		log.Debug("enqueueing status fix", slog.Any("file_id", mismatch.FileID), slog.String("status", mismatch.ExpectedStatus))
+		if err := u.outboxRepo.EnqueueFileStatus(ctx, mismatch.ApprovalID, mismatch.FileID, mismatch.ExpectedStatus); err != nil {
+			log.Error("failed to enqueue status fix", slogger.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := u.DispatchPending(ctx); err != nil {
+		log.Error("failed to dispatch status fixes", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("file statuses reconciled")
+	return mismatches, nil
+}
+
+// deliver отправляет одно сообщение в file-service и фиксирует результат. Если файл не найден
+// или исчерпаны попытки, доставка сообщения прекращается
+func (u *OutboxUsecase) deliver(ctx context.Context, message domain.OutboxMessage) error {
+	deliveryCtx, cancel := context.WithTimeout(ctx, outboxDeliveryTimeout)
+	defer cancel()
+
+	deliveryErr := u.fileService.UpdateFileStatus(deliveryCtx, message.FileID, message.FileStatus, message.IdempotencyKey)
+	if deliveryErr == nil {
+		return u.outboxRepo.MarkOutboxDelivered(ctx, message.ID, time.Now())
+	}
+
+	dead := errors.Is(deliveryErr, domain.ErrFileNotFound) || message.Attempts+1 >= u.cfg.Outbox.MaxAttempts
+	nextAttemptAt := time.Now().Add(u.backoff(message.Attempts))
+	if err := u.outboxRepo.MarkOutboxFailed(ctx, message.ID, nextAttemptAt, dead, deliveryErr.Error()); err != nil {
+		return err
+	}
+
+	if dead {
+		u.log.Error("outbox message delivery abandoned",
+			slog.Any("message_id", message.ID),
+			slog.Any("file_id", message.FileID),
+			slogger.Err(deliveryErr),
+		)
+	}
+	return deliveryErr
+}
+
+// backoff возвращает задержку перед следующей попыткой: Interval, удваиваемый с каждой
+// попыткой, но не больше MaxBackoff
+func (u *OutboxUsecase) backoff(attempts int) time.Duration {
+	delay := u.cfg.Outbox.Interval
+	for i := 0; i < attempts && delay < u.cfg.Outbox.MaxBackoff; i++ {
+		delay *= 2
+	}
+	if delay > u.cfg.Outbox.MaxBackoff {
+		delay = u.cfg.Outbox.MaxBackoff
+	}
+	return delay
+}
@@ -1,210 +1,299 @@
-package usecase
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"log/slog"
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-	"service-core/pkg/logger/slogger"
-)
-
-type RoleUsecase struct {
-	roleRepo interfaces.RoleRepository
-	userRepo interfaces.UserRepository
-	log      *slog.Logger
-}
-
-func NewRoleUsecase(roleRepo interfaces.RoleRepository, userRepo interfaces.UserRepository, log *slog.Logger) *RoleUsecase {
-	return &RoleUsecase{
-		roleRepo: roleRepo,
-		userRepo: userRepo,
-		log:      log,
-	}
-}
-
-func (roleUsecase *RoleUsecase) RegisterRole(ctx context.Context, roleName string, userID uint) error {
-	const op = "usecase.role.RegisterRole"
-
-	log := roleUsecase.log.With(slog.String("op", op), slog.Any("role", roleName))
-	log.Info("registering new role")
-
-	log.Debug("checking if user is admin")
-	if err := roleUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("inserting role into DB")
-	err := roleUsecase.roleRepo.CreateRole(ctx, roleName)
-	if err != nil {
-		if errors.Is(err, domain.ErrRoleAlreadyExists) {
-			log.Error("role already exists", slogger.Err(domain.ErrRoleAlreadyExists))
-			return fmt.Errorf("%s: %w", op, domain.ErrRoleAlreadyExists)
-		}
-		log.Error("failed to save role", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("role registered successfully")
-	return nil
-}
-
-func (roleUsecase *RoleUsecase) GetRoles(ctx context.Context, userID uint) ([]domain.RoleResponse, error) {
-	const op = "usecase.role.GetRoles"
-
-	log := roleUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID))
-	log.Info("getting roles")
-
-	log.Debug("checking if user is admin")
-	if err := roleUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("getting roles from database")
-	roles, err := roleUsecase.roleRepo.GetRoles(ctx)
-	if err != nil {
-		// TODO: custom errors?
-		log.Error("failed to get roles", slogger.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("roles got succesfully")
-	return roles, nil
-}
-
-func (roleUsecase *RoleUsecase) GetRoleByID(ctx context.Context, roleID uint, userID uint) (string, error) {
-	const op = "usecase.role.GetRoleByID"
-
-	log := roleUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID))
-	log.Info("getting roles")
-
-	log.Debug("checking if user is admin")
-	if err := roleUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return "", fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("getting role from database")
-	role, err := roleUsecase.roleRepo.GetRoleByID(ctx, roleID)
-	if err != nil {
-		// TODO: custom errors?
-		log.Error("failed to get role", slogger.Err(err))
-		return "", fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("role got succesfully")
-	return role, nil
-}
-
-func (roleUsecase *RoleUsecase) UpdateRole(ctx context.Context, roleID uint, roleName string, userID uint) error {
-	const op = "usecase.role.UpdateRole"
-
-	log := roleUsecase.log.With(slog.String("op", op))
-	log.Info("updating role")
-
-	log.Debug("checking if user is admin")
-	if err := roleUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking role access")
-	if err := roleUsecase.checkRole(ctx, roleID); err != nil {
-		log.Error("failed role access check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking is role name exists")
-	if err := roleUsecase.checkRoleByName(ctx, roleName); err != nil {
-		log.Error("role already exists", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("updating role")
-	if err := roleUsecase.roleRepo.UpdateRole(ctx, roleID, roleName); err != nil {
-		// TODO: custom errors?
-		log.Error("failed to update role", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("role updated successfully")
-	return nil
-}
-
-func (roleUsecase *RoleUsecase) DeleteRole(ctx context.Context, roleID uint, userID uint) error {
-	const op = "usecase.role.DeleteRole"
-
-	log := roleUsecase.log.With(slog.String("op", op))
-	log.Info("deleting role")
-
-	log.Debug("checking if user is admin")
-	if err := roleUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking role access")
-	if err := roleUsecase.checkRole(ctx, roleID); err != nil {
-		log.Error("failed role access check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking if role in use")
-	hasUsers, err := roleUsecase.userRepo.CheckUsersWithRole(ctx, roleID)
-	if err != nil {
-		log.Error("failed to check users with role", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if hasUsers {
-		log.Warn("role is in use by users")
-		return fmt.Errorf("%s: %w", op, domain.ErrRoleInUse)
-	}
-
-	log.Debug("deleting role")
-	if err := roleUsecase.roleRepo.DeleteRole(ctx, roleID); err != nil {
-		// TODO: custom errors
-		log.Error("failed to delete role", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (roleUsecase *RoleUsecase) checkAdmin(ctx context.Context, userID uint) error {
-	role, err := roleUsecase.userRepo.GetUserRole(ctx, userID)
-	if err != nil {
-		return err
-	}
-	if role != "admin" {
-		return domain.ErrAccessDenied
-	}
-	return nil
-}
-
-func (roleUsecase *RoleUsecase) checkRole(ctx context.Context, roleID uint) error {
-	roleName, err := roleUsecase.roleRepo.GetRoleByID(ctx, roleID)
-	switch {
-	case err != nil:
-		return err
-	case roleName == "":
-		return domain.ErrRoleNotFound
-	case roleName == "admin":
-		return domain.ErrAccessDenied
-	}
-
-	return nil
-}
-
-func (roleUsecase *RoleUsecase) checkRoleByName(ctx context.Context, roleName string) error {
-	exists, err := roleUsecase.roleRepo.CheckRoleByName(ctx, roleName)
-	if err != nil {
-		return err
-	}
-	if exists {
-		return domain.ErrRoleAlreadyExists
-	}
-	return nil
-}
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/logger/slogger"
+	"slices"
+)
+
+type RoleUsecase struct {
+	roleRepo     interfaces.RoleRepository
+	userRepo     interfaces.UserRepository
+	workflowRepo interfaces.WorkflowRepository
+	authorizer   interfaces.Authorizer
+	log          *slog.Logger
+}
+
+func NewRoleUsecase(
+	roleRepo interfaces.RoleRepository,
+	userRepo interfaces.UserRepository,
+	workflowRepo interfaces.WorkflowRepository,
+	authorizer interfaces.Authorizer,
+	log *slog.Logger,
+) *RoleUsecase {
+	return &RoleUsecase{
+		roleRepo:     roleRepo,
+		userRepo:     userRepo,
+		workflowRepo: workflowRepo,
+		authorizer:   authorizer,
+		log:          log,
+	}
+}
+
+func (roleUsecase *RoleUsecase) RegisterRole(ctx context.Context, roleName string, userID uint) error {
+	const op = "usecase.role.RegisterRole"
+
+	log := roleUsecase.log.With(slog.String("op", op), slog.Any("role", roleName))
+	log.Info("registering new role")
+
+	log.Debug("checking user permission")
+	if err := roleUsecase.authorizer.Authorize(ctx, userID, domain.PermissionRoleManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("inserting role into DB")
+	err := roleUsecase.roleRepo.CreateRole(ctx, roleName)
+	if err != nil {
+		if errors.Is(err, domain.ErrRoleAlreadyExists) {
+			log.Error("role already exists", slogger.Err(domain.ErrRoleAlreadyExists))
+			return fmt.Errorf("%s: %w", op, domain.ErrRoleAlreadyExists)
+		}
+		log.Error("failed to save role", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role registered successfully")
+	return nil
+}
+
+func (roleUsecase *RoleUsecase) GetRoles(ctx context.Context, userID uint) ([]domain.RoleResponse, error) {
+	const op = "usecase.role.GetRoles"
+
+	log := roleUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("getting roles")
+
+	log.Debug("checking user permission")
+	if err := roleUsecase.authorizer.Authorize(ctx, userID, domain.PermissionRoleManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting roles from database")
+	roles, err := roleUsecase.roleRepo.GetRoles(ctx)
+	if err != nil {
+		// TODO: custom errors?
+		log.Error("failed to get roles", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting role permissions from database")
+	roleIDs := make([]uint, 0, len(roles))
+	for _, role := range roles {
+		roleIDs = append(roleIDs, role.RoleID)
+	}
+	permissions, err := roleUsecase.roleRepo.GetRolePermissions(ctx, roleIDs)
+	if err != nil {
+		log.Error("failed to get role permissions", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	for i := range roles {
+		roles[i].Permissions = permissions[roles[i].RoleID]
+		if roles[i].Permissions == nil {
+			roles[i].Permissions = []string{}
+		}
+	}
+
+	log.Info("roles got succesfully")
+	return roles, nil
+}
+
+func (roleUsecase *RoleUsecase) GetRoleByID(ctx context.Context, roleID uint, userID uint) (string, error) {
+	const op = "usecase.role.GetRoleByID"
+
+	log := roleUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("getting roles")
+
+	log.Debug("checking user permission")
+	if err := roleUsecase.authorizer.Authorize(ctx, userID, domain.PermissionRoleManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting role from database")
+	role, err := roleUsecase.roleRepo.GetRoleByID(ctx, roleID)
+	if err != nil {
+		// TODO: custom errors?
+		log.Error("failed to get role", slogger.Err(err))
+		return "", fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role got succesfully")
+	return role, nil
+}
+
+func (roleUsecase *RoleUsecase) UpdateRole(ctx context.Context, roleID uint, roleName string, userID uint) error {
+	const op = "usecase.role.UpdateRole"
+
+	log := roleUsecase.log.With(slog.String("op", op))
+	log.Info("updating role")
+
+	log.Debug("checking user permission")
+	if err := roleUsecase.authorizer.Authorize(ctx, userID, domain.PermissionRoleManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking role access")
+	if err := roleUsecase.checkRole(ctx, roleID); err != nil {
+		log.Error("failed role access check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking is role name exists")
+	if err := roleUsecase.checkRoleByName(ctx, roleName); err != nil {
+		log.Error("role already exists", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("updating role")
+	if err := roleUsecase.roleRepo.UpdateRole(ctx, roleID, roleName); err != nil {
+		// TODO: custom errors?
+		log.Error("failed to update role", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("role updated successfully")
+	return nil
+}
+
+func (roleUsecase *RoleUsecase) DeleteRole(ctx context.Context, roleID uint, userID uint) error {
+	const op = "usecase.role.DeleteRole"
+
+	log := roleUsecase.log.With(slog.String("op", op))
+	log.Info("deleting role")
+
+	log.Debug("checking user permission")
+	if err := roleUsecase.authorizer.Authorize(ctx, userID, domain.PermissionRoleManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking role access")
+	if err := roleUsecase.checkRole(ctx, roleID); err != nil {
+		log.Error("failed role access check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking if role in use")
+	hasUsers, err := roleUsecase.userRepo.CheckUsersWithRole(ctx, roleID)
+	if err != nil {
+		log.Error("failed to check users with role", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if hasUsers {
+		log.Warn("role is in use by users")
+		return fmt.Errorf("%s: %w", op, domain.ErrRoleInUse)
+	}
+
+	log.Debug("checking if role in workflows")
+	inWorkflow, err := roleUsecase.workflowRepo.CheckRoleInWorkflow(ctx, roleID)
+	if err != nil {
+		log.Error("failed to check role in workflows", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if inWorkflow {
+		log.Warn("role is in use by workflows")
+		return fmt.Errorf("%s: %w", op, domain.ErrRoleInUse)
+	}
+
+	log.Debug("deleting role")
+	if err := roleUsecase.roleRepo.DeleteRole(ctx, roleID); err != nil {
+		// TODO: custom errors
+		log.Error("failed to delete role", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	roleUsecase.authorizer.Invalidate()
+
+	return nil
+}
+
+// SetRolePermissions заменяет набор разрешений роли. Разрешения встроенной роли admin не меняются
+// Кастомные ошибки: ErrAccessDenied, ErrRoleNotFound, ErrUnknownPermission
+func (roleUsecase *RoleUsecase) SetRolePermissions(ctx context.Context, roleID uint, permissions []string, userID uint) error {
+	const op = "usecase.role.SetRolePermissions"
+
+	log := roleUsecase.log.With(slog.String("op", op), slog.Any("role_id", roleID))
+	log.Info("setting role permissions")
+
+	log.Debug("checking user permission")
+	if err := roleUsecase.authorizer.Authorize(ctx, userID, domain.PermissionRoleManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking role access")
+	if err := roleUsecase.checkRole(ctx, roleID); err != nil {
+		log.Error("failed role access check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("validating permissions")
+	for _, permission := range permissions {
+		if !domain.IsPermission(permission) {
+			log.Warn("unknown permission", slog.String("permission", permission))
+			return fmt.Errorf("%s: %w: %s", op, domain.ErrUnknownPermission, permission)
+		}
+	}
+	permissions = slices.Clone(permissions)
+	slices.Sort(permissions)
+	permissions = slices.Compact(permissions)
+
+	log.Debug("saving role permissions")
+	if err := roleUsecase.roleRepo.SetRolePermissions(ctx, roleID, permissions); err != nil {
+		log.Error("failed to save role permissions", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	roleUsecase.authorizer.Invalidate()
+
+	log.Info("role permissions set successfully")
+	return nil
+}
+
+// GetPermissions возвращает справочник всех разрешений, которые можно выдать роли
+// Кастомные ошибки: ErrAccessDenied
+func (roleUsecase *RoleUsecase) GetPermissions(ctx context.Context, userID uint) ([]domain.PermissionInfo, error) {
+	const op = "usecase.role.GetPermissions"
+
+	log := roleUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("getting permissions")
+
+	log.Debug("checking user permission")
+	if err := roleUsecase.authorizer.Authorize(ctx, userID, domain.PermissionRoleManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("permissions got successfully")
+	return slices.Clone(domain.Permissions), nil
+}
+
+func (roleUsecase *RoleUsecase) checkRole(ctx context.Context, roleID uint) error {
+	roleName, err := roleUsecase.roleRepo.GetRoleByID(ctx, roleID)
+	switch {
+	case err != nil:
+		return err
+	case roleName == "":
+		return domain.ErrRoleNotFound
+	case roleName == "admin":
+		return domain.ErrAccessDenied
+	}
+
+	return nil
+}
+
+func (roleUsecase *RoleUsecase) checkRoleByName(ctx context.Context, roleName string) error {
+	exists, err := roleUsecase.roleRepo.CheckRoleByName(ctx, roleName)
+	if err != nil {
+		return err
+	}
+	if exists {
+		return domain.ErrRoleAlreadyExists
+	}
+	return nil
+}
@@ -0,0 +1,349 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"path"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/logger/slogger"
+	"slices"
+)
+
+type RoutingUsecase struct {
+	routingRepo  interfaces.RoutingRuleRepository
+	workflowRepo interfaces.WorkflowRepository
+	userRepo     interfaces.UserRepository
+	fileService  interfaces.FileService
+	authorizer   interfaces.Authorizer
+	log          *slog.Logger
+}
+
+func NewRoutingUsecase(
+	routingRepo interfaces.RoutingRuleRepository,
+	workflowRepo interfaces.WorkflowRepository,
+	userRepo interfaces.UserRepository,
+	fileService interfaces.FileService,
+	authorizer interfaces.Authorizer,
+	log *slog.Logger,
+) *RoutingUsecase {
+	return &RoutingUsecase{
+		routingRepo:  routingRepo,
+		workflowRepo: workflowRepo,
+		userRepo:     userRepo,
+		fileService:  fileService,
+		authorizer:   authorizer,
+		log:          log,
+	}
+}
+
+// GetRoutingRules возвращает правила маршрутизации в порядке их проверки. Требуется разрешение workflow.manage
+// Кастомные ошибки: ErrAccessDenied
+func (u *RoutingUsecase) GetRoutingRules(ctx context.Context, actorID uint) ([]domain.RoutingRuleResponse, error) {
+	const op = "usecase.routing.GetRoutingRules"
+
+	log := u.log.With(slog.String("op", op), slog.Any("actor_id", actorID))
+	log.Info("getting routing rules")
+
+	log.Debug("checking user permission")
+	if err := u.authorizer.Authorize(ctx, actorID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	rules, err := u.routingRepo.GetRoutingRules(ctx)
+	if err != nil {
+		log.Error("failed to get routing rules", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	response := make([]domain.RoutingRuleResponse, 0, len(rules))
+	for _, rule := range rules {
+		response = append(response, domain.RoutingRuleResponse{ID: rule.ID, RoutingRuleSpec: routingRuleSpec(rule)})
+	}
+
+	log.Info("routing rules got successfully")
+	return response, nil
+}
+
+// CreateRoutingRule добавляет правило маршрутизации. Требуется разрешение workflow.manage
+// Кастомные ошибки: ErrAccessDenied, ErrInvalidRoutingRule, ErrWorkflowNotFound
+func (u *RoutingUsecase) CreateRoutingRule(ctx context.Context, spec domain.RoutingRuleSpec, actorID uint) error {
+	const op = "usecase.routing.CreateRoutingRule"
+
+	log := u.log.With(slog.String("op", op), slog.Any("actor_id", actorID))
+	log.Info("creating routing rule")
+
+	log.Debug("checking user permission")
+	if err := u.authorizer.Authorize(ctx, actorID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("validating routing rule")
+	rule, err := u.validateRule(ctx, spec)
+	if err != nil {
+		log.Error("invalid routing rule", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("inserting routing rule into db")
+	if err := u.routingRepo.CreateRoutingRule(ctx, &rule); err != nil {
+		log.Error("failed to create routing rule", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("routing rule created successfully", slog.Any("rule_id", rule.ID))
+	return nil
+}
+
+// UpdateRoutingRule заменяет условия и действия правила маршрутизации. Требуется разрешение workflow.manage
+// Кастомные ошибки: ErrAccessDenied, ErrInvalidRoutingRule, ErrWorkflowNotFound, ErrRoutingRuleNotFound
+func (u *RoutingUsecase) UpdateRoutingRule(ctx context.Context, ruleID uint, spec domain.RoutingRuleSpec, actorID uint) error {
+	const op = "usecase.routing.UpdateRoutingRule"
+
+	log := u.log.With(slog.String("op", op), slog.Any("rule_id", ruleID), slog.Any("actor_id", actorID))
+	log.Info("updating routing rule")
+
+	log.Debug("checking user permission")
+	if err := u.authorizer.Authorize(ctx, actorID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("validating routing rule")
+	rule, err := u.validateRule(ctx, spec)
+	if err != nil {
+		log.Error("invalid routing rule", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	rule.ID = ruleID
+
+	log.Debug("updating routing rule in db")
+	if err := u.routingRepo.UpdateRoutingRule(ctx, &rule); err != nil {
+		if errors.Is(err, domain.ErrRoutingRuleNotFound) {
+			log.Error("routing rule not found", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrRoutingRuleNotFound)
+		}
+		log.Error("failed to update routing rule", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("routing rule updated successfully")
+	return nil
+}
+
+// DeleteRoutingRule удаляет правило маршрутизации. Требуется разрешение workflow.manage
+// Кастомные ошибки: ErrAccessDenied, ErrRoutingRuleNotFound
+func (u *RoutingUsecase) DeleteRoutingRule(ctx context.Context, ruleID, actorID uint) error {
+	const op = "usecase.routing.DeleteRoutingRule"
+
+	log := u.log.With(slog.String("op", op), slog.Any("rule_id", ruleID), slog.Any("actor_id", actorID))
+	log.Info("deleting routing rule")
+
+	log.Debug("checking user permission")
+	if err := u.authorizer.Authorize(ctx, actorID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("deleting routing rule from db")
+	if err := u.routingRepo.DeleteRoutingRule(ctx, ruleID); err != nil {
+		if errors.Is(err, domain.ErrRoutingRuleNotFound) {
+			log.Error("routing rule not found", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, domain.ErrRoutingRuleNotFound)
+		}
+		log.Error("failed to delete routing rule", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("routing rule deleted successfully")
+	return nil
+}
+
+// ResolveRoute выбирает маршрут согласования файла: первое по приоритету правило, под которое
+// подходит файл, задает процедуру и пропускаемые этапы. Если ни одно правило не подошло,
+// используется процедура директории файла (назначенная ей или унаследованная от родителя)
+func (u *RoutingUsecase) ResolveRoute(ctx context.Context, file *domain.File) (domain.ApprovalRoute, error) {
+	const op = "usecase.routing.ResolveRoute"
+
+	log := u.log.With(slog.String("op", op), slog.Any("file_id", file.ID))
+
+	log.Debug("getting routing rules")
+	rules, err := u.routingRepo.GetRoutingRules(ctx)
+	if err != nil {
+		log.Error("failed to get routing rules", slogger.Err(err))
+		return domain.ApprovalRoute{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	route := routeFile(file, rules)
+	if route.RuleID != 0 {
+		log.Debug("routing rule matched", slog.Any("rule_id", route.RuleID), slog.Any("workflow_id", route.WorkflowID))
+	}
+
+	return route, nil
+}
+
+// DryRunRoute показывает, по какому маршруту пошло бы согласование файла, ничего не создавая.
+// Требуется разрешение workflow.manage
+// Кастомные ошибки: ErrAccessDenied, ErrFileNotFound, ErrWorkflowNotFound
+func (u *RoutingUsecase) DryRunRoute(ctx context.Context, fileID, actorID uint) (domain.ApprovalRoute, error) {
+	const op = "usecase.routing.DryRunRoute"
+
+	log := u.log.With(slog.String("op", op), slog.Any("file_id", fileID), slog.Any("actor_id", actorID))
+	log.Info("resolving file route")
+
+	log.Debug("checking user permission")
+	if err := u.authorizer.Authorize(ctx, actorID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return domain.ApprovalRoute{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("fetching file with directory from file service")
+	file, err := u.fileService.GetFileWithDirectory(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			log.Error("file not found", slogger.Err(err))
+			return domain.ApprovalRoute{}, fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		}
+		log.Error("failed to get file", slogger.Err(err))
+		return domain.ApprovalRoute{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	route, err := u.ResolveRoute(ctx, file)
+	if err != nil {
+		log.Error("failed to resolve route", slogger.Err(err))
+		return domain.ApprovalRoute{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting route workflow", slog.Any("workflow_id", route.WorkflowID))
+	workflow, err := u.workflowRepo.GetWorkflowByID(ctx, route.WorkflowID)
+	if err != nil {
+		if errors.Is(err, domain.ErrWorkflowNotFound) {
+			log.Error("route workflow not found", slogger.Err(err))
+			return domain.ApprovalRoute{}, fmt.Errorf("%s: %w", op, domain.ErrWorkflowNotFound)
+		}
+		log.Error("failed to get workflow", slogger.Err(err))
+		return domain.ApprovalRoute{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	route.WorkflowName = workflow.WorkflowName
+	route.Stages = skipStages(workflow.Stages, route.SkipOrders)
+
+	log.Info("file route resolved", slog.Any("rule_id", route.RuleID), slog.Int("stages", len(route.Stages)))
+	return route, nil
+}
+
+// validateRule проверяет условия и действия правила и преобразует его в модель.
+// Правило должно что-то менять: задавать процедуру или пропускаемые этапы
+func (u *RoutingUsecase) validateRule(ctx context.Context, spec domain.RoutingRuleSpec) (domain.RoutingRule, error) {
+	if spec.Name == "" ||
+		spec.MinSize < 0 || spec.MaxSize < 0 || (spec.MaxSize != 0 && spec.MaxSize < spec.MinSize) ||
+		spec.MinDepth < 0 || spec.MaxDepth < 0 || (spec.MaxDepth != 0 && spec.MaxDepth < spec.MinDepth) ||
+		(spec.WorkflowID == 0 && len(spec.SkipOrders) == 0) {
+		return domain.RoutingRule{}, domain.ErrInvalidRoutingRule
+	}
+
+	if _, err := path.Match(spec.NamePattern, ""); err != nil {
+		return domain.RoutingRule{}, domain.ErrInvalidRoutingRule
+	}
+
+	skipOrders := slices.Clone(spec.SkipOrders)
+	slices.Sort(skipOrders)
+	skipOrders = slices.Compact(skipOrders)
+	if len(skipOrders) > 0 && skipOrders[0] < 1 {
+		return domain.RoutingRule{}, domain.ErrInvalidRoutingRule
+	}
+
+	for _, workflowID := range []uint{spec.WorkflowID, spec.SourceWorkflowID} {
+		if workflowID == 0 {
+			continue
+		}
+		exists, err := u.workflowRepo.CheckWorkflow(ctx, workflowID)
+		if err != nil {
+			return domain.RoutingRule{}, err
+		}
+		if !exists {
+			return domain.RoutingRule{}, domain.ErrWorkflowNotFound
+		}
+	}
+
+	return domain.RoutingRule{
+		Name:             spec.Name,
+		Priority:         spec.Priority,
+		SourceWorkflowID: spec.SourceWorkflowID,
+		ContentType:      spec.ContentType,
+		NamePattern:      spec.NamePattern,
+		MinSize:          spec.MinSize,
+		MaxSize:          spec.MaxSize,
+		MinDepth:         spec.MinDepth,
+		MaxDepth:         spec.MaxDepth,
+		WorkflowID:       spec.WorkflowID,
+		SkipOrders:       skipOrders,
+	}, nil
+}
+
+func routingRuleSpec(rule domain.RoutingRule) domain.RoutingRuleSpec {
+	return domain.RoutingRuleSpec{
+		Name:             rule.Name,
+		Priority:         rule.Priority,
+		SourceWorkflowID: rule.SourceWorkflowID,
+		ContentType:      rule.ContentType,
+		NamePattern:      rule.NamePattern,
+		MinSize:          rule.MinSize,
+		MaxSize:          rule.MaxSize,
+		MinDepth:         rule.MinDepth,
+		MaxDepth:         rule.MaxDepth,
+		WorkflowID:       rule.WorkflowID,
+		SkipOrders:       rule.SkipOrders,
+	}
+}
+
+// routeFile применяет к файлу первое подходящее правило из rules, отсортированных по приоритету
+func routeFile(file *domain.File, rules []domain.RoutingRule) domain.ApprovalRoute {
+	route := domain.ApprovalRoute{
+		FileID:      file.ID,
+		ContentType: file.ContentType,
+		Size:        file.Size,
+		Depth:       file.Directory.Depth,
+		WorkflowID:  file.Directory.WorkflowID,
+		SkipOrders:  []int{},
+
+		WorkflowInherited: file.Directory.WorkflowInherited,
+	}
+
+	for _, rule := range rules {
+		if !rule.Matches(file) {
+			continue
+		}
+
+		route.RuleID = rule.ID
+		route.RuleName = rule.Name
+		if rule.WorkflowID != 0 {
+			route.WorkflowID = rule.WorkflowID
+			route.WorkflowInherited = false
+		}
+		if len(rule.SkipOrders) > 0 {
+			route.SkipOrders = rule.SkipOrders
+		}
+		break
+	}
+
+	return route
+}
+
+// skipStages убирает этапы skipOrders и нумерует оставшиеся этапы заново с 1,
+// так же как это происходит при создании Approval
+func skipStages(stages []domain.WorkflowStage, skipOrders []int) []domain.WorkflowStage {
+	kept := make([]domain.WorkflowStage, 0, len(stages))
+	for _, stage := range stages {
+		if slices.Contains(skipOrders, stage.Order) {
+			continue
+		}
+		stage.Order = len(kept) + 1
+		kept = append(kept, stage)
+	}
+	return kept
+}
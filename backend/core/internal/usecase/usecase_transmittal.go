@@ -0,0 +1,525 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/logger/slogger"
+	"strings"
+	"time"
+)
+
+type TransmittalUsecase struct {
+	transmittalRepo interfaces.TransmittalRepository
+	approvalRepo    interfaces.ApprovalRepository
+	workflowRepo    interfaces.WorkflowRepository
+	userRepo        interfaces.UserRepository
+	fileService     interfaces.FileService
+	outbox          interfaces.OutboxUsecase
+	authorizer      interfaces.Authorizer
+	log             *slog.Logger
+}
+
+func NewTransmittalUsecase(
+	transmittalRepo interfaces.TransmittalRepository,
+	approvalRepo interfaces.ApprovalRepository,
+	workflowRepo interfaces.WorkflowRepository,
+	userRepo interfaces.UserRepository,
+	fileService interfaces.FileService,
+	outbox interfaces.OutboxUsecase,
+	authorizer interfaces.Authorizer,
+	log *slog.Logger,
+) *TransmittalUsecase {
+	return &TransmittalUsecase{
+		transmittalRepo: transmittalRepo,
+		approvalRepo:    approvalRepo,
+		workflowRepo:    workflowRepo,
+		userRepo:        userRepo,
+		fileService:     fileService,
+		outbox:          outbox,
+		authorizer:      authorizer,
+		log:             log,
+	}
+}
+
+// CreateTransmittal создает комплект из файлов fileIDs. Если процедура не указана (workflowID = 0),
+// комплект согласуется по процедуре директорий файлов, которая должна быть у всех файлов одной.
+// Правила маршрутизации к комплектам не применяются. Нужен уровень доступа approve ко всем файлам.
+// Файлы не должны быть на отдельном согласовании или в другом открытом комплекте
+// Кастомные ошибки: ErrInvalidTransmittal, ErrBulkLimitExceeded, ErrAccessDenied, ErrFileNotFound,
+// ErrWorkflowNotFound, ErrFileInUse
+func (u *TransmittalUsecase) CreateTransmittal(ctx context.Context, title string, workflowID uint, fileIDs []uint, userID uint) (uint, error) {
+	const op = "usecase.transmittal.CreateTransmittal"
+
+	log := u.log.With(slog.String("op", op), slog.Any("user_id", userID), slog.Int("files", len(fileIDs)))
+	log.Info("creating transmittal")
+
+	title = strings.TrimSpace(title)
+	if title == "" || len(fileIDs) == 0 {
+		log.Warn("transmittal title or files are empty")
+		return 0, fmt.Errorf("%s: %w", op, domain.ErrInvalidTransmittal)
+	}
+	if len(fileIDs) > bulkMaxItems {
+		log.Warn("too many files in transmittal")
+		return 0, fmt.Errorf("%s: %w", op, domain.ErrBulkLimitExceeded)
+	}
+
+	uniqueIDs := make([]uint, 0, len(fileIDs))
+	seen := make(map[uint]struct{}, len(fileIDs))
+	for _, fileID := range fileIDs {
+		if _, ok := seen[fileID]; ok {
+			continue
+		}
+		seen[fileID] = struct{}{}
+		uniqueIDs = append(uniqueIDs, fileID)
+	}
+
+	log.Debug("fetching files from file service")
+	files, err := u.getFiles(ctx, uniqueIDs)
+	if err != nil {
+		log.Error("failed to get files", slogger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Без явно указанной процедуры комплект согласуется по общей процедуре директорий файлов
+	explicitWorkflow := workflowID != 0
+	members := make([]domain.TransmittalFile, 0, len(uniqueIDs))
+	for _, fileID := range uniqueIDs {
+		file, ok := files[fileID]
+		if !ok {
+			log.Warn("file not found", slog.Any("file_id", fileID))
+			return 0, fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		}
+
+		if !explicitWorkflow {
+			var directoryWorkflowID uint
+			if file.Directory != nil {
+				directoryWorkflowID = file.Directory.WorkflowID
+			}
+			if len(members) == 0 {
+				workflowID = directoryWorkflowID
+			}
+			if directoryWorkflowID != workflowID {
+				log.Warn("files have different workflows", slog.Any("file_id", fileID))
+				return 0, fmt.Errorf("%s: %w", op, domain.ErrInvalidTransmittal)
+			}
+		}
+		members = append(members, domain.TransmittalFile{FileID: fileID, FileVersion: file.Version})
+	}
+
+	log.Debug("checking access level to files")
+	if err := checkApproveAccess(ctx, u.fileService, userID, uniqueIDs); err != nil {
+		log.Warn("failed file access check", slogger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if workflowID == 0 {
+		log.Warn("files have no workflow")
+		return 0, fmt.Errorf("%s: %w", op, domain.ErrInvalidTransmittal)
+	}
+
+	log.Debug("checking workflow", slog.Any("workflow_id", workflowID))
+	exists, err := u.workflowRepo.CheckWorkflow(ctx, workflowID)
+	if err != nil {
+		log.Error("failed to check workflow", slogger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		log.Warn("workflow not found")
+		return 0, fmt.Errorf("%s: %w", op, domain.ErrWorkflowNotFound)
+	}
+
+	transmittal := &domain.Transmittal{
+		Title:      title,
+		WorkflowID: workflowID,
+		CreatedBy:  userID,
+	}
+
+	log.Debug("saving transmittal")
+	if err := u.transmittalRepo.CreateTransmittal(ctx, transmittal, members); err != nil {
+		if errors.Is(err, domain.ErrFileInUse) {
+			log.Warn("file is already on approval or in an open transmittal", slogger.Err(err))
+			return 0, fmt.Errorf("%s: %w", op, err)
+		}
+		log.Error("failed to create transmittal", slogger.Err(err))
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("transmittal created successfully", slog.Any("transmittal_id", transmittal.ID), slog.String("number", transmittal.Number))
+	return transmittal.ID, nil
+}
+
+// GetTransmittals возвращает комплекты пользователя. Пользователь с разрешением approval.view.any
+// получает все комплекты
+func (u *TransmittalUsecase) GetTransmittals(ctx context.Context, userID uint) ([]domain.TransmittalResponse, error) {
+	const op = "usecase.transmittal.GetTransmittals"
+
+	log := u.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("getting transmittals")
+
+	creatorID := userID
+	if err := u.authorizer.Authorize(ctx, userID, domain.PermissionApprovalViewAny); err == nil {
+		creatorID = 0
+	} else if !errors.Is(err, domain.ErrAccessDenied) {
+		log.Error("failed permission check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	transmittals, err := u.transmittalRepo.GetTransmittals(ctx, creatorID)
+	if err != nil {
+		log.Error("failed to get transmittals", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if transmittals == nil {
+		transmittals = []domain.TransmittalResponse{}
+	}
+
+	log.Info("transmittals got successfully")
+	return transmittals, nil
+}
+
+// GetTransmittal возвращает комплект с текущими статусами его файлов. Комплект доступен автору,
+// участникам его согласования и пользователям с разрешением approval.view.any
+// Кастомные ошибки: ErrTransmittalNotFound, ErrAccessDenied
+func (u *TransmittalUsecase) GetTransmittal(ctx context.Context, transmittalID, userID uint) (domain.TransmittalDetails, error) {
+	const op = "usecase.transmittal.GetTransmittal"
+
+	log := u.log.With(slog.String("op", op), slog.Any("transmittal_id", transmittalID), slog.Any("user_id", userID))
+	log.Info("getting transmittal")
+
+	details, err := u.getDetails(ctx, transmittalID, userID)
+	if err != nil {
+		log.Error("failed to get transmittal", slogger.Err(err))
+		return domain.TransmittalDetails{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("transmittal got successfully")
+	return details, nil
+}
+
+// SubmitTransmittal отправляет комплект на согласование. Все файлы комплекта должны быть черновиками.
+// Если по комплекту были запрошены изменения, согласование продолжается с того же этапа, для этого
+// хотя бы один файл должен быть обновлен. Отозванный комплект отправляется новым согласованием.
+// Отправить комплект может его автор или пользователь с разрешением approval.finalize.any,
+// если у него есть уровень доступа approve ко всем файлам комплекта
+// Кастомные ошибки: ErrTransmittalNotFound, ErrAccessDenied, ErrInvalidTransmittalStatus,
+// ErrFileNotFound, ErrInvalidFileStatus, ErrFileNotUpdated, ErrWorkflowNotFound
+func (u *TransmittalUsecase) SubmitTransmittal(ctx context.Context, transmittalID, userID uint) error {
+	const op = "usecase.transmittal.SubmitTransmittal"
+
+	log := u.log.With(slog.String("op", op), slog.Any("transmittal_id", transmittalID), slog.Any("user_id", userID))
+	log.Info("submitting transmittal")
+
+	log.Debug("getting transmittal")
+	transmittal, err := u.transmittalRepo.GetTransmittalByID(ctx, transmittalID)
+	if err != nil {
+		log.Error("failed to get transmittal", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.checkAuthor(ctx, transmittal, userID); err != nil {
+		log.Error("failed author check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	switch transmittal.Status {
+	case "draft", "annotated", "withdrawn":
+	default:
+		log.Warn("transmittal cannot be submitted", slog.String("status", transmittal.Status))
+		return fmt.Errorf("%s: %w", op, domain.ErrInvalidTransmittalStatus)
+	}
+
+	log.Debug("getting transmittal files")
+	members, err := u.transmittalRepo.GetTransmittalFiles(ctx, transmittalID)
+	if err != nil {
+		log.Error("failed to get transmittal files", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	fileIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		fileIDs = append(fileIDs, member.FileID)
+	}
+
+	log.Debug("fetching files from file service")
+	files, err := u.getFiles(ctx, fileIDs)
+	if err != nil {
+		log.Error("failed to get files", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	updated := false
+	submitted := make([]domain.TransmittalFile, 0, len(members))
+	for _, member := range members {
+		file, ok := files[member.FileID]
+		if !ok {
+			log.Warn("file not found", slog.Any("file_id", member.FileID))
+			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		}
+		if file.Status != "draft" {
+			log.Warn("invalid file status for approval", slog.Any("file_id", file.ID), slog.String("status", file.Status))
+			return fmt.Errorf("%s: %w", op, domain.ErrInvalidFileStatus)
+		}
+		if file.Version > member.FileVersion {
+			updated = true
+		}
+		submitted = append(submitted, domain.TransmittalFile{FileID: member.FileID, FileVersion: file.Version})
+	}
+
+	log.Debug("checking access level to files")
+	if err := checkApproveAccess(ctx, u.fileService, userID, fileIDs); err != nil {
+		log.Warn("failed file access check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Ревизия комплекта хранится в FileVersion его Approval и растёт с каждой отправкой
+	revision := 1
+	if transmittal.ApprovalID != 0 {
+		approval, err := u.approvalRepo.GetApprovalByID(ctx, transmittal.ApprovalID)
+		if err != nil {
+			log.Error("failed to get transmittal approval", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		revision = approval.FileVersion + 1
+	}
+
+	if transmittal.Status == "annotated" {
+		if !updated {
+			log.Warn("no file was updated since changes were requested")
+			return fmt.Errorf("%s: %w", op, domain.ErrFileNotUpdated)
+		}
+
+		log.Debug("resuming transmittal approval", slog.Any("approval_id", transmittal.ApprovalID), slog.Int("revision", revision))
+		if err := u.transmittalRepo.ResumeTransmittal(ctx, transmittalID, transmittal.ApprovalID, submitted, revision, userID); err != nil {
+			if errors.Is(err, domain.ErrApprovalNotFound) {
+				log.Warn("transmittal approval is not annotated anymore", slogger.Err(err))
+				return fmt.Errorf("%s: %w", op, domain.ErrInvalidTransmittalStatus)
+			}
+			log.Error("failed to resume transmittal approval", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	} else {
+		approval := &domain.Approval{
+			Status:        "on approval",
+			WorkflowID:    transmittal.WorkflowID,
+			WorkflowOrder: 1,
+			FileVersion:   revision,
+			SubmitterID:   userID,
+
+			StageStartedAt: time.Now(),
+		}
+
+		log.Debug("creating transmittal approval", slog.Any("workflow_id", transmittal.WorkflowID), slog.Int("revision", revision))
+		if err := u.transmittalRepo.SubmitTransmittal(ctx, transmittalID, transmittal.ApprovalID, submitted, approval, userID); err != nil {
+			log.Error("failed to create transmittal approval", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	// Статусы файлов уже записаны в outbox вместе с Approval комплекта. Если file-service
+	// недоступен, изменения доставит диспетчер
+	log.Debug("dispatching file statuses")
+	if err := u.outbox.DispatchPending(ctx); err != nil {
+		log.Warn("file status delivery postponed", slogger.Err(err))
+	}
+
+	log.Info("transmittal submitted successfully")
+	return nil
+}
+
+// DeleteTransmittal удаляет комплект, который не отправлялся на согласование или согласование
+// которого отозвано. Удалить комплект может его автор или пользователь с разрешением approval.finalize.any
+// Кастомные ошибки: ErrTransmittalNotFound, ErrAccessDenied, ErrInvalidTransmittalStatus
+func (u *TransmittalUsecase) DeleteTransmittal(ctx context.Context, transmittalID, userID uint) error {
+	const op = "usecase.transmittal.DeleteTransmittal"
+
+	log := u.log.With(slog.String("op", op), slog.Any("transmittal_id", transmittalID), slog.Any("user_id", userID))
+	log.Info("deleting transmittal")
+
+	log.Debug("getting transmittal")
+	transmittal, err := u.transmittalRepo.GetTransmittalByID(ctx, transmittalID)
+	if err != nil {
+		log.Error("failed to get transmittal", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.checkAuthor(ctx, transmittal, userID); err != nil {
+		log.Error("failed author check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := u.transmittalRepo.DeleteTransmittal(ctx, transmittalID); err != nil {
+		log.Error("failed to delete transmittal", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("transmittal deleted successfully")
+	return nil
+}
+
+// ExportManifest возвращает манифест комплекта: состав с версиями и статусами файлов и подписи
+// последнего согласования комплекта. Подписи этапа, по которому были запрошены изменения,
+// в манифест не попадают: этап заново подписывается по новой ревизии комплекта
+// Кастомные ошибки: ErrTransmittalNotFound, ErrAccessDenied
+func (u *TransmittalUsecase) ExportManifest(ctx context.Context, transmittalID, userID uint) (domain.TransmittalManifest, error) {
+	const op = "usecase.transmittal.ExportManifest"
+
+	log := u.log.With(slog.String("op", op), slog.Any("transmittal_id", transmittalID), slog.Any("user_id", userID))
+	log.Info("exporting transmittal manifest")
+
+	details, err := u.getDetails(ctx, transmittalID, userID)
+	if err != nil {
+		log.Error("failed to get transmittal", slogger.Err(err))
+		return domain.TransmittalManifest{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	manifest := domain.TransmittalManifest{
+		Number:       details.Number,
+		Title:        details.Title,
+		Status:       details.Status,
+		WorkflowName: details.WorkflowName,
+		CreatedBy:    details.CreatorLogin,
+		CreatedAt:    details.CreatedAt,
+		GeneratedAt:  time.Now(),
+		Files:        details.Files,
+		Signatures:   []domain.TransmittalSignature{},
+	}
+
+	if details.ApprovalID != 0 {
+		log.Debug("getting transmittal approval history", slog.Any("approval_id", details.ApprovalID))
+		events, err := u.approvalRepo.GetApprovalHistory(ctx, details.ApprovalID)
+		if err != nil {
+			log.Error("failed to get approval history", slogger.Err(err))
+			return domain.TransmittalManifest{}, fmt.Errorf("%s: %w", op, err)
+		}
+		manifest.Signatures = manifestSignatures(events)
+	}
+
+	log.Info("transmittal manifest exported successfully")
+	return manifest, nil
+}
+
+// getDetails возвращает комплект с файлами, проверяя доступ пользователя к нему
+func (u *TransmittalUsecase) getDetails(ctx context.Context, transmittalID, userID uint) (domain.TransmittalDetails, error) {
+	transmittal, err := u.transmittalRepo.GetTransmittalByID(ctx, transmittalID)
+	if err != nil {
+		return domain.TransmittalDetails{}, err
+	}
+
+	if err := u.checkAccess(ctx, transmittal, userID); err != nil {
+		return domain.TransmittalDetails{}, err
+	}
+
+	members, err := u.transmittalRepo.GetTransmittalFiles(ctx, transmittalID)
+	if err != nil {
+		return domain.TransmittalDetails{}, err
+	}
+
+	fileIDs := make([]uint, 0, len(members))
+	for _, member := range members {
+		fileIDs = append(fileIDs, member.FileID)
+	}
+	files, err := u.getFiles(ctx, fileIDs)
+	if err != nil {
+		return domain.TransmittalDetails{}, err
+	}
+
+	details := domain.TransmittalDetails{
+		TransmittalResponse: *transmittal,
+		Files:               make([]domain.TransmittalFileResponse, 0, len(members)),
+	}
+	for _, member := range members {
+		item := domain.TransmittalFileResponse{
+			FileID:      member.FileID,
+			FileName:    "unknown",
+			FileVersion: member.FileVersion,
+		}
+		if file, ok := files[member.FileID]; ok {
+			item.FileName = file.Name
+			item.Status = file.Status
+		}
+		details.Files = append(details.Files, item)
+	}
+
+	return details, nil
+}
+
+// getFiles запрашивает файлы у file-service пакетами. Ненайденные файлы в результат не попадают
+func (u *TransmittalUsecase) getFiles(ctx context.Context, fileIDs []uint) (map[uint]*domain.File, error) {
+	files := make(map[uint]*domain.File, len(fileIDs))
+	for start := 0; start < len(fileIDs); start += bulkFileBatchSize {
+		batch := make([]uint32, 0, bulkFileBatchSize)
+		for _, fileID := range fileIDs[start:min(start+bulkFileBatchSize, len(fileIDs))] {
+			batch = append(batch, uint32(fileID))
+		}
+
+		batchFiles, err := u.fileService.GetFilesWithDirectory(ctx, batch)
+		if err != nil {
+			return nil, err
+		}
+		for i := range batchFiles {
+			files[batchFiles[i].ID] = &batchFiles[i]
+		}
+	}
+	return files, nil
+}
+
+// checkAccess проверяет, что пользователь - автор комплекта, имеет разрешение approval.view.any
+// или участвует в последнем согласовании комплекта
+func (u *TransmittalUsecase) checkAccess(ctx context.Context, transmittal *domain.TransmittalResponse, userID uint) error {
+	if err := u.checkAuthor(ctx, transmittal, userID); !errors.Is(err, domain.ErrAccessDenied) {
+		return err
+	}
+	if err := u.authorizer.Authorize(ctx, userID, domain.PermissionApprovalViewAny); !errors.Is(err, domain.ErrAccessDenied) {
+		return err
+	}
+	if transmittal.ApprovalID == 0 {
+		return domain.ErrAccessDenied
+	}
+
+	participant, err := u.approvalRepo.CheckApprovalParticipant(ctx, userID, []uint{transmittal.ApprovalID})
+	if err != nil {
+		return err
+	}
+	if !participant {
+		return domain.ErrAccessDenied
+	}
+	return nil
+}
+
+// checkAuthor проверяет, что пользователь - автор комплекта или имеет разрешение approval.finalize.any
+func (u *TransmittalUsecase) checkAuthor(ctx context.Context, transmittal *domain.TransmittalResponse, userID uint) error {
+	if transmittal.CreatedBy == userID {
+		return nil
+	}
+	return u.authorizer.Authorize(ctx, userID, domain.PermissionApprovalFinalizeAny)
+}
+
+// manifestSignatures собирает действующие подписи согласования по его журналу. Повторная отправка
+// после запроса изменений сбрасывает подписи этапа, с которого продолжается согласование
+func manifestSignatures(events []domain.ApprovalEventResponse) []domain.TransmittalSignature {
+	signatures := []domain.TransmittalSignature{}
+	for _, event := range events {
+		switch event.Action {
+		case domain.ApprovalActionSign, domain.ApprovalActionFinalize:
+			signatures = append(signatures, domain.TransmittalSignature{
+				WorkflowOrder: event.WorkflowOrder,
+				Login:         event.ActorLogin,
+				OnBehalfOf:    event.OnBehalfOf,
+				SignedAt:      event.CreatedAt,
+			})
+		case domain.ApprovalActionResubmit:
+			valid := signatures[:0]
+			for _, signature := range signatures {
+				if signature.WorkflowOrder < event.WorkflowOrder {
+					valid = append(valid, signature)
+				}
+			}
+			signatures = valid
+		}
+	}
+	return signatures
+}
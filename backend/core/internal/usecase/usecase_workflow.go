@@ -1,265 +1,518 @@
-package usecase
-
-import (
-	"context"
-	"fmt"
-	"log/slog"
-	"service-core/internal/domain"
-	"service-core/internal/domain/interfaces"
-	"service-core/pkg/logger/slogger"
-)
-
-type WorkflowUsecase struct {
-	workflowRepo interfaces.WorkflowRepository
-	userRepo     interfaces.UserRepository
-	fileService  interfaces.FileService
-	log          *slog.Logger
-}
-
-func NewWorkflowUsecase(
-	workflowRepo interfaces.WorkflowRepository,
-	userRepo interfaces.UserRepository,
-	fileService interfaces.FileService,
-	log *slog.Logger,
-) *WorkflowUsecase {
-	return &WorkflowUsecase{
-		workflowRepo: workflowRepo,
-		userRepo:     userRepo,
-		fileService:  fileService,
-		log:          log,
-	}
-}
-
-func (workflowUsecase *WorkflowUsecase) GetWorkflows(ctx context.Context, userID uint) ([]domain.WorkflowResponse, error) {
-	const op = "usecase.workflow.GetWorkflows"
-
-	log := workflowUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID))
-	log.Info("getting workflows")
-
-	log.Debug("checking if user is admin")
-	if err := workflowUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("getting worklows from database")
-	workflows, err := workflowUsecase.workflowRepo.GetWorkflows(ctx)
-	if err != nil {
-		// TODO: custom errors?
-		log.Error("failed to get workflows", slogger.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("workflows got succesfully")
-	return workflows, nil
-}
-
-func (workflowUsecase *WorkflowUsecase) GetWorkflowByID(ctx context.Context, workflowID, userID uint) (domain.ExtendedWorkflowResponse, error) {
-	const op = "usecase.workflow.GetWorkflowByID"
-
-	log := workflowUsecase.log.With(slog.String("op", op))
-	log.Info("getting workfow by id")
-
-	log.Debug("checking if user is admin")
-	if err := workflowUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking workflow existence")
-	if err := workflowUsecase.checkWorkflow(ctx, workflowID); err != nil {
-		log.Error("failed workflow existence check", slogger.Err(err))
-		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("getting workflow by id from db")
-	workflow, err := workflowUsecase.workflowRepo.GetWorkflowByID(ctx, workflowID)
-	if err != nil {
-		log.Error("failed to get workflow", slogger.Err(err))
-		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("workflow got successfully")
-	return workflow, nil
-}
-
-func (workflowUsecase *WorkflowUsecase) CreateWorkflow(ctx context.Context, name string, stages []domain.WorkflowStage, userID uint) error {
-	const op = "usecase.workflow.CreateWorkflow"
-
-	log := workflowUsecase.log.With(slog.String("op", op))
-	log.Info("creating workfow")
-
-	log.Debug("checking if user is admin")
-	if err := workflowUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking users from request")
-	if err := workflowUsecase.checkUsers(ctx, stages); err != nil {
-		log.Error("failed to validate users", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("putting workflow into db")
-	if err := workflowUsecase.workflowRepo.CreateWorkflow(ctx, name, stages); err != nil {
-		// TODO: custom errors?
-		log.Error("failed to create workflow", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("workflow created successfully")
-	return nil
-}
-
-func (workflowUsecase *WorkflowUsecase) UpdateWorkflow(ctx context.Context, workflowID uint, name string, stages []domain.WorkflowStage, userID uint) error {
-	const op = "usecase.workflow.UpdateWorkflow"
-
-	log := workflowUsecase.log.With(slog.String("op", op))
-	log.Info("updating workfow")
-
-	log.Debug("checking if user is admin")
-	if err := workflowUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking workflow existence")
-	if err := workflowUsecase.checkWorkflow(ctx, workflowID); err != nil {
-		log.Error("failed workflow existence check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking users from request")
-	if err := workflowUsecase.checkUsers(ctx, stages); err != nil {
-		log.Error("failed to validate users", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("updating workflow")
-	if err := workflowUsecase.workflowRepo.UpdateWorkflow(ctx, workflowID, name, stages); err != nil {
-		// TODO: custom errors?
-		log.Error("failed to update workflow", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("workflow updated successfully")
-	return nil
-}
-
-func (workflowUsecase *WorkflowUsecase) DeleteWorkflow(ctx context.Context, workflowID uint, userID uint) error {
-	const op = "usecase.workflow.DeleteWorkflow"
-
-	log := workflowUsecase.log.With(slog.String("op", op))
-	log.Info("deleting workfow")
-
-	log.Debug("checking if user is admin")
-	if err := workflowUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking workflow existence")
-	if err := workflowUsecase.checkWorkflow(ctx, workflowID); err != nil {
-		log.Error("failed workflow existence check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking if this workflow in use")
-	exists, err := workflowUsecase.fileService.CheckWorkflow(ctx, workflowID)
-	if err != nil {
-		// TODO: custom errors
-		log.Error("failed workflow check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if exists {
-		log.Error("workflow is in use", slogger.Err(domain.ErrWorkflowInUse))
-		return fmt.Errorf("%s: %w", op, domain.ErrWorkflowInUse)
-	}
-
-	log.Debug("deleting workflow")
-	if err := workflowUsecase.workflowRepo.DeleteWorkflow(ctx, workflowID); err != nil {
-		// TODO: custom errors
-		log.Error("failed to delete workflow", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (workflowUsecase *WorkflowUsecase) AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint, userID uint) error {
-	const op = "usecase.workflow.AssignWorkflow"
-
-	log := workflowUsecase.log.With(slog.String("op", op))
-	log.Info("assigning workfow")
-
-	log.Debug("checking if user is admin")
-	if err := workflowUsecase.checkAdmin(ctx, userID); err != nil {
-		log.Error("failed admin check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("checking workflow existence")
-	if err := workflowUsecase.checkWorkflow(ctx, workflowID); err != nil {
-		log.Error("failed workflow existence check", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	directories := make([]uint32, len(directoryIDs))
-	for i, value := range directoryIDs {
-		directories[i] = uint32(value)
-	}
-
-	log.Debug("deleting workflow")
-	if err := workflowUsecase.fileService.AssignWorkflow(ctx, workflowID, directories); err != nil {
-		// TODO: custom errors
-		log.Error("failed to delete workflow", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (workflowUsecase *WorkflowUsecase) checkAdmin(ctx context.Context, userID uint) error {
-	role, err := workflowUsecase.userRepo.GetUserRole(ctx, userID)
-	if err != nil {
-		return err
-	}
-	if role != "admin" {
-		return domain.ErrAccessDenied
-	}
-	return nil
-}
-
-func (workflowUsecase *WorkflowUsecase) checkUsers(ctx context.Context, stages []domain.WorkflowStage) error {
-	userMap := make(map[uint]struct{})
-	for _, stage := range stages {
-		userMap[stage.UserID] = struct{}{}
-	}
-
-	userIDs := make([]uint, 0, len(userMap))
-	for userID := range userMap {
-		userIDs = append(userIDs, userID)
-	}
-
-	allExists, err := workflowUsecase.userRepo.CheckUsersExist(ctx, userIDs)
-	if err != nil {
-		return err
-	}
-	if !allExists {
-		return domain.ErrUserNotFound
-	}
-
-	return nil
-}
-
-func (workflowUsecase *WorkflowUsecase) checkWorkflow(ctx context.Context, workflowID uint) error {
-	exists, err := workflowUsecase.workflowRepo.CheckWorkflow(ctx, workflowID)
-	if err != nil {
-		return err
-	}
-	if !exists {
-		return domain.ErrWorkflowNotFound
-	}
-	return nil
-}
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/internal/domain/interfaces"
+	"service-core/pkg/logger/slogger"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+type WorkflowUsecase struct {
+	workflowRepo   interfaces.WorkflowRepository
+	userRepo       interfaces.UserRepository
+	roleRepo       interfaces.RoleRepository
+	routingRepo    interfaces.RoutingRuleRepository
+	delegationRepo interfaces.DelegationRepository
+	fileService    interfaces.FileService
+	authorizer     interfaces.Authorizer
+	log            *slog.Logger
+}
+
+func NewWorkflowUsecase(
+	workflowRepo interfaces.WorkflowRepository,
+	userRepo interfaces.UserRepository,
+	roleRepo interfaces.RoleRepository,
+	routingRepo interfaces.RoutingRuleRepository,
+	delegationRepo interfaces.DelegationRepository,
+	fileService interfaces.FileService,
+	authorizer interfaces.Authorizer,
+	log *slog.Logger,
+) *WorkflowUsecase {
+	return &WorkflowUsecase{
+		workflowRepo:   workflowRepo,
+		userRepo:       userRepo,
+		roleRepo:       roleRepo,
+		routingRepo:    routingRepo,
+		delegationRepo: delegationRepo,
+		fileService:    fileService,
+		authorizer:     authorizer,
+		log:            log,
+	}
+}
+
+func (workflowUsecase *WorkflowUsecase) GetWorkflows(ctx context.Context, userID uint) ([]domain.WorkflowResponse, error) {
+	const op = "usecase.workflow.GetWorkflows"
+
+	log := workflowUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("getting workflows")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting worklows from database")
+	workflows, err := workflowUsecase.workflowRepo.GetWorkflows(ctx)
+	if err != nil {
+		// TODO: custom errors?
+		log.Error("failed to get workflows", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("workflows got succesfully")
+	return workflows, nil
+}
+
+func (workflowUsecase *WorkflowUsecase) GetWorkflowByID(ctx context.Context, workflowID, userID uint) (domain.ExtendedWorkflowResponse, error) {
+	const op = "usecase.workflow.GetWorkflowByID"
+
+	log := workflowUsecase.log.With(slog.String("op", op))
+	log.Info("getting workfow by id")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking workflow existence")
+	if err := workflowUsecase.checkWorkflow(ctx, workflowID); err != nil {
+		log.Error("failed workflow existence check", slogger.Err(err))
+		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting workflow by id from db")
+	workflow, err := workflowUsecase.workflowRepo.GetWorkflowByID(ctx, workflowID)
+	if err != nil {
+		log.Error("failed to get workflow", slogger.Err(err))
+		return domain.ExtendedWorkflowResponse{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("workflow got successfully")
+	return workflow, nil
+}
+
+// CreateWorkflow создает процедуру согласования. Процедура проверяется целиком: все ошибки полей
+// возвращаются вместе в WorkflowValidationError
+// Кастомные ошибки: ErrAccessDenied, ErrInvalidWorkflow (WorkflowValidationError), ErrWorkflowNameTaken
+func (workflowUsecase *WorkflowUsecase) CreateWorkflow(ctx context.Context, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage, userID uint) error {
+	const op = "usecase.workflow.CreateWorkflow"
+
+	log := workflowUsecase.log.With(slog.String("op", op))
+	log.Info("creating workfow")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("validating workflow")
+	name = strings.TrimSpace(name)
+	stages, err := workflowUsecase.validateWorkflow(ctx, 0, name, stages)
+	if err != nil {
+		log.Error("invalid workflow", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("putting workflow into db")
+	if _, err := workflowUsecase.workflowRepo.CreateWorkflow(ctx, name, options, stages); err != nil {
+		// TODO: custom errors?
+		log.Error("failed to create workflow", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("workflow created successfully")
+	return nil
+}
+
+// UpdateWorkflow создает новую ревизию процедуры согласования. Она применяется только к новым
+// согласованиям: начатые идут по копии этапов, сделанной при отправке файла
+// Кастомные ошибки: ErrAccessDenied, ErrWorkflowNotFound, ErrInvalidWorkflow (WorkflowValidationError),
+// ErrWorkflowNameTaken
+func (workflowUsecase *WorkflowUsecase) UpdateWorkflow(ctx context.Context, workflowID uint, name string, options domain.WorkflowOptions, stages []domain.WorkflowStage, userID uint) error {
+	const op = "usecase.workflow.UpdateWorkflow"
+
+	log := workflowUsecase.log.With(slog.String("op", op))
+	log.Info("updating workfow")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking workflow existence")
+	if err := workflowUsecase.checkWorkflow(ctx, workflowID); err != nil {
+		log.Error("failed workflow existence check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("validating workflow")
+	name = strings.TrimSpace(name)
+	stages, err := workflowUsecase.validateWorkflow(ctx, workflowID, name, stages)
+	if err != nil {
+		log.Error("invalid workflow", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("updating workflow")
+	if err := workflowUsecase.workflowRepo.UpdateWorkflow(ctx, workflowID, name, options, stages); err != nil {
+		// TODO: custom errors?
+		log.Error("failed to update workflow", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("workflow updated successfully")
+	return nil
+}
+
+func (workflowUsecase *WorkflowUsecase) DeleteWorkflow(ctx context.Context, workflowID uint, userID uint) error {
+	const op = "usecase.workflow.DeleteWorkflow"
+
+	log := workflowUsecase.log.With(slog.String("op", op))
+	log.Info("deleting workfow")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking workflow existence")
+	if err := workflowUsecase.checkWorkflow(ctx, workflowID); err != nil {
+		log.Error("failed workflow existence check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking if this workflow in use")
+	exists, err := workflowUsecase.fileService.CheckWorkflow(ctx, workflowID)
+	if err != nil {
+		// TODO: custom errors
+		log.Error("failed workflow check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if exists {
+		log.Error("workflow is in use", slogger.Err(domain.ErrWorkflowInUse))
+		return fmt.Errorf("%s: %w", op, domain.ErrWorkflowInUse)
+	}
+
+	log.Debug("deleting workflow")
+	if err := workflowUsecase.workflowRepo.DeleteWorkflow(ctx, workflowID); err != nil {
+		// TODO: custom errors
+		log.Error("failed to delete workflow", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (workflowUsecase *WorkflowUsecase) AssignWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint, userID uint) error {
+	const op = "usecase.workflow.AssignWorkflow"
+
+	log := workflowUsecase.log.With(slog.String("op", op))
+	log.Info("assigning workfow")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionDirectoryAssign); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("checking workflow existence")
+	if err := workflowUsecase.checkWorkflow(ctx, workflowID); err != nil {
+		log.Error("failed workflow existence check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	directories := make([]uint32, len(directoryIDs))
+	for i, value := range directoryIDs {
+		directories[i] = uint32(value)
+	}
+
+	log.Debug("deleting workflow")
+	if err := workflowUsecase.fileService.AssignWorkflow(ctx, workflowID, directories); err != nil {
+		// TODO: custom errors
+		log.Error("failed to delete workflow", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// InheritWorkflow снимает с директорий назначенные им процедуры согласования: директории снова
+// наследуют процедуру родительской директории
+// Кастомные ошибки: ErrAccessDenied
+func (workflowUsecase *WorkflowUsecase) InheritWorkflow(ctx context.Context, directoryIDs []uint, userID uint) error {
+	const op = "usecase.workflow.InheritWorkflow"
+
+	log := workflowUsecase.log.With(slog.String("op", op))
+	log.Info("resetting directory workflows to inherited")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionDirectoryAssign); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	directories := make([]uint32, len(directoryIDs))
+	for i, value := range directoryIDs {
+		directories[i] = uint32(value)
+	}
+
+	log.Debug("clearing directory workflows")
+	if err := workflowUsecase.fileService.AssignWorkflow(ctx, 0, directories); err != nil {
+		log.Error("failed to clear directory workflows", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("directory workflows reset successfully")
+	return nil
+}
+
+// maxWorkflowNameLength - длина имени процедуры согласования в символах
+const maxWorkflowNameLength = 255
+
+// validateWorkflow проверяет имя и этапы процедуры согласования workflowID (0 - новая процедура):
+// имя задано и не занято другой процедурой, этапы корректны (см. validateStages), пользователи и роли
+// существуют. Возвращает нормализованные этапы или WorkflowValidationError со всеми ошибками полей
+func (workflowUsecase *WorkflowUsecase) validateWorkflow(ctx context.Context, workflowID uint, name string, stages []domain.WorkflowStage) ([]domain.WorkflowStage, error) {
+	var fieldErrors []domain.FieldError
+	fail := func(field, message string) {
+		fieldErrors = append(fieldErrors, domain.FieldError{Field: field, Message: message})
+	}
+
+	switch {
+	case name == "":
+		fail("workflow_name", "workflow name is required")
+	case utf8.RuneCountInString(name) > maxWorkflowNameLength:
+		fail("workflow_name", fmt.Sprintf("workflow name must be at most %d characters", maxWorkflowNameLength))
+	default:
+		taken, err := workflowUsecase.workflowRepo.CheckWorkflowName(ctx, name, workflowID)
+		if err != nil {
+			return nil, err
+		}
+		if taken {
+			fail("workflow_name", "workflow with this name already exists")
+		}
+	}
+
+	normalized, stageErrors := validateStages(stages)
+	fieldErrors = append(fieldErrors, stageErrors...)
+
+	// Пользователи и роли проверяются по исходным индексам этапов, чтобы поля указывали на запрос
+	users := make(map[uint]bool)
+	roles := make(map[uint]bool)
+	for i, stage := range stages {
+		userIDs := stage.UserIDs
+		if len(userIDs) == 0 && stage.UserID != 0 {
+			userIDs = []uint{stage.UserID}
+		}
+		for j, userID := range userIDs {
+			exists, err := workflowUsecase.userExists(ctx, users, userID)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				fail(fmt.Sprintf("stages[%d].user_ids[%d]", i, j), "user not found")
+			}
+		}
+		for j, roleID := range stage.RoleIDs {
+			exists, err := workflowUsecase.roleExists(ctx, roles, roleID)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				fail(fmt.Sprintf("stages[%d].role_ids[%d]", i, j), "role not found")
+			}
+		}
+		if stage.EscalationUserID != 0 {
+			exists, err := workflowUsecase.userExists(ctx, users, stage.EscalationUserID)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				fail(fmt.Sprintf("stages[%d].escalation_user_id", i), "user not found")
+			}
+		}
+		if stage.EscalationRoleID != 0 {
+			exists, err := workflowUsecase.roleExists(ctx, roles, stage.EscalationRoleID)
+			if err != nil {
+				return nil, err
+			}
+			if !exists {
+				fail(fmt.Sprintf("stages[%d].escalation_role_id", i), "role not found")
+			}
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, &domain.WorkflowValidationError{Fields: fieldErrors}
+	}
+	return normalized, nil
+}
+
+// userExists проверяет существование пользователя, запоминая результат в checked
+func (workflowUsecase *WorkflowUsecase) userExists(ctx context.Context, checked map[uint]bool, userID uint) (bool, error) {
+	if exists, ok := checked[userID]; ok {
+		return exists, nil
+	}
+
+	exists, err := workflowUsecase.userRepo.CheckUsersExist(ctx, []uint{userID})
+	if err != nil {
+		return false, err
+	}
+	checked[userID] = exists
+	return exists, nil
+}
+
+// roleExists проверяет существование роли, запоминая результат в checked
+func (workflowUsecase *WorkflowUsecase) roleExists(ctx context.Context, checked map[uint]bool, roleID uint) (bool, error) {
+	if exists, ok := checked[roleID]; ok {
+		return exists, nil
+	}
+
+	exists, err := workflowUsecase.roleRepo.CheckRole(ctx, roleID)
+	if err != nil {
+		return false, err
+	}
+	checked[roleID] = exists
+	return exists, nil
+}
+
+func (workflowUsecase *WorkflowUsecase) checkWorkflow(ctx context.Context, workflowID uint) error {
+	exists, err := workflowUsecase.workflowRepo.CheckWorkflow(ctx, workflowID)
+	if err != nil {
+		return err
+	}
+	if !exists {
+		return domain.ErrWorkflowNotFound
+	}
+	return nil
+}
+
+// normalizeStages приводит этапы к единому виду (см. validateStages)
+// Кастомные ошибки: ErrInvalidStage
+func normalizeStages(stages []domain.WorkflowStage) ([]domain.WorkflowStage, error) {
+	normalized, fieldErrors := validateStages(stages)
+	if len(fieldErrors) > 0 {
+		return nil, domain.ErrInvalidStage
+	}
+	return normalized, nil
+}
+
+// validateStages приводит этапы к единому виду (одиночный user_id переносится в user_ids, пустое
+// правило считается "all", этапы без номеров нумеруются по порядку) и проверяет их: номера этапов
+// идут подряд с 1 без повторов, у каждого этапа есть подписанты без повторов, корректны правило,
+// кворум, срок и резервный подписант. Возвращает этапы, отсортированные по номеру, и ошибки полей
+// с индексами этапов в исходном списке
+func validateStages(stages []domain.WorkflowStage) ([]domain.WorkflowStage, []domain.FieldError) {
+	var fieldErrors []domain.FieldError
+	fail := func(field, message string) {
+		fieldErrors = append(fieldErrors, domain.FieldError{Field: field, Message: message})
+	}
+
+	if len(stages) == 0 {
+		fail("stages", "workflow must have at least one stage")
+		return nil, fieldErrors
+	}
+
+	// Номера можно не указывать: тогда этапы идут в порядке списка
+	numbered := false
+	for _, stage := range stages {
+		if stage.Order != 0 {
+			numbered = true
+		}
+	}
+
+	normalized := make([]domain.WorkflowStage, len(stages))
+	orders := make(map[int]int, len(stages))
+	for i, stage := range stages {
+		field := fmt.Sprintf("stages[%d]", i)
+
+		if !numbered {
+			stage.Order = i + 1
+		}
+		if stage.Order < 1 {
+			fail(field+".order", "order must be a positive number")
+		} else if first, exists := orders[stage.Order]; exists {
+			fail(field+".order", fmt.Sprintf("order %d is already used by stages[%d]", stage.Order, first))
+		} else {
+			orders[stage.Order] = i
+		}
+
+		if len(stage.UserIDs) == 0 && stage.UserID != 0 {
+			stage.UserIDs = []uint{stage.UserID}
+		}
+		stage.UserID = 0
+
+		if stage.Signers() == 0 {
+			fail(field, "stage must have at least one user or role")
+		}
+		if j := duplicateIndex(stage.UserIDs); j >= 0 {
+			fail(fmt.Sprintf("%s.user_ids[%d]", field, j), "duplicate user")
+		}
+		if j := duplicateIndex(stage.RoleIDs); j >= 0 {
+			fail(fmt.Sprintf("%s.role_ids[%d]", field, j), "duplicate role")
+		}
+
+		switch stage.Rule {
+		case "", domain.StageRuleAll:
+			stage.Rule = domain.StageRuleAll
+			stage.Quorum = 0
+		case domain.StageRuleAny:
+			stage.Quorum = 0
+		case domain.StageRuleQuorum:
+			if stage.Quorum < 1 || stage.Quorum > stage.Signers() {
+				fail(field+".quorum", fmt.Sprintf("quorum must be between 1 and %d", stage.Signers()))
+			}
+		default:
+			fail(field+".rule", "rule must be all, any or quorum")
+		}
+
+		// Резервный подписант один: пользователь или роль, и только для этапа со сроком
+		if stage.DueHours < 0 {
+			fail(field+".due_hours", "due hours must not be negative")
+		}
+		if stage.EscalationUserID != 0 && stage.EscalationRoleID != 0 {
+			fail(field+".escalation_role_id", "only one of escalation user and escalation role can be set")
+		}
+		if stage.DueHours == 0 && (stage.EscalationUserID != 0 || stage.EscalationRoleID != 0) {
+			fail(field+".due_hours", "escalation requires due hours")
+		}
+
+		normalized[i] = stage
+	}
+
+	for order := 1; order <= len(stages); order++ {
+		if _, exists := orders[order]; !exists && len(orders) == len(stages) {
+			fail("stages", "stage orders must be consecutive starting from 1")
+			break
+		}
+	}
+
+	if len(fieldErrors) > 0 {
+		return nil, fieldErrors
+	}
+
+	sort.SliceStable(normalized, func(i, j int) bool { return normalized[i].Order < normalized[j].Order })
+	return normalized, nil
+}
+
+// duplicateIndex возвращает индекс первого повторяющегося ID или -1
+func duplicateIndex(ids []uint) int {
+	seen := make(map[uint]struct{}, len(ids))
+	for i, id := range ids {
+		if _, exists := seen[id]; exists {
+			return i
+		}
+		seen[id] = struct{}{}
+	}
+	return -1
+}
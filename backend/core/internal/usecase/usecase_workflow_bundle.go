@@ -0,0 +1,591 @@
+package usecase
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"reflect"
+	"service-core/internal/domain"
+	"service-core/pkg/logger/slogger"
+	"sort"
+	"strings"
+)
+
+// bundleCatalog - существующие пользователи, роли, процедуры и директории, на которые ссылается документ
+type bundleCatalog struct {
+	userIDs     map[string]uint
+	userLogins  map[uint]string
+	roleIDs     map[string]uint
+	roleNames   map[uint]string
+	workflows   map[string][]domain.WorkflowResponse
+	directories map[string]domain.DirectoryPath
+
+	// Пути, которые не определяют директорию однозначно, и причина
+	ambiguousPaths map[string]string
+}
+
+// ExportWorkflows выгружает роли, процедуры согласования и их назначение директориям в документ.
+// Пользователи указываются логинами, роли и процедуры - именами, директории - путями от корня
+// Кастомные ошибки: ErrAccessDenied
+func (workflowUsecase *WorkflowUsecase) ExportWorkflows(ctx context.Context, userID uint) (domain.WorkflowBundle, error) {
+	const op = "usecase.workflow.ExportWorkflows"
+
+	log := workflowUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID))
+	log.Info("exporting workflows")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return domain.WorkflowBundle{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("loading users, roles, workflows and directories")
+	catalog, err := workflowUsecase.loadCatalog(ctx)
+	if err != nil {
+		log.Error("failed to load catalog", slogger.Err(err))
+		return domain.WorkflowBundle{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	bundle := domain.WorkflowBundle{
+		Version:   domain.WorkflowBundleVersion,
+		Roles:     make([]string, 0, len(catalog.roleIDs)),
+		Workflows: []domain.WorkflowDefinition{},
+	}
+	for name := range catalog.roleIDs {
+		bundle.Roles = append(bundle.Roles, name)
+	}
+	sort.Strings(bundle.Roles)
+
+	paths := make(map[uint][]string)
+	for path, directory := range catalog.directories {
+		if directory.WorkflowID != 0 {
+			paths[directory.WorkflowID] = append(paths[directory.WorkflowID], path)
+		}
+	}
+
+	names := make([]string, 0, len(catalog.workflows))
+	for name := range catalog.workflows {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+
+	for _, name := range names {
+		for _, summary := range catalog.workflows[name] {
+			log.Debug("getting workflow", slog.Any("workflow_id", summary.WorkflowID))
+			workflow, err := workflowUsecase.workflowRepo.GetWorkflowByID(ctx, summary.WorkflowID)
+			if err != nil {
+				log.Error("failed to get workflow", slogger.Err(err))
+				return domain.WorkflowBundle{}, fmt.Errorf("%s: %w", op, err)
+			}
+
+			definition := domain.WorkflowDefinition{
+				Name:                    workflow.WorkflowName,
+				RequireResolvedComments: workflow.RequireResolvedComments,
+				Stages:                  make([]domain.StageDefinition, len(workflow.Stages)),
+				Directories:             paths[summary.WorkflowID],
+			}
+			sort.Strings(definition.Directories)
+			for i, stage := range workflow.Stages {
+				definition.Stages[i] = catalog.stageDefinition(stage)
+			}
+
+			bundle.Workflows = append(bundle.Workflows, definition)
+		}
+	}
+
+	log.Info("workflows exported successfully", slog.Int("workflows", len(bundle.Workflows)))
+	return bundle, nil
+}
+
+// ImportWorkflows проверяет документ и вычисляет изменения: новые роли, новые процедуры и ревизии
+// существующих процедур (процедуры сопоставляются по имени), назначения директорий. Назначение директорий
+// требует разрешения directory.assign, создание ролей - role.manage. Если dryRun или
+// документ содержит ошибки, ничего не меняется. Роли и процедуры, которых нет в документе, не удаляются,
+// поэтому повторный импорт того же документа ничего не меняет. Роли и процедуры записываются одной
+// транзакцией, затем директориям назначаются процедуры; невыполненные назначения отмечаются в изменениях
+// Кастомные ошибки: ErrAccessDenied
+func (workflowUsecase *WorkflowUsecase) ImportWorkflows(ctx context.Context, bundle domain.WorkflowBundle, dryRun bool, userID uint) (domain.ImportResult, error) {
+	const op = "usecase.workflow.ImportWorkflows"
+
+	log := workflowUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID), slog.Bool("dry_run", dryRun))
+	log.Info("importing workflows")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return domain.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+	if bundleAssignsDirectories(bundle) {
+		if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionDirectoryAssign); err != nil {
+			log.Error("failed permission check", slogger.Err(err))
+			return domain.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("loading users, roles, workflows and directories")
+	catalog, err := workflowUsecase.loadCatalog(ctx)
+	if err != nil {
+		log.Error("failed to load catalog", slogger.Err(err))
+		return domain.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("validating document")
+	result := domain.ImportResult{Changes: []domain.ImportChange{}}
+	result.Errors = catalog.validateBundle(bundle)
+	if len(result.Errors) > 0 {
+		log.Warn("document is invalid", slog.Int("errors", len(result.Errors)))
+		return result, nil
+	}
+	result.Valid = true
+
+	log.Debug("planning changes")
+	var newRoles []string
+	for _, name := range bundle.Roles {
+		if _, exists := catalog.roleIDs[name]; !exists {
+			newRoles = append(newRoles, name)
+			result.Changes = append(result.Changes, domain.ImportChange{
+				Kind: "role", Name: name, Action: domain.ImportActionCreate,
+			})
+		}
+	}
+	if len(newRoles) > 0 {
+		if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionRoleManage); err != nil {
+			log.Error("failed permission check", slogger.Err(err))
+			return domain.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+	// Новые роли получают временные ID, которые не совпадают с существующими
+	for i, name := range newRoles {
+		catalog.roleIDs[name] = ^uint(0) - uint(i)
+	}
+
+	workflowIDs := make([]uint, len(bundle.Workflows))
+	updates := make([]bool, len(bundle.Workflows))
+	for i, definition := range bundle.Workflows {
+		stages, _ := catalog.resolveStages(definition.Stages)
+		options := domain.WorkflowOptions{RequireResolvedComments: definition.RequireResolvedComments}
+
+		existing, exists := catalog.workflows[definition.Name]
+		if !exists {
+			result.Changes = append(result.Changes, domain.ImportChange{
+				Kind: "workflow", Name: definition.Name, Action: domain.ImportActionCreate,
+				Detail: fmt.Sprintf("%d stages", len(stages)),
+			})
+			continue
+		}
+
+		workflowIDs[i] = existing[0].WorkflowID
+		current, err := workflowUsecase.workflowRepo.GetWorkflowByID(ctx, workflowIDs[i])
+		if err != nil {
+			log.Error("failed to get workflow", slogger.Err(err))
+			return domain.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+		}
+		if current.WorkflowOptions != options || !sameStages(current.Stages, stages) {
+			updates[i] = true
+			result.Changes = append(result.Changes, domain.ImportChange{
+				Kind: "workflow", Name: definition.Name, Action: domain.ImportActionUpdate,
+				Detail: fmt.Sprintf("revision %d", current.Revision+1),
+			})
+		}
+	}
+
+	assignChanges := make(map[string]int)
+	for i, definition := range bundle.Workflows {
+		for _, path := range definition.Directories {
+			directory := catalog.directories[path]
+			if workflowIDs[i] != 0 && directory.WorkflowID == workflowIDs[i] {
+				continue
+			}
+			assignChanges[path] = len(result.Changes)
+			result.Changes = append(result.Changes, domain.ImportChange{
+				Kind: "directory", Name: path, Action: domain.ImportActionAssign, Detail: definition.Name,
+			})
+		}
+	}
+
+	if dryRun || len(result.Changes) == 0 {
+		log.Info("workflows import checked", slog.Int("changes", len(result.Changes)))
+		return result, nil
+	}
+
+	tempRoles := make(map[uint]string, len(newRoles))
+	for _, name := range newRoles {
+		tempRoles[catalog.roleIDs[name]] = name
+	}
+	imports := make([]domain.WorkflowImport, len(bundle.Workflows))
+	for i, definition := range bundle.Workflows {
+		stages, _ := catalog.resolveStages(definition.Stages)
+		stages, _ = normalizeStages(stages)
+		imports[i] = domain.WorkflowImport{
+			ID:      workflowIDs[i],
+			Name:    definition.Name,
+			Options: domain.WorkflowOptions{RequireResolvedComments: definition.RequireResolvedComments},
+			Stages:  stages,
+			Update:  updates[i],
+		}
+	}
+
+	log.Debug("saving roles and workflows", slog.Int("roles", len(newRoles)))
+	workflowIDs, err = workflowUsecase.workflowRepo.ImportWorkflows(ctx, tempRoles, imports)
+	if err != nil {
+		log.Error("failed to save roles and workflows", slogger.Err(err))
+		return domain.ImportResult{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	// file-service не участвует в транзакции, поэтому директории назначаются последними: ошибка
+	// назначения не отменяет записанные роли и процедуры и возвращается по каждой директории
+	for i, definition := range bundle.Workflows {
+		var paths []string
+		for _, path := range definition.Directories {
+			if directory := catalog.directories[path]; directory.WorkflowID != workflowIDs[i] {
+				paths = append(paths, path)
+			}
+		}
+		if len(paths) == 0 {
+			continue
+		}
+
+		log.Debug("assigning workflow", slog.String("workflow_name", definition.Name))
+		for path, err := range workflowUsecase.assignDirectories(ctx, workflowIDs[i], paths, catalog) {
+			log.Error("failed to assign workflow", slog.String("directory", path), slogger.Err(err))
+			result.Changes[assignChanges[path]].Err = err
+			result.Failed++
+		}
+	}
+
+	result.Applied = true
+	log.Info("workflows imported successfully", slog.Int("changes", len(result.Changes)), slog.Int("failed", result.Failed))
+	return result, nil
+}
+
+// assignDirectories назначает процедуру workflowID директориям paths одним запросом к file-service.
+// Если запрос не выполнен, директории назначаются по одной, чтобы найти те, которые назначить нельзя.
+// Возвращает ошибки по путям невыполненных назначений
+func (workflowUsecase *WorkflowUsecase) assignDirectories(ctx context.Context, workflowID uint, paths []string, catalog *bundleCatalog) map[string]error {
+	directoryIDs := make([]uint32, len(paths))
+	for i, path := range paths {
+		directoryIDs[i] = uint32(catalog.directories[path].ID)
+	}
+	if err := workflowUsecase.fileService.AssignWorkflow(ctx, workflowID, directoryIDs); err == nil {
+		return nil
+	}
+
+	failed := make(map[string]error)
+	for i, path := range paths {
+		if err := workflowUsecase.fileService.AssignWorkflow(ctx, workflowID, directoryIDs[i:i+1]); err != nil {
+			failed[path] = err
+		}
+	}
+	return failed
+}
+
+func (workflowUsecase *WorkflowUsecase) loadCatalog(ctx context.Context) (*bundleCatalog, error) {
+	catalog := &bundleCatalog{
+		userIDs:     make(map[string]uint),
+		userLogins:  make(map[uint]string),
+		workflows:   make(map[string][]domain.WorkflowResponse),
+		directories: make(map[string]domain.DirectoryPath),
+	}
+
+	groups, err := workflowUsecase.userRepo.GetUsersGroupedByRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, group := range groups {
+		for _, user := range group.Users {
+			catalog.userIDs[user.Login] = user.UserID
+			catalog.userLogins[user.UserID] = user.Login
+		}
+	}
+
+	if err := catalog.loadRoles(ctx, workflowUsecase); err != nil {
+		return nil, err
+	}
+
+	workflows, err := workflowUsecase.workflowRepo.GetWorkflows(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, workflow := range workflows {
+		catalog.workflows[workflow.WorkflowName] = append(catalog.workflows[workflow.WorkflowName], workflow)
+	}
+
+	directories, err := workflowUsecase.fileService.GetDirectoryPaths(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, directory := range directories {
+		catalog.directories[directory.Path] = directory
+	}
+	catalog.ambiguousPaths = ambiguousPaths(directories)
+
+	return catalog, nil
+}
+
+// ambiguousPaths находит пути, по которым нельзя однозначно найти директорию: общие у нескольких
+// директорий (соседние директории с одинаковыми именами) и пути директорий, в имени которых
+// или в имени одной из родительских директорий есть "/"
+func ambiguousPaths(directories []domain.DirectoryPath) map[string]string {
+	byID := make(map[uint]domain.DirectoryPath, len(directories))
+	counts := make(map[string]int, len(directories))
+	for _, directory := range directories {
+		byID[directory.ID] = directory
+		counts[directory.Path]++
+	}
+
+	// slashed[id] - есть ли "/" в имени директории id или ее родителей
+	slashed := make(map[uint]bool, len(directories))
+	var hasSlash func(directory domain.DirectoryPath) bool
+	hasSlash = func(directory domain.DirectoryPath) bool {
+		if result, known := slashed[directory.ID]; known {
+			return result
+		}
+		name := directory.Path
+		parent, hasParent := byID[directory.ParentID]
+		if hasParent {
+			name = strings.TrimPrefix(directory.Path, parent.Path+"/")
+		}
+		result := strings.Contains(name, "/") || hasParent && hasSlash(parent)
+		slashed[directory.ID] = result
+		return result
+	}
+
+	ambiguous := make(map[string]string)
+	for _, directory := range directories {
+		switch {
+		case counts[directory.Path] > 1:
+			ambiguous[directory.Path] = "several directories have this path"
+		case hasSlash(directory):
+			ambiguous[directory.Path] = `directory path contains a name with "/"`
+		}
+	}
+	return ambiguous
+}
+
+func (catalog *bundleCatalog) loadRoles(ctx context.Context, workflowUsecase *WorkflowUsecase) error {
+	roles, err := workflowUsecase.roleRepo.GetRoles(ctx)
+	if err != nil {
+		return err
+	}
+
+	catalog.roleIDs = make(map[string]uint, len(roles))
+	catalog.roleNames = make(map[uint]string, len(roles))
+	for _, role := range roles {
+		catalog.roleIDs[role.RoleName] = role.RoleID
+		catalog.roleNames[role.RoleID] = role.RoleName
+	}
+	return nil
+}
+
+// stageDefinition переводит этап процедуры в этап документа. Пользователь или роль, которых
+// уже нет, выгружаются как "#ID" и не пройдут проверку при импорте
+func (catalog *bundleCatalog) stageDefinition(stage domain.WorkflowStage) domain.StageDefinition {
+	user := func(id uint) string {
+		if login, exists := catalog.userLogins[id]; exists {
+			return login
+		}
+		return fmt.Sprintf("#%d", id)
+	}
+	role := func(id uint) string {
+		if name, exists := catalog.roleNames[id]; exists {
+			return name
+		}
+		return fmt.Sprintf("#%d", id)
+	}
+
+	definition := domain.StageDefinition{
+		Rule:     stage.Rule,
+		Quorum:   stage.Quorum,
+		DueHours: stage.DueHours,
+	}
+	for _, id := range stage.UserIDs {
+		definition.Users = append(definition.Users, user(id))
+	}
+	for _, id := range stage.RoleIDs {
+		definition.Roles = append(definition.Roles, role(id))
+	}
+	if stage.EscalationUserID != 0 {
+		definition.EscalationUser = user(stage.EscalationUserID)
+	}
+	if stage.EscalationRoleID != 0 {
+		definition.EscalationRole = role(stage.EscalationRoleID)
+	}
+	return definition
+}
+
+// resolveStages переводит этапы документа в этапы процедуры, заменяя логины и имена ролей на ID.
+// Для неизвестных пользователей и ролей возвращает ошибки полей относительно этапа
+func (catalog *bundleCatalog) resolveStages(definitions []domain.StageDefinition) ([]domain.WorkflowStage, []domain.FieldError) {
+	var fieldErrors []domain.FieldError
+
+	user := func(field, login string) uint {
+		id, exists := catalog.userIDs[login]
+		if !exists {
+			fieldErrors = append(fieldErrors, domain.FieldError{Field: field, Message: "user not found"})
+		}
+		return id
+	}
+	role := func(field, name string) uint {
+		id, exists := catalog.roleIDs[name]
+		if !exists {
+			fieldErrors = append(fieldErrors, domain.FieldError{Field: field, Message: "role not found"})
+		}
+		return id
+	}
+
+	stages := make([]domain.WorkflowStage, len(definitions))
+	for i, definition := range definitions {
+		prefix := fmt.Sprintf("stages[%d]", i)
+		stage := domain.WorkflowStage{
+			Order:    i + 1,
+			Rule:     definition.Rule,
+			Quorum:   definition.Quorum,
+			DueHours: definition.DueHours,
+		}
+		for j, login := range definition.Users {
+			stage.UserIDs = append(stage.UserIDs, user(fmt.Sprintf("%s.users[%d]", prefix, j), login))
+		}
+		for j, name := range definition.Roles {
+			stage.RoleIDs = append(stage.RoleIDs, role(fmt.Sprintf("%s.roles[%d]", prefix, j), name))
+		}
+		if definition.EscalationUser != "" {
+			stage.EscalationUserID = user(prefix+".escalation_user", definition.EscalationUser)
+		}
+		if definition.EscalationRole != "" {
+			stage.EscalationRoleID = role(prefix+".escalation_role", definition.EscalationRole)
+		}
+		stages[i] = stage
+	}
+
+	return stages, fieldErrors
+}
+
+// bundleFields переводит поля этапа процедуры в поля этапа документа
+var bundleFields = strings.NewReplacer(
+	".user_ids", ".users",
+	".role_ids", ".roles",
+	".escalation_user_id", ".escalation_user",
+	".escalation_role_id", ".escalation_role",
+)
+
+// validateBundle проверяет документ: версию формата, уникальность имен ролей и процедур, ссылки
+// на пользователей, роли и директории, однозначность путей директорий и правила этапов.
+// Роли документа считаются существующими
+func (catalog *bundleCatalog) validateBundle(bundle domain.WorkflowBundle) []domain.FieldError {
+	var fieldErrors []domain.FieldError
+	fail := func(field, message string) {
+		fieldErrors = append(fieldErrors, domain.FieldError{Field: field, Message: message})
+	}
+
+	if bundle.Version != domain.WorkflowBundleVersion {
+		fail("version", fmt.Sprintf("unsupported version, expected %d", domain.WorkflowBundleVersion))
+	}
+
+	roleIDs := make(map[string]uint, len(catalog.roleIDs)+len(bundle.Roles))
+	for name, id := range catalog.roleIDs {
+		roleIDs[name] = id
+	}
+	seenRoles := make(map[string]struct{}, len(bundle.Roles))
+	for i, name := range bundle.Roles {
+		field := fmt.Sprintf("roles[%d]", i)
+		if name == "" {
+			fail(field, "role name is required")
+			continue
+		}
+		if _, exists := seenRoles[name]; exists {
+			fail(field, "duplicate role")
+			continue
+		}
+		seenRoles[name] = struct{}{}
+		if _, exists := roleIDs[name]; !exists {
+			roleIDs[name] = ^uint(0) - uint(len(roleIDs))
+		}
+	}
+	resolver := *catalog
+	resolver.roleIDs = roleIDs
+
+	seenWorkflows := make(map[string]struct{}, len(bundle.Workflows))
+	seenDirectories := make(map[string]string)
+	for i, definition := range bundle.Workflows {
+		prefix := fmt.Sprintf("workflows[%d]", i)
+
+		switch _, seen := seenWorkflows[definition.Name]; {
+		case definition.Name == "":
+			fail(prefix+".name", "workflow name is required")
+		case seen:
+			fail(prefix+".name", "duplicate workflow")
+		case len(catalog.workflows[definition.Name]) > 1:
+			fail(prefix+".name", "several existing workflows have this name")
+		}
+		seenWorkflows[definition.Name] = struct{}{}
+
+		if len(definition.Stages) == 0 {
+			fail(prefix+".stages", "workflow must have at least one stage")
+		}
+		stages, stageErrors := resolver.resolveStages(definition.Stages)
+		for _, stageError := range stageErrors {
+			fail(prefix+"."+stageError.Field, stageError.Message)
+		}
+		if len(stageErrors) == 0 && len(stages) > 0 {
+			_, ruleErrors := validateStages(stages)
+			for _, ruleError := range ruleErrors {
+				fail(prefix+"."+bundleFields.Replace(ruleError.Field), ruleError.Message)
+			}
+		}
+
+		for j, path := range definition.Directories {
+			field := fmt.Sprintf("%s.directories[%d]", prefix, j)
+			if _, exists := catalog.directories[path]; !exists {
+				fail(field, "directory not found")
+				continue
+			}
+			if reason, ambiguous := catalog.ambiguousPaths[path]; ambiguous {
+				fail(field, reason)
+				continue
+			}
+			if other, exists := seenDirectories[path]; exists {
+				fail(field, fmt.Sprintf("directory is already assigned to workflow %q", other))
+				continue
+			}
+			seenDirectories[path] = definition.Name
+		}
+	}
+
+	return fieldErrors
+}
+
+// sameStages сравнивает этапы процедур без учета порядка подписантов внутри этапа
+func sameStages(a, b []domain.WorkflowStage) bool {
+	a, errA := normalizeStages(a)
+	b, errB := normalizeStages(b)
+	if errA != nil || errB != nil || len(a) != len(b) {
+		return false
+	}
+
+	for i := range a {
+		x, y := a[i], b[i]
+		x.UserIDs, y.UserIDs = sortedIDs(x.UserIDs), sortedIDs(y.UserIDs)
+		x.RoleIDs, y.RoleIDs = sortedIDs(x.RoleIDs), sortedIDs(y.RoleIDs)
+		if !reflect.DeepEqual(x, y) {
+			return false
+		}
+	}
+	return true
+}
+
+func sortedIDs(ids []uint) []uint {
+	sorted := make([]uint, len(ids))
+	copy(sorted, ids)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+	return sorted
+}
+
+// bundleAssignsDirectories проверяет, назначает ли документ процедуры директориям
+func bundleAssignsDirectories(bundle domain.WorkflowBundle) bool {
+	for _, definition := range bundle.Workflows {
+		if len(definition.Directories) > 0 {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,425 @@
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-core/internal/domain"
+	"service-core/pkg/logger/slogger"
+)
+
+// simulationSigners - пользователи, участники ролей и действующие замещения для подписантов этапов
+type simulationSigners struct {
+	logins    map[uint]string
+	roleNames map[uint]string
+	members   map[uint][]domain.UserData         // участники роли по её ID
+	delegates map[uint]domain.DelegationResponse // действующее замещение по ID замещаемого
+}
+
+// SimulateWorkflow моделирует назначение процедуры workflowID директориям directoryIDs, ничего не изменяя.
+// Отчет содержит директории, которые получат процедуру (выбранные и наследующие от них), их файлы
+// с маршрутами с учетом правил маршрутизации, подписантов этапов с их доступностью и незавершенные
+// согласования файлов. Требуется разрешение workflow.manage
+// Кастомные ошибки: ErrAccessDenied, ErrWorkflowNotFound, ErrDirectoryNotFound
+func (workflowUsecase *WorkflowUsecase) SimulateWorkflow(ctx context.Context, workflowID uint, directoryIDs []uint, userID uint) (domain.WorkflowSimulation, error) {
+	const op = "usecase.workflow.SimulateWorkflow"
+
+	log := workflowUsecase.log.With(slog.String("op", op), slog.Any("workflow_id", workflowID), slog.Any("user_id", userID))
+	log.Info("simulating workflow assignment")
+
+	log.Debug("checking user permission")
+	if err := workflowUsecase.authorizer.Authorize(ctx, userID, domain.PermissionWorkflowManage); err != nil {
+		log.Error("failed permission check", slogger.Err(err))
+		return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting workflow from database")
+	workflow, err := workflowUsecase.workflowRepo.GetWorkflowByID(ctx, workflowID)
+	if err != nil {
+		if errors.Is(err, domain.ErrWorkflowNotFound) {
+			log.Error("workflow not found", slogger.Err(err))
+			return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, domain.ErrWorkflowNotFound)
+		}
+		log.Error("failed to get workflow", slogger.Err(err))
+		return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting directory tree from file service")
+	paths, err := workflowUsecase.fileService.GetDirectoryPaths(ctx)
+	if err != nil {
+		log.Error("failed to get directory paths", slogger.Err(err))
+		return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	directories, roots, err := simulationScope(paths, workflowID, directoryIDs)
+	if err != nil {
+		log.Error("invalid directories", slogger.Err(err))
+		return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+	}
+	scope := make(map[uint]domain.SimulatedDirectory, len(directories))
+	for _, directory := range directories {
+		scope[directory.DirectoryID] = directory
+	}
+
+	log.Debug("getting routing rules")
+	rules, err := workflowUsecase.routingRepo.GetRoutingRules(ctx)
+	if err != nil {
+		log.Error("failed to get routing rules", slogger.Err(err))
+		return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("loading signers and delegations")
+	signers, err := workflowUsecase.loadSigners(ctx)
+	if err != nil {
+		log.Error("failed to load signers", slogger.Err(err))
+		return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("getting files of selected directories")
+	var fileIDs []uint32
+	for _, directoryID := range roots {
+		ids, err := workflowUsecase.fileService.GetDirectoryFiles(ctx, directoryID, "")
+		if err != nil {
+			if errors.Is(err, domain.ErrDirectoryNotFound) {
+				log.Error("directory not found", slogger.Err(err))
+				return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+			}
+			log.Error("failed to get directory files", slogger.Err(err))
+			return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+		}
+		fileIDs = append(fileIDs, ids...)
+	}
+
+	simulation := domain.WorkflowSimulation{
+		WorkflowID:    workflowID,
+		WorkflowName:  workflow.WorkflowName,
+		Revision:      workflow.Revision,
+		Directories:   directories,
+		Files:         []domain.SimulatedFile{},
+		Routes:        []domain.SimulatedRoute{},
+		OpenApprovals: []domain.AffectedApproval{},
+	}
+
+	// Маршрут зависит только от сработавшего правила: директории в scope получают одну процедуру
+	routeIndex := make(map[uint]int)
+	listed := make(map[uint]int)
+	for start := 0; start < len(fileIDs); start += bulkFileBatchSize {
+		batch := fileIDs[start:min(start+bulkFileBatchSize, len(fileIDs))]
+
+		log.Debug("fetching files batch from file service", slog.Int("files", len(batch)))
+		files, err := workflowUsecase.fileService.GetFilesWithDirectory(ctx, batch)
+		if err != nil {
+			log.Error("failed to get files", slogger.Err(err))
+			return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+		}
+
+		routed := make(map[uint]domain.File, len(files))
+		routeWorkflows := make(map[uint]uint, len(files))
+		for _, file := range files {
+			directory, inScope := scope[file.DirectoryID]
+			if !inScope {
+				// Вложенная директория со своей процедурой: назначение её не затрагивает
+				continue
+			}
+
+			if file.Directory == nil {
+				file.Directory = &domain.Directory{ID: file.DirectoryID}
+			}
+			file.Directory.WorkflowID = workflowID
+			file.Directory.WorkflowInherited = directory.WorkflowInherited
+			route := routeFile(&file, rules)
+
+			index, exists := routeIndex[route.RuleID]
+			if !exists {
+				log.Debug("simulating route", slog.Any("rule_id", route.RuleID), slog.Any("workflow_id", route.WorkflowID))
+				simulated, err := workflowUsecase.simulateRoute(ctx, route, workflowID, workflow, signers)
+				if err != nil {
+					log.Error("failed to simulate route", slogger.Err(err))
+					return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+				}
+				index = len(simulation.Routes)
+				routeIndex[route.RuleID] = index
+				simulation.Routes = append(simulation.Routes, simulated)
+			}
+			simulation.Routes[index].Files++
+			simulation.FilesTotal++
+
+			routed[file.ID] = file
+			routeWorkflows[file.ID] = route.WorkflowID
+			if len(simulation.Files) < domain.SimulationFileLimit {
+				listed[file.ID] = len(simulation.Files)
+				simulation.Files = append(simulation.Files, domain.SimulatedFile{
+					FileID:      file.ID,
+					FileName:    file.Name,
+					Status:      file.Status,
+					DirectoryID: file.DirectoryID,
+					Route:       index,
+				})
+			} else {
+				simulation.FilesTruncated = true
+			}
+		}
+
+		routedIDs := make([]uint, 0, len(routed))
+		for fileID := range routed {
+			routedIDs = append(routedIDs, fileID)
+		}
+
+		log.Debug("getting open approvals of files batch")
+		approvals, err := workflowUsecase.workflowRepo.GetOpenApprovals(ctx, routedIDs)
+		if err != nil {
+			log.Error("failed to get open approvals", slogger.Err(err))
+			return domain.WorkflowSimulation{}, fmt.Errorf("%s: %w", op, err)
+		}
+		for _, approval := range approvals {
+			simulation.OpenApprovals = append(simulation.OpenApprovals, domain.AffectedApproval{
+				ApprovalID:       approval.ID,
+				FileID:           approval.FileID,
+				FileName:         routed[approval.FileID].Name,
+				Status:           approval.Status,
+				WorkflowID:       approval.WorkflowID,
+				WorkflowRevision: approval.WorkflowRevision,
+				WorkflowOrder:    approval.WorkflowOrder,
+				RouteChanged:     approval.WorkflowID != routeWorkflows[approval.FileID],
+			})
+			if i, exists := listed[approval.FileID]; exists {
+				simulation.Files[i].ApprovalID = approval.ID
+			}
+		}
+	}
+
+	log.Info("workflow assignment simulated",
+		slog.Int("directories", len(simulation.Directories)),
+		slog.Int("files", simulation.FilesTotal),
+		slog.Int("routes", len(simulation.Routes)),
+		slog.Int("open_approvals", len(simulation.OpenApprovals)),
+	)
+	return simulation, nil
+}
+
+// simulationScope находит директории, процедуру которых после назначения workflowID директориям selected
+// будет определять это назначение: выбранные директории и вложенные в них директории без своей процедуры.
+// Также возвращает выбранные директории, не вложенные в другие выбранные
+// Кастомные ошибки: ErrDirectoryNotFound
+func simulationScope(paths []domain.DirectoryPath, workflowID uint, selected []uint) ([]domain.SimulatedDirectory, []uint, error) {
+	byID := make(map[uint]domain.DirectoryPath, len(paths))
+	for _, directory := range paths {
+		byID[directory.ID] = directory
+	}
+
+	isSelected := make(map[uint]bool, len(selected))
+	for _, directoryID := range selected {
+		if _, exists := byID[directoryID]; !exists {
+			return nil, nil, domain.ErrDirectoryNotFound
+		}
+		isSelected[directoryID] = true
+	}
+
+	// current - действующая процедура директории сейчас (0 - процедуры нет)
+	currentMemo := make(map[uint]uint, len(paths))
+	var current func(directoryID uint) uint
+	current = func(directoryID uint) uint {
+		if workflow, exists := currentMemo[directoryID]; exists {
+			return workflow
+		}
+		directory, exists := byID[directoryID]
+		if !exists {
+			return 0
+		}
+		workflow := directory.WorkflowID
+		if workflow == 0 {
+			workflow = current(directory.ParentID)
+		}
+		currentMemo[directoryID] = workflow
+		return workflow
+	}
+
+	// source - выбранная директория, от которой директория получит процедуру (0 - назначение её не затрагивает)
+	sourceMemo := make(map[uint]uint, len(paths))
+	var source func(directoryID uint) uint
+	source = func(directoryID uint) uint {
+		if sourceID, exists := sourceMemo[directoryID]; exists {
+			return sourceID
+		}
+		directory, exists := byID[directoryID]
+		var sourceID uint
+		switch {
+		case !exists:
+		case isSelected[directoryID]:
+			sourceID = directoryID
+		case directory.WorkflowID == 0:
+			sourceID = source(directory.ParentID)
+		}
+		sourceMemo[directoryID] = sourceID
+		return sourceID
+	}
+
+	directories := []domain.SimulatedDirectory{}
+	var roots []uint
+	for _, directory := range paths {
+		sourceID := source(directory.ID)
+		if sourceID == 0 {
+			continue
+		}
+		if sourceID == directory.ID && source(directory.ParentID) == 0 {
+			roots = append(roots, directory.ID)
+		}
+
+		currentID := current(directory.ID)
+		directories = append(directories, domain.SimulatedDirectory{
+			DirectoryID:       directory.ID,
+			Path:              directory.Path,
+			CurrentWorkflowID: currentID,
+			WorkflowInherited: sourceID != directory.ID,
+			Changed:           currentID != workflowID,
+		})
+	}
+
+	return directories, roots, nil
+}
+
+// loadSigners загружает логины пользователей, участников ролей и действующие замещения
+func (workflowUsecase *WorkflowUsecase) loadSigners(ctx context.Context) (*simulationSigners, error) {
+	signers := &simulationSigners{
+		logins:    make(map[uint]string),
+		roleNames: make(map[uint]string),
+		members:   make(map[uint][]domain.UserData),
+		delegates: make(map[uint]domain.DelegationResponse),
+	}
+
+	groups, err := workflowUsecase.userRepo.GetUsersGroupedByRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	roleUsers := make(map[string][]domain.UserData, len(groups))
+	for _, group := range groups {
+		roleUsers[group.RoleName] = group.Users
+		for _, user := range group.Users {
+			signers.logins[user.UserID] = user.Login
+		}
+	}
+
+	roles, err := workflowUsecase.roleRepo.GetRoles(ctx)
+	if err != nil {
+		return nil, err
+	}
+	for _, role := range roles {
+		signers.roleNames[role.RoleID] = role.RoleName
+		signers.members[role.RoleID] = roleUsers[role.RoleName]
+	}
+
+	delegations, err := workflowUsecase.delegationRepo.GetDelegations(ctx, 0)
+	if err != nil {
+		return nil, err
+	}
+	for _, delegation := range delegations {
+		// Замещения отсортированы по началу от поздних к ранним: берем последнее действующее
+		if _, exists := signers.delegates[delegation.DelegatorID]; delegation.Active && !exists {
+			signers.delegates[delegation.DelegatorID] = delegation
+		}
+	}
+
+	return signers, nil
+}
+
+// simulateRoute собирает этапы маршрута route с подписантами. Процедура assigned с ID assignedID
+// уже загружена, процедура, заданная правилом маршрутизации, загружается из БД
+func (workflowUsecase *WorkflowUsecase) simulateRoute(ctx context.Context, route domain.ApprovalRoute, assignedID uint, assigned domain.ExtendedWorkflowResponse, signers *simulationSigners) (domain.SimulatedRoute, error) {
+	simulated := domain.SimulatedRoute{
+		RuleID:     route.RuleID,
+		RuleName:   route.RuleName,
+		WorkflowID: route.WorkflowID,
+		SkipOrders: route.SkipOrders,
+		Stages:     []domain.SimulatedStage{},
+	}
+
+	workflow := assigned
+	if route.WorkflowID != assignedID {
+		var err error
+		workflow, err = workflowUsecase.workflowRepo.GetWorkflowByID(ctx, route.WorkflowID)
+		if errors.Is(err, domain.ErrWorkflowNotFound) {
+			simulated.Warnings = append(simulated.Warnings, fmt.Sprintf("routing rule workflow %d not found", route.WorkflowID))
+			return simulated, nil
+		}
+		if err != nil {
+			return domain.SimulatedRoute{}, err
+		}
+	}
+	simulated.WorkflowName = workflow.WorkflowName
+
+	stages := skipStages(workflow.Stages, route.SkipOrders)
+	if len(stages) == 0 {
+		simulated.Warnings = append(simulated.Warnings, domain.ErrEmptyRoute.Error())
+	}
+	for _, stage := range stages {
+		simulated.Stages = append(simulated.Stages, signers.simulateStage(stage))
+	}
+
+	return simulated, nil
+}
+
+// simulateStage перечисляет подписантов этапа: пользователей и участников ролей. Предупреждения
+// описывают места подписантов, за которые некому подписать, и недостижимое правило этапа
+func (signers *simulationSigners) simulateStage(stage domain.WorkflowStage) domain.SimulatedStage {
+	simulated := domain.SimulatedStage{
+		Order:            stage.Order,
+		Rule:             stage.Rule,
+		Required:         stage.RequiredSignatures(),
+		DueHours:         stage.DueHours,
+		EscalationUserID: stage.EscalationUserID,
+		EscalationRoleID: stage.EscalationRoleID,
+		Signers:          []domain.SimulatedSigner{},
+	}
+
+	signer := func(userID uint, login string, roleID uint) domain.SimulatedSigner {
+		result := domain.SimulatedSigner{
+			UserID:    userID,
+			Login:     login,
+			RoleID:    roleID,
+			RoleName:  signers.roleNames[roleID],
+			Available: true,
+		}
+		if delegation, away := signers.delegates[userID]; away {
+			result.Available = false
+			result.DelegateID = delegation.DelegateID
+			result.DelegateLogin = delegation.DelegateLogin
+		}
+		return result
+	}
+
+	// Место подписанта занято, если за него есть кому подписать
+	slots := 0
+	for _, userID := range stage.UserIDs {
+		login, exists := signers.logins[userID]
+		if !exists {
+			simulated.Warnings = append(simulated.Warnings, fmt.Sprintf("user %d not found", userID))
+			continue
+		}
+		simulated.Signers = append(simulated.Signers, signer(userID, login, 0))
+		slots++
+	}
+	for _, roleID := range stage.RoleIDs {
+		roleName, exists := signers.roleNames[roleID]
+		if !exists {
+			simulated.Warnings = append(simulated.Warnings, fmt.Sprintf("role %d not found", roleID))
+			continue
+		}
+		members := signers.members[roleID]
+		if len(members) == 0 {
+			simulated.Warnings = append(simulated.Warnings, fmt.Sprintf("role %q has no users", roleName))
+			continue
+		}
+		for _, member := range members {
+			simulated.Signers = append(simulated.Signers, signer(member.UserID, member.Login, roleID))
+		}
+		slots++
+	}
+
+	if slots < simulated.Required {
+		simulated.Warnings = append(simulated.Warnings,
+			fmt.Sprintf("stage requires %d signatures but only %d signers can sign", simulated.Required, slots))
+	}
+
+	return simulated
+}
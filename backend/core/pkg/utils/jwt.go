@@ -1,103 +1,113 @@
-package utils
-
-import (
-	"service-core/internal/domain"
-	"errors"
-	"strings"
-	"time"
-
-	"github.com/golang-jwt/jwt/v5"
-)
-
-// GenerateJWT генерирует JWT для конкретного пользователя, принимает AppSecret и время жизни токена, возвращает строку
-func GenerateJWT(user domain.User, appSecret string, duration time.Duration) (string, error) {
-	token := jwt.New(jwt.SigningMethodHS256)
-
-	claims := token.Claims.(jwt.MapClaims)
-	claims["uid"] = user.ID
-	claims["login"] = user.Login
-	claims["exp"] = time.Now().Add(duration).Unix()
-
-	tokenString, err := token.SignedString([]byte(appSecret))
-	if err != nil {
-		return "", err
-	}
-
-	return tokenString, nil
-}
-
-// ParseJWT проверяет JWT-токен и возвращает его claims
-func ParseJWT(tokenString string, appSecret string) (jwt.MapClaims, error) {
-	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
-		// Проверяем, что используется алгоритм HMAC
-		if _, ok := token.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, errors.New("unexpected signing method")
-		}
-		return []byte(appSecret), nil
-	})
-
-	if err != nil {
-		return nil, err
-	}
-
-	// Проверяем, что токен валиден
-	if !token.Valid {
-		return nil, errors.New("invalid token")
-	}
-
-	// Извлекаем claims
-	claims, ok := token.Claims.(jwt.MapClaims)
-	if !ok {
-		return nil, errors.New("invalid claims")
-	}
-
-	// Проверяем срок действия токена
-	expirationTime, ok := claims["exp"].(float64)
-	if !ok {
-		return nil, errors.New("missing expiration time")
-	}
-
-	if time.Now().Unix() > int64(expirationTime) {
-		return nil, errors.New("token expired")
-	}
-
-	return claims, nil
-}
-
-// VerifyJWT принимает токен и возвращает uid из него
-func VerifyJWT(tokenString string, appSecret string) (int, error) {
-	token, err := jwt.Parse(tokenString, func(t *jwt.Token) (interface{}, error) {
-		if _, ok := t.Method.(*jwt.SigningMethodHMAC); !ok {
-			return nil, jwt.ErrSignatureInvalid
-		}
-		return []byte(appSecret), nil
-	})
-	if err != nil {
-		return 0, err
-	}
-
-	if claims, ok := token.Claims.(jwt.MapClaims); ok && token.Valid {
-		userID, ok := claims["uid"].(float64)
-		if !ok {
-			return 0, jwt.ErrInvalidKey
-		}
-		return int(userID), nil
-	}
-
-	return 0, jwt.ErrTokenInvalidClaims
-}
-
-// ExtractBearerToken извлекает JWT-токен из заголовка Authorization
-func ExtractBearerToken(header string) string {
-	if header == "" {
-		return ""
-	}
-
-	// Разделяем строку по пробелу
-	parts := strings.Split(header, " ")
-	if len(parts) != 2 || parts[0] != "Bearer" {
-		return ""
-	}
-
-	return parts[1]
-}
+package utils
+
+import (
+	"service-core/internal/domain"
+	"crypto/ed25519"
+	"errors"
+	"strings"
+	"time"
+
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// PublicKeyFunc возвращает открытый ключ Ed25519 по kid из заголовка токена
+type PublicKeyFunc func(keyID string) (ed25519.PublicKey, error)
+
+// GenerateJWT генерирует JWT для пользователя в сессии sessionID, подписывая его ключом Ed25519 keyID,
+// и время истечения токена. Каждый токен получает уникальный jti, sid позволяет отозвать все токены сессии
+func GenerateJWT(user domain.User, sessionID string, keyID string, key ed25519.PrivateKey, expiresAt time.Time) (string, error) {
+	tokenID, err := NewToken(16)
+	if err != nil {
+		return "", err
+	}
+
+	token := jwt.New(jwt.SigningMethodEdDSA)
+	token.Header["kid"] = keyID
+
+	claims := token.Claims.(jwt.MapClaims)
+	claims["uid"] = user.ID
+	claims["login"] = user.Login
+	claims["sid"] = sessionID
+	claims["jti"] = tokenID
+	claims["iat"] = time.Now().Unix()
+	claims["exp"] = expiresAt.Unix()
+
+	tokenString, err := token.SignedString(key)
+	if err != nil {
+		return "", err
+	}
+
+	return tokenString, nil
+}
+
+// ParseJWT проверяет JWT-токен открытым ключом из publicKey по kid и возвращает его claims
+func ParseJWT(tokenString string, publicKey PublicKeyFunc) (jwt.MapClaims, error) {
+	token, err := jwt.Parse(tokenString, func(token *jwt.Token) (interface{}, error) {
+		// Проверяем, что используется алгоритм EdDSA
+		if _, ok := token.Method.(*jwt.SigningMethodEd25519); !ok {
+			return nil, errors.New("unexpected signing method")
+		}
+
+		keyID, ok := token.Header["kid"].(string)
+		if !ok || keyID == "" {
+			return nil, errors.New("missing key id")
+		}
+		return publicKey(keyID)
+	})
+
+	if err != nil {
+		return nil, err
+	}
+
+	// Проверяем, что токен валиден
+	if !token.Valid {
+		return nil, errors.New("invalid token")
+	}
+
+	// Извлекаем claims
+	claims, ok := token.Claims.(jwt.MapClaims)
+	if !ok {
+		return nil, errors.New("invalid claims")
+	}
+
+	// Проверяем срок действия токена
+	expirationTime, ok := claims["exp"].(float64)
+	if !ok {
+		return nil, errors.New("missing expiration time")
+	}
+
+	if time.Now().Unix() > int64(expirationTime) {
+		return nil, errors.New("token expired")
+	}
+
+	return claims, nil
+}
+
+// VerifyJWT принимает токен и возвращает uid из него
+func VerifyJWT(tokenString string, publicKey PublicKeyFunc) (int, error) {
+	claims, err := ParseJWT(tokenString, publicKey)
+	if err != nil {
+		return 0, err
+	}
+
+	userID, ok := claims["uid"].(float64)
+	if !ok {
+		return 0, jwt.ErrInvalidKey
+	}
+	return int(userID), nil
+}
+
+// ExtractBearerToken извлекает JWT-токен из заголовка Authorization
+func ExtractBearerToken(header string) string {
+	if header == "" {
+		return ""
+	}
+
+	// Разделяем строку по пробелу
+	parts := strings.Split(header, " ")
+	if len(parts) != 2 || parts[0] != "Bearer" {
+		return ""
+	}
+
+	return parts[1]
+}
@@ -0,0 +1,73 @@
+package utils
+
+import (
+	"crypto/aes"
+	"crypto/cipher"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/hex"
+	"errors"
+)
+
+// NewToken возвращает случайную строку из size байт в кодировке base64url
+func NewToken(size int) (string, error) {
+	buf := make([]byte, size)
+	if _, err := rand.Read(buf); err != nil {
+		return "", err
+	}
+	return base64.RawURLEncoding.EncodeToString(buf), nil
+}
+
+// HashToken возвращает SHA-256 хэш токена в hex: в базе хранятся только хэши refresh-токенов
+func HashToken(token string) string {
+	sum := sha256.Sum256([]byte(token))
+	return hex.EncodeToString(sum[:])
+}
+
+// SealToken шифрует token ключом, выведенным из keyToken. Ключ не выводится из HashToken(keyToken),
+// поэтому расшифровать token может только знающий keyToken
+func SealToken(keyToken, token string) ([]byte, error) {
+	aead, err := tokenAEAD(keyToken)
+	if err != nil {
+		return nil, err
+	}
+
+	nonce := make([]byte, aead.NonceSize())
+	if _, err := rand.Read(nonce); err != nil {
+		return nil, err
+	}
+
+	return aead.Seal(nonce, nonce, []byte(token), nil), nil
+}
+
+// OpenToken расшифровывает токен, зашифрованный SealToken ключом из keyToken
+func OpenToken(keyToken string, sealed []byte) (string, error) {
+	aead, err := tokenAEAD(keyToken)
+	if err != nil {
+		return "", err
+	}
+
+	nonceSize := aead.NonceSize()
+	if len(sealed) < nonceSize {
+		return "", errors.New("sealed token is corrupted")
+	}
+
+	token, err := aead.Open(nil, sealed[:nonceSize], sealed[nonceSize:], nil)
+	if err != nil {
+		return "", err
+	}
+
+	return string(token), nil
+}
+
+// tokenAEAD создает шифр AES-256-GCM с ключом из keyToken
+func tokenAEAD(keyToken string) (cipher.AEAD, error) {
+	key := sha256.Sum256([]byte("sealed-token:" + keyToken))
+	block, err := aes.NewCipher(key[:])
+	if err != nil {
+		return nil, err
+	}
+
+	return cipher.NewGCM(block)
+}
@@ -0,0 +1,139 @@
+package http
+
+import (
+	"errors"
+	"net/http"
+	"strconv"
+
+	"service-file/internal/domain"
+	"service-file/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+)
+
+type setAccessInput struct {
+	UserID      uint   `json:"user_id"`
+	AccessLevel string `json:"access_level"`
+}
+
+// SetDirectoryAccess godoc
+//
+//	@Summary		Выдать доступ к директории
+//	@Description	Выдает пользователю доступ к директории уровня read, write, approve или manage (каждый уровень включает предыдущие). Доступ распространяется на вложенные директории и файлы, которым не выдан свой уровень. Уровень deny запрещает доступ, унаследованный от родительских директорий, none удаляет выданный уровень. Доступно пользователям с уровнем manage к директории.
+//	@Tags			directory
+//	@Security		ApiKeyAuth
+//	@Accept			json
+//	@Param			directory_id	path		int						true	"ID директории"
+//	@Param			input			body		setAccessInput			true	"Пользователь и уровень доступа"
+//	@Success		204				{string}	string					"Доступ сохранен"
+//	@Failure		400				{object}	domain.ErrorResponse	"Невалидный ID директории, пользователь или уровень доступа"
+//	@Failure		401				{object}	domain.ErrorResponse	"Пользователь не авторизован"
+//	@Failure		403				{object}	domain.ErrorResponse	"Нет доступа уровня manage к директории"
+//	@Failure		404				{object}	domain.ErrorResponse	"Директория не найдена"
+//	@Failure		500				{object}	domain.ErrorResponse	"Ошибка при сохранении доступа"
+//	@Router			/directories/{directory_id}/access [put]
+func (h *TreeHandler) SetDirectoryAccess(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+		return
+	}
+
+	directoryID, err := strconv.ParseUint(c.Param("directory_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid directory ID")
+		return
+	}
+
+	targetUserID, level, ok := bindSetAccessInput(c)
+	if !ok {
+		return
+	}
+
+	err = h.directoryUsecase.SetDirectoryAccess(c.Request.Context(), uint(directoryID), targetUserID, level, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDirectoryNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "Directory not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User cannot manage access to this directory")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to set directory access")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// SetFileAccess godoc
+//
+//	@Summary		Выдать доступ к файлу
+//	@Description	Выдает пользователю доступ к файлу уровня read, write, approve или manage (каждый уровень включает предыдущие) вместо унаследованного от директорий. Уровень deny запрещает унаследованный доступ, none удаляет выданный уровень. Доступно пользователям с уровнем manage к файлу.
+//	@Tags			file
+//	@Security		ApiKeyAuth
+//	@Accept			json
+//	@Param			file_id	path		int						true	"ID файла"
+//	@Param			input	body		setAccessInput			true	"Пользователь и уровень доступа"
+//	@Success		204		{string}	string					"Доступ сохранен"
+//	@Failure		400		{object}	domain.ErrorResponse	"Невалидный ID файла, пользователь или уровень доступа"
+//	@Failure		401		{object}	domain.ErrorResponse	"Пользователь не авторизован"
+//	@Failure		403		{object}	domain.ErrorResponse	"Нет доступа уровня manage к файлу"
+//	@Failure		404		{object}	domain.ErrorResponse	"Файл не найден"
+//	@Failure		500		{object}	domain.ErrorResponse	"Ошибка при сохранении доступа"
+//	@Router			/files/{file_id}/access [put]
+func (h *TreeHandler) SetFileAccess(c *gin.Context) {
+	userID, err := utils.ExtractUserID(c)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusUnauthorized, "UNAUTHORIZED", err.Error())
+		return
+	}
+
+	fileID, err := strconv.ParseUint(c.Param("file_id"), 10, 64)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid file ID")
+		return
+	}
+
+	targetUserID, level, ok := bindSetAccessInput(c)
+	if !ok {
+		return
+	}
+
+	err = h.fileUsecase.SetFileAccess(c.Request.Context(), uint(fileID), targetUserID, level, userID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrFileNotFound):
+			utils.SendErrorResponse(c, http.StatusNotFound, "NOT_FOUND", "File not found")
+		case errors.Is(err, domain.ErrAccessDenied):
+			utils.SendErrorResponse(c, http.StatusForbidden, "ACCESS_DENIED", "User cannot manage access to this file")
+		default:
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to set file access")
+		}
+		return
+	}
+
+	c.Status(http.StatusNoContent)
+}
+
+// bindSetAccessInput читает пользователя и уровень доступа из тела запроса. При ошибке ответ уже отправлен
+func bindSetAccessInput(c *gin.Context) (uint, domain.AccessLevel, bool) {
+	var req setAccessInput
+	if err := c.ShouldBindJSON(&req); err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_REQUEST", "Invalid request body")
+		return 0, domain.AccessNone, false
+	}
+
+	if req.UserID == 0 || req.AccessLevel == "" {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "MISSING_FIELDS", "User_id and access_level are required")
+		return 0, domain.AccessNone, false
+	}
+
+	level, err := domain.ParseAccessLevel(req.AccessLevel)
+	if err != nil {
+		utils.SendErrorResponse(c, http.StatusBadRequest, "INVALID_ACCESS_LEVEL", "Access level must be one of none, deny, read, write, approve, manage")
+		return 0, domain.AccessNone, false
+	}
+
+	return req.UserID, level, true
+}
@@ -1,57 +1,82 @@
-package http
-
-import (
-	"errors"
-	"net/http"
-
-	"service-file/pkg/config"
-	"service-file/pkg/utils"
-
-	"github.com/gin-gonic/gin"
-	"github.com/golang-jwt/jwt/v5"
-)
-
-func AuthMiddleware(cfg *config.Config) gin.HandlerFunc {
-	return func(c *gin.Context) {
-		// Пропускаем OPTIONS-запросы
-		if c.Request.Method == "OPTIONS" {
-			c.Next()
-			return
-		}
-
-		// Извлечение токена из куки
-		tokenString, err := c.Cookie("auth_token")
-		if err != nil || tokenString == "" {
-			utils.SendErrorResponse(c, http.StatusUnauthorized, "MISSING_TOKEN", "Authentication token is missing")
-			c.Abort()
-			return
-		}
-
-		// Проверка JWT
-		claims, err := utils.ParseJWT(tokenString, cfg.AppSecret)
-		if err != nil {
-			switch {
-			case errors.Is(err, jwt.ErrTokenExpired):
-				utils.SendErrorResponse(c, http.StatusUnauthorized, "TOKEN_EXPIRED", "Token has expired")
-			case errors.Is(err, jwt.ErrTokenMalformed):
-				utils.SendErrorResponse(c, http.StatusUnauthorized, "MALFORMED_TOKEN", "Token is malformed")
-			default:
-				utils.SendErrorResponse(c, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid token")
-			}
-			c.Abort()
-			return
-		}
-
-		// Извлечение userID из claims
-		userID, ok := claims["uid"].(float64)
-		if !ok {
-			utils.SendErrorResponse(c, http.StatusUnauthorized, "INVALID_TOKEN_CLAIMS", "Missing or invalid user ID in token claims")
-			c.Abort()
-			return
-		}
-
-		// Сохранение userID в контексте
-		c.Set("userID", uint(userID))
-		c.Next()
-	}
-}
+package http
+
+import (
+	"crypto/ed25519"
+	"errors"
+	"net/http"
+
+	"service-file/internal/domain/interfaces"
+	"service-file/pkg/utils"
+
+	"github.com/gin-gonic/gin"
+	"github.com/golang-jwt/jwt/v5"
+)
+
+// AuthMiddleware проверяет подпись access-токена из куки auth_token открытым ключом core-service
+// по kid и то, что его сессия не отозвана
+func AuthMiddleware(tokenKeys interfaces.TokenKeySet, sessionRepo interfaces.SessionRepository) gin.HandlerFunc {
+	return func(c *gin.Context) {
+		// Пропускаем OPTIONS-запросы
+		if c.Request.Method == "OPTIONS" {
+			c.Next()
+			return
+		}
+
+		// Извлечение токена из куки
+		tokenString, err := c.Cookie("auth_token")
+		if err != nil || tokenString == "" {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "MISSING_TOKEN", "Authentication token is missing")
+			c.Abort()
+			return
+		}
+
+		// Проверка JWT
+		claims, err := utils.ParseJWT(tokenString, func(keyID string) (ed25519.PublicKey, error) {
+			return tokenKeys.PublicKey(c.Request.Context(), keyID)
+		})
+		if err != nil {
+			switch {
+			case errors.Is(err, jwt.ErrTokenExpired):
+				utils.SendErrorResponse(c, http.StatusUnauthorized, "TOKEN_EXPIRED", "Token has expired")
+			case errors.Is(err, jwt.ErrTokenMalformed):
+				utils.SendErrorResponse(c, http.StatusUnauthorized, "MALFORMED_TOKEN", "Token is malformed")
+			default:
+				utils.SendErrorResponse(c, http.StatusUnauthorized, "INVALID_TOKEN", "Invalid token")
+			}
+			c.Abort()
+			return
+		}
+
+		// Извлечение userID из claims
+		userID, ok := claims["uid"].(float64)
+		if !ok {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "INVALID_TOKEN_CLAIMS", "Missing or invalid user ID in token claims")
+			c.Abort()
+			return
+		}
+
+		// Проверка, что сессия токена не отозвана
+		sessionID, ok := claims["sid"].(string)
+		if !ok || sessionID == "" {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "INVALID_TOKEN_CLAIMS", "Missing or invalid session ID in token claims")
+			c.Abort()
+			return
+		}
+
+		revoked, err := sessionRepo.IsSessionRevoked(c.Request.Context(), sessionID)
+		if err != nil {
+			utils.SendErrorResponse(c, http.StatusInternalServerError, "INTERNAL_ERROR", "Failed to check token session")
+			c.Abort()
+			return
+		}
+		if revoked {
+			utils.SendErrorResponse(c, http.StatusUnauthorized, "TOKEN_REVOKED", "Token has been revoked")
+			c.Abort()
+			return
+		}
+
+		// Сохранение userID в контексте
+		c.Set("userID", uint(userID))
+		c.Next()
+	}
+}
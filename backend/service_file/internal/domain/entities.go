@@ -1,51 +1,150 @@
-package domain
-
-type GetFileTreeResponse struct {
-	Data []DirectoryResponse `json:"data"`
-}
-
-type DirectoryResponse struct {
-	ID           uint           `json:"id" example:"123"`
-	NameFolder   string         `json:"name_folder" example:"Documents"`
-	Status       string         `json:"status" example:"active"`
-	ParentPathID *uint          `json:"parent_path_id,omitempty" example:"456"`
-	Files        []FileResponse `json:"files"`
-}
-
-type FileResponse struct {
-	ID          uint   `json:"id" example:"789"`
-	NameFile    string `json:"name_file" example:"report.pdf"`
-	Status      string `json:"status" example:"draft"`
-	DirectoryID uint   `json:"directory_id" example:"123"`
-}
-
-type DirectoryUserResponse struct {
-	ID            uint               `json:"directory_id"`
-	NameFolder    string             `json:"name_folder"`
-	ParentPathID  *uint              `json:"parent_path_id,omitempty"`
-	UserHasAccess bool               `json:"user_has_access"`
-	Files         []FileUserResponse `json:"files"`
-}
-
-type FileUserResponse struct {
-	ID            uint   `json:"id"`
-	NameFile      string `json:"name_file"`
-	DirectoryID   uint   `json:"directory_id"`
-	UserHasAccess bool   `json:"user_has_access"`
-}
-
-type DirectoryWorkflowResponse struct {
-	ID                      uint   `json:"directory_id"`
-	NameFolder              string `json:"name_folder"`
-	ParentPathID            *uint  `json:"parent_path_id,omitempty"`
-	CurrentWorkflowAssigned bool   `json:"current_workflow_assigned"`
-}
-
-// ErrorResponse godoc
-// @Description Стандартизированный ответ при ошибке API
-type ErrorResponse struct {
-	Error struct {
-		Code    string `json:"code" example:"NOT_FOUND"`
-		Message string `json:"message" example:"Resource not found"`
-	} `json:"error"`
-}
+package domain
+
+type GetFileTreeResponse struct {
+	Data []DirectoryResponse `json:"data"`
+}
+
+type DirectoryResponse struct {
+	ID           uint           `json:"id" example:"123"`
+	NameFolder   string         `json:"name_folder" example:"Documents"`
+	Status       string         `json:"status" example:"active"`
+	ParentPathID *uint          `json:"parent_path_id,omitempty" example:"456"`
+	AccessLevel  string         `json:"access_level,omitempty" example:"write"` // уровень доступа пользователя, в архиве не заполняется
+	Files        []FileResponse `json:"files"`
+}
+
+type FileResponse struct {
+	ID          uint   `json:"id" example:"789"`
+	NameFile    string `json:"name_file" example:"report.pdf"`
+	Status      string `json:"status" example:"draft"`
+	DirectoryID uint   `json:"directory_id" example:"123"`
+	AccessLevel string `json:"access_level,omitempty" example:"write"` // уровень доступа пользователя, в архиве не заполняется
+}
+
+type DirectoryUserResponse struct {
+	ID                   uint               `json:"directory_id"`
+	NameFolder           string             `json:"name_folder"`
+	ParentPathID         *uint              `json:"parent_path_id,omitempty"`
+	UserHasAccess        bool               `json:"user_has_access"`                  // доступ выдан самой директории
+	AccessLevel          string             `json:"access_level,omitempty"`           // выданный директории уровень или deny
+	EffectiveAccessLevel string             `json:"effective_access_level,omitempty"` // действующий уровень с учетом наследования
+	InheritedFromID      *uint              `json:"inherited_from_id,omitempty"`      // директория, от которой унаследован уровень
+	Files                []FileUserResponse `json:"files"`
+}
+
+type FileUserResponse struct {
+	ID                   uint   `json:"id"`
+	NameFile             string `json:"name_file"`
+	DirectoryID          uint   `json:"directory_id"`
+	UserHasAccess        bool   `json:"user_has_access"`                  // доступ выдан самому файлу
+	AccessLevel          string `json:"access_level,omitempty"`           // выданный файлу уровень или deny
+	EffectiveAccessLevel string `json:"effective_access_level,omitempty"` // действующий уровень с учетом наследования
+	InheritedFromID      *uint  `json:"inherited_from_id,omitempty"`      // директория, от которой унаследован уровень
+}
+
+// AccessLevel - уровень доступа пользователя к директории или файлу. Каждый уровень включает
+// предыдущие: кто может изменять, может и читать. Доступ к директории распространяется на вложенные
+// директории и файлы, пока им не выдан свой уровень; AccessDeny запрещает унаследованный доступ
+type AccessLevel int16
+
+const (
+	AccessDeny    AccessLevel = iota - 1 // явный запрет
+	AccessNone                           // доступа нет
+	AccessRead                           // просмотр и скачивание
+	AccessWrite                          // загрузка, изменение и удаление файлов, создание директорий
+	AccessApprove                        // отправка файлов на согласование
+	AccessManage                         // удаление директорий и выдача доступа другим пользователям
+)
+
+var accessLevelNames = map[AccessLevel]string{
+	AccessDeny:    "deny",
+	AccessRead:    "read",
+	AccessWrite:   "write",
+	AccessApprove: "approve",
+	AccessManage:  "manage",
+}
+
+func (l AccessLevel) String() string {
+	if name, ok := accessLevelNames[l]; ok {
+		return name
+	}
+	return "none"
+}
+
+// ParseAccessLevel возвращает уровень доступа по имени. Пустое имя и "none" - своего уровня нет,
+// доступ наследуется от родительской директории
+// Кастомные ошибки: ErrInvalidAccessLevel
+func ParseAccessLevel(name string) (AccessLevel, error) {
+	if name == "" || name == "none" {
+		return AccessNone, nil
+	}
+	for level, levelName := range accessLevelNames {
+		if levelName == name {
+			return level, nil
+		}
+	}
+	return AccessNone, ErrInvalidAccessLevel
+}
+
+// ObjectAccess - уровень доступа к директории или файлу с ID
+type ObjectAccess struct {
+	ID    uint
+	Level AccessLevel
+}
+
+// Способ, которым директория получает процедуру согласования
+const (
+	WorkflowModeOverride = "override" // процедура назначена самой директории
+	WorkflowModeInherit  = "inherit"  // процедура унаследована от родительской директории
+	WorkflowModeNone     = "none"     // ни директории, ни её родителям процедура не назначена
+)
+
+type DirectoryWorkflowResponse struct {
+	ID                      uint   `json:"directory_id"`
+	NameFolder              string `json:"name_folder"`
+	ParentPathID            *uint  `json:"parent_path_id,omitempty"`
+	CurrentWorkflowAssigned bool   `json:"current_workflow_assigned"` // процедура назначена самой директории
+
+	WorkflowID               uint   `json:"workflow_id"`                 // действующая процедура
+	WorkflowMode             string `json:"workflow_mode"`               // override, inherit или none
+	InheritedFromID          *uint  `json:"inherited_from_id,omitempty"` // директория, от которой унаследована процедура
+	CurrentWorkflowEffective bool   `json:"current_workflow_effective"`  // процедура действует в директории
+}
+
+// DirectoryWorkflow - процедура согласования, действующая в директории: назначенная ей самой
+// или унаследованная от ближайшей родительской директории с назначенной процедурой
+type DirectoryWorkflow struct {
+	DirectoryID       uint
+	WorkflowID        uint
+	SourceDirectoryID uint // директория, которой назначена процедура
+}
+
+// Inherited сообщает, унаследована ли процедура от родительской директории
+func (w DirectoryWorkflow) Inherited() bool {
+	return w.SourceDirectoryID != 0 && w.SourceDirectoryID != w.DirectoryID
+}
+
+// DirectoryPath - директория с путем от корня (имена директорий, разделенные "/")
+type DirectoryPath struct {
+	ID         uint
+	ParentID   uint // 0 - корневая директория
+	Path       string
+	WorkflowID uint
+}
+
+// FileContentHash - хэш содержимого версии файла в хранилище
+type FileContentHash struct {
+	FileID  uint
+	Version int
+	SHA256  string // hex-строка
+	Size    int64
+}
+
+// ErrorResponse godoc
+// @Description Стандартизированный ответ при ошибке API
+type ErrorResponse struct {
+	Error struct {
+		Code    string `json:"code" example:"NOT_FOUND"`
+		Message string `json:"message" example:"Resource not found"`
+	} `json:"error"`
+}
@@ -0,0 +1,11 @@
+package interfaces
+
+import (
+	"context"
+	"crypto/ed25519"
+)
+
+// TokenKeySet возвращает открытые ключи core-service, которыми подписаны access-токены
+type TokenKeySet interface {
+	PublicKey(ctx context.Context, keyID string) (ed25519.PublicKey, error)
+}
@@ -1,50 +1,86 @@
-package domain
-
-import "gorm.io/gorm"
-
-// Directory модель
-type Directory struct {
-	gorm.Model
-	ParentPathID *uint  `gorm:"index"` // Указатель для NULL
-	Name         string `gorm:"not null"`
-	Status       string `gorm:"not null"`
-	Version      int    `gorm:"default:1"`
-	WorkflowID   uint   `gorm:"not null"` // Логическая связь с core-service
-
-	// Родительская директория
-	ParentPath *Directory `gorm:"foreignKey:ParentPathID"`
-
-	// Связь с файлами
-	Files []File `gorm:"foreignKey:DirectoryID"`
-}
-
-// File модель
-type File struct {
-	gorm.Model
-	DirectoryID uint   `gorm:"not null;index"`
-	Name        string `gorm:"not null"`
-	Status      string `gorm:"not null"`
-	Version     int    `gorm:"default:1"`
-
-	// Ссылка на MinIO
-	MinioObjectKey string `gorm:"not null"`
-
-	// Metadata
-	Size        int64  `gorm:"not null"` // Размер файла
-	ContentType string `gorm:"not null"` // MIME-тип (например, "application/pdf")
-
-	// Связь с директорией
-	Directory Directory `gorm:"foreignKey:DirectoryID"`
-}
-
-// UserDirectory связующая таблица
-type UserDirectory struct {
-	UserID      uint `gorm:"primaryKey;column:user_id;foreignKey:User"`
-	DirectoryID uint `gorm:"primaryKey;column:directory_id;foreignKey:Directory"`
-}
-
-// UserFile связующая таблица
-type UserFile struct {
-	UserID uint `gorm:"primaryKey;column:user_id;foreignKey:User"`
-	FileID uint `gorm:"primaryKey;column:file_id;foreignKey:File"`
-}
+package domain
+
+import (
+	"time"
+
+	"gorm.io/gorm"
+)
+
+// Directory модель
+type Directory struct {
+	gorm.Model
+	ParentPathID *uint  `gorm:"index"` // Указатель для NULL
+	Name         string `gorm:"not null"`
+	Status       string `gorm:"not null"`
+	Version      int    `gorm:"default:1"`
+	WorkflowID   uint   `gorm:"not null;default:0"` // Логическая связь с core-service, 0 - наследуется от родителя
+	Depth        int    `gorm:"-"`                  // Уровень вложенности, заполняется по запросу
+
+	// Действующая процедура с учетом наследования, заполняется по запросу
+	EffectiveWorkflowID uint `gorm:"-"`
+	WorkflowInherited   bool `gorm:"-"`
+
+	// Уровень доступа пользователя, заполняется по запросу
+	AccessLevel AccessLevel `gorm:"->;-:migration"`
+
+	// Родительская директория
+	ParentPath *Directory `gorm:"foreignKey:ParentPathID"`
+
+	// Связь с файлами
+	Files []File `gorm:"foreignKey:DirectoryID"`
+}
+
+// File модель
+type File struct {
+	gorm.Model
+	DirectoryID uint   `gorm:"not null;index"`
+	Name        string `gorm:"not null"`
+	Status      string `gorm:"not null"`
+	Version     int    `gorm:"default:1"`
+
+	// Ссылка на MinIO
+	MinioObjectKey string `gorm:"not null"`
+
+	// Metadata
+	Size        int64  `gorm:"not null"` // Размер файла
+	ContentType string `gorm:"not null"` // MIME-тип (например, "application/pdf")
+
+	// Уровень доступа пользователя, заполняется по запросу
+	AccessLevel AccessLevel `gorm:"->;-:migration"`
+
+	// Связь с директорией
+	Directory Directory `gorm:"foreignKey:DirectoryID"`
+}
+
+// FileStatusUpdate модель - примененное изменение статуса файла из core-service.
+// По ключу идемпотентности повторная доставка того же изменения пропускается
+type FileStatusUpdate struct {
+	IdempotencyKey string    `gorm:"primaryKey"`
+	FileID         uint      `gorm:"not null;index"`
+	Status         string    `gorm:"not null"`
+	CreatedAt      time.Time `gorm:"not null"`
+}
+
+// RevokedSession модель - сессия, отозванная в core-service. JWT с этим sid отклоняются
+// до ExpiresAt, после которого истекают все access-токены сессии и запись можно удалить
+type RevokedSession struct {
+	SessionID string    `gorm:"primaryKey;size:32"`
+	ExpiresAt time.Time `gorm:"not null;index"`
+	CreatedAt time.Time `gorm:"not null"`
+}
+
+// UserDirectory связующая таблица. У колонки access_level нет значения по умолчанию, чтобы нулевой
+// уровень не превратился в выданный доступ. Строкам, созданным до появления уровней, мигратор
+// выставляет уровень approve: прежний полный доступ без выдачи доступа другим
+type UserDirectory struct {
+	UserID      uint        `gorm:"primaryKey;column:user_id;foreignKey:User"`
+	DirectoryID uint        `gorm:"primaryKey;column:directory_id;foreignKey:Directory"`
+	AccessLevel AccessLevel `gorm:"not null"`
+}
+
+// UserFile связующая таблица. Уровень хранится так же, как у UserDirectory
+type UserFile struct {
+	UserID      uint        `gorm:"primaryKey;column:user_id;foreignKey:User"`
+	FileID      uint        `gorm:"primaryKey;column:file_id;foreignKey:File"`
+	AccessLevel AccessLevel `gorm:"not null"`
+}
@@ -1,126 +1,292 @@
-package filegrpc
-
-import (
-	"context"
-	"errors"
-	"service-file/internal/domain"
-	"service-file/internal/domain/interfaces"
-	pb "service-file/internal/proto"
-
-	"google.golang.org/grpc"
-	"google.golang.org/grpc/codes"
-	"google.golang.org/grpc/status"
-	"google.golang.org/protobuf/types/known/emptypb"
-)
-
-type GRPCServer struct {
-	pb.UnimplementedFileServiceServer
-	usecase interfaces.GRPCUsecase
-}
-
-func NewGRPCServer(gRPC *grpc.Server, usecase interfaces.GRPCUsecase) {
-	pb.RegisterFileServiceServer(gRPC, &GRPCServer{usecase: usecase})
-}
-
-func (s *GRPCServer) GetFileByID(ctx context.Context, req *pb.GetFileRequest) (*pb.FileResponse, error) {
-	file, err := s.usecase.GetFileByID(ctx, uint(req.GetFileId()))
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrFileNotFound):
-			return nil, status.Error(codes.NotFound, "file not found")
-		default:
-			return nil, status.Error(codes.Internal, "internal error")
-		}
-	}
-
-	return &pb.FileResponse{
-		Id:          uint32(file.ID),
-		DirectoryId: uint32(file.DirectoryID),
-		Name:        file.Name,
-		Status:      file.Status,
-		Version:     int32(file.Version),
-		Directory: &pb.DirectoryResponse{
-			Id:           uint32(file.Directory.ID),
-			ParentPathId: uint32PtrOrNil(file.Directory.ParentPathID),
-			Name:         file.Directory.Name,
-			Status:       file.Directory.Status,
-			WorkflowId:   uint32(file.Directory.WorkflowID),
-		},
-	}, nil
-}
-
-func uint32PtrOrNil(value *uint) uint32 {
-	if value == nil {
-		return 0
-	}
-	return uint32(*value)
-}
-
-func (s *GRPCServer) UpdateFileStatus(ctx context.Context, req *pb.UpdateFileStatusRequest) (*emptypb.Empty, error) {
-	err := s.usecase.UpdateFileStatus(ctx, uint(req.GetFileId()), req.GetStatus())
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrFileNotFound):
-			return &emptypb.Empty{}, status.Error(codes.NotFound, "file not found")
-		default:
-			return &emptypb.Empty{}, status.Error(codes.Internal, "internal error")
-		}
-	}
-	return &emptypb.Empty{}, nil
-}
-
-func (s *GRPCServer) GetFilesInfo(ctx context.Context, req *pb.GetFilesRequest) (*pb.GetFilesResponse, error) {
-	fileIDs := req.FileIds
-	fileNames := make(map[uint32]string)
-
-	files, err := s.usecase.GetFilesByID(ctx, fileIDs)
-	if err != nil {
-		// TODO: custom errors
-		return nil, status.Errorf(codes.Internal, "failed to get files: %v", err)
-	}
-
-	for _, file := range files {
-		fileNames[uint32(file.ID)] = file.Name
-	}
-
-	return &pb.GetFilesResponse{
-		FileNames: fileNames,
-	}, nil
-}
-
-func (s *GRPCServer) CheckWorkflow(ctx context.Context, req *pb.CheckWorkflowRequest) (*pb.CheckWorkflowResponse, error) {
-	exists, err := s.usecase.CheckWorkflow(ctx, uint(req.WorkflowId))
-	if err != nil {
-		// TODO: custom errors
-		return nil, status.Errorf(codes.Internal, "failed to check workflow id")
-	}
-
-	return &pb.CheckWorkflowResponse{Exists: exists}, nil
-}
-
-func (s *GRPCServer) AssignWorkflow(ctx context.Context, req *pb.AssignWorkflowRequest) (*emptypb.Empty, error) {
-	if err := s.usecase.AssignWorkflow(ctx, req.GetWorkflowId(), req.GetDirectoryIds()); err != nil {
-		// TODO: custom errors
-		return nil, status.Errorf(codes.Internal, "failed to assign workflows")
-	}
-
-	return &emptypb.Empty{}, nil
-}
-
-func (s *GRPCServer) DeleteUserRelations(ctx context.Context, req *pb.DeleteUserRelationsRequest) (*emptypb.Empty, error) {
-	if err := s.usecase.DeleteUserRelations(ctx, uint(req.GetUserId())); err != nil {
-		// TODO: custom errors
-		return nil, status.Errorf(codes.Internal, "failed to delete user relations")
-	}
-
-	return &emptypb.Empty{}, nil
-}
-
-func (s *GRPCServer) AssignUser(ctx context.Context, req *pb.AssignUserRequest) (*emptypb.Empty, error) {
-	if err := s.usecase.AssignUser(ctx, uint(req.GetUserId()), req.GetDirectoryIds(), req.GetFileIds()); err != nil {
-		// TODO: custom errors
-		return nil, status.Errorf(codes.Internal, "failed to assign user")
-	}
-
-	return &emptypb.Empty{}, nil
-}
+package filegrpc
+
+import (
+	"context"
+	"errors"
+	"service-file/internal/domain"
+	"service-file/internal/domain/interfaces"
+	pb "service-file/internal/proto"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/emptypb"
+)
+
+type GRPCServer struct {
+	pb.UnimplementedFileServiceServer
+	usecase interfaces.GRPCUsecase
+}
+
+func NewGRPCServer(gRPC *grpc.Server, usecase interfaces.GRPCUsecase) {
+	pb.RegisterFileServiceServer(gRPC, &GRPCServer{usecase: usecase})
+}
+
+func (s *GRPCServer) GetFileByID(ctx context.Context, req *pb.GetFileRequest) (*pb.FileResponse, error) {
+	file, err := s.usecase.GetFileByID(ctx, uint(req.GetFileId()))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrFileNotFound):
+			return nil, status.Error(codes.NotFound, "file not found")
+		default:
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+	}
+
+	return fileResponse(file), nil
+}
+
+func fileResponse(file *domain.File) *pb.FileResponse {
+	return &pb.FileResponse{
+		Id:          uint32(file.ID),
+		DirectoryId: uint32(file.DirectoryID),
+		Name:        file.Name,
+		Status:      file.Status,
+		Version:     int32(file.Version),
+		Size:        file.Size,
+		ContentType: file.ContentType,
+		Directory: &pb.DirectoryResponse{
+			Id:           uint32(file.Directory.ID),
+			ParentPathId: uint32PtrOrNil(file.Directory.ParentPathID),
+			Name:         file.Directory.Name,
+			Status:       file.Directory.Status,
+			WorkflowId:   uint32(file.Directory.EffectiveWorkflowID),
+			Depth:        int32(file.Directory.Depth),
+
+			WorkflowInherited: file.Directory.WorkflowInherited,
+		},
+	}
+}
+
+func uint32PtrOrNil(value *uint) uint32 {
+	if value == nil {
+		return 0
+	}
+	return uint32(*value)
+}
+
+func (s *GRPCServer) UpdateFileStatus(ctx context.Context, req *pb.UpdateFileStatusRequest) (*emptypb.Empty, error) {
+	err := s.usecase.UpdateFileStatus(ctx, uint(req.GetFileId()), req.GetStatus(), req.GetIdempotencyKey())
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrFileNotFound):
+			return &emptypb.Empty{}, status.Error(codes.NotFound, "file not found")
+		default:
+			return &emptypb.Empty{}, status.Error(codes.Internal, "internal error")
+		}
+	}
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) GetFilesInfo(ctx context.Context, req *pb.GetFilesRequest) (*pb.GetFilesResponse, error) {
+	fileIDs := req.FileIds
+	fileNames := make(map[uint32]string)
+
+	files, err := s.usecase.GetFilesInfo(ctx, fileIDs)
+	if err != nil {
+		// TODO: custom errors
+		return nil, status.Errorf(codes.Internal, "failed to get files: %v", err)
+	}
+
+	fileResponses := make([]*pb.FileResponse, 0, len(files))
+	for i := range files {
+		fileNames[uint32(files[i].ID)] = files[i].Name
+		fileResponses = append(fileResponses, fileResponse(&files[i]))
+	}
+
+	return &pb.GetFilesResponse{
+		FileNames: fileNames,
+		Files:     fileResponses,
+	}, nil
+}
+
+func (s *GRPCServer) GetDirectoryFiles(ctx context.Context, req *pb.GetDirectoryFilesRequest) (*pb.GetDirectoryFilesResponse, error) {
+	fileIDs, err := s.usecase.GetDirectoryFiles(ctx, uint(req.GetDirectoryId()), req.GetStatus())
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDirectoryNotFound):
+			return nil, status.Error(codes.NotFound, "directory not found")
+		default:
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+	}
+
+	response := &pb.GetDirectoryFilesResponse{FileIds: make([]uint32, 0, len(fileIDs))}
+	for _, fileID := range fileIDs {
+		response.FileIds = append(response.FileIds, uint32(fileID))
+	}
+
+	return response, nil
+}
+
+func (s *GRPCServer) FindFiles(ctx context.Context, req *pb.FindFilesRequest) (*pb.GetDirectoryFilesResponse, error) {
+	fileIDs, err := s.usecase.FindFiles(ctx, uint(req.GetDirectoryId()), req.GetNameQuery(), int(req.GetLimit()))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrDirectoryNotFound):
+			return nil, status.Error(codes.NotFound, "directory not found")
+		default:
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+	}
+
+	response := &pb.GetDirectoryFilesResponse{FileIds: make([]uint32, 0, len(fileIDs))}
+	for _, fileID := range fileIDs {
+		response.FileIds = append(response.FileIds, uint32(fileID))
+	}
+
+	return response, nil
+}
+
+func (s *GRPCServer) GetFileContentHash(ctx context.Context, req *pb.GetFileContentHashRequest) (*pb.FileContentHashResponse, error) {
+	hash, err := s.usecase.GetFileContentHash(ctx, uint(req.GetFileId()), int(req.GetVersion()))
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrFileNotFound):
+			return nil, status.Error(codes.NotFound, "file not found")
+		case errors.Is(err, domain.ErrFileVersionNotFound):
+			return nil, status.Error(codes.NotFound, "file version not found")
+		default:
+			return nil, status.Error(codes.Internal, "internal error")
+		}
+	}
+
+	return &pb.FileContentHashResponse{
+		FileId:  uint32(hash.FileID),
+		Version: int32(hash.Version),
+		Sha256:  hash.SHA256,
+		Size:    hash.Size,
+	}, nil
+}
+
+func (s *GRPCServer) GetFileStatuses(ctx context.Context, req *pb.GetFilesRequest) (*pb.GetFileStatusesResponse, error) {
+	files, err := s.usecase.GetFilesByID(ctx, req.GetFileIds())
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get files: %v", err)
+	}
+
+	fileStatuses := make(map[uint32]string, len(files))
+	for _, file := range files {
+		fileStatuses[uint32(file.ID)] = file.Status
+	}
+
+	return &pb.GetFileStatusesResponse{
+		FileStatuses: fileStatuses,
+	}, nil
+}
+
+func (s *GRPCServer) CheckWorkflow(ctx context.Context, req *pb.CheckWorkflowRequest) (*pb.CheckWorkflowResponse, error) {
+	exists, err := s.usecase.CheckWorkflow(ctx, uint(req.WorkflowId))
+	if err != nil {
+		// TODO: custom errors
+		return nil, status.Errorf(codes.Internal, "failed to check workflow id")
+	}
+
+	return &pb.CheckWorkflowResponse{Exists: exists}, nil
+}
+
+func (s *GRPCServer) AssignWorkflow(ctx context.Context, req *pb.AssignWorkflowRequest) (*emptypb.Empty, error) {
+	if err := s.usecase.AssignWorkflow(ctx, req.GetWorkflowId(), req.GetDirectoryIds()); err != nil {
+		// TODO: custom errors
+		return nil, status.Errorf(codes.Internal, "failed to assign workflows")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) GetDirectoryPaths(ctx context.Context, _ *emptypb.Empty) (*pb.GetDirectoryPathsResponse, error) {
+	directories, err := s.usecase.GetDirectoryPaths(ctx)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to get directory paths: %v", err)
+	}
+
+	resp := &pb.GetDirectoryPathsResponse{Directories: make([]*pb.DirectoryPath, len(directories))}
+	for i, directory := range directories {
+		resp.Directories[i] = &pb.DirectoryPath{
+			DirectoryId: uint32(directory.ID),
+			Path:        directory.Path,
+			WorkflowId:  uint32(directory.WorkflowID),
+			ParentId:    uint32(directory.ParentID),
+		}
+	}
+
+	return resp, nil
+}
+
+func (s *GRPCServer) DeleteUserRelations(ctx context.Context, req *pb.DeleteUserRelationsRequest) (*emptypb.Empty, error) {
+	if err := s.usecase.DeleteUserRelations(ctx, uint(req.GetUserId())); err != nil {
+		// TODO: custom errors
+		return nil, status.Errorf(codes.Internal, "failed to delete user relations")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) RevokeSessions(ctx context.Context, req *pb.RevokeSessionsRequest) (*emptypb.Empty, error) {
+	if len(req.GetSessionIds()) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "session_ids is required")
+	}
+
+	if err := s.usecase.RevokeSessions(ctx, req.GetSessionIds(), time.Unix(req.GetExpiresAt(), 0)); err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to revoke sessions")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) AssignUser(ctx context.Context, req *pb.AssignUserRequest) (*emptypb.Empty, error) {
+	directories, err := objectAccessFromProto(req.GetDirectories())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid directory access level")
+	}
+	files, err := objectAccessFromProto(req.GetFiles())
+	if err != nil {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid file access level")
+	}
+
+	if err := s.usecase.AssignUser(ctx, uint(req.GetUserId()), directories, files); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrInvalidAccessLevel):
+			return nil, status.Errorf(codes.InvalidArgument, "invalid access level")
+		case errors.Is(err, domain.ErrDirectoryNotFound):
+			return nil, status.Errorf(codes.NotFound, "directory not found")
+		case errors.Is(err, domain.ErrFileNotFound):
+			return nil, status.Errorf(codes.NotFound, "file not found")
+		}
+		return nil, status.Errorf(codes.Internal, "failed to assign user")
+	}
+
+	return &emptypb.Empty{}, nil
+}
+
+func (s *GRPCServer) CheckFileAccess(ctx context.Context, req *pb.CheckFileAccessRequest) (*pb.CheckFileAccessResponse, error) {
+	level, err := domain.ParseAccessLevel(req.GetAccessLevel())
+	if err != nil || level <= domain.AccessNone {
+		return nil, status.Errorf(codes.InvalidArgument, "invalid access level")
+	}
+
+	allowed, err := s.usecase.CheckFileAccess(ctx, uint(req.GetUserId()), req.GetFileIds(), level)
+	if err != nil {
+		return nil, status.Errorf(codes.Internal, "failed to check file access")
+	}
+
+	return &pb.CheckFileAccessResponse{Allowed: allowed}, nil
+}
+
+// objectAccessFromProto переводит уровни доступа из запроса в domain.ObjectAccess.
+// Пустой уровень и none не выдают доступ, поэтому в связях пользователя недопустимы
+func objectAccessFromProto(objects []*pb.ObjectAccess) ([]domain.ObjectAccess, error) {
+	result := make([]domain.ObjectAccess, len(objects))
+	for i, object := range objects {
+		level, err := domain.ParseAccessLevel(object.GetAccessLevel())
+		if err != nil {
+			return nil, err
+		}
+		if level == domain.AccessNone {
+			return nil, domain.ErrInvalidAccessLevel
+		}
+		result[i] = domain.ObjectAccess{ID: uint(object.GetId()), Level: level}
+	}
+	return result, nil
+}
@@ -0,0 +1,154 @@
+package jwks
+
+import (
+	"context"
+	"crypto/ed25519"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"service-file/internal/domain"
+	"sync"
+	"time"
+)
+
+// jwk - открытый ключ из JWKS core-service
+type jwk struct {
+	Kty string `json:"kty"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Kid string `json:"kid"`
+	Alg string `json:"alg"`
+}
+
+// KeySet кэширует открытые ключи из JWKS core-service. Ключи перезапрашиваются по истечении cacheTTL
+// и при неизвестном kid (после ротации), но не чаще refreshInterval. Одновременные запросы ждут
+// одну загрузку, блокировка на время запроса к core-service не удерживается. Если core-service
+// недоступен, используются ранее загруженные ключи
+type KeySet struct {
+	url             string
+	client          *http.Client
+	cacheTTL        time.Duration
+	refreshInterval time.Duration
+
+	mu          sync.Mutex
+	keys        map[string]ed25519.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	refreshing  chan struct{} // закрывается по окончании текущей загрузки, nil - загрузки нет
+}
+
+func NewKeySet(url string, cacheTTL, refreshInterval time.Duration) *KeySet {
+	return &KeySet{
+		url:             url,
+		client:          &http.Client{Timeout: 5 * time.Second},
+		cacheTTL:        cacheTTL,
+		refreshInterval: refreshInterval,
+		keys:            make(map[string]ed25519.PublicKey),
+	}
+}
+
+// PublicKey возвращает открытый ключ kid
+// Кастомные ошибки: ErrTokenKeyNotFound
+func (s *KeySet) PublicKey(ctx context.Context, keyID string) (ed25519.PublicKey, error) {
+	const op = "infrastructure.jwks.PublicKey"
+
+	for {
+		s.mu.Lock()
+		now := time.Now()
+		key, ok := s.keys[keyID]
+		if ok && now.Sub(s.fetchedAt) < s.cacheTTL {
+			s.mu.Unlock()
+			return key, nil
+		}
+
+		// Ключи уже загружает другой запрос: ждем его результата
+		if wait := s.refreshing; wait != nil {
+			s.mu.Unlock()
+			select {
+			case <-wait:
+				continue
+			case <-ctx.Done():
+				if ok {
+					return key, nil
+				}
+				return nil, fmt.Errorf("%s: %w", op, ctx.Err())
+			}
+		}
+
+		if now.Sub(s.attemptedAt) < s.refreshInterval {
+			s.mu.Unlock()
+			if !ok {
+				return nil, fmt.Errorf("%s: %w", op, domain.ErrTokenKeyNotFound)
+			}
+			return key, nil
+		}
+
+		s.attemptedAt = now
+		done := make(chan struct{})
+		s.refreshing = done
+		s.mu.Unlock()
+
+		// Загрузка не прерывается отменой запроса, который ее начал: ее результата ждут другие запросы
+		keys, err := s.fetch(context.WithoutCancel(ctx))
+
+		s.mu.Lock()
+		if err == nil {
+			s.keys = keys
+			s.fetchedAt = now
+			key, ok = keys[keyID]
+		}
+		s.refreshing = nil
+		close(done)
+		s.mu.Unlock()
+
+		if err != nil && !ok {
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+		if !ok {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrTokenKeyNotFound)
+		}
+
+		return key, nil
+	}
+}
+
+// fetch загружает JWKS и возвращает ключи Ed25519 по kid
+func (s *KeySet) fetch(ctx context.Context) (map[string]ed25519.PublicKey, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, s.url, nil)
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected JWKS response status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(resp.Body).Decode(&set); err != nil {
+		return nil, err
+	}
+
+	keys := make(map[string]ed25519.PublicKey, len(set.Keys))
+	for _, key := range set.Keys {
+		if key.Kty != "OKP" || key.Crv != "Ed25519" || key.Kid == "" {
+			continue
+		}
+
+		publicKey, err := base64.RawURLEncoding.DecodeString(key.X)
+		if err != nil || len(publicKey) != ed25519.PublicKeySize {
+			continue
+		}
+		keys[key.Kid] = ed25519.PublicKey(publicKey)
+	}
+
+	return keys, nil
+}
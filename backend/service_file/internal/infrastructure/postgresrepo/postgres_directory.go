@@ -1,513 +1,852 @@
-package postgresrepo
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"service-file/internal/domain"
-	"service-file/internal/domain/interfaces"
-	"sort"
-
-	"gorm.io/gorm"
-)
-
-type DirectoryRepository struct {
-	db *gorm.DB
-}
-
-func NewDirectoryRepository(db *Database) *DirectoryRepository {
-	return &DirectoryRepository{db: db.db}
-}
-
-func (r *DirectoryRepository) WithTx(tx *gorm.DB) interfaces.DirectoryRepository {
-	return &DirectoryRepository{db: tx}
-}
-
-func (r *DirectoryRepository) GetDB() *gorm.DB {
-	return r.db
-}
-
-func (r *DirectoryRepository) GetFileTreeWorking(ctx context.Context, userID uint) ([]domain.Directory, error) {
-	const op = "infrastructure.postgresrepo.directory.GetFileTreeWorking"
-
-	var directories []domain.Directory
-
-	query := r.db.WithContext(ctx).
-		Select("directories.*").
-		Preload("Files", func(db *gorm.DB) *gorm.DB {
-			return db.Joins("JOIN user_files AS uf ON uf.file_id = files.id").
-				Where("status != ? AND uf.user_id = ?", "archive", userID)
-		})
-
-	query = query.Joins("JOIN user_directories ON user_directories.directory_id = directories.id").
-		Where("user_directories.user_id = ?", userID).
-		Where("directories.status != ?", "archive")
-
-	if err := query.Find(&directories).Error; err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return directories, nil
-}
-
-func (r *DirectoryRepository) GetFileTreeArchive(ctx context.Context, userID uint) ([]domain.Directory, error) {
-	const op = "infrastructure.postgresrepo.directory.GetFileTreeArchive"
-
-	var directories []domain.Directory
-
-	query := r.db.WithContext(ctx).
-		Select("directories.*").
-		Preload("Files", func(db *gorm.DB) *gorm.DB {
-			return db.Where("status = ?", "archive")
-		})
-
-	query = query.Where("directories.status = ? ", "archive")
-
-	if err := query.Find(&directories).Error; err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return directories, nil
-}
-
-func (r *DirectoryRepository) GetUserFileTree(ctx context.Context, userID uint) ([]domain.DirectoryUserResponse, error) {
-	const op = "infrastructure.postgresrepo.directory.GerUserFileTree"
-
-	query := `
-        SELECT 
-            d.id AS directory_id,
-            d.name AS name_folder,
-            d.parent_path_id AS parent_path_id,
-            (ud.user_id IS NOT NULL) AS user_has_access,
-            f.id AS file_id,
-            f.name AS name_file,
-            f.directory_id AS file_directory_id,
-            (uf.user_id IS NOT NULL) AS file_user_has_access
-        FROM directories d
-        LEFT JOIN user_directories ud ON ud.directory_id = d.id AND ud.user_id = ?
-        LEFT JOIN files f ON f.directory_id = d.id
-        LEFT JOIN user_files uf ON uf.file_id = f.id AND uf.user_id = ?
-    `
-
-	var rawResults []struct {
-		DirectoryID       uint    `json:"directory_id"`
-		NameFolder        string  `json:"name_folder"`
-		ParentPathID      *uint   `json:"parent_path_id"`
-		UserHasAccess     bool    `json:"user_has_access"`
-		FileID            *uint   `json:"file_id"`
-		NameFile          *string `json:"name_file"`
-		FileDirectoryID   *uint   `json:"file_directory_id"`
-		FileUserHasAccess *bool   `json:"file_user_has_access"`
-	}
-
-	if err := r.db.WithContext(ctx).Raw(query, userID, userID).Scan(&rawResults).Error; err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	directoryMap := make(map[uint]*domain.DirectoryUserResponse)
-	for _, row := range rawResults {
-		if _, exists := directoryMap[row.DirectoryID]; !exists {
-			directoryMap[row.DirectoryID] = &domain.DirectoryUserResponse{
-				ID:            row.DirectoryID,
-				NameFolder:    row.NameFolder,
-				ParentPathID:  row.ParentPathID,
-				UserHasAccess: row.UserHasAccess,
-				Files:         []domain.FileUserResponse{},
-			}
-		}
-
-		if row.FileID != nil {
-			directoryMap[row.DirectoryID].Files = append(directoryMap[row.DirectoryID].Files, domain.FileUserResponse{
-				ID:            *row.FileID,
-				NameFile:      *row.NameFile,
-				DirectoryID:   *row.FileDirectoryID,
-				UserHasAccess: *row.FileUserHasAccess,
-			})
-		}
-	}
-
-	var result []domain.DirectoryUserResponse
-	for _, dir := range directoryMap {
-		result = append(result, *dir)
-	}
-
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].ID < result[j].ID
-	})
-
-	return result, nil
-}
-
-func (r *DirectoryRepository) GetWorkflowFileTree(ctx context.Context, workflowID uint) ([]domain.DirectoryWorkflowResponse, error) {
-	const op = "infrastructure.postgresrepo.directory.GetWorkflowFileTree"
-
-	query := `
-        SELECT 
-            d.id AS directory_id,
-            d.name AS name_folder,
-            d.parent_path_id AS parent_path_id,
-            (d.workflow_id = ?) AS current_workflow_assigned
-        FROM directories d
-    `
-
-	var rawResults []struct {
-		DirectoryID             uint   `json:"directory_id"`
-		NameFolder              string `json:"name_folder"`
-		ParentPathID            *uint  `json:"parent_path_id"`
-		CurrentWorkflowAssigned bool   `json:"current_workflow_assigned"`
-	}
-
-	if err := r.db.WithContext(ctx).Raw(query, workflowID).Scan(&rawResults).Error; err != nil {
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	directoryMap := make(map[uint]*domain.DirectoryWorkflowResponse)
-	for _, row := range rawResults {
-		if _, exists := directoryMap[row.DirectoryID]; !exists {
-			directoryMap[row.DirectoryID] = &domain.DirectoryWorkflowResponse{
-				ID:                      row.DirectoryID,
-				NameFolder:              row.NameFolder,
-				ParentPathID:            row.ParentPathID,
-				CurrentWorkflowAssigned: row.CurrentWorkflowAssigned,
-			}
-		}
-	}
-
-	var result []domain.DirectoryWorkflowResponse
-	for _, dir := range directoryMap {
-		result = append(result, *dir)
-	}
-
-	sort.Slice(result, func(i, j int) bool {
-		return result[i].ID < result[j].ID
-	})
-
-	return result, nil
-}
-
-func (r *DirectoryRepository) CreateDirectory(ctx context.Context, parentPathID *uint, name string, status string, userID uint) error {
-	const op = "infrastructure.postgresrepo.directory.CreateDirectory"
-
-	// Начинаем транзакцию
-	tx := r.db.WithContext(ctx).Begin()
-	defer func() {
-		// Если транзакция не зафиксирована, откатываем
-		if r := recover(); r != nil {
-			tx.Rollback()
-		} else if tx.Error != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Создаем новый файл
-	newDirectory := domain.Directory{
-		ParentPathID: parentPathID,
-		Name:         name,
-		Status:       status,
-	}
-	if err := tx.Create(&newDirectory).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, domain.ErrDirectoryAlreadyExists)
-	}
-
-	userDirectory := domain.UserDirectory{
-		UserID:      userID,
-		DirectoryID: newDirectory.ID,
-	}
-	if err := tx.Create(&userDirectory).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	return nil
-}
-
-func (r *DirectoryRepository) DeleteDirectory(ctx context.Context, directoryID, userID uint) error {
-	const op = "infrastructure.postgresrepo.directory.DeleteDirectory"
-	tx := r.db.WithContext(ctx).Begin()
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Получаем файл и проверяем его существование
-	var directory domain.Directory
-	if err := tx.First(&directory, directoryID).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
-	}
-
-	// Проверяем, существует ли директория и имеет ли пользователь к ней доступ
-	var hasAccess bool
-	err := tx.Raw(`
-		SELECT COUNT(*) > 0
-		FROM user_directories ud
-		JOIN directories d ON ud.directory_id = d.id
-		WHERE ud.user_id = ? AND d.id = ?
-	`, userID, directoryID).Scan(&hasAccess).Error
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if !hasAccess {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, domain.ErrAccessDenied)
-	}
-
-	// Проверяем, есть ли в текущей директории или её потомках файлы со статусом != "draft"
-	var hasNonDraftFiles bool
-	err = tx.Raw(`
-		SELECT EXISTS (
-			SELECT 1 FROM files 
-			WHERE directory_id IN (
-				WITH RECURSIVE subdirs AS (
-					SELECT id FROM directories WHERE id = ?
-					UNION ALL
-					SELECT d.id FROM directories d 
-					JOIN subdirs s ON d.parent_path_id = s.id
-				) SELECT id FROM subdirs
-			) AND status != 'draft'
-		)
-	`, directoryID).Scan(&hasNonDraftFiles).Error
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if hasNonDraftFiles {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, domain.ErrDirectoryContainsNonDraftFiles)
-	}
-
-	// Удаляем файлы и их связи одним запросом
-	err = tx.Exec(`
-		DELETE FROM files 
-		WHERE directory_id IN (
-			WITH RECURSIVE subdirs AS (
-				SELECT id FROM directories WHERE id = ?
-				UNION ALL
-				SELECT d.id FROM directories d 
-				JOIN subdirs s ON d.parent_path_id = s.id
-			) SELECT id FROM subdirs
-		)
-	`, directoryID).Error
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	// Удаляем директории и их связи одним запросом
-	err = tx.Exec(`
-		DELETE FROM directories 
-		WHERE id IN (
-			WITH RECURSIVE subdirs AS (
-				SELECT id FROM directories WHERE id = ?
-				UNION ALL
-				SELECT d.id FROM directories d 
-				JOIN subdirs s ON d.parent_path_id = s.id
-			) SELECT id FROM subdirs
-		)
-	`, directoryID).Error
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	err = tx.Exec(`
-		DELETE FROM user_directories 
-		WHERE directory_id IN (
-			WITH RECURSIVE subdirs AS (
-				SELECT id FROM directories WHERE id = ?
-				UNION ALL
-				SELECT d.id FROM directories d 
-				JOIN subdirs s ON d.parent_path_id = s.id
-			) SELECT id FROM subdirs
-		)
-	`, directoryID).Error
-	if err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (r *DirectoryRepository) CheckUserDirectoryAccess(ctx context.Context, userID, directoryID uint) (bool, error) {
-	const op = "infrastructure.postgresrepo.directory.CheckUserDirectoryAccess"
-
-	var dir domain.Directory
-	if err := r.db.WithContext(ctx).First(&dir, directoryID).Error; err != nil {
-		if errors.Is(err, gorm.ErrRecordNotFound) {
-			return false, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
-		}
-		return false, fmt.Errorf("%s: %w", op, err)
-	}
-
-	var exists bool
-	err := r.db.WithContext(ctx).
-		Model(&domain.UserDirectory{}).
-		Select("COUNT(*) > 0").
-		Where("user_id = ? AND directory_id = ?", userID, directoryID).
-		Scan(&exists).Error
-
-	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return exists, nil
-}
-
-func (directoryRepository *DirectoryRepository) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
-	const op = "infrastructure.postgresrepo.directory.CheckWorkflowExists"
-
-	var count int64
-	err := directoryRepository.db.WithContext(ctx).
-		Model(&domain.Directory{}).
-		Where("workflow_id = ?", workflowID).
-		Count(&count).Error
-
-	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return count > 0, nil
-}
-
-func (directoryRepository *DirectoryRepository) DeleteUserRelations(ctx context.Context, userID uint) error {
-	const op = "infrastructure.postgresrepo.directory.DeleteUserRelations"
-
-	tx := directoryRepository.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return fmt.Errorf("%s: failed to begin transaction: %w", op, tx.Error)
-	}
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	result := tx.Where("user_id = ?", userID).Delete(&domain.UserDirectory{})
-	if result.Error != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, result.Error)
-	}
-
-	if result.RowsAffected == 0 {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, domain.ErrNoRelationsFound)
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (directoryRepository *DirectoryRepository) CheckDirectoriesExist(ctx context.Context, directoryIDs []uint) (bool, error) {
-	const op = "infrastructure.postgresrepo.directory.CheckDirectoriesExist"
-
-	if len(directoryIDs) == 0 {
-		return false, fmt.Errorf("%s: empty user list", op)
-	}
-
-	var count int64
-	err := directoryRepository.db.WithContext(ctx).
-		Model(&domain.Directory{}).
-		Where("id IN (?)", directoryIDs).
-		Count(&count).Error
-
-	if err != nil {
-		return false, fmt.Errorf("%s: %w", op, err)
-	}
-
-	return count == int64(len(directoryIDs)), nil
-}
-
-func (r *DirectoryRepository) UpdateDirectories(ctx context.Context, workflowID uint, directoryIDs []uint) error {
-	const op = "infrastructure.postgresrepo.directory.UpdateDirectories"
-
-	// Начало транзакции
-	tx := r.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return fmt.Errorf("%s: failed to begin transaction: %w", op, tx.Error)
-	}
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	// Обновление workflow_id для всех указанных directory_ids
-	result := tx.Model(&domain.Directory{}).
-		Where("id IN ?", directoryIDs).
-		Update("workflow_id", workflowID)
-
-	if result.Error != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, result.Error)
-	}
-
-	// Проверка, были ли затронуты строки
-	if result.RowsAffected == 0 {
-		tx.Rollback()
-		return fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
-	}
-
-	// Фиксация изменений
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
-
-func (r *DirectoryRepository) UpdateUserDirectoryRelations(ctx context.Context, userID uint, directoryIDs []uint) error {
-	const op = "infrastructure.postgresrepo.directory.UpdateUserDirectoryRelations"
-
-	tx := r.db.WithContext(ctx).Begin()
-	if tx.Error != nil {
-		return fmt.Errorf("%s: failed to begin transaction: %w", op, tx.Error)
-	}
-
-	defer func() {
-		if r := recover(); r != nil {
-			tx.Rollback()
-		}
-	}()
-
-	if err := tx.Where("user_id = ?", userID).Delete(&domain.UserDirectory{}).Error; err != nil {
-		tx.Rollback()
-		return fmt.Errorf("%s: failed to delete user directory relations: %w", op, err)
-	}
-
-	var newRelations []domain.UserDirectory
-	for _, directoryID := range directoryIDs {
-		newRelations = append(newRelations, domain.UserDirectory{
-			UserID:      userID,
-			DirectoryID: directoryID,
-		})
-	}
-
-	if len(newRelations) > 0 {
-		if err := tx.Create(&newRelations).Error; err != nil {
-			tx.Rollback()
-			return fmt.Errorf("%s: failed to create user directory relations: %w", op, err)
-		}
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	return nil
-}
+package postgresrepo
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"service-file/internal/domain"
+	"service-file/internal/domain/interfaces"
+	"sort"
+	"strings"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type DirectoryRepository struct {
+	db *gorm.DB
+}
+
+func NewDirectoryRepository(db *Database) *DirectoryRepository {
+	return &DirectoryRepository{db: db.db}
+}
+
+func (r *DirectoryRepository) WithTx(tx *gorm.DB) interfaces.DirectoryRepository {
+	return &DirectoryRepository{db: tx}
+}
+
+func (r *DirectoryRepository) GetDB() *gorm.DB {
+	return r.db
+}
+
+// userDirectoryAccessCTE вычисляет действующие уровни доступа пользователя ко всем директориям,
+// проходя дерево от корня: уровень, выданный директории, иначе уровень родительской директории.
+// Запрет (AccessDeny) наследуется так же, как уровень, пока ниже не выдан свой.
+// explicit_level - выданный самой директории уровень (0 - не выдан), source_directory_id - директория,
+// от которой получен уровень. Параметры: userID, userID
+const userDirectoryAccessCTE = `
+	WITH RECURSIVE access AS (
+		SELECT d.id AS directory_id,
+			COALESCE(ud.access_level, 0) AS explicit_level,
+			COALESCE(ud.access_level, 0) AS access_level,
+			CASE WHEN ud.user_id IS NOT NULL THEN d.id END AS source_directory_id
+		FROM directories d
+		LEFT JOIN user_directories ud ON ud.directory_id = d.id AND ud.user_id = ?
+		WHERE d.parent_path_id IS NULL AND d.deleted_at IS NULL
+		UNION ALL
+		SELECT d.id,
+			COALESCE(ud.access_level, 0),
+			COALESCE(ud.access_level, access.access_level),
+			CASE WHEN ud.user_id IS NOT NULL THEN d.id ELSE access.source_directory_id END
+		FROM directories d
+		JOIN access ON d.parent_path_id = access.directory_id
+		LEFT JOIN user_directories ud ON ud.directory_id = d.id AND ud.user_id = ?
+		WHERE d.deleted_at IS NULL
+	)
+`
+
+// directoryAccessQuery возвращает действующий уровень доступа пользователя к одной директории:
+// уровень, выданный ближайшей из директории и её родителей. Параметры: directoryID, userID
+const directoryAccessQuery = `
+	WITH RECURSIVE parents AS (
+		SELECT id, parent_path_id, 1 AS depth FROM directories WHERE id = ? AND deleted_at IS NULL
+		UNION ALL
+		SELECT d.id, d.parent_path_id, p.depth + 1 FROM directories d
+		JOIN parents p ON d.id = p.parent_path_id
+	)
+	SELECT COALESCE((
+		SELECT ud.access_level FROM parents p
+		JOIN user_directories ud ON ud.directory_id = p.id AND ud.user_id = ?
+		ORDER BY p.depth
+		LIMIT 1
+	), 0)
+`
+
+// directoryAccessLevel возвращает действующий уровень доступа пользователя к директории с учетом наследования
+func directoryAccessLevel(db *gorm.DB, userID, directoryID uint) (domain.AccessLevel, error) {
+	var level domain.AccessLevel
+	if err := db.Raw(directoryAccessQuery, directoryID, userID).Scan(&level).Error; err != nil {
+		return domain.AccessNone, err
+	}
+	return level, nil
+}
+
+// GetFileTreeWorking возвращает рабочие директории и файлы, доступные пользователю с учетом наследования,
+// с действующими уровнями доступа. Файл без своего уровня получает уровень директории
+func (r *DirectoryRepository) GetFileTreeWorking(ctx context.Context, userID uint) ([]domain.Directory, error) {
+	const op = "infrastructure.postgresrepo.directory.GetFileTreeWorking"
+
+	var directories []domain.Directory
+
+	query := r.db.WithContext(ctx).
+		Select("directories.*, access.access_level").
+		Preload("Files", func(db *gorm.DB) *gorm.DB {
+			return db.Select("files.*, COALESCE(uf.access_level, 0) AS access_level").
+				Joins("LEFT JOIN user_files AS uf ON uf.file_id = files.id AND uf.user_id = ?", userID).
+				Where("status != ?", "archive")
+		})
+
+	query = query.Joins("JOIN ("+userDirectoryAccessCTE+"SELECT directory_id, access_level FROM access) AS access ON access.directory_id = directories.id", userID, userID).
+		Where("access.access_level >= ?", domain.AccessRead).
+		Where("directories.status != ?", "archive")
+
+	if err := query.Find(&directories).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range directories {
+		files := directories[i].Files[:0]
+		for _, file := range directories[i].Files {
+			if file.AccessLevel == domain.AccessNone {
+				file.AccessLevel = directories[i].AccessLevel
+			}
+			if file.AccessLevel >= domain.AccessRead {
+				files = append(files, file)
+			}
+		}
+		directories[i].Files = files
+	}
+
+	return directories, nil
+}
+
+func (r *DirectoryRepository) GetFileTreeArchive(ctx context.Context, userID uint) ([]domain.Directory, error) {
+	const op = "infrastructure.postgresrepo.directory.GetFileTreeArchive"
+
+	var directories []domain.Directory
+
+	query := r.db.WithContext(ctx).
+		Select("directories.*").
+		Preload("Files", func(db *gorm.DB) *gorm.DB {
+			return db.Where("status = ?", "archive")
+		})
+
+	query = query.Where("directories.status = ? ", "archive")
+
+	if err := query.Find(&directories).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return directories, nil
+}
+
+// GetUserFileTree возвращает все директории и файлы с уровнями доступа пользователя: выданными
+// самим директориям и файлам и действующими с учетом наследования
+func (r *DirectoryRepository) GetUserFileTree(ctx context.Context, userID uint) ([]domain.DirectoryUserResponse, error) {
+	const op = "infrastructure.postgresrepo.directory.GerUserFileTree"
+
+	query := userDirectoryAccessCTE + `
+        SELECT
+            d.id AS directory_id,
+            d.name AS name_folder,
+            d.parent_path_id AS parent_path_id,
+            access.explicit_level,
+            access.access_level,
+            access.source_directory_id,
+            f.id AS file_id,
+            f.name AS name_file,
+            f.directory_id AS file_directory_id,
+            COALESCE(uf.access_level, 0) AS file_explicit_level
+        FROM access
+        JOIN directories d ON d.id = access.directory_id
+        LEFT JOIN files f ON f.directory_id = d.id AND f.deleted_at IS NULL
+        LEFT JOIN user_files uf ON uf.file_id = f.id AND uf.user_id = ?
+    `
+
+	var rawResults []struct {
+		DirectoryID       uint               `json:"directory_id"`
+		NameFolder        string             `json:"name_folder"`
+		ParentPathID      *uint              `json:"parent_path_id"`
+		ExplicitLevel     domain.AccessLevel `json:"explicit_level"`
+		AccessLevel       domain.AccessLevel `json:"access_level"`
+		SourceDirectoryID *uint              `json:"source_directory_id"`
+		FileID            *uint              `json:"file_id"`
+		NameFile          *string            `json:"name_file"`
+		FileDirectoryID   *uint              `json:"file_directory_id"`
+		FileExplicitLevel domain.AccessLevel `json:"file_explicit_level"`
+	}
+
+	if err := r.db.WithContext(ctx).Raw(query, userID, userID, userID).Scan(&rawResults).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	directoryMap := make(map[uint]*domain.DirectoryUserResponse)
+	for _, row := range rawResults {
+		if _, exists := directoryMap[row.DirectoryID]; !exists {
+			directory := &domain.DirectoryUserResponse{
+				ID:            row.DirectoryID,
+				NameFolder:    row.NameFolder,
+				ParentPathID:  row.ParentPathID,
+				UserHasAccess: row.ExplicitLevel >= domain.AccessRead,
+				Files:         []domain.FileUserResponse{},
+			}
+			if row.ExplicitLevel != domain.AccessNone {
+				directory.AccessLevel = row.ExplicitLevel.String()
+			}
+			if row.AccessLevel >= domain.AccessRead {
+				directory.EffectiveAccessLevel = row.AccessLevel.String()
+				if row.ExplicitLevel == domain.AccessNone {
+					directory.InheritedFromID = row.SourceDirectoryID
+				}
+			}
+			directoryMap[row.DirectoryID] = directory
+		}
+
+		if row.FileID != nil {
+			file := domain.FileUserResponse{
+				ID:            *row.FileID,
+				NameFile:      *row.NameFile,
+				DirectoryID:   *row.FileDirectoryID,
+				UserHasAccess: row.FileExplicitLevel >= domain.AccessRead,
+			}
+
+			// Файл без своего уровня получает уровень директории
+			level, inheritedFromID := row.FileExplicitLevel, (*uint)(nil)
+			if row.FileExplicitLevel != domain.AccessNone {
+				file.AccessLevel = row.FileExplicitLevel.String()
+			} else {
+				level, inheritedFromID = row.AccessLevel, row.SourceDirectoryID
+			}
+			if level >= domain.AccessRead {
+				file.EffectiveAccessLevel = level.String()
+				file.InheritedFromID = inheritedFromID
+			}
+			directoryMap[row.DirectoryID].Files = append(directoryMap[row.DirectoryID].Files, file)
+		}
+	}
+
+	var result []domain.DirectoryUserResponse
+	for _, dir := range directoryMap {
+		result = append(result, *dir)
+	}
+
+	sort.Slice(result, func(i, j int) bool {
+		return result[i].ID < result[j].ID
+	})
+
+	return result, nil
+}
+
+// GetWorkflowFileTree возвращает дерево директорий с процедурами согласования: назначенными
+// самим директориям и действующими с учетом наследования от родителей
+func (r *DirectoryRepository) GetWorkflowFileTree(ctx context.Context, workflowID uint) ([]domain.DirectoryWorkflowResponse, error) {
+	const op = "infrastructure.postgresrepo.directory.GetWorkflowFileTree"
+
+	query := `
+        WITH RECURSIVE tree AS (
+            SELECT id, name, parent_path_id, workflow_id,
+                workflow_id AS effective_workflow_id,
+                CASE WHEN workflow_id <> 0 THEN id END AS source_directory_id
+            FROM directories
+            WHERE parent_path_id IS NULL AND deleted_at IS NULL
+            UNION ALL
+            SELECT d.id, d.name, d.parent_path_id, d.workflow_id,
+                CASE WHEN d.workflow_id <> 0 THEN d.workflow_id ELSE tree.effective_workflow_id END,
+                CASE WHEN d.workflow_id <> 0 THEN d.id ELSE tree.source_directory_id END
+            FROM directories d
+            JOIN tree ON d.parent_path_id = tree.id
+            WHERE d.deleted_at IS NULL
+        )
+        SELECT
+            id AS directory_id,
+            name AS name_folder,
+            parent_path_id,
+            workflow_id,
+            effective_workflow_id,
+            source_directory_id
+        FROM tree
+        ORDER BY id
+    `
+
+	var rawResults []struct {
+		DirectoryID         uint
+		NameFolder          string
+		ParentPathID        *uint
+		WorkflowID          uint
+		EffectiveWorkflowID uint
+		SourceDirectoryID   *uint
+	}
+
+	if err := r.db.WithContext(ctx).Raw(query).Scan(&rawResults).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	result := make([]domain.DirectoryWorkflowResponse, 0, len(rawResults))
+	for _, row := range rawResults {
+		directory := domain.DirectoryWorkflowResponse{
+			ID:                       row.DirectoryID,
+			NameFolder:               row.NameFolder,
+			ParentPathID:             row.ParentPathID,
+			CurrentWorkflowAssigned:  row.WorkflowID == workflowID,
+			WorkflowID:               row.EffectiveWorkflowID,
+			CurrentWorkflowEffective: row.EffectiveWorkflowID == workflowID,
+		}
+		switch {
+		case row.WorkflowID != 0:
+			directory.WorkflowMode = domain.WorkflowModeOverride
+		case row.SourceDirectoryID != nil:
+			directory.WorkflowMode = domain.WorkflowModeInherit
+			directory.InheritedFromID = row.SourceDirectoryID
+		default:
+			directory.WorkflowMode = domain.WorkflowModeNone
+		}
+		result = append(result, directory)
+	}
+
+	return result, nil
+}
+
+func (r *DirectoryRepository) CreateDirectory(ctx context.Context, parentPathID *uint, name string, status string, userID uint) error {
+	const op = "infrastructure.postgresrepo.directory.CreateDirectory"
+
+	// Начинаем транзакцию
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		// Если транзакция не зафиксирована, откатываем
+		if r := recover(); r != nil {
+			tx.Rollback()
+		} else if tx.Error != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Создаем новую директорию. Процедура согласования не назначается: директория наследует
+	// процедуру родителя, пока ей не назначат свою
+	newDirectory := domain.Directory{
+		ParentPathID: parentPathID,
+		Name:         name,
+		Status:       status,
+		WorkflowID:   0,
+	}
+	if err := tx.Create(&newDirectory).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrDirectoryAlreadyExists)
+	}
+
+	// Создатель директории управляет доступом к ней
+	userDirectory := domain.UserDirectory{
+		UserID:      userID,
+		DirectoryID: newDirectory.ID,
+		AccessLevel: domain.AccessManage,
+	}
+	if err := tx.Create(&userDirectory).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	return nil
+}
+
+func (r *DirectoryRepository) DeleteDirectory(ctx context.Context, directoryID, userID uint) error {
+	const op = "infrastructure.postgresrepo.directory.DeleteDirectory"
+	tx := r.db.WithContext(ctx).Begin()
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Получаем файл и проверяем его существование
+	var directory domain.Directory
+	if err := tx.First(&directory, directoryID).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+	}
+
+	// Проверяем, может ли пользователь управлять директорией, в том числе по доступу к родительской
+	level, err := directoryAccessLevel(tx, userID, directoryID)
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if level < domain.AccessManage {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrAccessDenied)
+	}
+
+	// Проверяем, есть ли в текущей директории или её потомках файлы со статусом != "draft"
+	var hasNonDraftFiles bool
+	err = tx.Raw(`
+		SELECT EXISTS (
+			SELECT 1 FROM files 
+			WHERE directory_id IN (
+				WITH RECURSIVE subdirs AS (
+					SELECT id FROM directories WHERE id = ?
+					UNION ALL
+					SELECT d.id FROM directories d 
+					JOIN subdirs s ON d.parent_path_id = s.id
+				) SELECT id FROM subdirs
+			) AND status != 'draft'
+		)
+	`, directoryID).Scan(&hasNonDraftFiles).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if hasNonDraftFiles {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrDirectoryContainsNonDraftFiles)
+	}
+
+	// Удаляем файлы и их связи одним запросом
+	err = tx.Exec(`
+		DELETE FROM files 
+		WHERE directory_id IN (
+			WITH RECURSIVE subdirs AS (
+				SELECT id FROM directories WHERE id = ?
+				UNION ALL
+				SELECT d.id FROM directories d 
+				JOIN subdirs s ON d.parent_path_id = s.id
+			) SELECT id FROM subdirs
+		)
+	`, directoryID).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	// Удаляем директории и их связи одним запросом
+	err = tx.Exec(`
+		DELETE FROM directories 
+		WHERE id IN (
+			WITH RECURSIVE subdirs AS (
+				SELECT id FROM directories WHERE id = ?
+				UNION ALL
+				SELECT d.id FROM directories d 
+				JOIN subdirs s ON d.parent_path_id = s.id
+			) SELECT id FROM subdirs
+		)
+	`, directoryID).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	err = tx.Exec(`
+		DELETE FROM user_directories 
+		WHERE directory_id IN (
+			WITH RECURSIVE subdirs AS (
+				SELECT id FROM directories WHERE id = ?
+				UNION ALL
+				SELECT d.id FROM directories d 
+				JOIN subdirs s ON d.parent_path_id = s.id
+			) SELECT id FROM subdirs
+		)
+	`, directoryID).Error
+	if err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// CheckUserDirectoryAccess проверяет, что у пользователя есть доступ к директории уровня не ниже level,
+// выданный самой директории или унаследованный от родительской
+// Кастомные ошибки: ErrDirectoryNotFound
+func (r *DirectoryRepository) CheckUserDirectoryAccess(ctx context.Context, userID, directoryID uint, level domain.AccessLevel) (bool, error) {
+	const op = "infrastructure.postgresrepo.directory.CheckUserDirectoryAccess"
+
+	var dir domain.Directory
+	if err := r.db.WithContext(ctx).First(&dir, directoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return false, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+		}
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	userLevel, err := directoryAccessLevel(r.db.WithContext(ctx), userID, directoryID)
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return userLevel >= level, nil
+}
+
+func (directoryRepository *DirectoryRepository) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
+	const op = "infrastructure.postgresrepo.directory.CheckWorkflowExists"
+
+	var count int64
+	err := directoryRepository.db.WithContext(ctx).
+		Model(&domain.Directory{}).
+		Where("workflow_id = ?", workflowID).
+		Count(&count).Error
+
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count > 0, nil
+}
+
+// GetDirectoryDepth возвращает уровень вложенности директории: у корневой директории 1
+// Кастомные ошибки: ErrDirectoryNotFound
+func (directoryRepository *DirectoryRepository) GetDirectoryDepth(ctx context.Context, directoryID uint) (int, error) {
+	const op = "infrastructure.postgresrepo.directory.GetDirectoryDepth"
+
+	var depth int
+	err := directoryRepository.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE parents AS (
+			SELECT id, parent_path_id FROM directories WHERE id = ? AND deleted_at IS NULL
+			UNION ALL
+			SELECT d.id, d.parent_path_id FROM directories d
+			JOIN parents p ON d.id = p.parent_path_id
+		) SELECT COUNT(*) FROM parents
+	`, directoryID).Scan(&depth).Error
+	if err != nil {
+		return 0, fmt.Errorf("%s: %w", op, err)
+	}
+	if depth == 0 {
+		return 0, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+	}
+
+	return depth, nil
+}
+
+// GetDirectoryDepths возвращает уровни вложенности директорий directoryIDs
+func (directoryRepository *DirectoryRepository) GetDirectoryDepths(ctx context.Context, directoryIDs []uint) (map[uint]int, error) {
+	const op = "infrastructure.postgresrepo.directory.GetDirectoryDepths"
+
+	var rows []struct {
+		DirectoryID uint
+		Depth       int
+	}
+	err := directoryRepository.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE parents AS (
+			SELECT id AS directory_id, parent_path_id FROM directories WHERE id IN (?) AND deleted_at IS NULL
+			UNION ALL
+			SELECT p.directory_id, d.parent_path_id FROM directories d
+			JOIN parents p ON d.id = p.parent_path_id
+		) SELECT directory_id, COUNT(*) AS depth FROM parents GROUP BY directory_id
+	`, directoryIDs).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	depths := make(map[uint]int, len(rows))
+	for _, row := range rows {
+		depths[row.DirectoryID] = row.Depth
+	}
+
+	return depths, nil
+}
+
+// GetDirectoryWorkflows возвращает действующие процедуры согласования директорий directoryIDs:
+// процедуру самой директории или ближайшей родительской директории с назначенной процедурой.
+// Директорий, в которых процедура не действует, в результате нет
+func (directoryRepository *DirectoryRepository) GetDirectoryWorkflows(ctx context.Context, directoryIDs []uint) (map[uint]domain.DirectoryWorkflow, error) {
+	const op = "infrastructure.postgresrepo.directory.GetDirectoryWorkflows"
+
+	var rows []domain.DirectoryWorkflow
+	err := directoryRepository.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE parents AS (
+			SELECT id AS directory_id, id, parent_path_id, workflow_id, 1 AS level
+			FROM directories WHERE id IN (?) AND deleted_at IS NULL
+			UNION ALL
+			SELECT p.directory_id, d.id, d.parent_path_id, d.workflow_id, p.level + 1
+			FROM directories d
+			JOIN parents p ON d.id = p.parent_path_id
+			WHERE p.workflow_id = 0
+		)
+		SELECT DISTINCT ON (directory_id) directory_id, workflow_id, id AS source_directory_id
+		FROM parents
+		WHERE workflow_id <> 0
+		ORDER BY directory_id, level
+	`, directoryIDs).Scan(&rows).Error
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	workflows := make(map[uint]domain.DirectoryWorkflow, len(rows))
+	for _, row := range rows {
+		workflows[row.DirectoryID] = row
+	}
+
+	return workflows, nil
+}
+
+// GetDirectoryFileIDs возвращает ID файлов директории и всех вложенных директорий.
+// Если status не пустой, возвращаются только файлы в этом статусе
+// Кастомные ошибки: ErrDirectoryNotFound
+func (directoryRepository *DirectoryRepository) GetDirectoryFileIDs(ctx context.Context, directoryID uint, status string) ([]uint, error) {
+	const op = "infrastructure.postgresrepo.directory.GetDirectoryFileIDs"
+
+	var directory domain.Directory
+	if err := directoryRepository.db.WithContext(ctx).Select("id").First(&directory, directoryID).Error; err != nil {
+		if errors.Is(err, gorm.ErrRecordNotFound) {
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+		}
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	query := directoryRepository.db.WithContext(ctx).
+		Model(&domain.File{}).
+		Where(`directory_id IN (
+			WITH RECURSIVE subdirs AS (
+				SELECT id FROM directories WHERE id = ? AND deleted_at IS NULL
+				UNION ALL
+				SELECT d.id FROM directories d
+				JOIN subdirs s ON d.parent_path_id = s.id
+				WHERE d.deleted_at IS NULL
+			) SELECT id FROM subdirs
+		)`, directoryID)
+	if status != "" {
+		query = query.Where("status = ?", status)
+	}
+
+	var fileIDs []uint
+	if err := query.Order("id ASC").Pluck("id", &fileIDs).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fileIDs, nil
+}
+
+// GetDirectoryPaths возвращает все директории с путями от корня и назначенными процедурами
+// согласования, отсортированные по пути
+func (directoryRepository *DirectoryRepository) GetDirectoryPaths(ctx context.Context) ([]domain.DirectoryPath, error) {
+	const op = "infrastructure.postgresrepo.directory.GetDirectoryPaths"
+
+	var directories []domain.DirectoryPath
+	err := directoryRepository.db.WithContext(ctx).Raw(`
+		WITH RECURSIVE tree AS (
+			SELECT id, 0::bigint AS parent_id, name::text AS path, workflow_id FROM directories
+			WHERE parent_path_id IS NULL AND deleted_at IS NULL
+			UNION ALL
+			SELECT d.id, d.parent_path_id, tree.path || '/' || d.name, d.workflow_id FROM directories d
+			JOIN tree ON d.parent_path_id = tree.id
+			WHERE d.deleted_at IS NULL
+		)
+		SELECT id, parent_id, path, workflow_id FROM tree ORDER BY path
+	`).Scan(&directories).Error
+
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return directories, nil
+}
+
+// FindFileIDs возвращает ID файлов, имя которых содержит nameQuery без учета регистра, в директории
+// directoryID и всех вложенных директориях (0 - во всех директориях). Возвращается не больше limit
+// файлов с наименьшими ID (0 - без ограничения)
+// Кастомные ошибки: ErrDirectoryNotFound
+func (directoryRepository *DirectoryRepository) FindFileIDs(ctx context.Context, directoryID uint, nameQuery string, limit int) ([]uint, error) {
+	const op = "infrastructure.postgresrepo.directory.FindFileIDs"
+
+	query := directoryRepository.db.WithContext(ctx).Model(&domain.File{})
+	if directoryID != 0 {
+		var directory domain.Directory
+		if err := directoryRepository.db.WithContext(ctx).Select("id").First(&directory, directoryID).Error; err != nil {
+			if errors.Is(err, gorm.ErrRecordNotFound) {
+				return nil, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+			}
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+
+		query = query.Where(`directory_id IN (
+			WITH RECURSIVE subdirs AS (
+				SELECT id FROM directories WHERE id = ? AND deleted_at IS NULL
+				UNION ALL
+				SELECT d.id FROM directories d
+				JOIN subdirs s ON d.parent_path_id = s.id
+				WHERE d.deleted_at IS NULL
+			) SELECT id FROM subdirs
+		)`, directoryID)
+	}
+	if nameQuery != "" {
+		// Символы шаблона LIKE в запросе ищутся как обычные символы
+		escaped := strings.NewReplacer(`\`, `\\`, "%", `\%`, "_", `\_`).Replace(nameQuery)
+		query = query.Where("name ILIKE ?", "%"+escaped+"%")
+	}
+	if limit > 0 {
+		query = query.Limit(limit)
+	}
+
+	var fileIDs []uint
+	if err := query.Order("id ASC").Pluck("id", &fileIDs).Error; err != nil {
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return fileIDs, nil
+}
+
+func (directoryRepository *DirectoryRepository) DeleteUserRelations(ctx context.Context, userID uint) error {
+	const op = "infrastructure.postgresrepo.directory.DeleteUserRelations"
+
+	tx := directoryRepository.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, tx.Error)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	result := tx.Where("user_id = ?", userID).Delete(&domain.UserDirectory{})
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, result.Error)
+	}
+
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrNoRelationsFound)
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+func (directoryRepository *DirectoryRepository) CheckDirectoriesExist(ctx context.Context, directoryIDs []uint) (bool, error) {
+	const op = "infrastructure.postgresrepo.directory.CheckDirectoriesExist"
+
+	if len(directoryIDs) == 0 {
+		return false, fmt.Errorf("%s: empty user list", op)
+	}
+
+	var count int64
+	err := directoryRepository.db.WithContext(ctx).
+		Model(&domain.Directory{}).
+		Where("id IN (?)", directoryIDs).
+		Count(&count).Error
+
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count == int64(len(directoryIDs)), nil
+}
+
+func (r *DirectoryRepository) UpdateDirectories(ctx context.Context, workflowID uint, directoryIDs []uint) error {
+	const op = "infrastructure.postgresrepo.directory.UpdateDirectories"
+
+	// Начало транзакции
+	tx := r.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, tx.Error)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	// Обновление workflow_id для всех указанных directory_ids
+	result := tx.Model(&domain.Directory{}).
+		Where("id IN ?", directoryIDs).
+		Update("workflow_id", workflowID)
+
+	if result.Error != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, result.Error)
+	}
+
+	// Проверка, были ли затронуты строки
+	if result.RowsAffected == 0 {
+		tx.Rollback()
+		return fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+	}
+
+	// Фиксация изменений
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// UpdateUserDirectoryRelations заменяет доступ пользователя к директориям на directories
+func (r *DirectoryRepository) UpdateUserDirectoryRelations(ctx context.Context, userID uint, directories []domain.ObjectAccess) error {
+	const op = "infrastructure.postgresrepo.directory.UpdateUserDirectoryRelations"
+
+	tx := r.db.WithContext(ctx).Begin()
+	if tx.Error != nil {
+		return fmt.Errorf("%s: failed to begin transaction: %w", op, tx.Error)
+	}
+
+	defer func() {
+		if r := recover(); r != nil {
+			tx.Rollback()
+		}
+	}()
+
+	if err := tx.Where("user_id = ?", userID).Delete(&domain.UserDirectory{}).Error; err != nil {
+		tx.Rollback()
+		return fmt.Errorf("%s: failed to delete user directory relations: %w", op, err)
+	}
+
+	var newRelations []domain.UserDirectory
+	for _, directory := range directories {
+		// Уровень none не выдает доступ: связь без уровня не создается
+		if directory.Level == domain.AccessNone {
+			continue
+		}
+		newRelations = append(newRelations, domain.UserDirectory{
+			UserID:      userID,
+			DirectoryID: directory.ID,
+			AccessLevel: directory.Level,
+		})
+	}
+
+	if len(newRelations) > 0 {
+		if err := tx.Create(&newRelations).Error; err != nil {
+			tx.Rollback()
+			return fmt.Errorf("%s: failed to create user directory relations: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// SetUserDirectoryAccess выдает пользователю доступ к директории уровня level или запрет (AccessDeny).
+// AccessNone удаляет выданный уровень: доступ снова наследуется от родительской директории
+func (r *DirectoryRepository) SetUserDirectoryAccess(ctx context.Context, userID, directoryID uint, level domain.AccessLevel) error {
+	const op = "infrastructure.postgresrepo.directory.SetUserDirectoryAccess"
+
+	db := r.db.WithContext(ctx)
+	if level == domain.AccessNone {
+		if err := db.Where("user_id = ? AND directory_id = ?", userID, directoryID).Delete(&domain.UserDirectory{}).Error; err != nil {
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		return nil
+	}
+
+	relation := domain.UserDirectory{UserID: userID, DirectoryID: directoryID, AccessLevel: level}
+	err := db.Clauses(clause.OnConflict{
+		Columns:   []clause.Column{{Name: "user_id"}, {Name: "directory_id"}},
+		DoUpdates: clause.AssignmentColumns([]string{"access_level"}),
+	}).Create(&relation).Error
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
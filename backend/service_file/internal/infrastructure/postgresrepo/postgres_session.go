@@ -0,0 +1,68 @@
+package postgresrepo
+
+import (
+	"context"
+	"fmt"
+	"service-file/internal/domain"
+	"time"
+
+	"gorm.io/gorm"
+	"gorm.io/gorm/clause"
+)
+
+type SessionRepository struct {
+	db *gorm.DB
+}
+
+func NewSessionRepository(db *Database) *SessionRepository {
+	return &SessionRepository{db: db.db}
+}
+
+// RevokeSessions сохраняет отозванные сессии до expiresAt. Повторный отзыв продлевает срок хранения,
+// записи с истекшим сроком удаляются
+func (r *SessionRepository) RevokeSessions(ctx context.Context, sessionIDs []string, expiresAt time.Time) error {
+	const op = "infrastructure.postgresrepo.session.RevokeSessions"
+
+	now := time.Now()
+	sessions := make([]domain.RevokedSession, len(sessionIDs))
+	for i, sessionID := range sessionIDs {
+		sessions[i] = domain.RevokedSession{
+			SessionID: sessionID,
+			ExpiresAt: expiresAt,
+			CreatedAt: now,
+		}
+	}
+
+	err := r.db.WithContext(ctx).Transaction(func(tx *gorm.DB) error {
+		err := tx.Clauses(clause.OnConflict{
+			Columns:   []clause.Column{{Name: "session_id"}},
+			DoUpdates: clause.AssignmentColumns([]string{"expires_at"}),
+		}).Create(&sessions).Error
+		if err != nil {
+			return err
+		}
+
+		return tx.Where("expires_at <= ?", now).Delete(&domain.RevokedSession{}).Error
+	})
+	if err != nil {
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	return nil
+}
+
+// IsSessionRevoked проверяет, что сессия отозвана и срок действия ее токенов еще не истек
+func (r *SessionRepository) IsSessionRevoked(ctx context.Context, sessionID string) (bool, error) {
+	const op = "infrastructure.postgresrepo.session.IsSessionRevoked"
+
+	var count int64
+	err := r.db.WithContext(ctx).
+		Model(&domain.RevokedSession{}).
+		Where("session_id = ? AND expires_at > ?", sessionID, time.Now()).
+		Count(&count).Error
+	if err != nil {
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	return count > 0, nil
+}
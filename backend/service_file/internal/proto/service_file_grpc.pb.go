@@ -23,10 +23,17 @@ const (
 	FileService_GetFileByID_FullMethodName         = "/file.FileService/GetFileByID"
 	FileService_UpdateFileStatus_FullMethodName    = "/file.FileService/UpdateFileStatus"
 	FileService_GetFilesInfo_FullMethodName        = "/file.FileService/GetFilesInfo"
+	FileService_GetFileStatuses_FullMethodName     = "/file.FileService/GetFileStatuses"
+	FileService_GetDirectoryFiles_FullMethodName   = "/file.FileService/GetDirectoryFiles"
+	FileService_FindFiles_FullMethodName           = "/file.FileService/FindFiles"
+	FileService_GetFileContentHash_FullMethodName  = "/file.FileService/GetFileContentHash"
 	FileService_CheckWorkflow_FullMethodName       = "/file.FileService/CheckWorkflow"
 	FileService_AssignWorkflow_FullMethodName      = "/file.FileService/AssignWorkflow"
+	FileService_GetDirectoryPaths_FullMethodName   = "/file.FileService/GetDirectoryPaths"
 	FileService_DeleteUserRelations_FullMethodName = "/file.FileService/DeleteUserRelations"
 	FileService_AssignUser_FullMethodName          = "/file.FileService/AssignUser"
+	FileService_CheckFileAccess_FullMethodName     = "/file.FileService/CheckFileAccess"
+	FileService_RevokeSessions_FullMethodName      = "/file.FileService/RevokeSessions"
 )
 
 // FileServiceClient is the client API for FileService service.
@@ -36,10 +43,17 @@ type FileServiceClient interface {
 	GetFileByID(ctx context.Context, in *GetFileRequest, opts ...grpc.CallOption) (*FileResponse, error)
 	UpdateFileStatus(ctx context.Context, in *UpdateFileStatusRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	GetFilesInfo(ctx context.Context, in *GetFilesRequest, opts ...grpc.CallOption) (*GetFilesResponse, error)
+	GetFileStatuses(ctx context.Context, in *GetFilesRequest, opts ...grpc.CallOption) (*GetFileStatusesResponse, error)
+	GetDirectoryFiles(ctx context.Context, in *GetDirectoryFilesRequest, opts ...grpc.CallOption) (*GetDirectoryFilesResponse, error)
+	FindFiles(ctx context.Context, in *FindFilesRequest, opts ...grpc.CallOption) (*GetDirectoryFilesResponse, error)
+	GetFileContentHash(ctx context.Context, in *GetFileContentHashRequest, opts ...grpc.CallOption) (*FileContentHashResponse, error)
 	CheckWorkflow(ctx context.Context, in *CheckWorkflowRequest, opts ...grpc.CallOption) (*CheckWorkflowResponse, error)
 	AssignWorkflow(ctx context.Context, in *AssignWorkflowRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	GetDirectoryPaths(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetDirectoryPathsResponse, error)
 	DeleteUserRelations(ctx context.Context, in *DeleteUserRelationsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 	AssignUser(ctx context.Context, in *AssignUserRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
+	CheckFileAccess(ctx context.Context, in *CheckFileAccessRequest, opts ...grpc.CallOption) (*CheckFileAccessResponse, error)
+	RevokeSessions(ctx context.Context, in *RevokeSessionsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error)
 }
 
 type fileServiceClient struct {
@@ -80,6 +94,46 @@ func (c *fileServiceClient) GetFilesInfo(ctx context.Context, in *GetFilesReques
 	return out, nil
 }
 
+func (c *fileServiceClient) GetFileStatuses(ctx context.Context, in *GetFilesRequest, opts ...grpc.CallOption) (*GetFileStatusesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetFileStatusesResponse)
+	err := c.cc.Invoke(ctx, FileService_GetFileStatuses_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) GetDirectoryFiles(ctx context.Context, in *GetDirectoryFilesRequest, opts ...grpc.CallOption) (*GetDirectoryFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDirectoryFilesResponse)
+	err := c.cc.Invoke(ctx, FileService_GetDirectoryFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) FindFiles(ctx context.Context, in *FindFilesRequest, opts ...grpc.CallOption) (*GetDirectoryFilesResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDirectoryFilesResponse)
+	err := c.cc.Invoke(ctx, FileService_FindFiles_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) GetFileContentHash(ctx context.Context, in *GetFileContentHashRequest, opts ...grpc.CallOption) (*FileContentHashResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(FileContentHashResponse)
+	err := c.cc.Invoke(ctx, FileService_GetFileContentHash_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *fileServiceClient) CheckWorkflow(ctx context.Context, in *CheckWorkflowRequest, opts ...grpc.CallOption) (*CheckWorkflowResponse, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(CheckWorkflowResponse)
@@ -100,6 +154,16 @@ func (c *fileServiceClient) AssignWorkflow(ctx context.Context, in *AssignWorkfl
 	return out, nil
 }
 
+func (c *fileServiceClient) GetDirectoryPaths(ctx context.Context, in *emptypb.Empty, opts ...grpc.CallOption) (*GetDirectoryPathsResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetDirectoryPathsResponse)
+	err := c.cc.Invoke(ctx, FileService_GetDirectoryPaths_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 func (c *fileServiceClient) DeleteUserRelations(ctx context.Context, in *DeleteUserRelationsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
 	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
 	out := new(emptypb.Empty)
@@ -120,6 +184,26 @@ func (c *fileServiceClient) AssignUser(ctx context.Context, in *AssignUserReques
 	return out, nil
 }
 
+func (c *fileServiceClient) CheckFileAccess(ctx context.Context, in *CheckFileAccessRequest, opts ...grpc.CallOption) (*CheckFileAccessResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(CheckFileAccessResponse)
+	err := c.cc.Invoke(ctx, FileService_CheckFileAccess_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *fileServiceClient) RevokeSessions(ctx context.Context, in *RevokeSessionsRequest, opts ...grpc.CallOption) (*emptypb.Empty, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(emptypb.Empty)
+	err := c.cc.Invoke(ctx, FileService_RevokeSessions_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
 // FileServiceServer is the server API for FileService service.
 // All implementations must embed UnimplementedFileServiceServer
 // for forward compatibility.
@@ -127,10 +211,17 @@ type FileServiceServer interface {
 	GetFileByID(context.Context, *GetFileRequest) (*FileResponse, error)
 	UpdateFileStatus(context.Context, *UpdateFileStatusRequest) (*emptypb.Empty, error)
 	GetFilesInfo(context.Context, *GetFilesRequest) (*GetFilesResponse, error)
+	GetFileStatuses(context.Context, *GetFilesRequest) (*GetFileStatusesResponse, error)
+	GetDirectoryFiles(context.Context, *GetDirectoryFilesRequest) (*GetDirectoryFilesResponse, error)
+	FindFiles(context.Context, *FindFilesRequest) (*GetDirectoryFilesResponse, error)
+	GetFileContentHash(context.Context, *GetFileContentHashRequest) (*FileContentHashResponse, error)
 	CheckWorkflow(context.Context, *CheckWorkflowRequest) (*CheckWorkflowResponse, error)
 	AssignWorkflow(context.Context, *AssignWorkflowRequest) (*emptypb.Empty, error)
+	GetDirectoryPaths(context.Context, *emptypb.Empty) (*GetDirectoryPathsResponse, error)
 	DeleteUserRelations(context.Context, *DeleteUserRelationsRequest) (*emptypb.Empty, error)
 	AssignUser(context.Context, *AssignUserRequest) (*emptypb.Empty, error)
+	CheckFileAccess(context.Context, *CheckFileAccessRequest) (*CheckFileAccessResponse, error)
+	RevokeSessions(context.Context, *RevokeSessionsRequest) (*emptypb.Empty, error)
 	mustEmbedUnimplementedFileServiceServer()
 }
 
@@ -150,18 +241,39 @@ func (UnimplementedFileServiceServer) UpdateFileStatus(context.Context, *UpdateF
 func (UnimplementedFileServiceServer) GetFilesInfo(context.Context, *GetFilesRequest) (*GetFilesResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method GetFilesInfo not implemented")
 }
+func (UnimplementedFileServiceServer) GetFileStatuses(context.Context, *GetFilesRequest) (*GetFileStatusesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFileStatuses not implemented")
+}
+func (UnimplementedFileServiceServer) GetDirectoryFiles(context.Context, *GetDirectoryFilesRequest) (*GetDirectoryFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDirectoryFiles not implemented")
+}
+func (UnimplementedFileServiceServer) FindFiles(context.Context, *FindFilesRequest) (*GetDirectoryFilesResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method FindFiles not implemented")
+}
+func (UnimplementedFileServiceServer) GetFileContentHash(context.Context, *GetFileContentHashRequest) (*FileContentHashResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetFileContentHash not implemented")
+}
 func (UnimplementedFileServiceServer) CheckWorkflow(context.Context, *CheckWorkflowRequest) (*CheckWorkflowResponse, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method CheckWorkflow not implemented")
 }
 func (UnimplementedFileServiceServer) AssignWorkflow(context.Context, *AssignWorkflowRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AssignWorkflow not implemented")
 }
+func (UnimplementedFileServiceServer) GetDirectoryPaths(context.Context, *emptypb.Empty) (*GetDirectoryPathsResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetDirectoryPaths not implemented")
+}
 func (UnimplementedFileServiceServer) DeleteUserRelations(context.Context, *DeleteUserRelationsRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method DeleteUserRelations not implemented")
 }
 func (UnimplementedFileServiceServer) AssignUser(context.Context, *AssignUserRequest) (*emptypb.Empty, error) {
 	return nil, status.Errorf(codes.Unimplemented, "method AssignUser not implemented")
 }
+func (UnimplementedFileServiceServer) CheckFileAccess(context.Context, *CheckFileAccessRequest) (*CheckFileAccessResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method CheckFileAccess not implemented")
+}
+func (UnimplementedFileServiceServer) RevokeSessions(context.Context, *RevokeSessionsRequest) (*emptypb.Empty, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method RevokeSessions not implemented")
+}
 func (UnimplementedFileServiceServer) mustEmbedUnimplementedFileServiceServer() {}
 func (UnimplementedFileServiceServer) testEmbeddedByValue()                     {}
 
@@ -237,6 +349,78 @@ func _FileService_GetFilesInfo_Handler(srv interface{}, ctx context.Context, dec
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FileService_GetFileStatuses_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).GetFileStatuses(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_GetFileStatuses_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).GetFileStatuses(ctx, req.(*GetFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_GetDirectoryFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetDirectoryFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).GetDirectoryFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_GetDirectoryFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).GetDirectoryFiles(ctx, req.(*GetDirectoryFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_FindFiles_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(FindFilesRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).FindFiles(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_FindFiles_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).FindFiles(ctx, req.(*FindFilesRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_GetFileContentHash_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetFileContentHashRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).GetFileContentHash(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_GetFileContentHash_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).GetFileContentHash(ctx, req.(*GetFileContentHashRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _FileService_CheckWorkflow_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(CheckWorkflowRequest)
 	if err := dec(in); err != nil {
@@ -273,6 +457,24 @@ func _FileService_AssignWorkflow_Handler(srv interface{}, ctx context.Context, d
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FileService_GetDirectoryPaths_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(emptypb.Empty)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).GetDirectoryPaths(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_GetDirectoryPaths_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).GetDirectoryPaths(ctx, req.(*emptypb.Empty))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 func _FileService_DeleteUserRelations_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
 	in := new(DeleteUserRelationsRequest)
 	if err := dec(in); err != nil {
@@ -309,6 +511,42 @@ func _FileService_AssignUser_Handler(srv interface{}, ctx context.Context, dec f
 	return interceptor(ctx, in, info, handler)
 }
 
+func _FileService_CheckFileAccess_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(CheckFileAccessRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).CheckFileAccess(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_CheckFileAccess_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).CheckFileAccess(ctx, req.(*CheckFileAccessRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _FileService_RevokeSessions_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(RevokeSessionsRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(FileServiceServer).RevokeSessions(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: FileService_RevokeSessions_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(FileServiceServer).RevokeSessions(ctx, req.(*RevokeSessionsRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
 // FileService_ServiceDesc is the grpc.ServiceDesc for FileService service.
 // It's only intended for direct use with grpc.RegisterService,
 // and not to be introspected or modified (even as a copy)
@@ -328,6 +566,22 @@ var FileService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "GetFilesInfo",
 			Handler:    _FileService_GetFilesInfo_Handler,
 		},
+		{
+			MethodName: "GetFileStatuses",
+			Handler:    _FileService_GetFileStatuses_Handler,
+		},
+		{
+			MethodName: "GetDirectoryFiles",
+			Handler:    _FileService_GetDirectoryFiles_Handler,
+		},
+		{
+			MethodName: "FindFiles",
+			Handler:    _FileService_FindFiles_Handler,
+		},
+		{
+			MethodName: "GetFileContentHash",
+			Handler:    _FileService_GetFileContentHash_Handler,
+		},
 		{
 			MethodName: "CheckWorkflow",
 			Handler:    _FileService_CheckWorkflow_Handler,
@@ -336,6 +590,10 @@ var FileService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AssignWorkflow",
 			Handler:    _FileService_AssignWorkflow_Handler,
 		},
+		{
+			MethodName: "GetDirectoryPaths",
+			Handler:    _FileService_GetDirectoryPaths_Handler,
+		},
 		{
 			MethodName: "DeleteUserRelations",
 			Handler:    _FileService_DeleteUserRelations_Handler,
@@ -344,6 +602,14 @@ var FileService_ServiceDesc = grpc.ServiceDesc{
 			MethodName: "AssignUser",
 			Handler:    _FileService_AssignUser_Handler,
 		},
+		{
+			MethodName: "CheckFileAccess",
+			Handler:    _FileService_CheckFileAccess_Handler,
+		},
+		{
+			MethodName: "RevokeSessions",
+			Handler:    _FileService_RevokeSessions_Handler,
+		},
 	},
 	Streams:  []grpc.StreamDesc{},
 	Metadata: "service_file.proto",
@@ -1,222 +1,476 @@
-package usecase
-
-import (
-	"context"
-	"errors"
-	"fmt"
-	"log/slog"
-	"service-file/internal/domain"
-	"service-file/internal/domain/interfaces"
-	"service-file/pkg/logger/slogger"
-)
-
-type GRPCUsecase struct {
-	fileMetadataRepo interfaces.FileMetadataRepository
-	directoryRepo    interfaces.DirectoryRepository
-	log              *slog.Logger
-}
-
-func NewGRPCUsecase(fileMetadataRepo interfaces.FileMetadataRepository, directoryRepo interfaces.DirectoryRepository, log *slog.Logger) *GRPCUsecase {
-	return &GRPCUsecase{
-		fileMetadataRepo: fileMetadataRepo,
-		directoryRepo:    directoryRepo,
-		log:              log,
-	}
-}
-
-func (u *GRPCUsecase) GetFileByID(ctx context.Context, fileID uint) (*domain.File, error) {
-	const op = "usecases.grpc.GetFileByID"
-
-	log := u.log.With(slog.String("op", op))
-	log.Info("getting file by id")
-
-	file, err := u.fileMetadataRepo.GetFileByID(ctx, fileID)
-	if err != nil {
-		switch {
-		case errors.Is(err, domain.ErrFileNotFound):
-			log.Error("file not found", slogger.Err(domain.ErrFileNotFound))
-			return nil, fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
-		default:
-			log.Error("failed to get file info", slogger.Err(err))
-			return nil, fmt.Errorf("%s: %w", op, err)
-		}
-	}
-
-	log.Info("file by id got successfully")
-	return file, nil
-}
-
-func (u *GRPCUsecase) UpdateFileStatus(ctx context.Context, fileID uint, status string) error {
-	const op = "usecases.grpc.UpdateFileStatus"
-
-	log := u.log.With(slog.String("op", op))
-	log.Info("updating file status")
-
-	tx := u.fileMetadataRepo.GetDB().Begin()
-	defer tx.Rollback()
-
-	if err := u.fileMetadataRepo.UpdateFileStatus(ctx, fileID, status, tx); err != nil {
-		switch {
-		case errors.Is(err, domain.ErrFileNotFound):
-			log.Error("file not found", slogger.Err(domain.ErrFileNotFound))
-			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
-		default:
-			log.Error("failed to update file status", slogger.Err(err))
-			return fmt.Errorf("%s: %w", op, err)
-		}
-	}
-
-	if err := tx.Commit().Error; err != nil {
-		log.Error("failed to commit transaction", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("file status updated successfully")
-	return nil
-}
-
-func (u *GRPCUsecase) GetFilesByID(ctx context.Context, fileIDs []uint32) ([]domain.File, error) {
-	const op = "usecases.grpc.GetFilesByID"
-
-	log := u.log.With(slog.String("op", op))
-	log.Info("getting files by id")
-
-	var files []domain.File
-	if err := u.fileMetadataRepo.GetFilesByID(ctx, fileIDs, &files); err != nil {
-		log.Error("failed to get files info", slogger.Err(err))
-		return nil, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("files by id got successfully")
-	return files, nil
-}
-
-func (grpcUsecase *GRPCUsecase) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
-	const op = "usecases.grpc.CheckWorkflow"
-
-	log := grpcUsecase.log.With(slog.String("op", op))
-	log.Info("checking workflow existence")
-
-	log.Debug("checking database")
-	exists, err := grpcUsecase.directoryRepo.CheckWorkflow(ctx, workflowID)
-	if err != nil {
-		log.Error("failed to check workflow existence", slogger.Err(err))
-		return false, fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("workflow existence checked successfully")
-	return exists, nil
-}
-
-func (grpcUsecase *GRPCUsecase) AssignWorkflow(ctx context.Context, workflowID uint32, directoryIDs []uint32) error {
-	const op = "usecases.grpc.AssignWorkflow"
-
-	log := grpcUsecase.log.With(slog.String("op", op))
-	log.Info("assigning workflow")
-
-	log.Debug("checking directories")
-	directories := make([]uint, len(directoryIDs))
-	for i, value := range directoryIDs {
-		directories[i] = uint(value)
-	}
-
-	exists, err := grpcUsecase.directoryRepo.CheckDirectoriesExist(ctx, directories)
-	if err != nil {
-		log.Error("failed to check workflow existence", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if !exists {
-		return domain.ErrDirectoryNotFound
-	}
-
-	log.Debug("updating directory data")
-	if err := grpcUsecase.directoryRepo.UpdateDirectories(ctx, uint(workflowID), directories); err != nil {
-		log.Error("failed to update directories", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("workflow assigned successfully")
-	return nil
-}
-
-func (grpcUsecase *GRPCUsecase) DeleteUserRelations(ctx context.Context, userID uint) error {
-	const op = "usecases.grpc.DeleteUserRelations"
-
-	log := grpcUsecase.log.With(slog.String("op", op))
-	log.Info("deleting user relations")
-
-	log.Debug("deleting user_directories relations")
-	if err := grpcUsecase.directoryRepo.DeleteUserRelations(ctx, userID); err != nil {
-		if errors.Is(err, domain.ErrNoRelationsFound) {
-			log.Warn("no user relations found")
-			return nil
-		}
-		log.Error("failed to delete user_directories relations", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("deleting user_files relations")
-	if err := grpcUsecase.fileMetadataRepo.DeleteUserRelations(ctx, userID); err != nil {
-		if errors.Is(err, domain.ErrNoRelationsFound) {
-			log.Warn("no user relations found")
-			return nil
-		}
-		log.Error("failed to delete user_files relations", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("user relations deleted successfully")
-	return nil
-}
-
-func (grpcUsecase *GRPCUsecase) AssignUser(ctx context.Context, userID uint, directoryIDs []uint32, fileIDs []uint32) error {
-	const op = "usecases.grpc.AssignUser"
-
-	log := grpcUsecase.log.With(slog.String("op", op))
-	log.Info("assigning user")
-
-	log.Debug("preparing directories")
-	directories := make([]uint, len(directoryIDs))
-	for i, value := range directoryIDs {
-		directories[i] = uint(value)
-	}
-
-	log.Debug("preparing files")
-	files := make([]uint, len(fileIDs))
-	for i, value := range fileIDs {
-		files[i] = uint(value)
-	}
-
-	exists, err := grpcUsecase.directoryRepo.CheckDirectoriesExist(ctx, directories)
-	if err != nil {
-		log.Error("failed to check directories existence", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if !exists {
-		return domain.ErrDirectoryNotFound
-	}
-
-	exists, err = grpcUsecase.fileMetadataRepo.CheckFilesExist(ctx, files)
-	if err != nil {
-		log.Error("failed to check files existence", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-	if !exists {
-		return domain.ErrFileNotFound
-	}
-
-	log.Debug("updating directory data")
-	if err := grpcUsecase.directoryRepo.UpdateUserDirectoryRelations(ctx, userID, directories); err != nil {
-		log.Error("failed to update directories", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Debug("updating file data")
-	if err := grpcUsecase.fileMetadataRepo.UpdateUserFileRelations(ctx, userID, files); err != nil {
-		log.Error("failed to update files", slogger.Err(err))
-		return fmt.Errorf("%s: %w", op, err)
-	}
-
-	log.Info("user assigned successfully")
-	return nil
-}
+package usecase
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"service-file/internal/domain"
+	"service-file/internal/domain/interfaces"
+	"service-file/pkg/logger/slogger"
+	"service-file/pkg/utils"
+	"slices"
+	"time"
+)
+
+type GRPCUsecase struct {
+	fileMetadataRepo interfaces.FileMetadataRepository
+	directoryRepo    interfaces.DirectoryRepository
+	sessionRepo      interfaces.SessionRepository
+	fileStorage      interfaces.FileStorage
+	log              *slog.Logger
+}
+
+func NewGRPCUsecase(fileMetadataRepo interfaces.FileMetadataRepository, directoryRepo interfaces.DirectoryRepository, sessionRepo interfaces.SessionRepository, fileStorage interfaces.FileStorage, log *slog.Logger) *GRPCUsecase {
+	return &GRPCUsecase{
+		fileMetadataRepo: fileMetadataRepo,
+		directoryRepo:    directoryRepo,
+		sessionRepo:      sessionRepo,
+		fileStorage:      fileStorage,
+		log:              log,
+	}
+}
+
+func (u *GRPCUsecase) GetFileByID(ctx context.Context, fileID uint) (*domain.File, error) {
+	const op = "usecases.grpc.GetFileByID"
+
+	log := u.log.With(slog.String("op", op))
+	log.Info("getting file by id")
+
+	file, err := u.fileMetadataRepo.GetFileByID(ctx, fileID)
+	if err != nil {
+		switch {
+		case errors.Is(err, domain.ErrFileNotFound):
+			log.Error("file not found", slogger.Err(domain.ErrFileNotFound))
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		default:
+			log.Error("failed to get file info", slogger.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	log.Debug("getting directory depth")
+	depth, err := u.directoryRepo.GetDirectoryDepth(ctx, file.DirectoryID)
+	if err != nil {
+		log.Error("failed to get directory depth", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	file.Directory.Depth = depth
+
+	log.Debug("resolving directory workflow")
+	workflows, err := u.directoryRepo.GetDirectoryWorkflows(ctx, []uint{file.DirectoryID})
+	if err != nil {
+		log.Error("failed to resolve directory workflow", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	setDirectoryWorkflow(&file.Directory, workflows)
+
+	log.Info("file by id got successfully")
+	return file, nil
+}
+
+// UpdateFileStatus обновляет статус файла. Если передан ключ идемпотентности и изменение
+// с этим ключом уже применялось, статус не меняется
+func (u *GRPCUsecase) UpdateFileStatus(ctx context.Context, fileID uint, status, idempotencyKey string) error {
+	const op = "usecases.grpc.UpdateFileStatus"
+
+	log := u.log.With(slog.String("op", op), slog.String("idempotency_key", idempotencyKey))
+	log.Info("updating file status")
+
+	tx := u.fileMetadataRepo.GetDB().Begin()
+	defer tx.Rollback()
+
+	if idempotencyKey != "" {
+		applied, err := u.fileMetadataRepo.RegisterStatusUpdate(ctx, idempotencyKey, fileID, status, tx)
+		if err != nil {
+			log.Error("failed to register status update", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !applied {
+			log.Info("status update already applied")
+			return nil
+		}
+	}
+
+	if err := u.fileMetadataRepo.UpdateFileStatus(ctx, fileID, status, tx); err != nil {
+		switch {
+		case errors.Is(err, domain.ErrFileNotFound):
+			log.Error("file not found", slogger.Err(domain.ErrFileNotFound))
+			return fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		default:
+			log.Error("failed to update file status", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+	}
+
+	if err := tx.Commit().Error; err != nil {
+		log.Error("failed to commit transaction", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("file status updated successfully")
+	return nil
+}
+
+// GetFilesByID возвращает файлы по списку ID. Отсутствующие файлы пропускаются
+func (u *GRPCUsecase) GetFilesByID(ctx context.Context, fileIDs []uint32) ([]domain.File, error) {
+	const op = "usecases.grpc.GetFilesByID"
+
+	log := u.log.With(slog.String("op", op))
+	log.Info("getting files by id")
+
+	var files []domain.File
+	if err := u.fileMetadataRepo.GetFilesByID(ctx, fileIDs, &files); err != nil {
+		log.Error("failed to get files info", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("files by id got successfully")
+	return files, nil
+}
+
+// GetFilesInfo возвращает файлы вместе с директориями, уровнем их вложенности и действующими процедурами
+func (u *GRPCUsecase) GetFilesInfo(ctx context.Context, fileIDs []uint32) ([]domain.File, error) {
+	const op = "usecases.grpc.GetFilesInfo"
+
+	log := u.log.With(slog.String("op", op), slog.Int("files", len(fileIDs)))
+	log.Info("getting files info")
+
+	var files []domain.File
+	if err := u.fileMetadataRepo.GetFilesByID(ctx, fileIDs, &files); err != nil {
+		log.Error("failed to get files", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	if len(files) == 0 {
+		return files, nil
+	}
+
+	directoryIDs := make([]uint, 0, len(files))
+	for _, file := range files {
+		directoryIDs = append(directoryIDs, file.DirectoryID)
+	}
+
+	log.Debug("getting directory depths")
+	depths, err := u.directoryRepo.GetDirectoryDepths(ctx, directoryIDs)
+	if err != nil {
+		log.Error("failed to get directory depths", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+	log.Debug("resolving directory workflows")
+	workflows, err := u.directoryRepo.GetDirectoryWorkflows(ctx, directoryIDs)
+	if err != nil {
+		log.Error("failed to resolve directory workflows", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	for i := range files {
+		files[i].Directory.Depth = depths[files[i].DirectoryID]
+		setDirectoryWorkflow(&files[i].Directory, workflows)
+	}
+
+	log.Info("files info got successfully")
+	return files, nil
+}
+
+// GetDirectoryFiles возвращает ID файлов поддерева директории в статусе status (пустой - в любом)
+// Кастомные ошибки: ErrDirectoryNotFound
+func (u *GRPCUsecase) GetDirectoryFiles(ctx context.Context, directoryID uint, status string) ([]uint, error) {
+	const op = "usecases.grpc.GetDirectoryFiles"
+
+	log := u.log.With(slog.String("op", op), slog.Any("directory_id", directoryID), slog.String("status", status))
+	log.Info("getting directory files")
+
+	fileIDs, err := u.directoryRepo.GetDirectoryFileIDs(ctx, directoryID, status)
+	if err != nil {
+		if errors.Is(err, domain.ErrDirectoryNotFound) {
+			log.Error("directory not found", slogger.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+		}
+		log.Error("failed to get directory files", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("directory files got successfully", slog.Int("files", len(fileIDs)))
+	return fileIDs, nil
+}
+
+// FindFiles возвращает не больше limit (0 - без ограничения) ID файлов поддерева директории
+// (0 - всех директорий), имя которых содержит nameQuery
+// Кастомные ошибки: ErrDirectoryNotFound
+func (u *GRPCUsecase) FindFiles(ctx context.Context, directoryID uint, nameQuery string, limit int) ([]uint, error) {
+	const op = "usecases.grpc.FindFiles"
+
+	log := u.log.With(slog.String("op", op), slog.Any("directory_id", directoryID), slog.String("name_query", nameQuery))
+	log.Info("finding files")
+
+	fileIDs, err := u.directoryRepo.FindFileIDs(ctx, directoryID, nameQuery, limit)
+	if err != nil {
+		if errors.Is(err, domain.ErrDirectoryNotFound) {
+			log.Error("directory not found", slogger.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrDirectoryNotFound)
+		}
+		log.Error("failed to find files", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("files found successfully", slog.Int("files", len(fileIDs)))
+	return fileIDs, nil
+}
+
+// GetFileContentHash считает SHA-256 версии version файла (0 - текущей версии) по объекту в MinIO
+// Кастомные ошибки: ErrFileNotFound, ErrFileVersionNotFound
+func (u *GRPCUsecase) GetFileContentHash(ctx context.Context, fileID uint, version int) (*domain.FileContentHash, error) {
+	const op = "usecases.grpc.GetFileContentHash"
+
+	log := u.log.With(slog.String("op", op), slog.Any("file_id", fileID), slog.Int("version", version))
+	log.Info("hashing file content")
+
+	file, err := u.fileMetadataRepo.GetFileByID(ctx, fileID)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileNotFound) {
+			log.Error("file not found", slogger.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrFileNotFound)
+		}
+		log.Error("failed to get file", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	if version == 0 {
+		version = file.Version
+	}
+	if version < 1 || version > file.Version {
+		log.Warn("file has no such version", slog.Int("current_version", file.Version))
+		return nil, fmt.Errorf("%s: %w", op, domain.ErrFileVersionNotFound)
+	}
+
+	key := file.MinioObjectKey
+	if version != file.Version {
+		key = utils.VersionObjectKey(file.MinioObjectKey, version)
+	}
+
+	log.Debug("hashing object", slog.String("key", key))
+	hash, size, err := u.fileStorage.HashFile(ctx, "files", key)
+	if err != nil {
+		if errors.Is(err, domain.ErrFileVersionNotFound) {
+			log.Error("file version object not found", slogger.Err(err))
+			return nil, fmt.Errorf("%s: %w", op, domain.ErrFileVersionNotFound)
+		}
+		log.Error("failed to hash file", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("file content hashed successfully")
+	return &domain.FileContentHash{
+		FileID:  file.ID,
+		Version: version,
+		SHA256:  hash,
+		Size:    size,
+	}, nil
+}
+
+func (grpcUsecase *GRPCUsecase) CheckWorkflow(ctx context.Context, workflowID uint) (bool, error) {
+	const op = "usecases.grpc.CheckWorkflow"
+
+	log := grpcUsecase.log.With(slog.String("op", op))
+	log.Info("checking workflow existence")
+
+	log.Debug("checking database")
+	exists, err := grpcUsecase.directoryRepo.CheckWorkflow(ctx, workflowID)
+	if err != nil {
+		log.Error("failed to check workflow existence", slogger.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("workflow existence checked successfully")
+	return exists, nil
+}
+
+func (grpcUsecase *GRPCUsecase) AssignWorkflow(ctx context.Context, workflowID uint32, directoryIDs []uint32) error {
+	const op = "usecases.grpc.AssignWorkflow"
+
+	log := grpcUsecase.log.With(slog.String("op", op))
+	log.Info("assigning workflow")
+
+	log.Debug("checking directories")
+	directories := make([]uint, len(directoryIDs))
+	for i, value := range directoryIDs {
+		directories[i] = uint(value)
+	}
+
+	exists, err := grpcUsecase.directoryRepo.CheckDirectoriesExist(ctx, directories)
+	if err != nil {
+		log.Error("failed to check workflow existence", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+	if !exists {
+		return domain.ErrDirectoryNotFound
+	}
+
+	log.Debug("updating directory data")
+	if err := grpcUsecase.directoryRepo.UpdateDirectories(ctx, uint(workflowID), directories); err != nil {
+		log.Error("failed to update directories", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("workflow assigned successfully")
+	return nil
+}
+
+// GetDirectoryPaths возвращает все директории с путями от корня и назначенными процедурами согласования
+func (grpcUsecase *GRPCUsecase) GetDirectoryPaths(ctx context.Context) ([]domain.DirectoryPath, error) {
+	const op = "usecases.grpc.GetDirectoryPaths"
+
+	log := grpcUsecase.log.With(slog.String("op", op))
+	log.Info("getting directory paths")
+
+	directories, err := grpcUsecase.directoryRepo.GetDirectoryPaths(ctx)
+	if err != nil {
+		log.Error("failed to get directory paths", slogger.Err(err))
+		return nil, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("directory paths got successfully", slog.Int("directories", len(directories)))
+	return directories, nil
+}
+
+func (grpcUsecase *GRPCUsecase) DeleteUserRelations(ctx context.Context, userID uint) error {
+	const op = "usecases.grpc.DeleteUserRelations"
+
+	log := grpcUsecase.log.With(slog.String("op", op))
+	log.Info("deleting user relations")
+
+	log.Debug("deleting user_directories relations")
+	if err := grpcUsecase.directoryRepo.DeleteUserRelations(ctx, userID); err != nil {
+		if errors.Is(err, domain.ErrNoRelationsFound) {
+			log.Warn("no user relations found")
+			return nil
+		}
+		log.Error("failed to delete user_directories relations", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("deleting user_files relations")
+	if err := grpcUsecase.fileMetadataRepo.DeleteUserRelations(ctx, userID); err != nil {
+		if errors.Is(err, domain.ErrNoRelationsFound) {
+			log.Warn("no user relations found")
+			return nil
+		}
+		log.Error("failed to delete user_files relations", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user relations deleted successfully")
+	return nil
+}
+
+// AssignUser заменяет доступ пользователя к директориям и файлам на directories и files.
+// Уровень AccessDeny сохраняется как явный запрет унаследованного доступа
+// Кастомные ошибки: ErrDirectoryNotFound, ErrFileNotFound, ErrInvalidAccessLevel
+func (grpcUsecase *GRPCUsecase) AssignUser(ctx context.Context, userID uint, directories []domain.ObjectAccess, files []domain.ObjectAccess) error {
+	const op = "usecases.grpc.AssignUser"
+
+	log := grpcUsecase.log.With(slog.String("op", op))
+	log.Info("assigning user")
+
+	log.Debug("preparing directories")
+	directoryIDs := make([]uint, len(directories))
+	for i, directory := range directories {
+		if directory.Level == domain.AccessNone {
+			return fmt.Errorf("%s: %w", op, domain.ErrInvalidAccessLevel)
+		}
+		directoryIDs[i] = directory.ID
+	}
+
+	log.Debug("preparing files")
+	fileIDs := make([]uint, len(files))
+	for i, file := range files {
+		if file.Level == domain.AccessNone {
+			return fmt.Errorf("%s: %w", op, domain.ErrInvalidAccessLevel)
+		}
+		fileIDs[i] = file.ID
+	}
+
+	if len(directoryIDs) > 0 {
+		exists, err := grpcUsecase.directoryRepo.CheckDirectoriesExist(ctx, directoryIDs)
+		if err != nil {
+			log.Error("failed to check directories existence", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !exists {
+			return domain.ErrDirectoryNotFound
+		}
+	}
+
+	if len(fileIDs) > 0 {
+		exists, err := grpcUsecase.fileMetadataRepo.CheckFilesExist(ctx, fileIDs)
+		if err != nil {
+			log.Error("failed to check files existence", slogger.Err(err))
+			return fmt.Errorf("%s: %w", op, err)
+		}
+		if !exists {
+			return domain.ErrFileNotFound
+		}
+	}
+
+	log.Debug("updating directory data")
+	if err := grpcUsecase.directoryRepo.UpdateUserDirectoryRelations(ctx, userID, directories); err != nil {
+		log.Error("failed to update directories", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Debug("updating file data")
+	if err := grpcUsecase.fileMetadataRepo.UpdateUserFileRelations(ctx, userID, files); err != nil {
+		log.Error("failed to update files", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("user assigned successfully")
+	return nil
+}
+
+// CheckFileAccess проверяет, что у пользователя есть доступ уровня не ниже level к каждому из файлов fileIDs
+func (grpcUsecase *GRPCUsecase) CheckFileAccess(ctx context.Context, userID uint, fileIDs []uint32, level domain.AccessLevel) (bool, error) {
+	const op = "usecases.grpc.CheckFileAccess"
+
+	log := grpcUsecase.log.With(slog.String("op", op), slog.Any("user_id", userID), slog.String("access_level", level.String()))
+	log.Info("checking file access")
+
+	ids := make([]uint, 0, len(fileIDs))
+	for _, fileID := range fileIDs {
+		if !slices.Contains(ids, uint(fileID)) {
+			ids = append(ids, uint(fileID))
+		}
+	}
+
+	allowed, err := grpcUsecase.fileMetadataRepo.CheckUserFileAccess(ctx, userID, ids, level)
+	if err != nil {
+		log.Error("failed to check file access", slogger.Err(err))
+		return false, fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("file access checked", slog.Bool("allowed", allowed))
+	return allowed, nil
+}
+
+// RevokeSessions сохраняет сессии, отозванные в core-service: их JWT отклоняются до expiresAt
+func (grpcUsecase *GRPCUsecase) RevokeSessions(ctx context.Context, sessionIDs []string, expiresAt time.Time) error {
+	const op = "usecases.grpc.RevokeSessions"
+
+	log := grpcUsecase.log.With(slog.String("op", op), slog.Int("sessions", len(sessionIDs)))
+	log.Info("revoking sessions")
+
+	if err := grpcUsecase.sessionRepo.RevokeSessions(ctx, sessionIDs, expiresAt); err != nil {
+		log.Error("failed to revoke sessions", slogger.Err(err))
+		return fmt.Errorf("%s: %w", op, err)
+	}
+
+	log.Info("sessions revoked successfully")
+	return nil
+}
+
+// setDirectoryWorkflow заполняет действующую процедуру директории по результату GetDirectoryWorkflows
+func setDirectoryWorkflow(directory *domain.Directory, workflows map[uint]domain.DirectoryWorkflow) {
+	workflow := workflows[directory.ID]
+	directory.EffectiveWorkflowID = workflow.WorkflowID
+	directory.WorkflowInherited = workflow.Inherited()
+}
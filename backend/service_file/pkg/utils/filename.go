@@ -1,18 +1,29 @@
-package utils
-
-import (
-	"path/filepath"
-	"regexp"
-	"strings"
-)
-
-func ParseBaseName(originalKey string) (string, string) {
-	ext := filepath.Ext(originalKey)
-	base := strings.TrimSuffix(originalKey, ext)
-
-	// Удаляем все суффиксы версий
-	re := regexp.MustCompile(`_v\d+$`)
-	base = re.ReplaceAllString(base, "")
-
-	return base, ext
-}
+package utils
+
+import (
+	"fmt"
+	"path/filepath"
+	"regexp"
+	"strings"
+)
+
+func ParseBaseName(originalKey string) (string, string) {
+	ext := filepath.Ext(originalKey)
+	base := strings.TrimSuffix(originalKey, ext)
+
+	// Удаляем все суффиксы версий
+	re := regexp.MustCompile(`_v\d+$`)
+	base = re.ReplaceAllString(base, "")
+
+	return base, ext
+}
+
+// VersionObjectKey возвращает ключ объекта версии version файла с ключом key:
+// первая версия хранится под исходным ключом, следующие - с суффиксом "_v<version>"
+func VersionObjectKey(key string, version int) string {
+	base, ext := ParseBaseName(key)
+	if version <= 1 {
+		return base + ext
+	}
+	return fmt.Sprintf("%s_v%d%s", base, version, ext)
+}
@@ -15,24 +15,49 @@ export const axiosFetchingFiles = axios.create({
     withCredentials: true,
 });
 
-axiosFetching.interceptors.response.use(
-	response => response,
-	error => {
-		if (error.response && error.response.status === 401) {
-			// Use our navigation service instead of useNavigate
+// Единственный выполняющийся запрос обновления токенов: параллельные 401 ждут его же
+let refreshPromise: Promise<void> | null = null;
+
+const refreshTokens = () => {
+	if (!refreshPromise) {
+		refreshPromise = axios
+			.post('/auth/refresh', null, { baseURL: serverURL, withCredentials: true })
+			.then(() => undefined)
+			.finally(() => {
+				refreshPromise = null;
+			});
+	}
+	return refreshPromise;
+};
+
+const isAuthRequest = (url?: string) =>
+	!!url && ['/auth/login', '/auth/refresh', '/auth/logout'].some(path => url.includes(path));
+
+// При 401 один раз обновляет access-токен по refresh-токену и повторяет запрос,
+// если обновить не удалось - перенаправляет на страницу логина
+const retryWithRefresh = (instance: typeof axiosFetching) => async (error: any) => {
+	const request = error.config;
+	if (!error.response || error.response.status !== 401 || !request) {
+		return Promise.reject(error);
+	}
+
+	if (request._retry || isAuthRequest(request.url)) {
+		if (!request.url?.includes('/auth/login')) {
 			redirectToLogin();
 		}
-		return Promise.reject(error); // Не забудь вернуть rejected Promise для дальнейшей обработки ошибок
+		return Promise.reject(error);
+	}
+
+	request._retry = true;
+	try {
+		await refreshTokens();
+	} catch {
+		redirectToLogin();
+		return Promise.reject(error);
 	}
-);
-
-axiosFetchingFiles.interceptors.response.use(
-    response => response,
-    error => {
-        if (error.response && error.response.status === 401) {
-            // Перенаправляем на страницу логина в случае ошибки 401
-            redirectToLogin();
-        }
-        return Promise.reject(error);
-    }
-);
+	return instance(request);
+};
+
+axiosFetching.interceptors.response.use(response => response, retryWithRefresh(axiosFetching));
+
+axiosFetchingFiles.interceptors.response.use(response => response, retryWithRefresh(axiosFetchingFiles));
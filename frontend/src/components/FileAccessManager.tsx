@@ -48,6 +48,9 @@ interface File {
 	name_file: string;
 	directory_id: number;
 	user_has_access: boolean;
+	access_level?: AccessLevel;
+	effective_access_level?: AccessLevel;
+	inherited_from_id?: number;
 }
 
 interface Directory {
@@ -55,9 +58,19 @@ interface Directory {
 	name_folder: string;
 	parent_path_id?: number | null;
 	user_has_access: boolean;
+	access_level?: AccessLevel;
+	effective_access_level?: AccessLevel;
+	inherited_from_id?: number;
 	files: File[];
 }
 
+// Уровень доступа к директории или файлу; каждый следующий включает предыдущие.
+// Доступ к директории распространяется на вложенные директории и файлы, deny запрещает унаследованный доступ
+type AccessLevel = 'deny' | 'read' | 'write' | 'approve' | 'manage';
+
+// Уровень, с которым выдается новый доступ
+const DEFAULT_ACCESS_LEVEL: AccessLevel = 'approve';
+
 interface AccessChanges {
 	directory_ids: number[];
 	file_ids: number[];
@@ -260,13 +273,37 @@ const FileAccessManager = () => {
 		setErrors(prev => ({ ...prev, saveChanges: null }));
 
 		try {
-			// Отправляем запрос на обновление доступов
+			// Сохраняем уже выданные уровни доступа и запреты, новые объекты получают уровень по умолчанию
+			const dirLevels = new Map<number, AccessLevel | undefined>();
+			const fileLevels = new Map<number, AccessLevel | undefined>();
+			fileTree.forEach(dir => {
+				dirLevels.set(dir.directory_id, dir.access_level);
+				dir.files.forEach(file => fileLevels.set(file.id, file.access_level));
+			});
+
+			const withLevels = (
+				ids: number[],
+				levels: Map<number, AccessLevel | undefined>
+			) => [
+				...ids.map(id => {
+					const level = levels.get(id);
+					return {
+						id,
+						access_level:
+							level && level !== 'deny' ? level : DEFAULT_ACCESS_LEVEL,
+					};
+				}),
+				...[...levels]
+					.filter(([id, level]) => level === 'deny' && !ids.includes(id))
+					.map(([id]) => ({ id, access_level: 'deny' as AccessLevel })),
+			];
+
 			// Используем эндпоинт ЯДРА: PUT "/admin/users/:user_id/assign"
-			// с правильной структурой данных { directory_ids: [...], file_ids: [...] }
-			await axiosFetching.put(
-				`/admin/users/${selectedUser.user_id}/assign`,
-				newAccess
-			);
+			// со структурой данных { directories: [{ id, access_level }], files: [...] }
+			await axiosFetching.put(`/admin/users/${selectedUser.user_id}/assign`, {
+				directories: withLevels(newAccess.directory_ids, dirLevels),
+				files: withLevels(newAccess.file_ids, fileLevels),
+			});
 
 			setOriginalAccess(newAccess);
 			setSnackbar({
@@ -293,6 +330,18 @@ const FileAccessManager = () => {
 		}
 	};
 
+	// Подпись для объекта без выданного доступа: запрет или уровень, унаследованный от директорий
+	const inheritedAccessLabel = (
+		item: { access_level?: AccessLevel; effective_access_level?: AccessLevel },
+		checked: boolean
+	) => {
+		if (checked) return undefined;
+		if (item.access_level === 'deny') return 'Доступ запрещен';
+		if (item.effective_access_level)
+			return `Наследуется: ${item.effective_access_level}`;
+		return undefined;
+	};
+
 	// Проверка наличия несохраненных изменений
 	const hasUnsavedChanges = () => {
 		return JSON.stringify(originalAccess) !== JSON.stringify(newAccess);
@@ -388,6 +437,7 @@ const FileAccessManager = () => {
 						/>
 						<ListItemText
 							primary={dir.name_folder}
+							secondary={inheritedAccessLabel(dir, isDirChecked)}
 							primaryTypographyProps={{
 								fontWeight: isDirChecked ? 600 : 400,
 								color: isDirChecked ? 'primary' : 'inherit',
@@ -435,6 +485,7 @@ const FileAccessManager = () => {
 											/>
 											<ListItemText
 												primary={file.name_file}
+												secondary={inheritedAccessLabel(file, isFileChecked)}
 												primaryTypographyProps={{
 													variant: 'body2',
 													fontWeight: isFileChecked ? 500 : 400,
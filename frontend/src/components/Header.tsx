@@ -33,6 +33,7 @@ interface UserInfo {
 	id: number;
 	login: string;
 	role: string;
+	permissions?: string[];
 }
 
 const Header = () => {
@@ -53,8 +54,12 @@ const Header = () => {
 	const mobileMenuOpen = Boolean(mobileMenuAnchor);
 	const userMenuOpen = Boolean(userMenuAnchor);
 
-	// Check if user is admin
-	const isAdmin = userInfo?.role === 'admin';
+	// Check user permissions from /auth/me
+	const hasPermission = (permission: string) =>
+		userInfo?.permissions?.includes(permission) ?? false;
+	const canManageWorkflows = hasPermission('workflow.manage');
+	const canManageUsers =
+		hasPermission('user.manage') || hasPermission('role.manage');
 
 	// Check auth status and load user data
 	useEffect(() => {
@@ -150,8 +155,14 @@ const Header = () => {
 		setUserMenuAnchor(null);
 	};
 
-	// Logout handler - client-side approach
-	const handleLogout = () => {
+	// Logout handler - revokes the session on the server, then clears client state
+	const handleLogout = async () => {
+		try {
+			await axiosFetching.post('/auth/logout');
+		} catch (error) {
+			console.error('Logout request error:', error);
+		}
+
 		try {
 			// Clear cookies
 			document.cookie.split(';').forEach(c => {
@@ -199,17 +210,18 @@ const Header = () => {
 		},
 	];
 
-	// Add Admin section if user has admin role
-	if (isAdmin) {
-		// Add Approval Editor tab for admins
+	// Add Approval Editor tab for users who manage workflows
+	if (canManageWorkflows) {
 		navItems.push({
 			label: 'Редактор согласования',
 			icon: <BuildCircleIcon sx={{ mr: 1 }} />,
 			path: '/approval-editor',
 			active: isActive('/approval-editor'),
 		});
+	}
 
-		// Keep Users management as the last tab
+	// Keep Users management as the last tab
+	if (canManageUsers) {
 		navItems.push({
 			label: 'Права и пользователи',
 			icon: <PeopleOutlineIcon sx={{ mr: 1 }} />,